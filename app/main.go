@@ -16,9 +16,19 @@ func main() {
 
 	var port int
 	var replicaOf string
+	var notifyKeyspaceEvents string
+	var requirePass string
+	var masterUser string
+	var masterAuth string
+	var aclFile string
 
 	flag.IntVar(&port, "port", defaultPortValue, "Defines port number for redis server")
 	flag.StringVar(&replicaOf, "replicaof", "", "Defines replica host and port")
+	flag.StringVar(&notifyKeyspaceEvents, "notify-keyspace-events", "", "Defines the classes of keyspace events to publish")
+	flag.StringVar(&requirePass, "requirepass", "", "Defines the password clients have to AUTH with")
+	flag.StringVar(&masterUser, "masteruser", "", "Defines the user a replica authenticates to its master as")
+	flag.StringVar(&masterAuth, "masterauth", "", "Defines the password a replica authenticates to its master with")
+	flag.StringVar(&aclFile, "aclfile", "", "Defines the file the ACL users are loaded from and saved to")
 	flag.Parse()
 
 	if port < 1 || port > 65535 {
@@ -30,6 +40,27 @@ func main() {
 
 	s := server.NewRedisServer(port, isReplica)
 
+	options := [][2]string{
+		{"notify-keyspace-events", notifyKeyspaceEvents},
+		{"requirepass", requirePass},
+		{"masteruser", masterUser},
+		{"masterauth", masterAuth},
+	}
+
+	for _, option := range options {
+		if err := s.SetConfig(option[0], option[1]); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if aclFile != "" {
+		if err := s.LoadACLFile(aclFile); err != nil {
+			logger.Error(err.Error(), "aclfile", aclFile)
+			os.Exit(1)
+		}
+	}
+
 	if isReplica {
 		go func() {
 			if err := s.ConnectToMaster(replicaOf, port); err != nil {
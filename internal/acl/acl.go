@@ -0,0 +1,334 @@
+package acl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultUser is the user the clients are authenticated as when they
+// connect, requirepass sets its password.
+const DefaultUser = "default"
+
+// ErrNoFile is returned by LoadFile and SaveFile without an aclfile.
+var ErrNoFile = errors.New("ERR This Redis instance is not configured to use an ACL file. You may want to specify users via the ACL SETUSER command and then issue a CONFIG REWRITE (assuming you have a Redis configuration file set) in order to store users in the Redis configuration.")
+
+// ACL is the registry of the users. It is safe for concurrent use.
+type ACL struct {
+	mu    sync.RWMutex
+	users map[string]*User
+	// isCommand tells the commands and cmd|sub subcommands the rules may
+	// name
+	isCommand   func(name string) bool
+	requirePass string
+	file        string
+	log         log
+}
+
+// New returns an ACL with only the default user, which may run every
+// command without a password.
+func New(isCommand func(name string) bool) *ACL {
+	a := &ACL{
+		users:     map[string]*User{},
+		isCommand: isCommand,
+		log:       log{maxLen: DefaultLogMaxLen},
+	}
+
+	a.users[DefaultUser] = a.newDefaultUser()
+
+	return a
+}
+
+func (a *ACL) newDefaultUser() *User {
+	u := newUser(DefaultUser)
+
+	for _, rule := range []string{"on", "nopass", "~*", "&*", "+@all"} {
+		u.rules.apply(rule, a.isCommand)
+	}
+
+	return u
+}
+
+// User returns the user called name.
+func (a *ACL) User(name string) (*User, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	u, ok := a.users[name]
+	return u, ok
+}
+
+// DefaultUser returns the user new clients are authenticated as.
+func (a *ACL) DefaultUser() *User {
+	u, _ := a.User(DefaultUser)
+	return u
+}
+
+// Users returns the users sorted by name.
+func (a *ACL) Users() []*User {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	users := make([]*User, 0, len(a.users))
+	for _, u := range a.users {
+		users = append(users, u)
+	}
+
+	slices.SortFunc(users, func(x, y *User) int {
+		return strings.Compare(x.name, y.name)
+	})
+
+	return users
+}
+
+// SetUser creates the user called name when needed and applies rules to
+// it. Either every rule applies or the user is left unchanged.
+func (a *ACL) SetUser(name string, rules ...string) error {
+	if strings.ContainsAny(name, " \x00") {
+		return errors.New("ERR Usernames can't contain spaces or null characters")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	u, ok := a.users[name]
+	if !ok {
+		u = newUser(name)
+	}
+
+	u.mu.RLock()
+	updated := u.rules.clone()
+	u.mu.RUnlock()
+
+	for _, rule := range rules {
+		if err := updated.apply(rule, a.isCommand); err != nil {
+			return fmt.Errorf("ERR Error in ACL SETUSER modifier '%s': %s", rule, err)
+		}
+	}
+
+	u.mu.Lock()
+	u.rules = updated
+	u.mu.Unlock()
+
+	a.users[name] = u
+
+	return nil
+}
+
+// DeleteUsers removes the users called names and returns how many existed.
+// The default user cannot be removed.
+func (a *ACL) DeleteUsers(names ...string) (int, error) {
+	if slices.Contains(names, DefaultUser) {
+		return 0, errors.New("ERR The 'default' user cannot be removed")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	deleted := 0
+
+	for _, name := range names {
+		if u, ok := a.users[name]; ok {
+			u.delete()
+			delete(a.users, name)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (u *User) delete() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.deleted = true
+}
+
+// Authenticate returns the user called name when it is on and password is
+// one of its passwords.
+func (a *ACL) Authenticate(name, password string) (*User, bool) {
+	u, ok := a.User(name)
+	if !ok || !u.checkPassword(password) {
+		return nil, false
+	}
+
+	return u, true
+}
+
+// RequirePass returns the password set with requirepass.
+func (a *ACL) RequirePass() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.requirePass
+}
+
+// SetRequirePass makes password the only password of the default user,
+// an empty one letting it in without a password.
+func (a *ACL) SetRequirePass(password string) {
+	a.mu.Lock()
+	a.requirePass = password
+	a.mu.Unlock()
+
+	rule := "nopass"
+	if password != "" {
+		rule = ">" + password
+	}
+
+	a.SetUser(DefaultUser, "resetpass", rule)
+}
+
+// File returns the path of the aclfile, empty when there is none.
+func (a *ACL) File() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.file
+}
+
+func (a *ACL) SetFile(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.file = path
+}
+
+// LoadFile replaces the users with the ones of the aclfile.
+func (a *ACL) LoadFile() error {
+	path := a.File()
+	if path == "" {
+		return ErrNoFile
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ERR Error loading ACLs, opening file '%s': %s", path, err)
+	}
+	defer f.Close()
+
+	return a.Load(f, path)
+}
+
+// SaveFile writes the users to the aclfile. The file is replaced at once,
+// it never holds only part of the users.
+func (a *ACL) SaveFile() error {
+	path := a.File()
+	if path == "" {
+		return ErrNoFile
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("ERR There was an error trying to save the ACLs. Please check the server logs for more information")
+	}
+	defer os.Remove(f.Name())
+
+	if err := a.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("ERR There was an error trying to save the ACLs. Please check the server logs for more information")
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("ERR There was an error trying to save the ACLs. Please check the server logs for more information")
+	}
+
+	return nil
+}
+
+// Save writes a "user <name> <rules>" line per user, the format of the
+// aclfile.
+func (a *ACL) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, u := range a.Users() {
+		fmt.Fprintf(bw, "user %s %s\n", u.name, u.Describe())
+	}
+
+	return bw.Flush()
+}
+
+// Load replaces the users with the ones read from r, in the format of the
+// aclfile, source naming it in the errors. Nothing changes when any line
+// is invalid. The users that still exist are updated in place, so that the
+// clients authenticated as them keep their connection. A default user is
+// created when r has none.
+func (a *ACL) Load(r io.Reader, source string) error {
+	users := map[string]rules{}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if fields[0] != "user" || len(fields) < 2 {
+			return loadError(source, line, "line should start with user keyword")
+		}
+
+		name := fields[1]
+		if _, ok := users[name]; ok {
+			return loadError(source, line, fmt.Sprintf("Duplicate user '%s' found", name))
+		}
+
+		var userRules rules
+		for _, rule := range fields[2:] {
+			if err := userRules.apply(rule, a.isCommand); err != nil {
+				return loadError(source, line, fmt.Sprintf("Error in user declaration '%s': %s", rule, err))
+			}
+		}
+
+		users[name] = userRules
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ERR Error loading ACLs, reading '%s': %s", source, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := users[DefaultUser]; !ok {
+		users[DefaultUser] = a.newDefaultUser().rules
+	}
+
+	for name, u := range a.users {
+		if _, ok := users[name]; !ok {
+			u.delete()
+			delete(a.users, name)
+		}
+	}
+
+	for name, userRules := range users {
+		u, ok := a.users[name]
+		if !ok {
+			u = newUser(name)
+			a.users[name] = u
+		}
+
+		u.mu.Lock()
+		u.rules = userRules
+		u.mu.Unlock()
+	}
+
+	return nil
+}
+
+func loadError(source string, line int, msg string) error {
+	return fmt.Errorf("ERR %s:%d: %s. WARNING: ACL errors detected, no change to the previously active ACL rules was performed", source, line, msg)
+}
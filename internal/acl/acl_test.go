@@ -0,0 +1,247 @@
+package acl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func isCommand(name string) bool {
+	switch name {
+	case "get", "set", "config", "config|get", "config|set", "publish", "subscribe":
+		return true
+	default:
+		return false
+	}
+}
+
+func TestDefaultUser(t *testing.T) {
+	a := New(isCommand)
+
+	u := a.DefaultUser()
+	if got := u.Describe(); got != "on nopass ~* &* +@all" {
+		t.Fatalf("unexpected default user %q", got)
+	}
+
+	if _, ok := a.Authenticate(DefaultUser, "anything"); !ok {
+		t.Fatalf("expected the default user to need no password")
+	}
+
+	if _, err := a.DeleteUsers(DefaultUser); err == nil {
+		t.Fatalf("expected the default user not to be removable")
+	}
+}
+
+func TestSetUserRules(t *testing.T) {
+	a := New(isCommand)
+
+	if err := a.SetUser("alice", "on", ">secret", "~cached:*", "%R~shared:*", "&news.*", "+@read", "+set", "-config", "+config|get"); err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := a.User("alice")
+
+	want := "on #" + hashPassword("secret") + " ~cached:* %R~shared:* &news.* -@all +@read +set -config +config|get"
+	if got := u.Describe(); got != want {
+		t.Fatalf("unexpected rules\n got: %s\nwant: %s", got, want)
+	}
+
+	if _, ok := a.Authenticate("alice", "wrong"); ok {
+		t.Fatalf("expected a wrong password to be refused")
+	}
+
+	if _, ok := a.Authenticate("alice", "secret"); !ok {
+		t.Fatalf("expected the password to be accepted")
+	}
+
+	cases := []struct {
+		req    Request
+		denied *Denial
+	}{
+		{Request{Command: "get", Categories: ReadCategory, Keys: []Key{{"cached:1", ReadAccess}}}, nil},
+		{Request{Command: "get", Categories: ReadCategory, Keys: []Key{{"shared:1", ReadAccess}}}, nil},
+		{Request{Command: "get", Categories: ReadCategory, Keys: []Key{{"other", ReadAccess}}}, &Denial{KeyReason, "other"}},
+		{Request{Command: "set", Categories: WriteCategory, Keys: []Key{{"cached:1", WriteAccess}}}, nil},
+		{Request{Command: "set", Categories: WriteCategory, Keys: []Key{{"shared:1", WriteAccess}}}, &Denial{KeyReason, "shared:1"}},
+		{Request{Command: "incr", Categories: WriteCategory}, &Denial{CommandReason, "incr"}},
+		{Request{Command: "config", Subcommand: "get", Categories: AdminCategory}, nil},
+		{Request{Command: "config", Subcommand: "set", Categories: AdminCategory}, &Denial{CommandReason, "config|set"}},
+		{Request{Command: "publish", Categories: ReadCategory, Channels: []Channel{{Name: "news.tech"}}}, nil},
+		{Request{Command: "publish", Categories: ReadCategory, Channels: []Channel{{Name: "sports"}}}, &Denial{ChannelReason, "sports"}},
+		// a pattern subscription needs the very same pattern
+		{Request{Command: "psubscribe", Categories: ReadCategory, Channels: []Channel{{Name: "news.*", Pattern: true}}}, nil},
+		{Request{Command: "psubscribe", Categories: ReadCategory, Channels: []Channel{{Name: "news.t*", Pattern: true}}}, &Denial{ChannelReason, "news.t*"}},
+	}
+
+	for _, tc := range cases {
+		denial := u.Check(&tc.req)
+
+		if (denial == nil) != (tc.denied == nil) || (denial != nil && *denial != *tc.denied) {
+			t.Errorf("%s|%s %v: expected %v, got %v", tc.req.Command, tc.req.Subcommand, tc.req.Keys, tc.denied, denial)
+		}
+	}
+}
+
+func TestKeyAccessFromSeveralPatterns(t *testing.T) {
+	a := New(isCommand)
+
+	if err := a.SetUser("alice", "on", "nopass", "%R~k*", "%W~k*", "%R~other", "+@all"); err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := a.User("alice")
+
+	// the read and the write access are granted by different patterns
+	if denial := u.Check(&Request{Command: "incr", Categories: WriteCategory, Keys: []Key{{"k1", ReadAccess | WriteAccess}}}); denial != nil {
+		t.Fatalf("expected k1 to be readable and writable, got %v", denial)
+	}
+
+	want := Denial{KeyReason, "other"}
+	if denial := u.Check(&Request{Command: "incr", Categories: WriteCategory, Keys: []Key{{"other", ReadAccess | WriteAccess}}}); denial == nil || *denial != want {
+		t.Fatalf("expected other to be read only, got %v", denial)
+	}
+}
+
+func TestSetUserErrors(t *testing.T) {
+	a := New(isCommand)
+
+	if err := a.SetUser("bob", "on", "+get"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		rule string
+		want string
+	}{
+		{"+nosuchcommand", "ERR Error in ACL SETUSER modifier '+nosuchcommand': Unknown command or category name in ACL"},
+		{"-@nosuchcategory", "ERR Error in ACL SETUSER modifier '-@nosuchcategory': Unknown command or category name in ACL"},
+		{"<unknown", "ERR Error in ACL SETUSER modifier '<unknown': The password you are trying to remove from the user does not exist"},
+		{"#abc", "ERR Error in ACL SETUSER modifier '#abc': The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters"},
+		{"%X~key", "ERR Error in ACL SETUSER modifier '%X~key': Syntax error"},
+		{"bogus", "ERR Error in ACL SETUSER modifier 'bogus': Syntax error"},
+	}
+
+	for _, tc := range cases {
+		// the rules before the invalid one are not applied either
+		if err := a.SetUser("bob", "off", tc.rule); err == nil || err.Error() != tc.want {
+			t.Errorf("expected %q, got %v", tc.want, err)
+		}
+	}
+
+	u, _ := a.User("bob")
+	if got := u.Describe(); got != "on resetchannels -@all +get" {
+		t.Fatalf("expected bob to be unchanged, got %q", got)
+	}
+
+	if err := a.SetUser("bad name"); err == nil {
+		t.Fatalf("expected a name with a space to be refused")
+	}
+}
+
+func TestPasswordHashes(t *testing.T) {
+	a := New(isCommand)
+	hash := hashPassword("pass")
+
+	if err := a.SetUser("carol", "on", "#"+hash, ">other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := a.Authenticate("carol", "pass"); !ok {
+		t.Fatalf("expected the hashed password to be accepted")
+	}
+
+	if err := a.SetUser("carol", "!"+hash, "<other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := a.Authenticate("carol", "pass"); ok {
+		t.Fatalf("expected the removed password to be refused")
+	}
+
+	// a user that is off cannot authenticate
+	if err := a.SetUser("carol", ">pass", "off"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := a.Authenticate("carol", "pass"); ok {
+		t.Fatalf("expected a disabled user to be refused")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	a := New(isCommand)
+	a.SetUser("alice", "on", ">secret", "%RW~app:*", "&*", "+@all", "-config|set")
+	a.SetUser("bob", "off")
+
+	var buf bytes.Buffer
+	if err := a.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := buf.String()
+
+	alice, _ := a.User("alice")
+	bob, _ := a.User("bob")
+
+	// the users that are kept are updated in place, the others deleted
+	input := strings.Replace(saved, "user bob off resetchannels -@all\n", "", 1) + "user dave on nopass +get\n"
+	if err := a.Load(strings.NewReader(input), "users.acl"); err != nil {
+		t.Fatal(err)
+	}
+
+	if u, _ := a.User("alice"); u != alice || u.Describe() != alice.Describe() {
+		t.Fatalf("expected alice to be kept")
+	}
+
+	if _, ok := a.User("bob"); ok || !bob.Deleted() {
+		t.Fatalf("expected bob to be deleted")
+	}
+
+	if _, ok := a.Authenticate("dave", ""); !ok {
+		t.Fatalf("expected dave to be loaded")
+	}
+
+	buf.Reset()
+	a.Save(&buf)
+
+	if want := strings.Replace(saved, "user bob off resetchannels -@all\n", "user dave on nopass resetchannels -@all +get\n", 1); buf.String() != want {
+		t.Fatalf("unexpected file\n got: %s\nwant: %s", buf.String(), want)
+	}
+
+	// nothing changes when a line is invalid
+	err := a.Load(strings.NewReader("user eve on\nuser frank +nosuchcommand\n"), "users.acl")
+	if err == nil || !strings.HasPrefix(err.Error(), "ERR users.acl:2: Error in user declaration '+nosuchcommand'") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, ok := a.User("eve"); ok {
+		t.Fatalf("expected no user to be loaded")
+	}
+}
+
+func TestLogGroupsDenials(t *testing.T) {
+	a := New(isCommand)
+	a.SetLogMaxLen(2)
+
+	a.Log(LogEntry{Reason: CommandReason, Context: "toplevel", Object: "get", Username: "alice"})
+	a.Log(LogEntry{Reason: KeyReason, Context: "toplevel", Object: "k", Username: "alice"})
+	a.Log(LogEntry{Reason: CommandReason, Context: "toplevel", Object: "get", Username: "alice"})
+
+	entries := a.LogEntries(10)
+	if len(entries) != 2 || entries[0].Object != "get" || entries[0].Count != 2 || entries[1].Object != "k" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	a.Log(LogEntry{Reason: AuthReason, Context: "toplevel", Object: "AUTH", Username: "bob"})
+
+	entries = a.LogEntries(10)
+	if len(entries) != 2 || entries[0].Object != "AUTH" || entries[0].EntryID != 2 {
+		t.Fatalf("expected the oldest entry to be dropped, got %+v", entries)
+	}
+
+	a.ResetLog()
+
+	if entries := a.LogEntries(10); len(entries) != 0 {
+		t.Fatalf("expected no entries, got %+v", entries)
+	}
+}
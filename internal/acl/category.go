@@ -0,0 +1,85 @@
+package acl
+
+// Category is a set of ACL command categories, the @name groups that rules
+// such as +@read grant or revoke at once.
+type Category uint32
+
+const (
+	KeyspaceCategory Category = 1 << iota
+	ReadCategory
+	WriteCategory
+	SetCategory
+	SortedSetCategory
+	ListCategory
+	HashCategory
+	StringCategory
+	BitmapCategory
+	HyperLogLogCategory
+	GeoCategory
+	StreamCategory
+	PubSubCategory
+	AdminCategory
+	FastCategory
+	SlowCategory
+	BlockingCategory
+	DangerousCategory
+	ConnectionCategory
+	TransactionCategory
+	ScriptingCategory
+
+	// AllCategories is @all, every command belongs to it
+	AllCategories Category = 1<<iota - 1
+)
+
+// categoryNames lists the categories in the order ACL CAT replies with.
+var categoryNames = []struct {
+	name     string
+	category Category
+}{
+	{"keyspace", KeyspaceCategory},
+	{"read", ReadCategory},
+	{"write", WriteCategory},
+	{"set", SetCategory},
+	{"sortedset", SortedSetCategory},
+	{"list", ListCategory},
+	{"hash", HashCategory},
+	{"string", StringCategory},
+	{"bitmap", BitmapCategory},
+	{"hyperloglog", HyperLogLogCategory},
+	{"geo", GeoCategory},
+	{"stream", StreamCategory},
+	{"pubsub", PubSubCategory},
+	{"admin", AdminCategory},
+	{"fast", FastCategory},
+	{"slow", SlowCategory},
+	{"blocking", BlockingCategory},
+	{"dangerous", DangerousCategory},
+	{"connection", ConnectionCategory},
+	{"transaction", TransactionCategory},
+	{"scripting", ScriptingCategory},
+}
+
+// CategoryNames returns the names of the categories, without @all.
+func CategoryNames() []string {
+	names := make([]string, len(categoryNames))
+	for i, c := range categoryNames {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+// ParseCategory returns the category called name, which may be "all".
+func ParseCategory(name string) (Category, bool) {
+	if name == "all" {
+		return AllCategories, true
+	}
+
+	for _, c := range categoryNames {
+		if c.name == name {
+			return c.category, true
+		}
+	}
+
+	return 0, false
+}
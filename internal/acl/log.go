@@ -0,0 +1,114 @@
+package acl
+
+import (
+	"time"
+)
+
+// DefaultLogMaxLen is the default acl-log-max-len, the number of entries
+// ACL LOG keeps.
+const DefaultLogMaxLen = 128
+
+// logGroupingWindow is how long a denial is counted in the entry of the
+// same previous denial rather than getting its own.
+const logGroupingWindow = 60 * time.Second
+
+// LogEntry is a denied command or a failed authentication, as ACL LOG
+// shows it.
+type LogEntry struct {
+	Count int
+	// Reason is one of the reasons of a Denial
+	Reason string
+	// Context is where the command ran: toplevel, multi or lua
+	Context    string
+	Object     string
+	Username   string
+	ClientInfo string
+	EntryID    int64
+	Created    time.Time
+	Updated    time.Time
+}
+
+// log holds the entries, the most recent first.
+type log struct {
+	entries []*LogEntry
+	nextID  int64
+	maxLen  int
+}
+
+// Log records a denial. It is counted in the entry of the same denial when
+// one happened within the last minute.
+func (a *ACL) Log(entry LogEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+
+	for i, e := range a.log.entries {
+		if e.Reason == entry.Reason && e.Context == entry.Context && e.Object == entry.Object &&
+			e.Username == entry.Username && now.Sub(e.Updated) < logGroupingWindow {
+			e.Count++
+			e.ClientInfo = entry.ClientInfo
+			e.Updated = now
+
+			// the updated entry becomes the most recent one
+			copy(a.log.entries[1:i+1], a.log.entries[:i])
+			a.log.entries[0] = e
+
+			return
+		}
+	}
+
+	entry.Count = 1
+	entry.EntryID = a.log.nextID
+	entry.Created = now
+	entry.Updated = now
+	a.log.nextID++
+
+	a.log.entries = append([]*LogEntry{&entry}, a.log.entries...)
+	a.trimLog()
+}
+
+// LogEntries returns up to count entries, the most recent first.
+func (a *ACL) LogEntries(count int) []LogEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	count = min(count, len(a.log.entries))
+
+	entries := make([]LogEntry, count)
+	for i := range entries {
+		entries[i] = *a.log.entries[i]
+	}
+
+	return entries
+}
+
+// ResetLog removes every entry.
+func (a *ACL) ResetLog() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.log.entries = nil
+}
+
+// LogMaxLen returns the acl-log-max-len.
+func (a *ACL) LogMaxLen() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.log.maxLen
+}
+
+func (a *ACL) SetLogMaxLen(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.log.maxLen = n
+	a.trimLog()
+}
+
+func (a *ACL) trimLog() {
+	if len(a.log.entries) > a.log.maxLen {
+		a.log.entries = a.log.entries[:a.log.maxLen]
+	}
+}
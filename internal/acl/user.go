@@ -0,0 +1,556 @@
+package acl
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/glob"
+)
+
+// Access is the way a command accesses a key, %R~ and %W~ patterns only
+// grant one of them.
+type Access uint8
+
+const (
+	ReadAccess Access = 1 << iota
+	WriteAccess
+)
+
+// Key is a key a command accesses.
+type Key struct {
+	Name   string
+	Access Access
+}
+
+// Channel is a channel a command publishes or subscribes to, or a pattern
+// it subscribes to when Pattern is set.
+type Channel struct {
+	Name    string
+	Pattern bool
+}
+
+// Request is what a command is checked against the rules of a user with.
+type Request struct {
+	// Command is the lower case name of the command
+	Command string
+	// Subcommand is the lower case subcommand of a container command such
+	// as CONFIG, empty for the other commands
+	Subcommand string
+	Categories Category
+	Keys       []Key
+	Channels   []Channel
+}
+
+// fullName returns the command as ACL rules name it, cmd|sub for a
+// subcommand.
+func (r *Request) fullName() string {
+	if r.Subcommand == "" {
+		return r.Command
+	}
+
+	return r.Command + "|" + r.Subcommand
+}
+
+// The reasons a request is denied for, as ACL LOG reports them.
+const (
+	CommandReason = "command"
+	KeyReason     = "key"
+	ChannelReason = "channel"
+	AuthReason    = "auth"
+)
+
+// Denial tells why a request was denied, Object being the command, key or
+// channel that is not allowed.
+type Denial struct {
+	Reason string
+	Object string
+}
+
+// The errors of a rule, ACL SETUSER replies with them.
+var (
+	errSyntax          = errors.New("Syntax error")
+	errUnknownCommand  = errors.New("Unknown command or category name in ACL")
+	errUnknownPassword = errors.New("The password you are trying to remove from the user does not exist")
+	errBadHash         = errors.New("The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters")
+)
+
+// commandRule is a +command, -command, +@category or -@category rule.
+type commandRule struct {
+	allow bool
+	// categories is set by the category rules, command by the other ones
+	categories Category
+	command    string
+}
+
+func (r commandRule) String() string {
+	sign := "-"
+	if r.allow {
+		sign = "+"
+	}
+
+	if r.categories == 0 {
+		return sign + r.command
+	}
+
+	for _, c := range categoryNames {
+		if c.category == r.categories {
+			return sign + "@" + c.name
+		}
+	}
+
+	return sign + "@all"
+}
+
+func (r commandRule) matches(req *Request) bool {
+	if r.categories != 0 {
+		return r.categories == AllCategories || r.categories&req.Categories != 0
+	}
+
+	return r.command == req.Command || r.command == req.fullName()
+}
+
+// keyPattern is a ~pattern rule, or a %R~ or %W~ one granting only reads or
+// writes.
+type keyPattern struct {
+	pattern string
+	access  Access
+}
+
+func (p keyPattern) String() string {
+	switch p.access {
+	case ReadAccess:
+		return "%R~" + p.pattern
+	case WriteAccess:
+		return "%W~" + p.pattern
+	default:
+		return "~" + p.pattern
+	}
+}
+
+// rules is what the rules of a user set. A new user has none of them: it is
+// off, without passwords and cannot run any command.
+type rules struct {
+	enabled bool
+	noPass  bool
+	// passwords are the SHA-256 of the passwords, in hex
+	passwords []string
+	commands  []commandRule
+	keys      []keyPattern
+	channels  []string
+}
+
+func (r rules) clone() rules {
+	r.passwords = slices.Clone(r.passwords)
+	r.commands = slices.Clone(r.commands)
+	r.keys = slices.Clone(r.keys)
+	r.channels = slices.Clone(r.channels)
+
+	return r
+}
+
+// apply applies a single rule, isCommand telling the commands and
+// subcommands that exist.
+func (r *rules) apply(rule string, isCommand func(name string) bool) error {
+	switch lower := strings.ToLower(rule); {
+	case lower == "on":
+		r.enabled = true
+	case lower == "off":
+		r.enabled = false
+	case lower == "nopass":
+		r.noPass = true
+		r.passwords = nil
+	case lower == "resetpass":
+		r.noPass = false
+		r.passwords = nil
+	case lower == "allkeys":
+		r.keys = []keyPattern{{pattern: "*", access: ReadAccess | WriteAccess}}
+	case lower == "resetkeys":
+		r.keys = nil
+	case lower == "allchannels":
+		r.channels = []string{"*"}
+	case lower == "resetchannels":
+		r.channels = nil
+	case lower == "allcommands":
+		r.commands = []commandRule{{allow: true, categories: AllCategories}}
+	case lower == "nocommands":
+		r.commands = nil
+	case lower == "reset":
+		*r = rules{}
+	case strings.HasPrefix(rule, ">"):
+		r.addPassword(hashPassword(rule[1:]))
+	case strings.HasPrefix(rule, "#"):
+		if !validHash(rule[1:]) {
+			return errBadHash
+		}
+
+		r.addPassword(rule[1:])
+	case strings.HasPrefix(rule, "<"):
+		return r.removePassword(hashPassword(rule[1:]))
+	case strings.HasPrefix(rule, "!"):
+		if !validHash(rule[1:]) {
+			return errBadHash
+		}
+
+		return r.removePassword(rule[1:])
+	case strings.HasPrefix(rule, "~"), strings.HasPrefix(rule, "%"):
+		return r.addKeyPattern(rule)
+	case strings.HasPrefix(rule, "&"):
+		r.channels = append(r.channels, rule[1:])
+	case strings.HasPrefix(rule, "+"), strings.HasPrefix(rule, "-"):
+		return r.addCommandRule(rule[0] == '+', lower[1:], isCommand)
+	default:
+		return errSyntax
+	}
+
+	return nil
+}
+
+func (r *rules) addPassword(hash string) {
+	r.noPass = false
+
+	if !slices.Contains(r.passwords, hash) {
+		r.passwords = append(r.passwords, hash)
+	}
+}
+
+func (r *rules) removePassword(hash string) error {
+	i := slices.Index(r.passwords, hash)
+	if i < 0 {
+		return errUnknownPassword
+	}
+
+	r.noPass = false
+	r.passwords = slices.Delete(r.passwords, i, i+1)
+
+	return nil
+}
+
+// addKeyPattern adds a ~pattern rule or a %<access>~pattern one, the access
+// being made of R and W.
+func (r *rules) addKeyPattern(rule string) error {
+	access := ReadAccess | WriteAccess
+
+	if rule[0] == '%' {
+		flags, pattern, ok := strings.Cut(rule[1:], "~")
+		if !ok || flags == "" {
+			return errSyntax
+		}
+
+		access = 0
+		for _, flag := range strings.ToUpper(flags) {
+			switch flag {
+			case 'R':
+				access |= ReadAccess
+			case 'W':
+				access |= WriteAccess
+			default:
+				return errSyntax
+			}
+		}
+
+		rule = "~" + pattern
+	}
+
+	r.keys = append(r.keys, keyPattern{pattern: rule[1:], access: access})
+
+	return nil
+}
+
+// addCommandRule adds a rule on name, a command, a cmd|sub subcommand or a
+// @category. The earlier rules it overrides are dropped so that the
+// description of the user stays short.
+func (r *rules) addCommandRule(allow bool, name string, isCommand func(name string) bool) error {
+	rule := commandRule{allow: allow}
+
+	if category, ok := strings.CutPrefix(name, "@"); ok {
+		c, ok := ParseCategory(category)
+		if !ok {
+			return errUnknownCommand
+		}
+
+		rule.categories = c
+	} else {
+		if !isCommand(name) {
+			return errUnknownCommand
+		}
+
+		rule.command = name
+	}
+
+	if rule.categories == AllCategories {
+		r.commands = nil
+		if allow {
+			r.commands = []commandRule{rule}
+		}
+
+		return nil
+	}
+
+	r.commands = slices.DeleteFunc(r.commands, func(old commandRule) bool {
+		if rule.categories != 0 {
+			return old.categories == rule.categories
+		}
+
+		return old.command == rule.command || strings.HasPrefix(old.command, rule.command+"|")
+	})
+	r.commands = append(r.commands, rule)
+
+	return nil
+}
+
+// User is an ACL user. A session keeps the user it authenticated as, the
+// rules are therefore changed in place.
+type User struct {
+	mu      sync.RWMutex
+	name    string
+	rules   rules
+	deleted bool
+}
+
+func newUser(name string) *User {
+	return &User{name: name}
+}
+
+func (u *User) Name() string {
+	return u.name
+}
+
+// Enabled reports whether the user is on, users that are off cannot
+// authenticate.
+func (u *User) Enabled() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.rules.enabled
+}
+
+// NoPass reports whether the user accepts any password.
+func (u *User) NoPass() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.rules.noPass
+}
+
+// Deleted reports whether the user was removed, the clients authenticated
+// as it have to be disconnected.
+func (u *User) Deleted() bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.deleted
+}
+
+// checkPassword reports whether the user is on and password is one of its
+// passwords.
+func (u *User) checkPassword(password string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	if !u.rules.enabled {
+		return false
+	}
+
+	if u.rules.noPass {
+		return true
+	}
+
+	hash := hashPassword(password)
+	ok := false
+
+	// go through every password so that the time does not tell which one
+	// matched
+	for _, p := range u.rules.passwords {
+		if subtle.ConstantTimeCompare([]byte(p), []byte(hash)) == 1 {
+			ok = true
+		}
+	}
+
+	return ok
+}
+
+// Check returns why the user may not run req, or nil when it may.
+func (u *User) Check(req *Request) *Denial {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	allowed := false
+	for _, rule := range u.rules.commands {
+		if rule.matches(req) {
+			allowed = rule.allow
+		}
+	}
+
+	if !allowed {
+		return &Denial{Reason: CommandReason, Object: req.fullName()}
+	}
+
+	for _, key := range req.Keys {
+		if !u.keyAllowed(key) {
+			return &Denial{Reason: KeyReason, Object: key.Name}
+		}
+	}
+
+	for _, channel := range req.Channels {
+		if !u.channelAllowed(channel) {
+			return &Denial{Reason: ChannelReason, Object: channel.Name}
+		}
+	}
+
+	return nil
+}
+
+// keyAllowed reports whether the user may access key. Like in Redis, the
+// read and the write access may be granted by different patterns.
+func (u *User) keyAllowed(key Key) bool {
+	var granted Access
+
+	for _, p := range u.rules.keys {
+		if glob.Match(p.pattern, key.Name) {
+			granted |= p.access
+
+			if granted&key.Access == key.Access {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// channelAllowed reports whether the user may use channel. Like in Redis, a
+// pattern subscription is only allowed for the very same pattern.
+func (u *User) channelAllowed(channel Channel) bool {
+	for _, p := range u.rules.channels {
+		if p == "*" || p == channel.Name || (!channel.Pattern && glob.Match(p, channel.Name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UserInfo describes a user for ACL GETUSER.
+type UserInfo struct {
+	Flags     []string
+	Passwords []string
+	Commands  string
+	Keys      string
+	Channels  string
+}
+
+func (u *User) Info() UserInfo {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	info := UserInfo{
+		Flags:     []string{"off"},
+		Passwords: slices.Clone(u.rules.passwords),
+		Commands:  u.describeCommands(),
+		Keys:      strings.Join(u.describeKeys(), " "),
+		Channels:  strings.Join(u.describeChannels(), " "),
+	}
+
+	if u.rules.enabled {
+		info.Flags[0] = "on"
+	}
+
+	if u.rules.noPass {
+		info.Flags = append(info.Flags, "nopass")
+	}
+
+	return info
+}
+
+// Describe returns the rules that set the user up the way it is, as ACL
+// LIST and the ACL file show them.
+func (u *User) Describe() string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	parts := []string{"off"}
+	if u.rules.enabled {
+		parts[0] = "on"
+	}
+
+	if u.rules.noPass {
+		parts = append(parts, "nopass")
+	}
+
+	for _, p := range u.rules.passwords {
+		parts = append(parts, "#"+p)
+	}
+
+	parts = append(parts, u.describeKeys()...)
+
+	if channels := u.describeChannels(); len(channels) > 0 {
+		parts = append(parts, channels...)
+	} else {
+		parts = append(parts, "resetchannels")
+	}
+
+	parts = append(parts, u.describeCommands())
+
+	return strings.Join(parts, " ")
+}
+
+func (u *User) describeCommands() string {
+	if len(u.rules.commands) == 0 {
+		return "-@all"
+	}
+
+	parts := make([]string, len(u.rules.commands))
+	for i, rule := range u.rules.commands {
+		parts[i] = rule.String()
+	}
+
+	// the rules apply on top of no command at all
+	if !u.rules.commands[0].allow || u.rules.commands[0].categories != AllCategories {
+		parts = append([]string{"-@all"}, parts...)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (u *User) describeKeys() []string {
+	parts := make([]string, len(u.rules.keys))
+	for i, p := range u.rules.keys {
+		parts[i] = p.String()
+	}
+
+	return parts
+}
+
+func (u *User) describeChannels() []string {
+	parts := make([]string, len(u.rules.channels))
+	for i, p := range u.rules.channels {
+		parts[i] = "&" + p
+	}
+
+	return parts
+}
+
+func hashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// validHash reports whether hash is a SHA-256 in lower case hex.
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,63 @@
+package cluster
+
+// SlotsCount is the number of hash slots the keyspace is partitioned in.
+const SlotsCount = 16384
+
+// KeySlot returns the hash slot of key, computed like Redis Cluster does:
+// CRC16 of the key modulo 16384. When the key contains a non-empty hash tag,
+// the part between the first { and the following }, only the tag is hashed,
+// so keys sharing a tag always land in the same slot.
+func KeySlot(key string) int {
+	start := -1
+
+	for i := 0; i < len(key); i++ {
+		if key[i] == '{' {
+			start = i
+			break
+		}
+	}
+
+	if start >= 0 {
+		for end := start + 1; end < len(key); end++ {
+			if key[end] == '}' {
+				if end > start+1 {
+					key = key[start+1 : end]
+				}
+
+				break
+			}
+		}
+	}
+
+	return int(crc16(key) & (SlotsCount - 1))
+}
+
+// crc16 implements the CRC16-CCITT (XMODEM) variant used by Redis Cluster:
+// polynomial 0x1021, initial value 0, no reflection and no final xor.
+func crc16(s string) uint16 {
+	var crc uint16
+
+	for i := 0; i < len(s); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^s[i]]
+	}
+
+	return crc
+}
+
+var crc16Table = func() (table [256]uint16) {
+	for i := range table {
+		crc := uint16(i) << 8
+
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+
+		table[i] = crc
+	}
+
+	return table
+}()
@@ -0,0 +1,37 @@
+package cluster
+
+import "testing"
+
+func TestCrc16(t *testing.T) {
+	// the check value of CRC16/XMODEM, also used by the Redis test suite
+	if got := crc16("123456789"); got != 0x31c3 {
+		t.Fatalf("expected 0x31c3, got %#x", got)
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"foo", 12182},
+		{"bar", 5061},
+		{"", 0},
+		{"{user1000}.following", 3443},
+		{"{user1000}.followers", 3443},
+		{"user1000", 3443},
+		{"foo{{bar}}zap", KeySlot("{bar")},
+		{"foo{bar}{zap}", KeySlot("bar")},
+	}
+
+	for _, tt := range tests {
+		if got := KeySlot(tt.key); got != tt.want {
+			t.Errorf("KeySlot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+
+	// an empty tag hashes the whole key
+	if KeySlot("foo{}{bar}") == KeySlot("bar") {
+		t.Fatal("expected an empty hash tag to be ignored")
+	}
+}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// The contexts a command is denied in, as ACL LOG reports them.
+const (
+	TopLevelContext = "toplevel"
+	MultiContext    = "multi"
+	ScriptContext   = "lua"
+)
+
+// IsCommand reports whether name, in lower case, is a command or a cmd|sub
+// subcommand that ACL rules may name.
+func IsCommand(name string) bool {
+	command, subcommand, isSubcommand := strings.Cut(name, "|")
+
+	spec, ok := commandTable[getCommandName([]byte(command))]
+	if !ok || !isSubcommand {
+		return ok
+	}
+
+	_, ok = spec.subcommands[subcommand]
+	return ok
+}
+
+// CommandsInCategory returns the commands, and the subcommands of the
+// container commands, that belong to category, in lower case.
+func CommandsInCategory(category acl.Category) []string {
+	names := []string{}
+
+	for name, spec := range commandTable {
+		command := strings.ToLower(string(name))
+
+		if spec.categories&category != 0 {
+			names = append(names, command)
+		}
+
+		for subcommand, categories := range spec.subcommands {
+			if categories&category != 0 {
+				names = append(names, command+"|"+subcommand)
+			}
+		}
+	}
+
+	return names
+}
+
+// aclRequest describes cmd for the permission check of a user.
+func aclRequest(cmd *Command) *acl.Request {
+	spec := commandTable[cmd.Name]
+
+	req := &acl.Request{
+		Command:    strings.ToLower(string(cmd.Name)),
+		Categories: spec.categories,
+	}
+
+	if spec.subcommands != nil {
+		subcommand, _ := cmd.ArgString(0)
+		subcommand = strings.ToLower(subcommand)
+
+		if categories, ok := spec.subcommands[subcommand]; ok {
+			req.Subcommand = subcommand
+			req.Categories = categories
+		}
+	}
+
+	if spec.keys != nil {
+		req.Keys = spec.keys(cmd)
+	}
+
+	if spec.channels != nil {
+		req.Channels = spec.channels(cmd)
+	}
+
+	return req
+}
+
+// Authorize checks that the user of handlerCtx may run its command. It is
+// the permission check of the ACL, made before the command is dispatched
+// or queued. A denial is logged with context, telling where the command
+// runs, and returned as the error to reply with.
+func Authorize(serverCtx *ServerContext, handlerCtx *HandlerContext, context string) *resp.Error {
+	denial := checkPermissions(serverCtx, handlerCtx, context)
+	if denial == nil {
+		return nil
+	}
+
+	return &resp.Error{Msg: "NOPERM " + shortDenialMessage(handlerCtx.User, denial)}
+}
+
+func checkPermissions(serverCtx *ServerContext, handlerCtx *HandlerContext, context string) *acl.Denial {
+	user := handlerCtx.User
+	if user == nil {
+		return nil
+	}
+
+	denial := user.Check(aclRequest(handlerCtx.Cmd))
+	if denial != nil && serverCtx.ACL != nil {
+		serverCtx.ACL.Log(acl.LogEntry{
+			Reason:     denial.Reason,
+			Context:    context,
+			Object:     denial.Object,
+			Username:   user.Name(),
+			ClientInfo: fmt.Sprintf("addr=%s user=%s", handlerCtx.RemoteAddr, user.Name()),
+		})
+	}
+
+	return denial
+}
+
+// shortDenialMessage is the message of a denial that does not name the
+// key or channel, which the error replies use.
+func shortDenialMessage(user *acl.User, denial *acl.Denial) string {
+	switch denial.Reason {
+	case acl.KeyReason:
+		return "No permissions to access a key"
+	case acl.ChannelReason:
+		return "No permissions to access a channel"
+	default:
+		return denialMessage(user, denial)
+	}
+}
+
+// denialMessage tells which permission user lacks, like ACL DRYRUN does.
+func denialMessage(user *acl.User, denial *acl.Denial) string {
+	switch denial.Reason {
+	case acl.KeyReason:
+		return fmt.Sprintf("User %s has no permissions to access the '%s' key", user.Name(), denial.Object)
+	case acl.ChannelReason:
+		return fmt.Sprintf("User %s has no permissions to access the '%s' channel", user.Name(), denial.Object)
+	default:
+		return fmt.Sprintf("User %s has no permissions to run the '%s' command", user.Name(), denial.Object)
+	}
+}
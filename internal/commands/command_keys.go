@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+)
+
+// keysFn returns the keys cmd accesses, which the key patterns of the ACL
+// users are checked against. The arity is checked beforehand.
+type keysFn func(cmd *Command) []acl.Key
+
+// channelsFn returns the channels cmd publishes or subscribes to.
+type channelsFn func(cmd *Command) []acl.Channel
+
+const readWrite = acl.ReadAccess | acl.WriteAccess
+
+// keyRange returns the keys from the argument first to the end of the
+// arguments, every step arguments, leaving out the last skip arguments.
+func keyRange(first, step, skip int, access acl.Access) keysFn {
+	return func(cmd *Command) []acl.Key {
+		keys := []acl.Key{}
+
+		for i := first; i < cmd.ArgsLen()-skip; i += step {
+			name, _ := cmd.ArgString(i)
+			keys = append(keys, acl.Key{Name: name, Access: access})
+		}
+
+		return keys
+	}
+}
+
+// firstKey is the single key of most commands.
+func firstKey(access acl.Access) keysFn {
+	return func(cmd *Command) []acl.Key {
+		name, _ := cmd.ArgString(0)
+		return []acl.Key{{Name: name, Access: access}}
+	}
+}
+
+func everyKey(access acl.Access) keysFn {
+	return keyRange(0, 1, 0, access)
+}
+
+// subcommandKey is the key following the subcommand of XGROUP and XINFO.
+func subcommandKey(access acl.Access) keysFn {
+	return func(cmd *Command) []acl.Key {
+		if cmd.ArgsLen() < 2 {
+			return nil
+		}
+
+		name, _ := cmd.ArgString(1)
+		return []acl.Key{{Name: name, Access: access}}
+	}
+}
+
+// destinationKeys is a destination key written followed by source keys
+// read, like in BITOP and PFMERGE. The destination of BITOP comes after
+// the operation.
+func destinationKeys(destination int, destinationAccess acl.Access) keysFn {
+	return func(cmd *Command) []acl.Key {
+		keys := keyRange(destination+1, 1, 0, acl.ReadAccess)(cmd)
+		name, _ := cmd.ArgString(destination)
+
+		return append([]acl.Key{{Name: name, Access: destinationAccess}}, keys...)
+	}
+}
+
+// setKeys is the key of SET, which is only read with the GET option.
+func setKeys(cmd *Command) []acl.Key {
+	access := acl.WriteAccess
+
+	for i := 2; i < cmd.ArgsLen(); i++ {
+		if arg, _ := cmd.ArgString(i); strings.EqualFold(arg, "GET") {
+			access |= acl.ReadAccess
+		}
+	}
+
+	return firstKey(access)(cmd)
+}
+
+// streamKeys is the keys following STREAMS in XREAD and XREADGROUP, the
+// first half of the arguments left. The token is searched from the argument
+// first, after GROUP group consumer in XREADGROUP since the group and the
+// consumer may be named streams.
+func streamKeys(first int, access acl.Access) keysFn {
+	return func(cmd *Command) []acl.Key {
+		for i := first; i < cmd.ArgsLen(); i++ {
+			if arg, _ := cmd.ArgString(i); strings.EqualFold(arg, "STREAMS") {
+				return keyRange(i+1, 1, (cmd.ArgsLen()-i)/2, access)(cmd)
+			}
+		}
+
+		return nil
+	}
+}
+
+// scriptKeys is the numkeys keys of EVAL and FCALL.
+func scriptKeys(access acl.Access) keysFn {
+	return func(cmd *Command) []acl.Key {
+		numKeys, ok := cmd.ArgInt(1)
+		if !ok || numKeys < 0 || numKeys > cmd.ArgsLen()-2 {
+			return nil
+		}
+
+		return keyRange(2, 1, cmd.ArgsLen()-2-numKeys, access)(cmd)
+	}
+}
+
+func firstChannel(cmd *Command) []acl.Channel {
+	name, _ := cmd.ArgString(0)
+	return []acl.Channel{{Name: name}}
+}
+
+func everyChannel(cmd *Command) []acl.Channel {
+	channels := make([]acl.Channel, cmd.ArgsLen())
+	for i := range channels {
+		channels[i].Name, _ = cmd.ArgString(i)
+	}
+
+	return channels
+}
+
+func everyPattern(cmd *Command) []acl.Channel {
+	channels := everyChannel(cmd)
+	for i := range channels {
+		channels[i].Pattern = true
+	}
+
+	return channels
+}
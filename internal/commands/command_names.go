@@ -5,53 +5,231 @@ import "strings"
 type Name string
 
 const (
-	PING_COMMAND    Name = "PING"
-	ECHO_COMMAND    Name = "ECHO"
-	GET_COMMAND     Name = "GET"
-	SET_COMMAND     Name = "SET"
-	RPUSH_COMMAND   Name = "RPUSH"
-	LRANGE_COMMAND  Name = "LRANGE"
-	LPUSH_COMMAND   Name = "LPUSH"
-	LLEN_COMMAND    Name = "LLEN"
-	LPOP_COMMAND    Name = "LPOP"
-	BLPOP_COMMAND   Name = "BLPOP"
-	TYPE_COMMAND    Name = "TYPE"
-	XADD_COMMAND    Name = "XADD"
-	XRANGE_COMMAND  Name = "XRANGE"
-	XREAD_COMMAND   Name = "XREAD"
-	INCR_COMMAND    Name = "INCR"
-	MULTI_COMMAND   Name = "MULTI"
-	EXEC_COMMAND    Name = "EXEC"
-	DISCARD_COMMAND Name = "DISCARD"
-	INFO_COMMAND    Name = "INFO"
-	REPLCONF        Name = "REPLCONF"
-	PSYNC           Name = "PSYNC"
-	WAIT            Name = "WAIT"
+	PING_COMMAND         Name = "PING"
+	ECHO_COMMAND         Name = "ECHO"
+	GET_COMMAND          Name = "GET"
+	SET_COMMAND          Name = "SET"
+	RPUSH_COMMAND        Name = "RPUSH"
+	LRANGE_COMMAND       Name = "LRANGE"
+	LPUSH_COMMAND        Name = "LPUSH"
+	LLEN_COMMAND         Name = "LLEN"
+	LPOP_COMMAND         Name = "LPOP"
+	BLPOP_COMMAND        Name = "BLPOP"
+	TYPE_COMMAND         Name = "TYPE"
+	XADD_COMMAND         Name = "XADD"
+	XRANGE_COMMAND       Name = "XRANGE"
+	XREAD_COMMAND        Name = "XREAD"
+	INCR_COMMAND         Name = "INCR"
+	MULTI_COMMAND        Name = "MULTI"
+	EXEC_COMMAND         Name = "EXEC"
+	DISCARD_COMMAND      Name = "DISCARD"
+	INFO_COMMAND         Name = "INFO"
+	REPLCONF             Name = "REPLCONF"
+	PSYNC                Name = "PSYNC"
+	WAIT                 Name = "WAIT"
+	MGET_COMMAND         Name = "MGET"
+	MSET_COMMAND         Name = "MSET"
+	MSETNX_COMMAND       Name = "MSETNX"
+	INCRBY_COMMAND       Name = "INCRBY"
+	DECR_COMMAND         Name = "DECR"
+	DECRBY_COMMAND       Name = "DECRBY"
+	INCRBYFLOAT_COMMAND  Name = "INCRBYFLOAT"
+	APPEND_COMMAND       Name = "APPEND"
+	STRLEN_COMMAND       Name = "STRLEN"
+	GETRANGE_COMMAND     Name = "GETRANGE"
+	SETRANGE_COMMAND     Name = "SETRANGE"
+	GETSET_COMMAND       Name = "GETSET"
+	GETDEL_COMMAND       Name = "GETDEL"
+	GETEX_COMMAND        Name = "GETEX"
+	PEXPIREAT_COMMAND    Name = "PEXPIREAT"
+	PERSIST_COMMAND      Name = "PERSIST"
+	SETNX_COMMAND        Name = "SETNX"
+	SETEX_COMMAND        Name = "SETEX"
+	PSETEX_COMMAND       Name = "PSETEX"
+	SETBIT_COMMAND       Name = "SETBIT"
+	GETBIT_COMMAND       Name = "GETBIT"
+	BITCOUNT_COMMAND     Name = "BITCOUNT"
+	BITPOS_COMMAND       Name = "BITPOS"
+	BITOP_COMMAND        Name = "BITOP"
+	BITFIELD_COMMAND     Name = "BITFIELD"
+	BITFIELD_RO_COMMAND  Name = "BITFIELD_RO"
+	PFADD_COMMAND        Name = "PFADD"
+	PFCOUNT_COMMAND      Name = "PFCOUNT"
+	PFMERGE_COMMAND      Name = "PFMERGE"
+	LCS_COMMAND          Name = "LCS"
+	XTRIM_COMMAND        Name = "XTRIM"
+	XREVRANGE_COMMAND    Name = "XREVRANGE"
+	XLEN_COMMAND         Name = "XLEN"
+	XDEL_COMMAND         Name = "XDEL"
+	XGROUP_COMMAND       Name = "XGROUP"
+	XREADGROUP_COMMAND   Name = "XREADGROUP"
+	XACK_COMMAND         Name = "XACK"
+	XPENDING_COMMAND     Name = "XPENDING"
+	XCLAIM_COMMAND       Name = "XCLAIM"
+	XAUTOCLAIM_COMMAND   Name = "XAUTOCLAIM"
+	XINFO_COMMAND        Name = "XINFO"
+	SUBSCRIBE_COMMAND    Name = "SUBSCRIBE"
+	UNSUBSCRIBE_COMMAND  Name = "UNSUBSCRIBE"
+	PSUBSCRIBE_COMMAND   Name = "PSUBSCRIBE"
+	PUNSUBSCRIBE_COMMAND Name = "PUNSUBSCRIBE"
+	PUBLISH_COMMAND      Name = "PUBLISH"
+	PUBSUB_COMMAND       Name = "PUBSUB"
+	QUIT_COMMAND         Name = "QUIT"
+	SSUBSCRIBE_COMMAND   Name = "SSUBSCRIBE"
+	SUNSUBSCRIBE_COMMAND Name = "SUNSUBSCRIBE"
+	SPUBLISH_COMMAND     Name = "SPUBLISH"
+	CONFIG_COMMAND       Name = "CONFIG"
+	WATCH_COMMAND        Name = "WATCH"
+	UNWATCH_COMMAND      Name = "UNWATCH"
+	EVAL_COMMAND         Name = "EVAL"
+	EVALSHA_COMMAND      Name = "EVALSHA"
+	EVAL_RO_COMMAND      Name = "EVAL_RO"
+	EVALSHA_RO_COMMAND   Name = "EVALSHA_RO"
+	SCRIPT_COMMAND       Name = "SCRIPT"
+	FUNCTION_COMMAND     Name = "FUNCTION"
+	FCALL_COMMAND        Name = "FCALL"
+	FCALL_RO_COMMAND     Name = "FCALL_RO"
+	HELLO_COMMAND        Name = "HELLO"
+	AUTH_COMMAND         Name = "AUTH"
+	ACL_COMMAND          Name = "ACL"
 )
 
 var commandByName = map[string]Name{
-	string(PING_COMMAND):    PING_COMMAND,
-	string(ECHO_COMMAND):    ECHO_COMMAND,
-	string(GET_COMMAND):     GET_COMMAND,
-	string(SET_COMMAND):     SET_COMMAND,
-	string(RPUSH_COMMAND):   RPUSH_COMMAND,
-	string(LRANGE_COMMAND):  LRANGE_COMMAND,
-	string(LPUSH_COMMAND):   LPUSH_COMMAND,
-	string(LLEN_COMMAND):    LLEN_COMMAND,
-	string(LPOP_COMMAND):    LPOP_COMMAND,
-	string(BLPOP_COMMAND):   BLPOP_COMMAND,
-	string(TYPE_COMMAND):    TYPE_COMMAND,
-	string(XADD_COMMAND):    XADD_COMMAND,
-	string(XRANGE_COMMAND):  XRANGE_COMMAND,
-	string(XREAD_COMMAND):   XREAD_COMMAND,
-	string(INCR_COMMAND):    INCR_COMMAND,
-	string(MULTI_COMMAND):   MULTI_COMMAND,
-	string(EXEC_COMMAND):    EXEC_COMMAND,
-	string(DISCARD_COMMAND): DISCARD_COMMAND,
-	string(INFO_COMMAND):    INFO_COMMAND,
-	string(REPLCONF):        REPLCONF,
-	string(PSYNC):           PSYNC,
-	string(WAIT):            WAIT,
+	string(PING_COMMAND):         PING_COMMAND,
+	string(ECHO_COMMAND):         ECHO_COMMAND,
+	string(GET_COMMAND):          GET_COMMAND,
+	string(SET_COMMAND):          SET_COMMAND,
+	string(RPUSH_COMMAND):        RPUSH_COMMAND,
+	string(LRANGE_COMMAND):       LRANGE_COMMAND,
+	string(LPUSH_COMMAND):        LPUSH_COMMAND,
+	string(LLEN_COMMAND):         LLEN_COMMAND,
+	string(LPOP_COMMAND):         LPOP_COMMAND,
+	string(BLPOP_COMMAND):        BLPOP_COMMAND,
+	string(TYPE_COMMAND):         TYPE_COMMAND,
+	string(XADD_COMMAND):         XADD_COMMAND,
+	string(XRANGE_COMMAND):       XRANGE_COMMAND,
+	string(XREAD_COMMAND):        XREAD_COMMAND,
+	string(INCR_COMMAND):         INCR_COMMAND,
+	string(MULTI_COMMAND):        MULTI_COMMAND,
+	string(EXEC_COMMAND):         EXEC_COMMAND,
+	string(DISCARD_COMMAND):      DISCARD_COMMAND,
+	string(INFO_COMMAND):         INFO_COMMAND,
+	string(REPLCONF):             REPLCONF,
+	string(PSYNC):                PSYNC,
+	string(WAIT):                 WAIT,
+	string(MGET_COMMAND):         MGET_COMMAND,
+	string(MSET_COMMAND):         MSET_COMMAND,
+	string(MSETNX_COMMAND):       MSETNX_COMMAND,
+	string(INCRBY_COMMAND):       INCRBY_COMMAND,
+	string(DECR_COMMAND):         DECR_COMMAND,
+	string(DECRBY_COMMAND):       DECRBY_COMMAND,
+	string(INCRBYFLOAT_COMMAND):  INCRBYFLOAT_COMMAND,
+	string(APPEND_COMMAND):       APPEND_COMMAND,
+	string(STRLEN_COMMAND):       STRLEN_COMMAND,
+	string(GETRANGE_COMMAND):     GETRANGE_COMMAND,
+	string(SETRANGE_COMMAND):     SETRANGE_COMMAND,
+	string(GETSET_COMMAND):       GETSET_COMMAND,
+	string(GETDEL_COMMAND):       GETDEL_COMMAND,
+	string(GETEX_COMMAND):        GETEX_COMMAND,
+	string(PEXPIREAT_COMMAND):    PEXPIREAT_COMMAND,
+	string(PERSIST_COMMAND):      PERSIST_COMMAND,
+	string(SETNX_COMMAND):        SETNX_COMMAND,
+	string(SETEX_COMMAND):        SETEX_COMMAND,
+	string(PSETEX_COMMAND):       PSETEX_COMMAND,
+	string(SETBIT_COMMAND):       SETBIT_COMMAND,
+	string(GETBIT_COMMAND):       GETBIT_COMMAND,
+	string(BITCOUNT_COMMAND):     BITCOUNT_COMMAND,
+	string(BITPOS_COMMAND):       BITPOS_COMMAND,
+	string(BITOP_COMMAND):        BITOP_COMMAND,
+	string(BITFIELD_COMMAND):     BITFIELD_COMMAND,
+	string(BITFIELD_RO_COMMAND):  BITFIELD_RO_COMMAND,
+	string(PFADD_COMMAND):        PFADD_COMMAND,
+	string(PFCOUNT_COMMAND):      PFCOUNT_COMMAND,
+	string(PFMERGE_COMMAND):      PFMERGE_COMMAND,
+	string(LCS_COMMAND):          LCS_COMMAND,
+	string(XTRIM_COMMAND):        XTRIM_COMMAND,
+	string(XREVRANGE_COMMAND):    XREVRANGE_COMMAND,
+	string(XLEN_COMMAND):         XLEN_COMMAND,
+	string(XDEL_COMMAND):         XDEL_COMMAND,
+	string(XGROUP_COMMAND):       XGROUP_COMMAND,
+	string(XREADGROUP_COMMAND):   XREADGROUP_COMMAND,
+	string(XACK_COMMAND):         XACK_COMMAND,
+	string(XPENDING_COMMAND):     XPENDING_COMMAND,
+	string(XCLAIM_COMMAND):       XCLAIM_COMMAND,
+	string(XAUTOCLAIM_COMMAND):   XAUTOCLAIM_COMMAND,
+	string(XINFO_COMMAND):        XINFO_COMMAND,
+	string(SUBSCRIBE_COMMAND):    SUBSCRIBE_COMMAND,
+	string(UNSUBSCRIBE_COMMAND):  UNSUBSCRIBE_COMMAND,
+	string(PSUBSCRIBE_COMMAND):   PSUBSCRIBE_COMMAND,
+	string(PUNSUBSCRIBE_COMMAND): PUNSUBSCRIBE_COMMAND,
+	string(PUBLISH_COMMAND):      PUBLISH_COMMAND,
+	string(PUBSUB_COMMAND):       PUBSUB_COMMAND,
+	string(QUIT_COMMAND):         QUIT_COMMAND,
+	string(SSUBSCRIBE_COMMAND):   SSUBSCRIBE_COMMAND,
+	string(SUNSUBSCRIBE_COMMAND): SUNSUBSCRIBE_COMMAND,
+	string(SPUBLISH_COMMAND):     SPUBLISH_COMMAND,
+	string(CONFIG_COMMAND):       CONFIG_COMMAND,
+	string(WATCH_COMMAND):        WATCH_COMMAND,
+	string(UNWATCH_COMMAND):      UNWATCH_COMMAND,
+	string(EVAL_COMMAND):         EVAL_COMMAND,
+	string(EVALSHA_COMMAND):      EVALSHA_COMMAND,
+	string(EVAL_RO_COMMAND):      EVAL_RO_COMMAND,
+	string(EVALSHA_RO_COMMAND):   EVALSHA_RO_COMMAND,
+	string(SCRIPT_COMMAND):       SCRIPT_COMMAND,
+	string(FUNCTION_COMMAND):     FUNCTION_COMMAND,
+	string(FCALL_COMMAND):        FCALL_COMMAND,
+	string(FCALL_RO_COMMAND):     FCALL_RO_COMMAND,
+	string(HELLO_COMMAND):        HELLO_COMMAND,
+	string(AUTH_COMMAND):         AUTH_COMMAND,
+	string(ACL_COMMAND):          ACL_COMMAND,
+}
+
+// writeCommands lists the commands that have to be propagated to replicas,
+// the commands that modify the keyspace and PUBLISH and SPUBLISH so that
+// the clients of replicas get the messages too. Like the other writes, the
+// messages are published with the store locked.
+var writeCommands = map[Name]bool{
+	SET_COMMAND:         true,
+	RPUSH_COMMAND:       true,
+	LPUSH_COMMAND:       true,
+	LPOP_COMMAND:        true,
+	BLPOP_COMMAND:       true,
+	XADD_COMMAND:        true,
+	INCR_COMMAND:        true,
+	MSET_COMMAND:        true,
+	MSETNX_COMMAND:      true,
+	INCRBY_COMMAND:      true,
+	DECR_COMMAND:        true,
+	DECRBY_COMMAND:      true,
+	INCRBYFLOAT_COMMAND: true,
+	APPEND_COMMAND:      true,
+	SETRANGE_COMMAND:    true,
+	GETSET_COMMAND:      true,
+	GETDEL_COMMAND:      true,
+	GETEX_COMMAND:       true,
+	PEXPIREAT_COMMAND:   true,
+	PERSIST_COMMAND:     true,
+	SETNX_COMMAND:       true,
+	SETEX_COMMAND:       true,
+	PSETEX_COMMAND:      true,
+	SETBIT_COMMAND:      true,
+	BITOP_COMMAND:       true,
+	BITFIELD_COMMAND:    true,
+	PFADD_COMMAND:       true,
+	PFMERGE_COMMAND:     true,
+	XTRIM_COMMAND:       true,
+	XDEL_COMMAND:        true,
+	XGROUP_COMMAND:      true,
+	XREADGROUP_COMMAND:  true,
+	XACK_COMMAND:        true,
+	XCLAIM_COMMAND:      true,
+	XAUTOCLAIM_COMMAND:  true,
+	PUBLISH_COMMAND:     true,
+	SPUBLISH_COMMAND:    true,
+}
+
+func IsWriteCommand(name Name) bool {
+	return writeCommands[name]
 }
 
 func getCommandName(name []byte) Name {
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+)
+
+// commandSpec describes how a command is invoked. arity follows the Redis
+// convention: it counts the command name, a positive value is the exact
+// number of arguments and a negative one the minimum. noScript commands
+// cannot be called from scripts. The ACL rules are checked against the
+// categories of the command, or of its subcommand for container commands,
+// and against the keys and channels it accesses.
+type commandSpec struct {
+	arity       int
+	noScript    bool
+	categories  acl.Category
+	subcommands map[string]acl.Category
+	keys        keysFn
+	channels    channelsFn
+}
+
+var commandTable = map[Name]commandSpec{
+	PING_COMMAND:         {arity: -1, categories: acl.FastCategory | acl.ConnectionCategory},
+	ECHO_COMMAND:         {arity: 2, categories: acl.FastCategory | acl.ConnectionCategory},
+	GET_COMMAND:          {arity: 2, categories: acl.ReadCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(acl.ReadAccess)},
+	SET_COMMAND:          {arity: -3, categories: acl.WriteCategory | acl.StringCategory | acl.SlowCategory, keys: setKeys},
+	RPUSH_COMMAND:        {arity: -3, categories: acl.WriteCategory | acl.ListCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	LRANGE_COMMAND:       {arity: 4, categories: acl.ReadCategory | acl.ListCategory | acl.SlowCategory, keys: firstKey(acl.ReadAccess)},
+	LPUSH_COMMAND:        {arity: -3, categories: acl.WriteCategory | acl.ListCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	LLEN_COMMAND:         {arity: 2, categories: acl.ReadCategory | acl.ListCategory | acl.FastCategory, keys: firstKey(acl.ReadAccess)},
+	LPOP_COMMAND:         {arity: -2, categories: acl.WriteCategory | acl.ListCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	BLPOP_COMMAND:        {arity: -3, categories: acl.WriteCategory | acl.ListCategory | acl.SlowCategory | acl.BlockingCategory, keys: keyRange(0, 1, 1, readWrite)},
+	TYPE_COMMAND:         {arity: 2, categories: acl.KeyspaceCategory | acl.ReadCategory | acl.FastCategory, keys: firstKey(acl.ReadAccess)},
+	XADD_COMMAND:         {arity: -5, categories: acl.WriteCategory | acl.StreamCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	XRANGE_COMMAND:       {arity: -4, categories: acl.ReadCategory | acl.StreamCategory | acl.SlowCategory, keys: firstKey(acl.ReadAccess)},
+	XREAD_COMMAND:        {arity: -4, categories: acl.ReadCategory | acl.StreamCategory | acl.SlowCategory | acl.BlockingCategory, keys: streamKeys(0, acl.ReadAccess)},
+	INCR_COMMAND:         {arity: 2, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	MULTI_COMMAND:        {arity: 1, noScript: true, categories: acl.FastCategory | acl.TransactionCategory},
+	EXEC_COMMAND:         {arity: 1, noScript: true, categories: acl.SlowCategory | acl.TransactionCategory},
+	DISCARD_COMMAND:      {arity: 1, noScript: true, categories: acl.FastCategory | acl.TransactionCategory},
+	INFO_COMMAND:         {arity: -1, categories: acl.SlowCategory | acl.DangerousCategory},
+	REPLCONF:             {arity: -1, noScript: true, categories: acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory},
+	PSYNC:                {arity: -3, noScript: true, categories: acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory},
+	WAIT:                 {arity: 3, noScript: true, categories: acl.SlowCategory | acl.ConnectionCategory},
+	MGET_COMMAND:         {arity: -2, categories: acl.ReadCategory | acl.StringCategory | acl.FastCategory, keys: everyKey(acl.ReadAccess)},
+	MSET_COMMAND:         {arity: -3, categories: acl.WriteCategory | acl.StringCategory | acl.SlowCategory, keys: keyRange(0, 2, 0, acl.WriteAccess)},
+	MSETNX_COMMAND:       {arity: -3, categories: acl.WriteCategory | acl.StringCategory | acl.SlowCategory, keys: keyRange(0, 2, 0, acl.WriteAccess)},
+	INCRBY_COMMAND:       {arity: 3, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	DECR_COMMAND:         {arity: 2, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	DECRBY_COMMAND:       {arity: 3, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	INCRBYFLOAT_COMMAND:  {arity: 3, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	APPEND_COMMAND:       {arity: 3, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	STRLEN_COMMAND:       {arity: 2, categories: acl.ReadCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(acl.ReadAccess)},
+	GETRANGE_COMMAND:     {arity: 4, categories: acl.ReadCategory | acl.StringCategory | acl.SlowCategory, keys: firstKey(acl.ReadAccess)},
+	SETRANGE_COMMAND:     {arity: 4, categories: acl.WriteCategory | acl.StringCategory | acl.SlowCategory, keys: firstKey(readWrite)},
+	GETSET_COMMAND:       {arity: 3, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	GETDEL_COMMAND:       {arity: 2, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	GETEX_COMMAND:        {arity: -2, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	PEXPIREAT_COMMAND:    {arity: 3, categories: acl.KeyspaceCategory | acl.WriteCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	PERSIST_COMMAND:      {arity: 2, categories: acl.KeyspaceCategory | acl.WriteCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	SETNX_COMMAND:        {arity: 3, categories: acl.WriteCategory | acl.StringCategory | acl.FastCategory, keys: firstKey(acl.WriteAccess)},
+	SETEX_COMMAND:        {arity: 4, categories: acl.WriteCategory | acl.StringCategory | acl.SlowCategory, keys: firstKey(acl.WriteAccess)},
+	PSETEX_COMMAND:       {arity: 4, categories: acl.WriteCategory | acl.StringCategory | acl.SlowCategory, keys: firstKey(acl.WriteAccess)},
+	SETBIT_COMMAND:       {arity: 4, categories: acl.WriteCategory | acl.BitmapCategory | acl.SlowCategory, keys: firstKey(readWrite)},
+	GETBIT_COMMAND:       {arity: 3, categories: acl.ReadCategory | acl.BitmapCategory | acl.FastCategory, keys: firstKey(acl.ReadAccess)},
+	BITCOUNT_COMMAND:     {arity: -2, categories: acl.ReadCategory | acl.BitmapCategory | acl.SlowCategory, keys: firstKey(acl.ReadAccess)},
+	BITPOS_COMMAND:       {arity: -3, categories: acl.ReadCategory | acl.BitmapCategory | acl.SlowCategory, keys: firstKey(acl.ReadAccess)},
+	BITOP_COMMAND:        {arity: -4, categories: acl.WriteCategory | acl.BitmapCategory | acl.SlowCategory, keys: destinationKeys(1, acl.WriteAccess)},
+	BITFIELD_COMMAND:     {arity: -2, categories: acl.WriteCategory | acl.BitmapCategory | acl.SlowCategory, keys: firstKey(readWrite)},
+	BITFIELD_RO_COMMAND:  {arity: -2, categories: acl.ReadCategory | acl.BitmapCategory | acl.FastCategory, keys: firstKey(acl.ReadAccess)},
+	PFADD_COMMAND:        {arity: -2, categories: acl.WriteCategory | acl.HyperLogLogCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	PFCOUNT_COMMAND:      {arity: -2, categories: acl.ReadCategory | acl.HyperLogLogCategory | acl.SlowCategory, keys: everyKey(acl.ReadAccess)},
+	PFMERGE_COMMAND:      {arity: -2, categories: acl.WriteCategory | acl.HyperLogLogCategory | acl.SlowCategory, keys: destinationKeys(0, readWrite)},
+	LCS_COMMAND:          {arity: -3, categories: acl.ReadCategory | acl.StringCategory | acl.SlowCategory, keys: keyRange(0, 1, 0, acl.ReadAccess)},
+	XTRIM_COMMAND:        {arity: -4, categories: acl.WriteCategory | acl.StreamCategory | acl.SlowCategory, keys: firstKey(readWrite)},
+	XREVRANGE_COMMAND:    {arity: -4, categories: acl.ReadCategory | acl.StreamCategory | acl.SlowCategory, keys: firstKey(acl.ReadAccess)},
+	XLEN_COMMAND:         {arity: 2, categories: acl.ReadCategory | acl.StreamCategory | acl.FastCategory, keys: firstKey(acl.ReadAccess)},
+	XDEL_COMMAND:         {arity: -3, categories: acl.WriteCategory | acl.StreamCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	XGROUP_COMMAND:       {arity: -2, categories: acl.StreamCategory | acl.SlowCategory, keys: subcommandKey(readWrite), subcommands: map[string]acl.Category{"create": acl.WriteCategory | acl.StreamCategory | acl.SlowCategory, "setid": acl.WriteCategory | acl.StreamCategory | acl.SlowCategory, "destroy": acl.WriteCategory | acl.StreamCategory | acl.SlowCategory, "createconsumer": acl.WriteCategory | acl.StreamCategory | acl.SlowCategory, "delconsumer": acl.WriteCategory | acl.StreamCategory | acl.SlowCategory}},
+	XREADGROUP_COMMAND:   {arity: -7, categories: acl.WriteCategory | acl.StreamCategory | acl.SlowCategory | acl.BlockingCategory, keys: streamKeys(3, readWrite)},
+	XACK_COMMAND:         {arity: -4, categories: acl.WriteCategory | acl.StreamCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	XPENDING_COMMAND:     {arity: -3, categories: acl.ReadCategory | acl.StreamCategory | acl.SlowCategory, keys: firstKey(acl.ReadAccess)},
+	XCLAIM_COMMAND:       {arity: -6, categories: acl.WriteCategory | acl.StreamCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	XAUTOCLAIM_COMMAND:   {arity: -6, categories: acl.WriteCategory | acl.StreamCategory | acl.FastCategory, keys: firstKey(readWrite)},
+	XINFO_COMMAND:        {arity: -2, categories: acl.StreamCategory | acl.SlowCategory, keys: subcommandKey(acl.ReadAccess), subcommands: map[string]acl.Category{"stream": acl.ReadCategory | acl.StreamCategory | acl.SlowCategory, "groups": acl.ReadCategory | acl.StreamCategory | acl.SlowCategory, "consumers": acl.ReadCategory | acl.StreamCategory | acl.SlowCategory}},
+	SUBSCRIBE_COMMAND:    {arity: -2, noScript: true, categories: acl.PubSubCategory | acl.SlowCategory, channels: everyChannel},
+	UNSUBSCRIBE_COMMAND:  {arity: -1, noScript: true, categories: acl.PubSubCategory | acl.SlowCategory},
+	PSUBSCRIBE_COMMAND:   {arity: -2, noScript: true, categories: acl.PubSubCategory | acl.SlowCategory, channels: everyPattern},
+	PUNSUBSCRIBE_COMMAND: {arity: -1, noScript: true, categories: acl.PubSubCategory | acl.SlowCategory},
+	PUBLISH_COMMAND:      {arity: 3, categories: acl.PubSubCategory | acl.FastCategory, channels: firstChannel},
+	PUBSUB_COMMAND:       {arity: -2, categories: acl.PubSubCategory | acl.SlowCategory, subcommands: map[string]acl.Category{"channels": acl.PubSubCategory | acl.SlowCategory, "numsub": acl.PubSubCategory | acl.SlowCategory, "numpat": acl.PubSubCategory | acl.SlowCategory, "shardchannels": acl.PubSubCategory | acl.SlowCategory, "shardnumsub": acl.PubSubCategory | acl.SlowCategory}},
+	QUIT_COMMAND:         {arity: -1, noScript: true, categories: acl.FastCategory | acl.ConnectionCategory},
+	SSUBSCRIBE_COMMAND:   {arity: -2, noScript: true, categories: acl.PubSubCategory | acl.SlowCategory, channels: everyChannel},
+	SUNSUBSCRIBE_COMMAND: {arity: -1, noScript: true, categories: acl.PubSubCategory | acl.SlowCategory},
+	SPUBLISH_COMMAND:     {arity: 3, categories: acl.PubSubCategory | acl.FastCategory, channels: firstChannel},
+	CONFIG_COMMAND:       {arity: -2, categories: acl.SlowCategory, subcommands: map[string]acl.Category{"get": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "set": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory}},
+	WATCH_COMMAND:        {arity: -2, noScript: true, categories: acl.FastCategory | acl.TransactionCategory, keys: everyKey(acl.ReadAccess)},
+	UNWATCH_COMMAND:      {arity: 1, noScript: true, categories: acl.FastCategory | acl.TransactionCategory},
+	EVAL_COMMAND:         {arity: -3, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, keys: scriptKeys(readWrite)},
+	EVALSHA_COMMAND:      {arity: -3, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, keys: scriptKeys(readWrite)},
+	EVAL_RO_COMMAND:      {arity: -3, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, keys: scriptKeys(acl.ReadAccess)},
+	EVALSHA_RO_COMMAND:   {arity: -3, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, keys: scriptKeys(acl.ReadAccess)},
+	SCRIPT_COMMAND:       {arity: -2, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, subcommands: map[string]acl.Category{"load": acl.SlowCategory | acl.ScriptingCategory, "exists": acl.SlowCategory | acl.ScriptingCategory, "flush": acl.SlowCategory | acl.ScriptingCategory, "kill": acl.SlowCategory | acl.ScriptingCategory}},
+	FUNCTION_COMMAND:     {arity: -2, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, subcommands: map[string]acl.Category{"load": acl.WriteCategory | acl.SlowCategory | acl.ScriptingCategory, "list": acl.SlowCategory | acl.ScriptingCategory, "delete": acl.WriteCategory | acl.SlowCategory | acl.ScriptingCategory, "flush": acl.WriteCategory | acl.SlowCategory | acl.ScriptingCategory, "dump": acl.SlowCategory | acl.ScriptingCategory, "restore": acl.WriteCategory | acl.SlowCategory | acl.ScriptingCategory, "stats": acl.SlowCategory | acl.ScriptingCategory, "kill": acl.SlowCategory | acl.ScriptingCategory}},
+	FCALL_COMMAND:        {arity: -3, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, keys: scriptKeys(readWrite)},
+	FCALL_RO_COMMAND:     {arity: -3, noScript: true, categories: acl.SlowCategory | acl.ScriptingCategory, keys: scriptKeys(acl.ReadAccess)},
+	HELLO_COMMAND:        {arity: -1, noScript: true, categories: acl.FastCategory | acl.ConnectionCategory},
+	AUTH_COMMAND:         {arity: -2, noScript: true, categories: acl.FastCategory | acl.ConnectionCategory},
+	ACL_COMMAND:          {arity: -2, noScript: true, categories: acl.SlowCategory, subcommands: map[string]acl.Category{"cat": acl.SlowCategory, "whoami": acl.SlowCategory, "setuser": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "getuser": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "deluser": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "list": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "users": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "dryrun": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "log": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "load": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory, "save": acl.AdminCategory | acl.SlowCategory | acl.DangerousCategory}},
+}
+
+// Validate checks cmd against the command table before it runs: its arity
+// and, for a container command, its subcommand. It is used to reject a
+// command at queue time inside MULTI, the handlers still check the rest of
+// the syntax when the command executes.
+func Validate(cmd *Command) error {
+	spec, ok := commandTable[cmd.Name]
+	if !ok {
+		return fmt.Errorf("ERR unknown command '%s'", cmd.Name)
+	}
+
+	argc := cmd.ArgsLen() + 1
+
+	if (spec.arity > 0 && argc != spec.arity) || argc < -spec.arity {
+		return fmt.Errorf("ERR wrong number of arguments for %s command", cmd.Name)
+	}
+
+	if spec.subcommands != nil {
+		subcommand, _ := cmd.ArgString(0)
+
+		if _, ok := spec.subcommands[strings.ToLower(subcommand)]; !ok {
+			return fmt.Errorf("ERR unknown subcommand '%s'. Try %s HELP.", subcommand, cmd.Name)
+		}
+	}
+
+	return nil
+}
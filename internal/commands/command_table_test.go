@@ -0,0 +1,60 @@
+package commands
+
+import "testing"
+
+func TestCommandTableCoversEveryCommand(t *testing.T) {
+	for _, name := range commandByName {
+		if _, ok := commandTable[name]; !ok {
+			t.Errorf("command %s is missing from the command table", name)
+		}
+	}
+}
+
+func TestValidateArity(t *testing.T) {
+	valid := []*Command{
+		newTestCommand(GET_COMMAND, "foo"),
+		newTestCommand(SET_COMMAND, "foo", "bar", "EX", "10"),
+		newTestCommand(PING_COMMAND),
+		newTestCommand(MULTI_COMMAND),
+	}
+
+	for _, cmd := range valid {
+		if err := Validate(cmd); err != nil {
+			t.Errorf("expected %s %v to be valid, got %v", cmd.Name, cmd.Args, err)
+		}
+	}
+
+	invalid := []*Command{
+		newTestCommand(GET_COMMAND),
+		newTestCommand(GET_COMMAND, "foo", "bar"),
+		newTestCommand(SET_COMMAND, "foo"),
+		newTestCommand(EXEC_COMMAND, "now"),
+	}
+
+	for _, cmd := range invalid {
+		err := Validate(cmd)
+		want := "ERR wrong number of arguments for " + string(cmd.Name) + " command"
+
+		if err == nil || err.Error() != want {
+			t.Errorf("expected %s %v to fail with %q, got %v", cmd.Name, cmd.Args, want, err)
+		}
+	}
+}
+
+func TestValidateSubcommand(t *testing.T) {
+	if err := Validate(newTestCommand(CONFIG_COMMAND, "get", "maxmemory")); err != nil {
+		t.Errorf("expected CONFIG get to be valid, got %v", err)
+	}
+
+	invalid := map[*Command]string{
+		newTestCommand(CONFIG_COMMAND, "FOO"):   "ERR unknown subcommand 'FOO'. Try CONFIG HELP.",
+		newTestCommand(XGROUP_COMMAND, "foo"):   "ERR unknown subcommand 'foo'. Try XGROUP HELP.",
+		newTestCommand(FUNCTION_COMMAND, "FOO"): "ERR unknown subcommand 'FOO'. Try FUNCTION HELP.",
+	}
+
+	for cmd, want := range invalid {
+		if err := Validate(cmd); err == nil || err.Error() != want {
+			t.Errorf("expected %s %v to fail with %q, got %v", cmd.Name, cmd.Args, want, err)
+		}
+	}
+}
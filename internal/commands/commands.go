@@ -3,8 +3,12 @@ package commands
 import (
 	"fmt"
 
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+	"github.com/codecrafters-io/redis-starter-go/internal/config"
+	"github.com/codecrafters-io/redis-starter-go/internal/pubsub"
 	"github.com/codecrafters-io/redis-starter-go/internal/replica"
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
 	"github.com/codecrafters-io/redis-starter-go/internal/store"
 )
 
@@ -45,6 +49,15 @@ func (c *Command) ArgFloat(idx int) (float64, bool) {
 	return valueAsFloat(c.Args[idx])
 }
 
+// Array returns the command as it is sent by a client, used to replicate it.
+func (c *Command) Array() *resp.Array {
+	arr := &resp.Array{Elements: make([]resp.Value, 0, c.ArgsLen()+1)}
+	arr.Elements = append(arr.Elements, bulkString(string(c.Name)))
+	arr.Elements = append(arr.Elements, c.Args...)
+
+	return arr
+}
+
 func Parse(v resp.Value) (*Command, error) {
 	switch v := v.(type) {
 	case *resp.Array:
@@ -93,42 +106,144 @@ type ServerContext struct {
 	ReplicationId     string
 	ReplicationOffset int
 	MasterOffset      int // bytes sent to replicas (master-side tracking)
+	PubSub            *pubsub.PubSub
+	Config            *config.Config
+	Scripts           *scripting.Scripts
+	ACL               *acl.ACL
 }
 
 type HandlerContext struct {
 	Cmd        *Command
 	RemoteAddr string
+	// InTransaction is set for the commands run by EXEC or by a script,
+	// blocking commands then behave as their non-blocking forms and the
+	// writes are replicated by EXEC or the script rather than by Dispatch
+	InTransaction bool
+	// User is the ACL user the client is authenticated as, no permission
+	// is checked without one
+	User *acl.User
+	// Effects, when set by the handler, replicate the command instead of
+	// the command itself, see Effects
+	Effects []resp.Value
 }
 
 type handlerFn func(*ServerContext, *HandlerContext) resp.Value
 
 var handlers = map[Name]handlerFn{
-	PING_COMMAND:    handlePing,
-	ECHO_COMMAND:    handleEcho,
-	GET_COMMAND:     handleGet,
-	SET_COMMAND:     handleSet,
-	RPUSH_COMMAND:   handlePush,
-	LPUSH_COMMAND:   handlePush,
-	LRANGE_COMMAND:  handleLrange,
-	LLEN_COMMAND:    handleLlen,
-	LPOP_COMMAND:    handleLpop,
-	BLPOP_COMMAND:   handleBlpop,
-	TYPE_COMMAND:    handleType,
-	XADD_COMMAND:    handleXadd,
-	XRANGE_COMMAND:  handleXrange,
-	XREAD_COMMAND:   handleXread,
-	INCR_COMMAND:    handleIncr,
-	MULTI_COMMAND:   handleMulti,
-	DISCARD_COMMAND: handleDiscard,
-	INFO_COMMAND:    handleInfo,
-	REPLCONF:        handleReplconf,
-	WAIT:            handleWait,
+	PING_COMMAND:        handlePing,
+	ECHO_COMMAND:        handleEcho,
+	GET_COMMAND:         handleGet,
+	SET_COMMAND:         handleSet,
+	RPUSH_COMMAND:       handlePush,
+	LPUSH_COMMAND:       handlePush,
+	LRANGE_COMMAND:      handleLrange,
+	LLEN_COMMAND:        handleLlen,
+	LPOP_COMMAND:        handleLpop,
+	BLPOP_COMMAND:       handleBlpop,
+	TYPE_COMMAND:        handleType,
+	XADD_COMMAND:        handleXadd,
+	XRANGE_COMMAND:      handleXrange,
+	XREAD_COMMAND:       handleXread,
+	INCR_COMMAND:        handleIncr,
+	MULTI_COMMAND:       handleMulti,
+	DISCARD_COMMAND:     handleDiscard,
+	INFO_COMMAND:        handleInfo,
+	REPLCONF:            handleReplconf,
+	WAIT:                handleWait,
+	MGET_COMMAND:        handleMget,
+	MSET_COMMAND:        handleMset,
+	MSETNX_COMMAND:      handleMset,
+	INCRBY_COMMAND:      handleIncrBy,
+	DECR_COMMAND:        handleIncrBy,
+	DECRBY_COMMAND:      handleIncrBy,
+	INCRBYFLOAT_COMMAND: handleIncrByFloat,
+	APPEND_COMMAND:      handleAppend,
+	STRLEN_COMMAND:      handleStrlen,
+	GETRANGE_COMMAND:    handleGetrange,
+	SETRANGE_COMMAND:    handleSetrange,
+	GETSET_COMMAND:      handleGetSet,
+	GETDEL_COMMAND:      handleGetSet,
+	GETEX_COMMAND:       handleGetex,
+	PEXPIREAT_COMMAND:   handlePexpireat,
+	PERSIST_COMMAND:     handlePersist,
+	SETNX_COMMAND:       handleSetnx,
+	SETEX_COMMAND:       handleSetex,
+	PSETEX_COMMAND:      handleSetex,
+	SETBIT_COMMAND:      handleSetbit,
+	GETBIT_COMMAND:      handleSetbit,
+	BITCOUNT_COMMAND:    handleBitcount,
+	BITPOS_COMMAND:      handleBitpos,
+	BITOP_COMMAND:       handleBitop,
+	BITFIELD_COMMAND:    handleBitfield,
+	BITFIELD_RO_COMMAND: handleBitfield,
+	PFADD_COMMAND:       handlePfadd,
+	PFCOUNT_COMMAND:     handlePfcount,
+	PFMERGE_COMMAND:     handlePfcount,
+	LCS_COMMAND:         handleLcs,
+	XTRIM_COMMAND:       handleXtrim,
+	XREVRANGE_COMMAND:   handleXrange,
+	XLEN_COMMAND:        handleXlen,
+	XDEL_COMMAND:        handleXdel,
+	XGROUP_COMMAND:      handleXgroup,
+	XREADGROUP_COMMAND:  handleXreadgroup,
+	XACK_COMMAND:        handleXack,
+	XPENDING_COMMAND:    handleXpending,
+	XCLAIM_COMMAND:      handleXclaim,
+	XAUTOCLAIM_COMMAND:  handleXautoclaim,
+	XINFO_COMMAND:       handleXinfo,
+	PUBLISH_COMMAND:     handlePublish,
+	PUBSUB_COMMAND:      handlePubsub,
+	SPUBLISH_COMMAND:    handlePublish,
+	CONFIG_COMMAND:      handleConfig,
+	UNWATCH_COMMAND:     handleUnwatch,
+	SCRIPT_COMMAND:      handleScript,
+	FUNCTION_COMMAND:    handleFunction,
+	ACL_COMMAND:         handleAcl,
 }
 
 func Dispatch(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
-	if handler, ok := handlers[handlerCtx.Cmd.Name]; ok {
+	handler, ok := handlers[handlerCtx.Cmd.Name]
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR handler for %s is not implemented", handlerCtx.Cmd.Name)}
+	}
+
+	// the commands of a transaction or a script are replicated together by
+	// EXEC or the script
+	if handlerCtx.InTransaction {
 		return handler(serverCtx, handlerCtx)
 	}
 
-	return &resp.Error{Msg: fmt.Sprintf("ERR handler for %s is not implemented", handlerCtx.Cmd.Name)}
+	// a command that does not write to the keyspace may still have effects
+	// to replicate, as FUNCTION LOAD
+	if !IsWriteCommand(handlerCtx.Cmd.Name) {
+		out := handler(serverCtx, handlerCtx)
+		propagate(serverCtx, handlerCtx.Effects...)
+
+		return out
+	}
+
+	// BLPOP and XREADGROUP may block waiting for another write, BLPOP
+	// replicates what it pops itself and the effects of XREADGROUP only
+	// involve entries already replicated
+	if handlerCtx.Cmd.Name == BLPOP_COMMAND || handlerCtx.Cmd.Name == XREADGROUP_COMMAND {
+		out := handler(serverCtx, handlerCtx)
+		propagate(serverCtx, Effects(handlerCtx, out)...)
+
+		return out
+	}
+
+	// the other writes are replicated before the store is unlocked, so
+	// that replicas get them in the order they were applied, a write
+	// waking up a blocked client before what the client did next
+	var out resp.Value
+
+	serverCtx.Store.Atomically(func(tx *store.Store) {
+		writeCtx := *serverCtx
+		writeCtx.Store = tx
+
+		out = handler(&writeCtx, handlerCtx)
+		propagate(serverCtx, Effects(handlerCtx, out)...)
+	})
+
+	return out
 }
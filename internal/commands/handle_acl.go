@@ -0,0 +1,240 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// defaultLogCount is the number of entries ACL LOG replies with by default.
+const defaultLogCount = 10
+
+// handleAcl serves ACL SETUSER username [rule ...], ACL GETUSER username,
+// ACL DELUSER username [username ...], ACL LIST, ACL USERS, ACL WHOAMI,
+// ACL CAT [category], ACL DRYRUN username command [arg ...], ACL LOG
+// [count|RESET], ACL LOAD and ACL SAVE. The users are not replicated.
+func handleAcl(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	cmd := handlerCtx.Cmd
+	argsLen := cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", cmd.Name)}
+	}
+
+	subcommand, _ := cmd.ArgString(0)
+	subcommand = strings.ToUpper(subcommand)
+
+	arityError := &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for 'acl|%s' command", strings.ToLower(subcommand))}
+
+	switch subcommand {
+	case "SETUSER":
+		if argsLen < 2 {
+			return arityError
+		}
+
+		args := make([]string, argsLen-1)
+		for i := range args {
+			args[i], _ = cmd.ArgString(i + 1)
+		}
+
+		if err := serverCtx.ACL.SetUser(args[0], args[1:]...); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	case "GETUSER":
+		if argsLen != 2 {
+			return arityError
+		}
+
+		name, _ := cmd.ArgString(1)
+
+		user, ok := serverCtx.ACL.User(name)
+		if !ok {
+			return &resp.Null{}
+		}
+
+		info := user.Info()
+
+		return resp.NewMap(
+			bulkString("flags"), stringArray(info.Flags),
+			bulkString("passwords"), stringArray(info.Passwords),
+			bulkString("commands"), bulkString(info.Commands),
+			bulkString("keys"), bulkString(info.Keys),
+			bulkString("channels"), bulkString(info.Channels),
+			bulkString("selectors"), &resp.Array{Elements: []resp.Value{}},
+		)
+	case "DELUSER":
+		if argsLen < 2 {
+			return arityError
+		}
+
+		names := make([]string, argsLen-1)
+		for i := range names {
+			names[i], _ = cmd.ArgString(i + 1)
+		}
+
+		deleted, err := serverCtx.ACL.DeleteUsers(names...)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.Integer{Number: int64(deleted)}
+	case "LIST", "USERS":
+		if argsLen != 1 {
+			return arityError
+		}
+
+		users := serverCtx.ACL.Users()
+
+		lines := make([]string, len(users))
+		for i, user := range users {
+			lines[i] = user.Name()
+
+			if subcommand == "LIST" {
+				lines[i] = fmt.Sprintf("user %s %s", user.Name(), user.Describe())
+			}
+		}
+
+		return stringArray(lines)
+	case "WHOAMI":
+		if argsLen != 1 {
+			return arityError
+		}
+
+		if handlerCtx.User == nil {
+			return bulkString(acl.DefaultUser)
+		}
+
+		return bulkString(handlerCtx.User.Name())
+	case "CAT":
+		if argsLen > 2 {
+			return arityError
+		}
+
+		if argsLen == 1 {
+			return stringArray(acl.CategoryNames())
+		}
+
+		name, _ := cmd.ArgString(1)
+
+		category, ok := acl.ParseCategory(strings.ToLower(name))
+		if !ok {
+			return &resp.Error{Msg: fmt.Sprintf("ERR Unknown category '%s'", name)}
+		}
+
+		names := CommandsInCategory(category)
+		slices.Sort(names)
+
+		return stringArray(names)
+	case "DRYRUN":
+		if argsLen < 3 {
+			return arityError
+		}
+
+		return aclDryRun(serverCtx, cmd)
+	case "LOG":
+		if argsLen > 2 {
+			return arityError
+		}
+
+		return aclLog(serverCtx, cmd)
+	case "LOAD", "SAVE":
+		if argsLen != 1 {
+			return arityError
+		}
+
+		apply := serverCtx.ACL.SaveFile
+		if subcommand == "LOAD" {
+			apply = serverCtx.ACL.LoadFile
+		}
+
+		if err := apply(); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	default:
+		return &resp.Error{Msg: fmt.Sprintf("ERR unknown subcommand '%s'. Try ACL HELP.", subcommand)}
+	}
+}
+
+// aclDryRun tells whether a user may run a command, without running it.
+func aclDryRun(serverCtx *ServerContext, cmd *Command) resp.Value {
+	username, _ := cmd.ArgString(1)
+
+	user, ok := serverCtx.ACL.User(username)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR User '%s' not found", username)}
+	}
+
+	name, _ := cmd.ArgBytes(2)
+
+	target := &Command{Name: getCommandName(name), Args: cmd.Args[3:]}
+	if target.Name == "" {
+		return &resp.Error{Msg: fmt.Sprintf("ERR Command '%s' not found", name)}
+	}
+
+	if err := Validate(target); err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	if denial := user.Check(aclRequest(target)); denial != nil {
+		return bulkString(denialMessage(user, denial))
+	}
+
+	return &resp.SimpleString{Bytes: []byte("OK")}
+}
+
+// aclLog replies with the most recent denials, or clears them with RESET.
+func aclLog(serverCtx *ServerContext, cmd *Command) resp.Value {
+	count := defaultLogCount
+
+	if cmd.ArgsLen() == 2 {
+		arg, _ := cmd.ArgString(1)
+		if strings.EqualFold(arg, "RESET") {
+			serverCtx.ACL.ResetLog()
+			return &resp.SimpleString{Bytes: []byte("OK")}
+		}
+
+		n, ok := cmd.ArgInt(1)
+		if !ok || n < 0 {
+			return &resp.Error{Msg: "ERR value is out of range, must be positive"}
+		}
+
+		count = n
+	}
+
+	entries := serverCtx.ACL.LogEntries(count)
+	arr := &resp.Array{Elements: make([]resp.Value, len(entries))}
+
+	for i, e := range entries {
+		arr.Elements[i] = resp.NewMap(
+			bulkString("count"), &resp.Integer{Number: int64(e.Count)},
+			bulkString("reason"), bulkString(e.Reason),
+			bulkString("context"), bulkString(e.Context),
+			bulkString("object"), bulkString(e.Object),
+			bulkString("username"), bulkString(e.Username),
+			bulkString("age-seconds"), &resp.Double{Number: time.Since(e.Created).Seconds()},
+			bulkString("client-info"), bulkString(e.ClientInfo),
+			bulkString("entry-id"), &resp.Integer{Number: e.EntryID},
+			bulkString("timestamp-created"), &resp.Integer{Number: e.Created.UnixMilli()},
+			bulkString("timestamp-last-updated"), &resp.Integer{Number: e.Updated.UnixMilli()},
+		)
+	}
+
+	return arr
+}
+
+func stringArray(values []string) *resp.Array {
+	arr := &resp.Array{Elements: make([]resp.Value, len(values))}
+	for i, v := range values {
+		arr.Elements[i] = bulkString(v)
+	}
+
+	return arr
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func aclDispatch(a *acl.ACL, user *acl.User, args ...string) resp.Value {
+	return Dispatch(&ServerContext{ACL: a}, &HandlerContext{Cmd: newTestCommand(ACL_COMMAND, args...), User: user})
+}
+
+func requireStrings(t *testing.T, out resp.Value, want ...string) {
+	t.Helper()
+
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != len(want) {
+		t.Fatalf("expected %d strings, got %#v", len(want), out)
+	}
+
+	for i, w := range want {
+		requireBulkString(t, arr.Elements[i], w)
+	}
+}
+
+func TestAclUsers(t *testing.T) {
+	a := acl.New(IsCommand)
+
+	requireSimpleString(t, aclDispatch(a, nil, "SETUSER", "alice", "on", "nopass", "~app:*", "+get", "+config|get"), "OK")
+	requireError(t, aclDispatch(a, nil, "SETUSER", "alice", "+nosuch"),
+		"ERR Error in ACL SETUSER modifier '+nosuch': Unknown command or category name in ACL")
+
+	// the fields are a map, sent as a flat array to RESP2 clients
+	out := resp.ToRESP2(aclDispatch(a, nil, "GETUSER", "alice"))
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 12 {
+		t.Fatalf("expected 6 fields, got %#v", out)
+	}
+
+	requireStrings(t, arr.Elements[1], "on", "nopass")
+	requireStrings(t, arr.Elements[3])
+	requireBulkString(t, arr.Elements[5], "-@all +get +config|get")
+	requireBulkString(t, arr.Elements[7], "~app:*")
+	requireBulkString(t, arr.Elements[9], "")
+
+	requireStrings(t, aclDispatch(a, nil, "USERS"), "alice", "default")
+	requireStrings(t, aclDispatch(a, nil, "LIST"),
+		"user alice on nopass ~app:* resetchannels -@all +get +config|get",
+		"user default on nopass ~* &* +@all")
+
+	requireError(t, aclDispatch(a, nil, "DELUSER", "alice", "default"), "ERR The 'default' user cannot be removed")
+	requireInteger(t, aclDispatch(a, nil, "DELUSER", "alice", "nosuch"), 1)
+
+	if out, ok := aclDispatch(a, nil, "GETUSER", "alice").(*resp.Null); !ok {
+		t.Fatalf("expected null for a deleted user, got %#v", out)
+	}
+
+	requireBulkString(t, aclDispatch(a, a.DefaultUser(), "WHOAMI"), "default")
+
+	requireError(t, aclDispatch(a, nil, "NOSUCH"), "ERR unknown subcommand 'NOSUCH'. Try ACL HELP.")
+	requireError(t, aclDispatch(a, nil, "SAVE"), acl.ErrNoFile.Error())
+}
+
+func TestAclCat(t *testing.T) {
+	a := acl.New(IsCommand)
+
+	out := aclDispatch(a, nil, "CAT")
+	if arr, ok := out.(*resp.Array); !ok || len(arr.Elements) != len(acl.CategoryNames()) {
+		t.Fatalf("expected every category, got %#v", out)
+	}
+
+	requireStrings(t, aclDispatch(a, nil, "CAT", "hyperloglog"), "pfadd", "pfcount", "pfmerge")
+	requireStrings(t, aclDispatch(a, nil, "CAT", "transaction"), "discard", "exec", "multi", "unwatch", "watch")
+	requireError(t, aclDispatch(a, nil, "CAT", "nosuch"), "ERR Unknown category 'nosuch'")
+}
+
+func TestAclDryRun(t *testing.T) {
+	a := acl.New(IsCommand)
+	a.SetUser("alice", "on", "nopass", "~app:*", "%R~shared:*", "&news", "+@read", "+@write", "-config", "+config|get", "+publish")
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"get", "app:1"}, "OK"},
+		{[]string{"get", "other"}, "User alice has no permissions to access the 'other' key"},
+		{[]string{"set", "shared:1", "v"}, "User alice has no permissions to access the 'shared:1' key"},
+		{[]string{"set", "app:1", "v", "GET"}, "OK"},
+		{[]string{"mset", "app:1", "v", "shared:2", "v"}, "User alice has no permissions to access the 'shared:2' key"},
+		{[]string{"xread", "COUNT", "1", "STREAMS", "shared:s", "app:s", "0", "0"}, "OK"},
+		{[]string{"xreadgroup", "GROUP", "g", "c", "STREAMS", "shared:s", ">"}, "User alice has no permissions to access the 'shared:s' key"},
+		// the group and the consumer are not mistaken for the STREAMS token
+		{[]string{"xreadgroup", "GROUP", "streams", "streams", "STREAMS", "app:s", ">"}, "OK"},
+		{[]string{"xreadgroup", "GROUP", "streams", "c", "STREAMS", "other", ">"}, "User alice has no permissions to access the 'other' key"},
+		{[]string{"bitop", "AND", "app:dest", "shared:1", "shared:2"}, "OK"},
+		{[]string{"bitop", "AND", "shared:dest", "app:1"}, "User alice has no permissions to access the 'shared:dest' key"},
+		{[]string{"config", "get", "maxclients"}, "OK"},
+		{[]string{"config", "set", "maxclients", "1"}, "User alice has no permissions to run the 'config|set' command"},
+		{[]string{"publish", "news", "hi"}, "OK"},
+		{[]string{"publish", "sports", "hi"}, "User alice has no permissions to access the 'sports' channel"},
+		{[]string{"eval", "return 1", "1", "other"}, "User alice has no permissions to run the 'eval' command"},
+	}
+
+	for _, tc := range cases {
+		out := aclDispatch(a, nil, append([]string{"DRYRUN", "alice"}, tc.args...)...)
+
+		if tc.want == "OK" {
+			requireSimpleString(t, out, "OK")
+		} else {
+			requireBulkString(t, out, tc.want)
+		}
+	}
+
+	requireError(t, aclDispatch(a, nil, "DRYRUN", "nosuch", "get", "k"), "ERR User 'nosuch' not found")
+	requireError(t, aclDispatch(a, nil, "DRYRUN", "alice", "nosuch"), "ERR Command 'nosuch' not found")
+	requireError(t, aclDispatch(a, nil, "DRYRUN", "alice", "get"), "ERR wrong number of arguments for GET command")
+}
+
+func TestAuthorizeLogsDenials(t *testing.T) {
+	a := acl.New(IsCommand)
+	a.SetUser("alice", "on", "nopass", "~app:*", "+get", "+eval")
+	alice, _ := a.User("alice")
+
+	serverCtx := &ServerContext{ACL: a, Store: store.NewStore(), Scripts: scripting.NewScripts()}
+
+	authorize := func(args ...string) *resp.Error {
+		cmd := newTestCommand(getCommandName([]byte(args[0])), args[1:]...)
+		return Authorize(serverCtx, &HandlerContext{Cmd: cmd, User: alice, RemoteAddr: "127.0.0.1:4000"}, TopLevelContext)
+	}
+
+	if errReply := authorize("GET", "app:1"); errReply != nil {
+		t.Fatalf("unexpected denial %v", errReply)
+	}
+
+	requireError(t, authorize("GET", "other"), "NOPERM No permissions to access a key")
+	requireError(t, authorize("SET", "app:1", "v"), "NOPERM User alice has no permissions to run the 'set' command")
+
+	// the commands of a script are checked too
+	out := Dispatch(serverCtx, &HandlerContext{
+		Cmd:  newTestCommand(EVAL_COMMAND, "return redis.call('SET', KEYS[1], 'v')", "1", "app:1"),
+		User: alice,
+	})
+	requireError(t, out, "ERR ACL failure in script: User alice has no permissions to run the 'set' command")
+
+	entries := a.LogEntries(10)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+
+	if e := entries[0]; e.Reason != acl.CommandReason || e.Context != ScriptContext || e.Object != "set" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	if e := entries[2]; e.Reason != acl.KeyReason || e.Object != "other" || e.Username != "alice" || e.ClientInfo != "addr=127.0.0.1:4000 user=alice" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
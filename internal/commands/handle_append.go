@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleAppend(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	value, ok := handlerCtx.Cmd.ArgBytes(1)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	length, err := serverCtx.Store.Append(key, value)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: length}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func handleBitcount(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	if argsLen == 2 || argsLen > 4 {
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	r, errValue := parseBitRange(handlerCtx.Cmd, 1)
+	if errValue != nil {
+		return errValue
+	}
+
+	count, err := serverCtx.Store.BitCount(key, r)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: count}
+}
+
+// parseBitRange parses the optional "start [end [BYTE|BIT]]" arguments
+// beginning at idx.
+func parseBitRange(cmd *Command, idx int) (store.BitRange, *resp.Error) {
+	r := store.BitRange{}
+	argsLen := cmd.ArgsLen()
+
+	if argsLen > idx {
+		start, ok := cmd.ArgInt(idx)
+		if !ok {
+			return r, &resp.Error{Msg: "ERR value is not an integer or out of range"}
+		}
+
+		r.Start, r.HasStart = start, true
+	}
+
+	if argsLen > idx+1 {
+		end, ok := cmd.ArgInt(idx + 1)
+		if !ok {
+			return r, &resp.Error{Msg: "ERR value is not an integer or out of range"}
+		}
+
+		r.End, r.HasEnd = end, true
+	}
+
+	if argsLen > idx+2 {
+		unit, _ := cmd.ArgString(idx + 2)
+
+		switch strings.ToUpper(unit) {
+		case "BYTE":
+		case "BIT":
+			r.IsBit = true
+		default:
+			return r, &resp.Error{Msg: "ERR syntax error"}
+		}
+	}
+
+	return r, nil
+}
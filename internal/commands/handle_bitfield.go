@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// handleBitfield serves BITFIELD and BITFIELD_RO.
+func handleBitfield(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	ops := []store.BitfieldOp{}
+	overflow := store.BITFIELD_OVERFLOW_WRAP
+
+	for i := 1; i < argsLen; {
+		sub, _ := handlerCtx.Cmd.ArgString(i)
+		kind := store.BitfieldOpKind(strings.ToUpper(sub))
+
+		if kind != store.BITFIELD_GET && handlerCtx.Cmd.Name == BITFIELD_RO_COMMAND {
+			return &resp.Error{Msg: "ERR BITFIELD_RO only supports the GET subcommand"}
+		}
+
+		if strings.EqualFold(sub, "OVERFLOW") {
+			mode, _ := handlerCtx.Cmd.ArgString(i + 1)
+			overflow = store.BitfieldOverflow(strings.ToUpper(mode))
+
+			switch overflow {
+			case store.BITFIELD_OVERFLOW_WRAP, store.BITFIELD_OVERFLOW_SAT, store.BITFIELD_OVERFLOW_FAIL:
+			default:
+				return &resp.Error{Msg: "ERR Invalid OVERFLOW type specified"}
+			}
+
+			i += 2
+			continue
+		}
+
+		argsNeeded := 3
+
+		switch kind {
+		case store.BITFIELD_GET:
+			argsNeeded = 2
+		case store.BITFIELD_SET, store.BITFIELD_INCRBY:
+		default:
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+
+		if i+argsNeeded >= argsLen {
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+
+		op := store.BitfieldOp{Kind: kind, Overflow: overflow}
+
+		typeLiteral, _ := handlerCtx.Cmd.ArgString(i + 1)
+		op.Signed, op.Bits, ok = parseBitfieldType(typeLiteral)
+		if !ok {
+			return &resp.Error{Msg: "ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is."}
+		}
+
+		offsetLiteral, _ := handlerCtx.Cmd.ArgString(i + 2)
+		op.Offset, ok = parseBitfieldOffset(offsetLiteral, op.Bits)
+		if !ok {
+			return &resp.Error{Msg: "ERR bit offset is not an integer or out of range"}
+		}
+
+		if argsNeeded == 3 {
+			valueLiteral, _ := handlerCtx.Cmd.ArgString(i + 3)
+			value, err := strconv.ParseInt(valueLiteral, 10, 64)
+			if err != nil {
+				return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+			}
+
+			op.Value = value
+		}
+
+		ops = append(ops, op)
+		i += argsNeeded + 1
+	}
+
+	results, err := serverCtx.Store.BitField(key, ops)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	arr := &resp.Array{}
+
+	for _, r := range results {
+		if r.Null {
+			arr.Elements = append(arr.Elements, &resp.BulkString{Null: true})
+			continue
+		}
+
+		arr.Elements = append(arr.Elements, &resp.Integer{Number: r.Value})
+	}
+
+	return arr
+}
+
+func parseBitfieldType(s string) (signed bool, width uint, ok bool) {
+	if len(s) < 2 {
+		return false, 0, false
+	}
+
+	switch s[0] {
+	case 'i', 'I':
+		signed = true
+	case 'u', 'U':
+	default:
+		return false, 0, false
+	}
+
+	n, err := strconv.ParseUint(s[1:], 10, 8)
+	if err != nil || n == 0 || (signed && n > 64) || (!signed && n > 63) {
+		return false, 0, false
+	}
+
+	return signed, uint(n), true
+}
+
+// parseBitfieldOffset parses a plain bit offset or a "#N" offset that is
+// multiplied by the field width.
+func parseBitfieldOffset(s string, width uint) (uint64, bool) {
+	multiply := strings.HasPrefix(s, "#")
+	s = strings.TrimPrefix(s, "#")
+
+	offset, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	if multiply {
+		if offset > math.MaxUint64/uint64(width) {
+			return 0, false
+		}
+
+		offset *= uint64(width)
+	}
+
+	return offset, true
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func requireBitfieldReply(t *testing.T, out resp.Value, want []any) {
+	t.Helper()
+
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != len(want) {
+		t.Fatalf("expected array of %d elements, got %#v", len(want), out)
+	}
+
+	for i, w := range want {
+		if w == nil {
+			if bs, ok := arr.Elements[i].(*resp.BulkString); !ok || !bs.Null {
+				t.Fatalf("expected null at %d, got %#v", i, arr.Elements[i])
+			}
+			continue
+		}
+
+		n, ok := arr.Elements[i].(*resp.Integer)
+		if !ok || n.Number != int64(w.(int)) {
+			t.Fatalf("expected %d at %d, got %#v", w, i, arr.Elements[i])
+		}
+	}
+}
+
+func TestHandleBitfield(t *testing.T) {
+	t.Run("INCRBY and GET", func(t *testing.T) {
+		s := store.NewStore()
+		out := testDispatch(newTestCommand(BITFIELD_COMMAND, "k", "INCRBY", "i5", "100", "1", "GET", "u4", "0"), s, false)
+		requireBitfieldReply(t, out, []any{1, 0})
+	})
+
+	t.Run("SET returns the previous value and supports # offsets", func(t *testing.T) {
+		s := store.NewStore()
+
+		out := testDispatch(newTestCommand(BITFIELD_COMMAND, "k", "SET", "u8", "#1", "200", "SET", "u8", "#1", "7", "GET", "u8", "8"), s, false)
+		requireBitfieldReply(t, out, []any{0, 200, 7})
+
+		if v, _ := s.Get("k"); string(v) != "\x00\x07" {
+			t.Fatalf("unexpected value %q", v)
+		}
+	})
+
+	t.Run("overflow modes", func(t *testing.T) {
+		s := store.NewStore()
+		want := [][]any{{1, 1}, {2, 2}, {3, 3}, {0, 3}}
+
+		for _, w := range want {
+			out := testDispatch(newTestCommand(BITFIELD_COMMAND, "k", "INCRBY", "u2", "100", "1", "OVERFLOW", "SAT", "INCRBY", "u2", "102", "1"), s, false)
+			requireBitfieldReply(t, out, w)
+		}
+
+		out := testDispatch(newTestCommand(BITFIELD_COMMAND, "k", "OVERFLOW", "FAIL", "INCRBY", "u2", "102", "1"), s, false)
+		requireBitfieldReply(t, out, []any{nil})
+
+		out = testDispatch(newTestCommand(BITFIELD_COMMAND, "k", "OVERFLOW", "SAT", "INCRBY", "i8", "0", "-200", "OVERFLOW", "WRAP", "INCRBY", "i8", "8", "130"), s, false)
+		requireBitfieldReply(t, out, []any{-128, -126})
+	})
+
+	t.Run("BITFIELD_RO only accepts GET", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "k", "\xff")
+
+		out := testDispatch(newTestCommand(BITFIELD_RO_COMMAND, "k", "GET", "i8", "0"), s, false)
+		requireBitfieldReply(t, out, []any{-1})
+
+		out = testDispatch(newTestCommand(BITFIELD_RO_COMMAND, "k", "SET", "i8", "0", "1"), s, false)
+		if _, ok := out.(*resp.Error); !ok {
+			t.Fatalf("expected error from BITFIELD_RO SET, got %#v", out)
+		}
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		out := testDispatch(newTestCommand(BITFIELD_COMMAND, "k", "GET", "u64", "0"), store.NewStore(), false)
+		if _, ok := out.(*resp.Error); !ok {
+			t.Fatalf("expected error for u64, got %#v", out)
+		}
+	})
+
+	t.Run("offset out of range", func(t *testing.T) {
+		s := store.NewStore()
+
+		// #2305843009213693953 wraps around to 8 once multiplied by the width
+		for _, offset := range []string{"18446744073709551615", "4294967289", "#2305843009213693953"} {
+			out := testDispatch(newTestCommand(BITFIELD_COMMAND, "k", "SET", "u8", offset, "1"), s, false)
+			if e, ok := out.(*resp.Error); !ok || e.Msg != "ERR bit offset is not an integer or out of range" {
+				t.Fatalf("expected offset %s to be out of range, got %#v", offset, out)
+			}
+		}
+	})
+}
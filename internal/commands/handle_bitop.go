@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func handleBitop(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 3 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	opName, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	op := store.BitOp(strings.ToUpper(opName))
+
+	switch op {
+	case store.BITOP_AND, store.BITOP_OR, store.BITOP_XOR, store.BITOP_NOT:
+	default:
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	dest, ok := handlerCtx.Cmd.ArgString(1)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	keys := make([]string, 0, argsLen-2)
+
+	for i := 2; i < argsLen; i++ {
+		key, ok := handlerCtx.Cmd.ArgString(i)
+		if !ok {
+			return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+		}
+
+		keys = append(keys, key)
+	}
+
+	length, err := serverCtx.Store.BitOp(op, dest, keys)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: length}
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleBitpos(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	if argsLen > 5 {
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	bit, ok := handlerCtx.Cmd.ArgInt(1)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	if bit != 0 && bit != 1 {
+		return &resp.Error{Msg: "ERR The bit argument must be 1 or 0."}
+	}
+
+	r, errValue := parseBitRange(handlerCtx.Cmd, 2)
+	if errValue != nil {
+		return errValue
+	}
+
+	pos, err := serverCtx.Store.BitPos(key, byte(bit), r)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: pos}
+}
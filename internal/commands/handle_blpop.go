@@ -26,7 +26,21 @@ func handleBlpop(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Valu
 		return &resp.Error{Msg: "ERR invalid timeout value for BLPOP command"}
 	}
 
-	el, ok, timeout := serverCtx.Store.Blpop(key, timeoutInSeconds)
+	// BLPOP is replicated as the LPOP of the element it popped from the
+	// list, an element handed by a push is replicated with the push
+	pop := effectCommand(LPOP_COMMAND, key)
+	handlerCtx.Effects = []resp.Value{}
+
+	el, ok, timeout := serverCtx.Store.Blpop(key, timeoutInSeconds, !handlerCtx.InTransaction, func() {
+		if handlerCtx.InTransaction {
+			handlerCtx.Effects = []resp.Value{pop}
+			return
+		}
+
+		// before the store is unlocked, as Dispatch does for the other
+		// writes
+		propagate(serverCtx, pop)
+	})
 
 	if timeout {
 		return &resp.Array{Null: true}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleConfig serves CONFIG GET parameter [parameter ...] and CONFIG SET
+// parameter value [parameter value ...].
+func handleConfig(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	subcommand, _ := handlerCtx.Cmd.ArgString(0)
+	subcommand = strings.ToUpper(subcommand)
+
+	switch subcommand {
+	case "GET":
+		if argsLen < 2 {
+			return &resp.Error{Msg: "ERR wrong number of arguments for 'config|get' command"}
+		}
+
+		m := &resp.Map{Entries: []resp.MapEntry{}}
+		seen := map[string]bool{}
+
+		for i := 1; i < argsLen; i++ {
+			pattern, _ := handlerCtx.Cmd.ArgString(i)
+
+			for _, pair := range serverCtx.Config.Get(pattern) {
+				if seen[pair[0]] {
+					continue
+				}
+
+				seen[pair[0]] = true
+				m.Entries = append(m.Entries, resp.MapEntry{Key: bulkString(pair[0]), Value: bulkString(pair[1])})
+			}
+		}
+
+		return m
+	case "SET":
+		if argsLen < 3 || argsLen%2 == 0 {
+			return &resp.Error{Msg: "ERR wrong number of arguments for 'config|set' command"}
+		}
+
+		for i := 1; i < argsLen; i += 2 {
+			name, _ := handlerCtx.Cmd.ArgString(i)
+			value, _ := handlerCtx.Cmd.ArgString(i + 1)
+
+			if err := serverCtx.Config.Set(name, value); err != nil {
+				return &resp.Error{Msg: err.Error()}
+			}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	default:
+		return &resp.Error{Msg: fmt.Sprintf("ERR unknown subcommand '%s'. Try CONFIG HELP.", subcommand)}
+	}
+}
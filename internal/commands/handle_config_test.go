@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/config"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func configDispatch(c *config.Config, cmd *Command) resp.Value {
+	return Dispatch(&ServerContext{Config: c}, &HandlerContext{Cmd: cmd})
+}
+
+func TestConfigGetAndSet(t *testing.T) {
+	c := config.NewConfig()
+	value := ""
+	c.Register("notify-keyspace-events", config.Param{
+		Get: func() string { return value },
+		Set: func(v string) error {
+			value = v
+			return nil
+		},
+	})
+	c.Register("maxclients", config.Param{
+		Get: func() string { return "10000" },
+		Set: func(string) error { return nil },
+	})
+
+	requireSimpleString(t, configDispatch(c, newTestCommand(CONFIG_COMMAND, "SET", "NOTIFY-KEYSPACE-EVENTS", "KEA")), "OK")
+
+	// the name/value pairs are a map, sent as a flat array to RESP2 clients
+	out := resp.ToRESP2(configDispatch(c, newTestCommand(CONFIG_COMMAND, "GET", "notify-*")))
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 2 {
+		t.Fatalf("expected a single name/value pair, got %#v", out)
+	}
+
+	requireBulkString(t, arr.Elements[0], "notify-keyspace-events")
+	requireBulkString(t, arr.Elements[1], "KEA")
+
+	out = resp.ToRESP2(configDispatch(c, newTestCommand(CONFIG_COMMAND, "GET", "*", "maxclients")))
+	arr, ok = out.(*resp.Array)
+	if !ok || len(arr.Elements) != 4 {
+		t.Fatalf("expected every parameter once, got %#v", out)
+	}
+
+	requireBulkString(t, arr.Elements[0], "maxclients")
+
+	requireError(t, configDispatch(c, newTestCommand(CONFIG_COMMAND, "SET", "foo", "bar")), "ERR Unknown option or number of arguments for CONFIG SET - 'foo'")
+	requireError(t, configDispatch(c, newTestCommand(CONFIG_COMMAND, "SET", "maxclients")), "ERR wrong number of arguments for 'config|set' command")
+}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// the script and function commands call back into Dispatch, they cannot be part of the
+// handlers literal without an initialization cycle
+func init() {
+	handlers[EVAL_COMMAND] = handleEval
+	handlers[EVALSHA_COMMAND] = handleEval
+	handlers[EVAL_RO_COMMAND] = handleEval
+	handlers[EVALSHA_RO_COMMAND] = handleEval
+	handlers[FCALL_COMMAND] = handleFcall
+	handlers[FCALL_RO_COMMAND] = handleFcall
+}
+
+// handleEval serves EVAL script numkeys [key ...] [arg ...], EVALSHA sha1
+// numkeys [key ...] [arg ...] and their read-only variants EVAL_RO and
+// EVALSHA_RO. The script runs atomically against the store. Rather than the
+// script itself, the write commands it executes are replicated, wrapped in a
+// MULTI/EXEC block.
+func handleEval(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	cmd := handlerCtx.Cmd
+
+	script, keys, argv, errReply := scriptArgs(cmd)
+	if errReply != nil {
+		return errReply
+	}
+
+	readOnly := cmd.Name == EVAL_RO_COMMAND || cmd.Name == EVALSHA_RO_COMMAND
+
+	return runScript(serverCtx, handlerCtx, readOnly, func(call scripting.Caller) resp.Value {
+		if cmd.Name == EVAL_COMMAND || cmd.Name == EVAL_RO_COMMAND {
+			return serverCtx.Scripts.Eval(script, keys, argv, call)
+		}
+
+		return serverCtx.Scripts.EvalSha(script, keys, argv, call)
+	})
+}
+
+// scriptArgs splits the arguments of the commands running a script or a
+// function: the script, the number of keys, the keys and the other
+// arguments.
+func scriptArgs(cmd *Command) (script string, keys, argv []string, errReply *resp.Error) {
+	argsLen := cmd.ArgsLen()
+
+	if argsLen < 2 {
+		return "", nil, nil, &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", cmd.Name)}
+	}
+
+	script, _ = cmd.ArgString(0)
+
+	numKeys, ok := cmd.ArgInt(1)
+	if !ok {
+		return "", nil, nil, &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	if numKeys < 0 {
+		return "", nil, nil, &resp.Error{Msg: "ERR Number of keys can't be negative"}
+	}
+
+	if numKeys > argsLen-2 {
+		return "", nil, nil, &resp.Error{Msg: "ERR Number of keys can't be greater than number of args"}
+	}
+
+	keys = make([]string, numKeys)
+	for i := range keys {
+		keys[i], _ = cmd.ArgString(2 + i)
+	}
+
+	argv = make([]string, argsLen-2-numKeys)
+	for i := range argv {
+		argv[i], _ = cmd.ArgString(2 + numKeys + i)
+	}
+
+	return script, keys, argv, nil
+}
+
+// runScript calls run atomically against the store with a caller running
+// the commands of the script, then replicates the write commands it
+// executed. Within a transaction they are left to EXEC as the effects of
+// the script, so that they are part of its MULTI/EXEC block.
+func runScript(serverCtx *ServerContext, handlerCtx *HandlerContext, readOnly bool, run func(call scripting.Caller) resp.Value) resp.Value {
+	var out resp.Value
+	var effects []resp.Value
+
+	serverCtx.Store.Atomically(func(tx *store.Store) {
+		scriptCtx := *serverCtx
+		scriptCtx.Store = tx
+
+		out = run(func(args []string) (resp.Value, bool) {
+			reply, callEffects := callFromScript(&scriptCtx, handlerCtx, args, readOnly)
+			effects = append(effects, callEffects...)
+
+			return reply, len(callEffects) > 0
+		})
+
+		if handlerCtx.InTransaction {
+			handlerCtx.Effects = effects
+			return
+		}
+
+		// before the store is unlocked, as Dispatch does for single writes
+		PropagateTransaction(serverCtx, effects)
+	})
+
+	return out
+}
+
+// callFromScript runs the command in args for the script run by
+// handlerCtx, with the permissions of its user. When the command wrote to
+// the dataset it also returns its effects, see Effects.
+func callFromScript(serverCtx *ServerContext, handlerCtx *HandlerContext, args []string, readOnly bool) (resp.Value, []resp.Value) {
+	name := getCommandName([]byte(args[0]))
+	if name == "" {
+		return &resp.Error{Msg: "ERR Unknown Redis command called from script"}, nil
+	}
+
+	if commandTable[name].noScript {
+		return &resp.Error{Msg: "ERR This Redis command is not allowed from script"}, nil
+	}
+
+	isWrite := IsWriteCommand(name)
+	if isWrite && readOnly {
+		return &resp.Error{Msg: "ERR Write commands are not allowed from read-only scripts."}, nil
+	}
+
+	cmdArgs := make([]resp.Value, len(args)-1)
+	for i, arg := range args[1:] {
+		cmdArgs[i] = bulkString(arg)
+	}
+
+	cmd := &Command{Name: name, Args: cmdArgs}
+	if err := Validate(cmd); err != nil {
+		return &resp.Error{Msg: err.Error()}, nil
+	}
+
+	callCtx := &HandlerContext{
+		Cmd:           cmd,
+		RemoteAddr:    handlerCtx.RemoteAddr,
+		InTransaction: true,
+		User:          handlerCtx.User,
+	}
+
+	if denial := checkPermissions(serverCtx, callCtx, ScriptContext); denial != nil {
+		return &resp.Error{Msg: "ERR ACL failure in script: " + shortDenialMessage(callCtx.User, denial)}, nil
+	}
+
+	reply := Dispatch(serverCtx, callCtx)
+
+	return reply, Effects(callCtx, reply)
+}
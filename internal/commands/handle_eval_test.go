@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func scriptDispatch(s *store.Store, scripts *scripting.Scripts, name Name, args ...string) resp.Value {
+	return Dispatch(&ServerContext{Store: s, Scripts: scripts}, &HandlerContext{Cmd: newTestCommand(name, args...)})
+}
+
+func TestEvalCallsCommands(t *testing.T) {
+	s := store.NewStore()
+	scripts := scripting.NewScripts()
+
+	out := scriptDispatch(s, scripts, EVAL_COMMAND,
+		"redis.call('SET', KEYS[1], ARGV[1]); return redis.call('GET', KEYS[1])", "1", "foo", "bar")
+	requireBulkString(t, out, "bar")
+
+	out = scriptDispatch(s, scripts, EVAL_COMMAND, "return redis.call('INCRBY', KEYS[1], 5)", "1", "counter")
+	requireInteger(t, out, 5)
+
+	out = scriptDispatch(s, scripts, EVAL_COMMAND, "return {1, 'two', {3}, nil, 'after'}", "0")
+
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 3 {
+		t.Fatalf("expected the table to stop at nil, got %#v", out)
+	}
+
+	requireInteger(t, arr.Elements[0], 1)
+	requireBulkString(t, arr.Elements[1], "two")
+
+	requireSimpleString(t, scriptDispatch(s, scripts, EVAL_COMMAND, "return redis.status_reply('FINE')", "0"), "FINE")
+	requireError(t, scriptDispatch(s, scripts, EVAL_COMMAND, "return redis.error_reply('MY failure')", "0"), "MY failure")
+	requireInteger(t, scriptDispatch(s, scripts, EVAL_COMMAND, "return 3.99", "0"), 3)
+}
+
+func TestEvalErrors(t *testing.T) {
+	s := store.NewStore()
+	scripts := scripting.NewScripts()
+
+	requireSimpleString(t, scriptDispatch(s, scripts, SET_COMMAND, "foo", "bar"), "OK")
+
+	// redis.call raises the error of the command
+	out := scriptDispatch(s, scripts, EVAL_COMMAND, "return redis.call('INCR', 'foo')", "0")
+	requireError(t, out, "ERR value is not an integer or out of range")
+
+	// redis.pcall returns it as a table
+	out = scriptDispatch(s, scripts, EVAL_COMMAND, "local r = redis.pcall('INCR', 'foo'); return r['err']", "0")
+	requireBulkString(t, out, "ERR value is not an integer or out of range")
+
+	requireError(t, scriptDispatch(s, scripts, EVAL_COMMAND, "return redis.call('EVAL', 'return 1', '0')", "0"),
+		"ERR This Redis command is not allowed from script")
+	requireError(t, scriptDispatch(s, scripts, EVAL_COMMAND, "return redis.call('NOSUCH')", "0"),
+		"ERR Unknown Redis command called from script")
+	requireError(t, scriptDispatch(s, scripts, EVAL_COMMAND, "return 1", "2", "a"),
+		"ERR Number of keys can't be greater than number of args")
+	requireError(t, scriptDispatch(s, scripts, EVAL_RO_COMMAND, "return redis.call('SET', 'a', 'b')", "0"),
+		"ERR Write commands are not allowed from read-only scripts.")
+
+	out = scriptDispatch(s, scripts, EVAL_COMMAND, "x = 1", "0")
+	if e, ok := out.(*resp.Error); !ok || e.Msg[:4] != "ERR " {
+		t.Fatalf("expected creating a global to fail, got %#v", out)
+	}
+}
+
+func TestScriptCache(t *testing.T) {
+	s := store.NewStore()
+	scripts := scripting.NewScripts()
+	body := "return ARGV[1]"
+	sha := scripting.Sha1Hex(body)
+
+	requireError(t, scriptDispatch(s, scripts, EVALSHA_COMMAND, sha, "0", "x"), "NOSCRIPT No matching script. Please use EVAL.")
+
+	requireBulkString(t, scriptDispatch(s, scripts, SCRIPT_COMMAND, "LOAD", body), sha)
+	requireBulkString(t, scriptDispatch(s, scripts, EVALSHA_COMMAND, sha, "0", "x"), "x")
+	requireBulkString(t, scriptDispatch(s, scripts, EVALSHA_RO_COMMAND, sha, "0", "y"), "y")
+
+	out := scriptDispatch(s, scripts, SCRIPT_COMMAND, "EXISTS", sha, "ffffffffffffffffffffffffffffffffffffffff")
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 2 {
+		t.Fatalf("expected two answers, got %#v", out)
+	}
+
+	requireInteger(t, arr.Elements[0], 1)
+	requireInteger(t, arr.Elements[1], 0)
+
+	requireSimpleString(t, scriptDispatch(s, scripts, SCRIPT_COMMAND, "FLUSH"), "OK")
+	requireError(t, scriptDispatch(s, scripts, EVALSHA_COMMAND, sha, "0", "x"), "NOSCRIPT No matching script. Please use EVAL.")
+
+	requireError(t, scriptDispatch(s, scripts, SCRIPT_COMMAND, "KILL"), "NOTBUSY No scripts in execution right now.")
+
+	out = scriptDispatch(s, scripts, SCRIPT_COMMAND, "LOAD", "return (")
+	if _, ok := out.(*resp.Error); !ok {
+		t.Fatalf("expected a compile error, got %#v", out)
+	}
+}
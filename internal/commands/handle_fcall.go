@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
+)
+
+// handleFcall serves FCALL function numkeys [key ...] [arg ...] and
+// FCALL_RO, which only calls functions flagged no-writes. Like EVAL, the
+// function runs atomically and the write commands it executes are
+// replicated.
+func handleFcall(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	cmd := handlerCtx.Cmd
+
+	name, keys, argv, errReply := scriptArgs(cmd)
+	if errReply != nil {
+		return errReply
+	}
+
+	noWrites, err := serverCtx.Scripts.FunctionNoWrites(name)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	if cmd.Name == FCALL_RO_COMMAND && !noWrites {
+		return &resp.Error{Msg: "ERR Can not execute a script with write flag using *_ro command."}
+	}
+
+	command := make([]string, 0, cmd.ArgsLen()+1)
+	command = append(command, string(cmd.Name))
+	for i := range cmd.ArgsLen() {
+		arg, _ := cmd.ArgString(i)
+		command = append(command, arg)
+	}
+
+	return runScript(serverCtx, handlerCtx, noWrites, func(call scripting.Caller) resp.Value {
+		return serverCtx.Scripts.FCall(name, command, keys, argv, call)
+	})
+}
@@ -0,0 +1,241 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/rdb"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleFunction serves FUNCTION LOAD [REPLACE] code, FUNCTION LIST
+// [LIBRARYNAME pattern] [WITHCODE], FUNCTION DELETE library, FUNCTION FLUSH
+// [ASYNC|SYNC], FUNCTION DUMP, FUNCTION RESTORE payload
+// [FLUSH|APPEND|REPLACE], FUNCTION STATS and FUNCTION KILL. The subcommands
+// changing the libraries are replicated as is.
+func handleFunction(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	cmd := handlerCtx.Cmd
+	argsLen := cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", cmd.Name)}
+	}
+
+	subcommand, _ := cmd.ArgString(0)
+	subcommand = strings.ToUpper(subcommand)
+
+	arityError := &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for 'function|%s' command", strings.ToLower(subcommand))}
+
+	switch subcommand {
+	case "LOAD":
+		if argsLen < 2 || argsLen > 3 {
+			return arityError
+		}
+
+		replace := false
+		if argsLen == 3 {
+			option, _ := cmd.ArgString(1)
+			if !strings.EqualFold(option, "REPLACE") {
+				return &resp.Error{Msg: fmt.Sprintf("ERR Unknown option given: %s", option)}
+			}
+
+			replace = true
+		}
+
+		code, _ := cmd.ArgString(argsLen - 1)
+
+		name, err := serverCtx.Scripts.LoadLibrary(code, replace)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		replicateFunctionCommand(handlerCtx)
+
+		return bulkString(name)
+	case "LIST":
+		return functionList(serverCtx, cmd)
+	case "DELETE":
+		if argsLen != 2 {
+			return arityError
+		}
+
+		name, _ := cmd.ArgString(1)
+
+		if err := serverCtx.Scripts.DeleteLibrary(name); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		replicateFunctionCommand(handlerCtx)
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	case "FLUSH":
+		if argsLen > 2 {
+			return arityError
+		}
+
+		if argsLen == 2 {
+			mode, _ := cmd.ArgString(1)
+			if mode = strings.ToUpper(mode); mode != "ASYNC" && mode != "SYNC" {
+				return &resp.Error{Msg: "ERR FUNCTION FLUSH only supports SYNC|ASYNC option"}
+			}
+		}
+
+		serverCtx.Scripts.FlushLibraries()
+		replicateFunctionCommand(handlerCtx)
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	case "DUMP":
+		if argsLen != 1 {
+			return arityError
+		}
+
+		return &resp.BulkString{Bytes: rdb.EncodeFunctions(serverCtx.Scripts.LibraryCodes())}
+	case "RESTORE":
+		if argsLen < 2 || argsLen > 3 {
+			return arityError
+		}
+
+		policy := "APPEND"
+		if argsLen == 3 {
+			policy, _ = cmd.ArgString(2)
+			if policy = strings.ToUpper(policy); policy != "FLUSH" && policy != "APPEND" && policy != "REPLACE" {
+				return &resp.Error{Msg: "ERR Wrong restore policy given, value should be either FLUSH, APPEND or REPLACE."}
+			}
+		}
+
+		payload, _ := cmd.ArgString(1)
+
+		codes, err := rdb.DecodeFunctions([]byte(payload))
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		if err := serverCtx.Scripts.RestoreLibraries(codes, policy); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		replicateFunctionCommand(handlerCtx)
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	case "STATS":
+		if argsLen != 1 {
+			return arityError
+		}
+
+		return functionStats(serverCtx)
+	case "KILL":
+		if argsLen != 1 {
+			return arityError
+		}
+
+		if err := serverCtx.Scripts.KillFunction(); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	default:
+		return &resp.Error{Msg: fmt.Sprintf("ERR unknown subcommand '%s'. Try FUNCTION HELP.", subcommand)}
+	}
+}
+
+func functionList(serverCtx *ServerContext, cmd *Command) resp.Value {
+	pattern := ""
+	withCode := false
+
+	for i := 1; i < cmd.ArgsLen(); i++ {
+		option, _ := cmd.ArgString(i)
+
+		switch strings.ToUpper(option) {
+		case "WITHCODE":
+			if withCode {
+				return &resp.Error{Msg: "ERR Unknown argument withcode"}
+			}
+
+			withCode = true
+		case "LIBRARYNAME":
+			if pattern != "" || i+1 >= cmd.ArgsLen() {
+				return &resp.Error{Msg: "ERR library name argument was not given"}
+			}
+
+			i++
+			pattern, _ = cmd.ArgString(i)
+		default:
+			return &resp.Error{Msg: fmt.Sprintf("ERR Unknown argument %s", option)}
+		}
+	}
+
+	libraries := serverCtx.Scripts.Libraries(pattern)
+
+	arr := &resp.Array{Elements: make([]resp.Value, len(libraries))}
+
+	for i, lib := range libraries {
+		functions := &resp.Array{Elements: make([]resp.Value, len(lib.Functions))}
+
+		for j, fn := range lib.Functions {
+			description := resp.Value(&resp.BulkString{Null: true})
+			if fn.Description != "" {
+				description = bulkString(fn.Description)
+			}
+
+			flags := &resp.Set{Elements: make([]resp.Value, len(fn.Flags))}
+			for k, flag := range fn.Flags {
+				flags.Elements[k] = bulkString(flag)
+			}
+
+			functions.Elements[j] = resp.NewMap(
+				bulkString("name"), bulkString(fn.Name),
+				bulkString("description"), description,
+				bulkString("flags"), flags,
+			)
+		}
+
+		info := resp.NewMap(
+			bulkString("library_name"), bulkString(lib.Name),
+			bulkString("engine"), bulkString(lib.Engine),
+			bulkString("functions"), functions,
+		)
+
+		if withCode {
+			info.Entries = append(info.Entries, resp.MapEntry{Key: bulkString("library_code"), Value: bulkString(lib.Code)})
+		}
+
+		arr.Elements[i] = info
+	}
+
+	return arr
+}
+
+func functionStats(serverCtx *ServerContext) resp.Value {
+	stats := serverCtx.Scripts.Stats()
+
+	running := resp.Value(&resp.BulkString{Null: true})
+	if stats.Running != nil {
+		command := &resp.Array{Elements: make([]resp.Value, len(stats.Running.Command))}
+		for i, arg := range stats.Running.Command {
+			command.Elements[i] = bulkString(arg)
+		}
+
+		running = resp.NewMap(
+			bulkString("name"), bulkString(stats.Running.Name),
+			bulkString("command"), command,
+			bulkString("duration_ms"), &resp.Integer{Number: stats.Running.Duration.Milliseconds()},
+		)
+	}
+
+	return resp.NewMap(
+		bulkString("running_script"), running,
+		bulkString("engines"), resp.NewMap(
+			bulkString("LUA"), resp.NewMap(
+				bulkString("libraries_count"), &resp.Integer{Number: int64(stats.LibrariesCount)},
+				bulkString("functions_count"), &resp.Integer{Number: int64(stats.FunctionsCount)},
+			),
+		),
+	)
+}
+
+// replicateFunctionCommand records the command of handlerCtx, a FUNCTION
+// subcommand that changed the libraries, as its effect. FUNCTION is not a
+// write command, the libraries are not part of the keyspace.
+func replicateFunctionCommand(handlerCtx *HandlerContext) {
+	handlerCtx.Effects = []resp.Value{handlerCtx.Cmd.Array()}
+}
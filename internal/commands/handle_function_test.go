@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+const testLibrary = `#!lua name=mylib
+redis.register_function('myset', function(keys, args)
+  return redis.call('SET', keys[1], args[1])
+end)
+redis.register_function{
+  function_name = 'myget',
+  callback = function(keys, args) return redis.call('GET', keys[1]) end,
+  flags = {'no-writes'},
+  description = 'reads a key',
+}`
+
+func TestFunctionLoadAndFcall(t *testing.T) {
+	s := store.NewStore()
+	scripts := scripting.NewScripts()
+
+	requireBulkString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "LOAD", testLibrary), "mylib")
+	requireError(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "LOAD", testLibrary), "ERR Library 'mylib' already exists")
+	requireBulkString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "LOAD", "REPLACE", testLibrary), "mylib")
+
+	requireSimpleString(t, scriptDispatch(s, scripts, FCALL_COMMAND, "myset", "1", "foo", "bar"), "OK")
+	requireBulkString(t, scriptDispatch(s, scripts, FCALL_COMMAND, "myget", "1", "foo"), "bar")
+	requireBulkString(t, scriptDispatch(s, scripts, FCALL_RO_COMMAND, "myget", "1", "foo"), "bar")
+
+	requireError(t, scriptDispatch(s, scripts, FCALL_RO_COMMAND, "myset", "1", "foo", "baz"),
+		"ERR Can not execute a script with write flag using *_ro command.")
+	requireError(t, scriptDispatch(s, scripts, FCALL_COMMAND, "nosuch", "0"), "ERR Function not found")
+
+	requireSimpleString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "DELETE", "mylib"), "OK")
+	requireError(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "DELETE", "mylib"), "ERR Library not found")
+	requireError(t, scriptDispatch(s, scripts, FCALL_COMMAND, "myget", "1", "foo"), "ERR Function not found")
+}
+
+func TestFunctionLoadErrors(t *testing.T) {
+	s := store.NewStore()
+	scripts := scripting.NewScripts()
+
+	for _, tc := range []struct {
+		code string
+		err  string
+	}{
+		{"return 1", "ERR Missing library metadata"},
+		{"#!js name=lib\nreturn 1", "ERR Engine 'js' not found"},
+		{"#!lua\nreturn 1", "ERR Library name was not given"},
+		{"#!lua name=lib foo=bar\nreturn 1", "ERR Invalid metadata value given: foo=bar"},
+		{"#!lua name=lib\nlocal x = 1", "ERR No functions registered"},
+		{"#!lua name=lib\nredis.register_function{function_name='f', callback=function() end, flags={'bad'}}", "ERR unknown flag given"},
+		{"#!lua name=lib\nredis.call('SET', 'a', 'b')", ""},
+	} {
+		out := scriptDispatch(s, scripts, FUNCTION_COMMAND, "LOAD", tc.code)
+
+		if tc.err == "" {
+			if _, ok := out.(*resp.Error); !ok {
+				t.Fatalf("expected loading %q to fail, got %#v", tc.code, out)
+			}
+
+			continue
+		}
+
+		requireError(t, out, tc.err)
+	}
+
+	requireBulkString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "LOAD", testLibrary), "mylib")
+	requireError(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "LOAD",
+		"#!lua name=other\nredis.register_function('myget', function() return 1 end)"),
+		"ERR Function myget already exists")
+}
+
+func TestFunctionListDumpRestore(t *testing.T) {
+	s := store.NewStore()
+	scripts := scripting.NewScripts()
+
+	requireBulkString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "LOAD", testLibrary), "mylib")
+
+	out := resp.ToRESP2(scriptDispatch(s, scripts, FUNCTION_COMMAND, "LIST", "WITHCODE"))
+
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 1 {
+		t.Fatalf("expected one library, got %#v", out)
+	}
+
+	info := arr.Elements[0].(*resp.Array)
+	requireBulkString(t, info.Elements[1], "mylib")
+	requireBulkString(t, info.Elements[3], "LUA")
+	requireBulkString(t, info.Elements[7], testLibrary)
+
+	functions := info.Elements[5].(*resp.Array)
+	if len(functions.Elements) != 2 {
+		t.Fatalf("expected two functions, got %#v", functions)
+	}
+
+	myget := functions.Elements[0].(*resp.Array)
+	requireBulkString(t, myget.Elements[1], "myget")
+	requireBulkString(t, myget.Elements[3], "reads a key")
+
+	out = scriptDispatch(s, scripts, FUNCTION_COMMAND, "LIST", "LIBRARYNAME", "other*")
+	if arr, ok := out.(*resp.Array); !ok || len(arr.Elements) != 0 {
+		t.Fatalf("expected no library to match, got %#v", out)
+	}
+
+	dump, ok := scriptDispatch(s, scripts, FUNCTION_COMMAND, "DUMP").(*resp.BulkString)
+	if !ok {
+		t.Fatalf("expected a payload")
+	}
+
+	payload := string(dump.Bytes)
+
+	requireError(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "RESTORE", payload), "ERR Library 'mylib' already exists")
+	requireSimpleString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "RESTORE", payload, "REPLACE"), "OK")
+
+	requireSimpleString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "FLUSH"), "OK")
+	requireError(t, scriptDispatch(s, scripts, FCALL_COMMAND, "myget", "1", "foo"), "ERR Function not found")
+
+	requireSimpleString(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "RESTORE", payload), "OK")
+	requireSimpleString(t, scriptDispatch(s, scripts, FCALL_COMMAND, "myset", "1", "foo", "bar"), "OK")
+
+	requireError(t, scriptDispatch(s, scripts, FUNCTION_COMMAND, "RESTORE", payload[:len(payload)-1]+"x"),
+		"ERR payload version or checksum are wrong")
+}
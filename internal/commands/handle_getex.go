@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	internalStore "github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func handleGetex(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 || argsLen > 3 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	var expiryType internalStore.ExpiryType
+	var expTime int
+	persist := false
+
+	if argsLen > 1 {
+		option, ok := handlerCtx.Cmd.ArgString(1)
+		if !ok {
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+
+		if strings.EqualFold(option, "persist") {
+			if argsLen != 2 {
+				return &resp.Error{Msg: "ERR syntax error"}
+			}
+
+			persist = true
+		} else {
+			expiryType, ok = internalStore.ProcessExpType(option)
+			if !ok || expiryType == "" || argsLen != 3 {
+				return &resp.Error{Msg: "ERR syntax error"}
+			}
+
+			expTime, ok = handlerCtx.Cmd.ArgInt(2)
+			if !ok {
+				return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+			}
+
+			if expTime <= 0 {
+				return &resp.Error{Msg: "ERR invalid expire time in 'getex' command"}
+			}
+		}
+	}
+
+	value, expiresAt, found, err := serverCtx.Store.GetEx(key, expiryType, expTime, persist)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	// the expiry is replicated as an absolute time, or its removal, so that
+	// replicas do not compute it from their own clock
+	handlerCtx.Effects = []resp.Value{}
+
+	if !found {
+		return &resp.BulkString{Null: true}
+	}
+
+	switch {
+	case persist:
+		handlerCtx.Effects = []resp.Value{effectCommand(PERSIST_COMMAND, key)}
+	case expiryType != "":
+		handlerCtx.Effects = []resp.Value{effectCommand(PEXPIREAT_COMMAND, key, strconv.FormatInt(expiresAt.UnixMilli(), 10))}
+	}
+
+	return &resp.BulkString{Bytes: value}
+}
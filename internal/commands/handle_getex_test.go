@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func TestHandleGetSetGetDel(t *testing.T) {
+	s := store.NewStore()
+
+	out := testDispatch(newTestCommand(GETSET_COMMAND, "k", "first"), s, false)
+	if bs, ok := out.(*resp.BulkString); !ok || !bs.Null {
+		t.Fatalf("expected null from GETSET on missing key, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(GETSET_COMMAND, "k", "second"), s, false)
+	if bs, ok := out.(*resp.BulkString); !ok || string(bs.Bytes) != "first" {
+		t.Fatalf("expected previous value from GETSET, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(GETDEL_COMMAND, "k"), s, false)
+	if bs, ok := out.(*resp.BulkString); !ok || string(bs.Bytes) != "second" {
+		t.Fatalf("expected value from GETDEL, got %#v", out)
+	}
+
+	if _, ok := s.Get("k"); ok {
+		t.Fatal("expected key to be deleted by GETDEL")
+	}
+}
+
+func TestHandleGetex(t *testing.T) {
+	t.Run("PX sets an expiry and PERSIST removes it", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "k", "v")
+
+		out := testDispatch(newTestCommand(GETEX_COMMAND, "k", "PX", "50"), s, false)
+		if bs, ok := out.(*resp.BulkString); !ok || string(bs.Bytes) != "v" {
+			t.Fatalf("expected value from GETEX, got %#v", out)
+		}
+
+		out = testDispatch(newTestCommand(GETEX_COMMAND, "k", "PERSIST"), s, false)
+		if bs, ok := out.(*resp.BulkString); !ok || string(bs.Bytes) != "v" {
+			t.Fatalf("expected value from GETEX PERSIST, got %#v", out)
+		}
+
+		time.Sleep(80 * time.Millisecond)
+
+		if _, ok := s.Get("k"); !ok {
+			t.Fatal("expected key to survive after PERSIST")
+		}
+	})
+
+	t.Run("PX expires the key", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "k", "v")
+
+		testDispatch(newTestCommand(GETEX_COMMAND, "k", "PX", "20"), s, false)
+		time.Sleep(40 * time.Millisecond)
+
+		if _, ok := s.Get("k"); ok {
+			t.Fatal("expected key to expire")
+		}
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "k", "v")
+
+		out := testDispatch(newTestCommand(GETEX_COMMAND, "k", "KEEPTTL"), s, false)
+		if err, ok := out.(*resp.Error); !ok || err.Msg != "ERR syntax error" {
+			t.Fatalf("expected syntax error, got %#v", out)
+		}
+	})
+}
+
+func TestHandleSetnxSetex(t *testing.T) {
+	s := store.NewStore()
+
+	out := testDispatch(newTestCommand(SETNX_COMMAND, "k", "1"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 1 {
+		t.Fatalf("expected 1 from SETNX, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(SETNX_COMMAND, "k", "2"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 0 {
+		t.Fatalf("expected 0 from SETNX, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(PSETEX_COMMAND, "k", "20", "3"), s, false)
+	if ss, ok := out.(*resp.SimpleString); !ok || string(ss.Bytes) != "OK" {
+		t.Fatalf("expected OK from PSETEX, got %#v", out)
+	}
+
+	if v, _ := s.Get("k"); string(v) != "3" {
+		t.Fatalf("expected value to equal 3, got %q", v)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	if _, ok := s.Get("k"); ok {
+		t.Fatal("expected key to expire after PSETEX")
+	}
+
+	out = testDispatch(newTestCommand(SETEX_COMMAND, "k", "0", "v"), s, false)
+	if err, ok := out.(*resp.Error); !ok || err.Msg != "ERR invalid expire time in 'setex' command" {
+		t.Fatalf("expected invalid expire time error, got %#v", out)
+	}
+}
+
+func TestHandlePexpireatPersist(t *testing.T) {
+	s := store.NewStore()
+	createKeyWithValueForIndefiniteTime(t, s, "k", "v")
+
+	at := strconv.FormatInt(time.Now().Add(30*time.Millisecond).UnixMilli(), 10)
+
+	out := testDispatch(newTestCommand(PEXPIREAT_COMMAND, "missing", at), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 0 {
+		t.Fatalf("expected 0 from PEXPIREAT on a missing key, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(PERSIST_COMMAND, "k"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 0 {
+		t.Fatalf("expected 0 from PERSIST without an expiry, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(PEXPIREAT_COMMAND, "k", at), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 1 {
+		t.Fatalf("expected 1 from PEXPIREAT, got %#v", out)
+	}
+
+	// SET KEEPTTL leaves the expiry in place
+	out = testDispatch(newTestCommand(SET_COMMAND, "k", "w", "KEEPTTL"), s, false)
+	if ss, ok := out.(*resp.SimpleString); !ok || string(ss.Bytes) != "OK" {
+		t.Fatalf("expected OK from SET KEEPTTL, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(PERSIST_COMMAND, "k"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 1 {
+		t.Fatalf("expected 1 from PERSIST, got %#v", out)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if v, _ := s.Get("k"); string(v) != "w" {
+		t.Fatalf("expected key to survive after PERSIST, got %q", v)
+	}
+
+	// a time in the past deletes the key
+	out = testDispatch(newTestCommand(PEXPIREAT_COMMAND, "k", "1"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 1 {
+		t.Fatalf("expected 1 from PEXPIREAT in the past, got %#v", out)
+	}
+
+	if _, ok := s.Get("k"); ok {
+		t.Fatal("expected key to be deleted by PEXPIREAT in the past")
+	}
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleGetrange(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 3 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	start, ok := handlerCtx.Cmd.ArgInt(1)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	end, ok := handlerCtx.Cmd.ArgInt(2)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	value, err := serverCtx.Store.Getrange(key, start, end)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.BulkString{Bytes: value}
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func TestHandleAppendStrlen(t *testing.T) {
+	s := store.NewStore()
+
+	out := testDispatch(newTestCommand(APPEND_COMMAND, "k", "Hello"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 5 {
+		t.Fatalf("expected 5 from APPEND, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(APPEND_COMMAND, "k", " World"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 11 {
+		t.Fatalf("expected 11 from APPEND, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(STRLEN_COMMAND, "k"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 11 {
+		t.Fatalf("expected 11 from STRLEN, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(STRLEN_COMMAND, "missing"), s, false)
+	if i, ok := out.(*resp.Integer); !ok || i.Number != 0 {
+		t.Fatalf("expected 0 from STRLEN, got %#v", out)
+	}
+}
+
+func TestHandleGetrange(t *testing.T) {
+	s := store.NewStore()
+	createKeyWithValueForIndefiniteTime(t, s, "k", "This is a string")
+
+	tests := []struct {
+		start, end string
+		want       string
+	}{
+		{"0", "3", "This"},
+		{"-3", "-1", "ing"},
+		{"0", "-1", "This is a string"},
+		{"10", "100", "string"},
+		{"5", "3", ""},
+		{"-1", "-5", ""},
+	}
+
+	for _, tt := range tests {
+		out := testDispatch(newTestCommand(GETRANGE_COMMAND, "k", tt.start, tt.end), s, false)
+		bs, ok := out.(*resp.BulkString)
+		if !ok || bs.Null {
+			t.Fatalf("GETRANGE %s %s: expected bulk string, got %#v", tt.start, tt.end, out)
+		}
+
+		if string(bs.Bytes) != tt.want {
+			t.Fatalf("GETRANGE %s %s: expected %q, got %q", tt.start, tt.end, tt.want, bs.Bytes)
+		}
+	}
+}
+
+func TestHandleSetrange(t *testing.T) {
+	t.Run("overwrite part of the string", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "k", "Hello World")
+
+		out := testDispatch(newTestCommand(SETRANGE_COMMAND, "k", "6", "Redis"), s, false)
+		if i, ok := out.(*resp.Integer); !ok || i.Number != 11 {
+			t.Fatalf("expected 11 from SETRANGE, got %#v", out)
+		}
+
+		if v, _ := s.Get("k"); string(v) != "Hello Redis" {
+			t.Fatalf("unexpected value %q", v)
+		}
+	})
+
+	t.Run("pads missing key with zero bytes", func(t *testing.T) {
+		s := store.NewStore()
+
+		out := testDispatch(newTestCommand(SETRANGE_COMMAND, "k", "3", "ab"), s, false)
+		if i, ok := out.(*resp.Integer); !ok || i.Number != 5 {
+			t.Fatalf("expected 5 from SETRANGE, got %#v", out)
+		}
+
+		if v, _ := s.Get("k"); string(v) != "\x00\x00\x00ab" {
+			t.Fatalf("unexpected value %q", v)
+		}
+	})
+
+	t.Run("negative offset", func(t *testing.T) {
+		out := testDispatch(newTestCommand(SETRANGE_COMMAND, "k", "-1", "ab"), store.NewStore(), false)
+		if err, ok := out.(*resp.Error); !ok || err.Msg != "ERR offset is out of range" {
+			t.Fatalf("expected offset error, got %#v", out)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleGetSet serves GETSET and GETDEL, which both return the previous value.
+func handleGetSet(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	expectedArgs := 2
+
+	if handlerCtx.Cmd.Name == GETDEL_COMMAND {
+		expectedArgs = 1
+	}
+
+	if handlerCtx.Cmd.ArgsLen() != expectedArgs {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	var old []byte
+	var found bool
+	var err error
+
+	if handlerCtx.Cmd.Name == GETDEL_COMMAND {
+		old, found, err = serverCtx.Store.GetDel(key)
+	} else {
+		value, ok := handlerCtx.Cmd.ArgBytes(1)
+		if !ok {
+			return &resp.Error{Msg: fmt.Sprintf("ERR invalid value for %s command", handlerCtx.Cmd.Name)}
+		}
+
+		old, found, err = serverCtx.Store.GetSet(key, value)
+	}
+
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	if !found {
+		return &resp.BulkString{Null: true}
+	}
+
+	return &resp.BulkString{Bytes: old}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleIncrBy serves INCRBY, DECR and DECRBY.
+func handleIncrBy(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+	expectedArgs := 2
+
+	if handlerCtx.Cmd.Name == DECR_COMMAND {
+		expectedArgs = 1
+	}
+
+	if argsLen != expectedArgs {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	delta := int64(1)
+
+	if expectedArgs == 2 {
+		v, ok := handlerCtx.Cmd.ArgInt(1)
+		if !ok {
+			return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+		}
+
+		delta = int64(v)
+	}
+
+	if handlerCtx.Cmd.Name != INCRBY_COMMAND {
+		if delta == math.MinInt64 {
+			return &resp.Error{Msg: "ERR decrement would overflow"}
+		}
+
+		delta = -delta
+	}
+
+	value, err := serverCtx.Store.IncrBy(key, delta)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: value}
+}
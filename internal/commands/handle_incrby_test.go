@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func TestHandleIncrBy(t *testing.T) {
+	t.Run("INCRBY, DECR and DECRBY update the same counter", func(t *testing.T) {
+		s := store.NewStore()
+
+		steps := []struct {
+			cmd  *Command
+			want int64
+		}{
+			{newTestCommand(INCRBY_COMMAND, "counter", "10"), 10},
+			{newTestCommand(DECR_COMMAND, "counter"), 9},
+			{newTestCommand(DECRBY_COMMAND, "counter", "20"), -11},
+			{newTestCommand(INCRBY_COMMAND, "counter", "-4"), -15},
+		}
+
+		for _, step := range steps {
+			out := testDispatch(step.cmd, s, false)
+			value, ok := out.(*resp.Integer)
+			if !ok {
+				t.Fatalf("expected resp.Integer from %s, got %#v", step.cmd.Name, out)
+			}
+
+			if value.Number != step.want {
+				t.Fatalf("expected %s to return %d, got %d", step.cmd.Name, step.want, value.Number)
+			}
+		}
+	})
+
+	t.Run("overflow is rejected and the value is kept", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "counter", strconv.FormatInt(math.MaxInt64, 10))
+
+		out := testDispatch(newTestCommand(INCRBY_COMMAND, "counter", "1"), s, false)
+		err, ok := out.(*resp.Error)
+		if !ok || err.Msg != "ERR increment or decrement would overflow" {
+			t.Fatalf("expected overflow error, got %#v", out)
+		}
+
+		v, _ := s.Get("counter")
+		if string(v) != strconv.FormatInt(math.MaxInt64, 10) {
+			t.Fatalf("expected value to be unchanged, got %q", v)
+		}
+	})
+
+	t.Run("non-integer increment", func(t *testing.T) {
+		out := testDispatch(newTestCommand(INCRBY_COMMAND, "counter", "1.5"), store.NewStore(), false)
+		err, ok := out.(*resp.Error)
+		if !ok || err.Msg != "ERR value is not an integer or out of range" {
+			t.Fatalf("expected integer error, got %#v", out)
+		}
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		s := store.NewStore()
+		createListWithValues(t, s, "list", []string{"a"})
+
+		out := testDispatch(newTestCommand(DECR_COMMAND, "list"), s, false)
+		if _, ok := out.(*resp.Error); !ok {
+			t.Fatalf("expected WRONGTYPE error, got %#v", out)
+		}
+	})
+}
+
+func TestHandleIncrByFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		delta   string
+		want    string
+	}{
+		{"missing key", "", "0.1", "0.1"},
+		{"decimal increment", "10.50", "0.1", "10.6"},
+		{"exponent notation", "5.0e3", "2.0e2", "5200"},
+		{"negative increment", "3", "-5", "-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.NewStore()
+
+			if tt.initial != "" {
+				createKeyWithValueForIndefiniteTime(t, s, "f", tt.initial)
+			}
+
+			out := testDispatch(newTestCommand(INCRBYFLOAT_COMMAND, "f", tt.delta), s, false)
+			bs, ok := out.(*resp.BulkString)
+			if !ok {
+				t.Fatalf("expected resp.BulkString, got %#v", out)
+			}
+
+			if string(bs.Bytes) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, bs.Bytes)
+			}
+		})
+	}
+
+	t.Run("infinity is rejected", func(t *testing.T) {
+		out := testDispatch(newTestCommand(INCRBYFLOAT_COMMAND, "f", "inf"), store.NewStore(), false)
+		err, ok := out.(*resp.Error)
+		if !ok || err.Msg != "ERR increment would produce NaN or Infinity" {
+			t.Fatalf("expected NaN or Infinity error, got %#v", out)
+		}
+	})
+}
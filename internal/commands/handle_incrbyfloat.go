@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleIncrByFloat(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	delta, ok := handlerCtx.Cmd.ArgFloat(1)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not a valid float"}
+	}
+
+	value, err := serverCtx.Store.IncrByFloat(key, delta)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	// replicas set the value as formatted here rather than adding delta
+	// themselves
+	handlerCtx.Effects = []resp.Value{effectCommand(SET_COMMAND, key, string(value), "KEEPTTL")}
+
+	return &resp.BulkString{Bytes: value}
+}
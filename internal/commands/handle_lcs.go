@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleLcs(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key1, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key2, ok := handlerCtx.Cmd.ArgString(1)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	getLen, getIdx, withMatchLen := false, false, false
+	minMatchLen := 0
+
+	for i := 2; i < argsLen; i++ {
+		option, _ := handlerCtx.Cmd.ArgString(i)
+
+		switch strings.ToUpper(option) {
+		case "LEN":
+			getLen = true
+		case "IDX":
+			getIdx = true
+		case "WITHMATCHLEN":
+			withMatchLen = true
+		case "MINMATCHLEN":
+			if i+1 >= argsLen {
+				return &resp.Error{Msg: "ERR syntax error"}
+			}
+
+			minMatchLen, ok = handlerCtx.Cmd.ArgInt(i + 1)
+			if !ok {
+				return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+			}
+
+			minMatchLen = max(minMatchLen, 0)
+			i++
+		default:
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+	}
+
+	if getLen && getIdx {
+		return &resp.Error{Msg: "ERR If you want both the length and indexes, please just use IDX."}
+	}
+
+	result, err := serverCtx.Store.Lcs(key1, key2)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	if getLen {
+		return &resp.Integer{Number: int64(len(result.Lcs))}
+	}
+
+	if !getIdx {
+		return &resp.BulkString{Bytes: result.Lcs}
+	}
+
+	matches := &resp.Array{}
+
+	for _, m := range result.Matches {
+		if m.Len() < minMatchLen {
+			continue
+		}
+
+		match := &resp.Array{Elements: []resp.Value{
+			&resp.Array{Elements: []resp.Value{
+				&resp.Integer{Number: int64(m.AStart)},
+				&resp.Integer{Number: int64(m.AEnd)},
+			}},
+			&resp.Array{Elements: []resp.Value{
+				&resp.Integer{Number: int64(m.BStart)},
+				&resp.Integer{Number: int64(m.BEnd)},
+			}},
+		}}
+
+		if withMatchLen {
+			match.Elements = append(match.Elements, &resp.Integer{Number: int64(m.Len())})
+		}
+
+		matches.Elements = append(matches.Elements, match)
+	}
+
+	return resp.NewMap(
+		&resp.BulkString{Bytes: []byte("matches")},
+		matches,
+		&resp.BulkString{Bytes: []byte("len")},
+		&resp.Integer{Number: int64(len(result.Lcs))},
+	)
+}
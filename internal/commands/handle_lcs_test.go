@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func newLcsStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	s := store.NewStore()
+	createKeyWithValueForIndefiniteTime(t, s, "key1", "ohmytext")
+	createKeyWithValueForIndefiniteTime(t, s, "key2", "mynewtext")
+
+	return s
+}
+
+func TestHandleLcs(t *testing.T) {
+	t.Run("returns the subsequence", func(t *testing.T) {
+		out := testDispatch(newTestCommand(LCS_COMMAND, "key1", "key2"), newLcsStore(t), false)
+		if bs, ok := out.(*resp.BulkString); !ok || string(bs.Bytes) != "mytext" {
+			t.Fatalf("expected mytext, got %#v", out)
+		}
+	})
+
+	t.Run("LEN", func(t *testing.T) {
+		requireInteger(t, testDispatch(newTestCommand(LCS_COMMAND, "key1", "key2", "LEN"), newLcsStore(t), false), 6)
+	})
+
+	t.Run("IDX with MINMATCHLEN and WITHMATCHLEN", func(t *testing.T) {
+		out := testDispatch(newTestCommand(LCS_COMMAND, "key1", "key2", "IDX", "MINMATCHLEN", "4", "WITHMATCHLEN"), newLcsStore(t), false)
+		want := "[matches,[[[4,7],[5,8],4]],len,6]"
+
+		if out := resp.ToRESP2(out); out.String() != want {
+			t.Fatalf("expected %s, got %s", want, out.String())
+		}
+	})
+
+	t.Run("IDX lists all ranges from the end", func(t *testing.T) {
+		out := testDispatch(newTestCommand(LCS_COMMAND, "key1", "key2", "IDX"), newLcsStore(t), false)
+		want := "[matches,[[[4,7],[5,8]],[[2,3],[0,1]]],len,6]"
+
+		if out := resp.ToRESP2(out); out.String() != want {
+			t.Fatalf("expected %s, got %s", want, out.String())
+		}
+	})
+
+	t.Run("missing keys are empty strings", func(t *testing.T) {
+		out := testDispatch(newTestCommand(LCS_COMMAND, "key1", "missing"), newLcsStore(t), false)
+		if bs, ok := out.(*resp.BulkString); !ok || bs.Null || len(bs.Bytes) != 0 {
+			t.Fatalf("expected empty string, got %#v", out)
+		}
+	})
+
+	t.Run("LEN and IDX together", func(t *testing.T) {
+		out := testDispatch(newTestCommand(LCS_COMMAND, "key1", "key2", "LEN", "IDX"), newLcsStore(t), false)
+		if _, ok := out.(*resp.Error); !ok {
+			t.Fatalf("expected error, got %#v", out)
+		}
+	})
+
+	t.Run("non-string key", func(t *testing.T) {
+		s := newLcsStore(t)
+		createListWithValues(t, s, "list", []string{"a"})
+
+		out := testDispatch(newTestCommand(LCS_COMMAND, "key1", "list"), s, false)
+		if err, ok := out.(*resp.Error); !ok || err.Msg != "ERR The specified keys must contain string values" {
+			t.Fatalf("expected string values error, got %#v", out)
+		}
+	})
+}
@@ -33,18 +33,29 @@ func handlePush(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value
 	}
 
 	var len int64
+	var served int
 	var isPushOk bool
 
 	switch handlerCtx.Cmd.Name {
 	case RPUSH_COMMAND:
-		len, isPushOk = serverCtx.Store.Rpush(key, values)
+		len, served, isPushOk = serverCtx.Store.Rpush(key, values)
 	case LPUSH_COMMAND:
-		len, isPushOk = serverCtx.Store.Lpush(key, values)
+		len, served, isPushOk = serverCtx.Store.Lpush(key, values)
 	}
 
 	if !isPushOk {
 		return &resp.Error{Msg: fmt.Sprintf("WRONGTYPE Operation against a key holding the wrong kind of value for %s command", handlerCtx.Cmd.Name)}
 	}
 
+	// the elements handed to the clients blocked in BLPOP are popped on
+	// replicas after the push
+	if served > 0 {
+		handlerCtx.Effects = []resp.Value{handlerCtx.Cmd.Array()}
+
+		for range served {
+			handlerCtx.Effects = append(handlerCtx.Effects, effectCommand(LPOP_COMMAND, key))
+		}
+	}
+
 	return &resp.Integer{Number: len}
 }
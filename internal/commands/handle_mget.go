@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleMget(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	keys := make([]string, 0, argsLen)
+
+	for i := range argsLen {
+		key, ok := handlerCtx.Cmd.ArgString(i)
+		if !ok {
+			return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+		}
+
+		keys = append(keys, key)
+	}
+
+	arr := &resp.Array{}
+
+	for _, v := range serverCtx.Store.Mget(keys) {
+		if v == nil {
+			arr.Elements = append(arr.Elements, &resp.BulkString{Null: true})
+			continue
+		}
+
+		arr.Elements = append(arr.Elements, &resp.BulkString{Bytes: v})
+	}
+
+	return arr
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleMset serves both MSET and MSETNX.
+func handleMset(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen == 0 || argsLen%2 != 0 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	pairs := make([][]byte, 0, argsLen)
+
+	for i := range argsLen {
+		b, ok := handlerCtx.Cmd.ArgBytes(i)
+		if !ok {
+			return &resp.Error{Msg: fmt.Sprintf("ERR invalid argument for %s command", handlerCtx.Cmd.Name)}
+		}
+
+		pairs = append(pairs, b)
+	}
+
+	if handlerCtx.Cmd.Name == MSETNX_COMMAND {
+		if !serverCtx.Store.Msetnx(pairs) {
+			return &resp.Integer{Number: 0}
+		}
+
+		return &resp.Integer{Number: 1}
+	}
+
+	serverCtx.Store.Mset(pairs)
+
+	return &resp.SimpleString{Bytes: []byte("OK")}
+}
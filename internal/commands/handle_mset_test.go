@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func TestHandleMsetMget(t *testing.T) {
+	t.Run("MSET then MGET with a missing and a non-string key", func(t *testing.T) {
+		s := store.NewStore()
+		createListWithValues(t, s, "list", []string{"x"})
+
+		out := testDispatch(newTestCommand(MSET_COMMAND, "a", "1", "b", "2"), s, false)
+		if ss, ok := out.(*resp.SimpleString); !ok || string(ss.Bytes) != "OK" {
+			t.Fatalf("expected OK from MSET, got %#v", out)
+		}
+
+		out = testDispatch(newTestCommand(MGET_COMMAND, "a", "missing", "b", "list"), s, false)
+		arr, ok := out.(*resp.Array)
+		if !ok || len(arr.Elements) != 4 {
+			t.Fatalf("expected 4 element array from MGET, got %#v", out)
+		}
+
+		want := []string{"1", "", "2", ""}
+		for i, el := range arr.Elements {
+			bs, ok := el.(*resp.BulkString)
+			if !ok {
+				t.Fatalf("expected BulkString at %d, got %T", i, el)
+			}
+
+			if want[i] == "" {
+				if !bs.Null {
+					t.Fatalf("expected null at %d, got %q", i, bs.Bytes)
+				}
+				continue
+			}
+
+			if string(bs.Bytes) != want[i] {
+				t.Fatalf("expected %q at %d, got %q", want[i], i, bs.Bytes)
+			}
+		}
+	})
+
+	t.Run("MSET with odd number of arguments", func(t *testing.T) {
+		out := testDispatch(newTestCommand(MSET_COMMAND, "a", "1", "b"), store.NewStore(), false)
+		if _, ok := out.(*resp.Error); !ok {
+			t.Fatalf("expected error, got %#v", out)
+		}
+	})
+
+	t.Run("MSETNX does nothing if any key exists", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "b", "old")
+
+		out := testDispatch(newTestCommand(MSETNX_COMMAND, "a", "1", "b", "2"), s, false)
+		if i, ok := out.(*resp.Integer); !ok || i.Number != 0 {
+			t.Fatalf("expected 0 from MSETNX, got %#v", out)
+		}
+
+		if _, ok := s.Get("a"); ok {
+			t.Fatal("expected key a to not be set")
+		}
+
+		out = testDispatch(newTestCommand(MSETNX_COMMAND, "a", "1", "c", "3"), s, false)
+		if i, ok := out.(*resp.Integer); !ok || i.Number != 1 {
+			t.Fatalf("expected 1 from MSETNX, got %#v", out)
+		}
+
+		if v, _ := s.Get("c"); string(v) != "3" {
+			t.Fatalf("expected c to equal 3, got %q", v)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handlePersist serves PERSIST key, it replies 1 when the expiry of key was
+// removed and 0 when key does not exist or has no expiry.
+func handlePersist(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	if !serverCtx.Store.Persist(key) {
+		return &resp.Integer{Number: 0}
+	}
+
+	return &resp.Integer{Number: 1}
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handlePexpireat serves PEXPIREAT key unix-time-milliseconds, without the
+// NX, XX, GT and LT options. A time in the past deletes the key.
+func handlePexpireat(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	ms, ok := handlerCtx.Cmd.ArgInt(1)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	if !serverCtx.Store.Pexpireat(key, time.UnixMilli(int64(ms))) {
+		return &resp.Integer{Number: 0}
+	}
+
+	return &resp.Integer{Number: 1}
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handlePfadd(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	elements := make([][]byte, 0, argsLen-1)
+
+	for i := 1; i < argsLen; i++ {
+		el, ok := handlerCtx.Cmd.ArgBytes(i)
+		if !ok {
+			return &resp.Error{Msg: fmt.Sprintf("ERR invalid element value for %s command", handlerCtx.Cmd.Name)}
+		}
+
+		elements = append(elements, el)
+	}
+
+	changed, err := serverCtx.Store.Pfadd(key, elements)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	if !changed {
+		return &resp.Integer{Number: 0}
+	}
+
+	return &resp.Integer{Number: 1}
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func TestHandlePfaddPfcount(t *testing.T) {
+	t.Run("count distinct elements", func(t *testing.T) {
+		s := store.NewStore()
+
+		requireInteger(t, testDispatch(newTestCommand(PFADD_COMMAND, "hll", "a", "b", "c", "d", "e", "f", "g"), s, false), 1)
+		requireInteger(t, testDispatch(newTestCommand(PFADD_COMMAND, "hll", "a", "b"), s, false), 0)
+		requireInteger(t, testDispatch(newTestCommand(PFCOUNT_COMMAND, "hll"), s, false), 7)
+		requireInteger(t, testDispatch(newTestCommand(PFCOUNT_COMMAND, "missing"), s, false), 0)
+	})
+
+	t.Run("PFADD without elements creates the key", func(t *testing.T) {
+		s := store.NewStore()
+
+		requireInteger(t, testDispatch(newTestCommand(PFADD_COMMAND, "hll"), s, false), 1)
+		requireInteger(t, testDispatch(newTestCommand(PFADD_COMMAND, "hll"), s, false), 0)
+
+		v, ok := s.Get("hll")
+		if !ok || !strings.HasPrefix(string(v), "HYLL") {
+			t.Fatalf("expected HYLL string value, got %q", v)
+		}
+	})
+
+	t.Run("value survives GET and SET", func(t *testing.T) {
+		s := store.NewStore()
+
+		testDispatch(newTestCommand(PFADD_COMMAND, "hll", "x", "y", "z"), s, false)
+		v, _ := s.Get("hll")
+		s.Set("copy", v, "", 0)
+
+		requireInteger(t, testDispatch(newTestCommand(PFCOUNT_COMMAND, "copy"), s, false), 3)
+	})
+
+	t.Run("non HLL string", func(t *testing.T) {
+		s := store.NewStore()
+		createKeyWithValueForIndefiniteTime(t, s, "k", "plain")
+
+		out := testDispatch(newTestCommand(PFADD_COMMAND, "k", "a"), s, false)
+		if err, ok := out.(*resp.Error); !ok || !strings.HasPrefix(err.Msg, "WRONGTYPE") {
+			t.Fatalf("expected WRONGTYPE error, got %#v", out)
+		}
+	})
+}
+
+func TestHandlePfmerge(t *testing.T) {
+	s := store.NewStore()
+
+	a := []string{"hll1"}
+	b := []string{"hll2"}
+
+	for i := range 100 {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+
+	b = append(b, "a1", "a2")
+
+	testDispatch(newTestCommand(PFADD_COMMAND, a...), s, false)
+	testDispatch(newTestCommand(PFADD_COMMAND, b...), s, false)
+
+	out := testDispatch(newTestCommand(PFMERGE_COMMAND, "dest", "hll1", "hll2"), s, false)
+	if ss, ok := out.(*resp.SimpleString); !ok || string(ss.Bytes) != "OK" {
+		t.Fatalf("expected OK from PFMERGE, got %#v", out)
+	}
+
+	merged, ok := testDispatch(newTestCommand(PFCOUNT_COMMAND, "dest"), s, false).(*resp.Integer)
+	if !ok {
+		t.Fatal("expected integer from PFCOUNT")
+	}
+
+	union, ok := testDispatch(newTestCommand(PFCOUNT_COMMAND, "hll1", "hll2"), s, false).(*resp.Integer)
+	if !ok {
+		t.Fatal("expected integer from PFCOUNT")
+	}
+
+	if merged.Number != union.Number || merged.Number < 195 || merged.Number > 205 {
+		t.Fatalf("expected merged count close to 200 and equal to union count, got %d and %d", merged.Number, union.Number)
+	}
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handlePfcount serves PFCOUNT and PFMERGE, which both take a list of keys.
+func handlePfcount(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	keys := make([]string, 0, argsLen)
+
+	for i := range argsLen {
+		key, ok := handlerCtx.Cmd.ArgString(i)
+		if !ok {
+			return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+		}
+
+		keys = append(keys, key)
+	}
+
+	if handlerCtx.Cmd.Name == PFMERGE_COMMAND {
+		if err := serverCtx.Store.Pfmerge(keys[0], keys[1:]); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	}
+
+	card, err := serverCtx.Store.Pfcount(keys)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: card}
+}
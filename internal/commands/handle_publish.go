@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handlePublish serves PUBLISH and SPUBLISH, the latter delivers to the
+// subscribers of a shard channel only.
+func handlePublish(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	channel, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid channel value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	message, ok := handlerCtx.Cmd.ArgBytes(1)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid message value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	var receivers int
+	if handlerCtx.Cmd.Name == SPUBLISH_COMMAND {
+		receivers = serverCtx.PubSub.SPublish(channel, message)
+	} else {
+		receivers = serverCtx.PubSub.Publish(channel, message)
+	}
+
+	return &resp.Integer{Number: int64(receivers)}
+}
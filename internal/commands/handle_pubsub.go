@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handlePubsub serves PUBSUB CHANNELS [pattern], PUBSUB NUMSUB [channel ...],
+// PUBSUB NUMPAT and their shard channel counterparts PUBSUB SHARDCHANNELS
+// [pattern] and PUBSUB SHARDNUMSUB [channel ...].
+func handlePubsub(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	subcommand, _ := handlerCtx.Cmd.ArgString(0)
+	subcommand = strings.ToUpper(subcommand)
+
+	switch subcommand {
+	case "CHANNELS", "SHARDCHANNELS":
+		if argsLen > 2 {
+			return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for 'pubsub|%s' command", strings.ToLower(subcommand))}
+		}
+
+		pattern, _ := handlerCtx.Cmd.ArgString(1)
+
+		var channels []string
+		if subcommand == "SHARDCHANNELS" {
+			channels = serverCtx.PubSub.ActiveShardChannels(pattern)
+		} else {
+			channels = serverCtx.PubSub.ActiveChannels(pattern)
+		}
+
+		arr := &resp.Array{Elements: make([]resp.Value, 0, len(channels))}
+		for _, channel := range channels {
+			arr.Elements = append(arr.Elements, bulkString(channel))
+		}
+
+		return arr
+	case "NUMSUB", "SHARDNUMSUB":
+		numSub := serverCtx.PubSub.NumSub
+		if subcommand == "SHARDNUMSUB" {
+			numSub = serverCtx.PubSub.ShardNumSub
+		}
+
+		m := &resp.Map{Entries: make([]resp.MapEntry, 0, argsLen-1)}
+
+		for i := 1; i < argsLen; i++ {
+			channel, _ := handlerCtx.Cmd.ArgString(i)
+			m.Entries = append(m.Entries, resp.MapEntry{
+				Key:   bulkString(channel),
+				Value: &resp.Integer{Number: int64(numSub(channel))},
+			})
+		}
+
+		return m
+	case "NUMPAT":
+		if argsLen != 1 {
+			return &resp.Error{Msg: "ERR wrong number of arguments for 'pubsub|numpat' command"}
+		}
+
+		return &resp.Integer{Number: int64(serverCtx.PubSub.NumPat())}
+	default:
+		return &resp.Error{Msg: fmt.Sprintf("ERR unknown subcommand '%s'. Try PUBSUB HELP.", subcommand)}
+	}
+}
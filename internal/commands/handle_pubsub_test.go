@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+type countingSubscriber struct {
+	pushed int
+}
+
+func (c *countingSubscriber) Push(resp.Value) {
+	c.pushed++
+}
+
+func pubsubDispatch(ps *pubsub.PubSub, cmd *Command) resp.Value {
+	return Dispatch(
+		&ServerContext{Store: store.NewStore(), PubSub: ps},
+		&HandlerContext{Cmd: cmd},
+	)
+}
+
+func TestPublishReturnsReceivers(t *testing.T) {
+	ps := pubsub.NewPubSub()
+	sub := &countingSubscriber{}
+	ps.Subscribe(sub, "news")
+	ps.PSubscribe(sub, "n*")
+
+	requireInteger(t, pubsubDispatch(ps, newTestCommand(PUBLISH_COMMAND, "news", "hello")), 2)
+
+	if sub.pushed != 2 {
+		t.Fatalf("expected a push per matching subscription, got %d", sub.pushed)
+	}
+
+	requireInteger(t, pubsubDispatch(ps, newTestCommand(PUBLISH_COMMAND, "other", "hello")), 0)
+	requireError(t, pubsubDispatch(ps, newTestCommand(PUBLISH_COMMAND, "news")), "ERR wrong number of arguments for PUBLISH command")
+}
+
+func TestPubsubIntrospection(t *testing.T) {
+	ps := pubsub.NewPubSub()
+	ps.Subscribe(&countingSubscriber{}, "news.tech")
+	ps.Subscribe(&countingSubscriber{}, "sports")
+	ps.PSubscribe(&countingSubscriber{}, "news.*")
+
+	out := pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "CHANNELS"))
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 2 {
+		t.Fatalf("expected two channels, got %#v", out)
+	}
+
+	requireBulkString(t, arr.Elements[0], "news.tech")
+	requireBulkString(t, arr.Elements[1], "sports")
+
+	out = pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "channels", "news.*"))
+	arr, ok = out.(*resp.Array)
+	if !ok || len(arr.Elements) != 1 {
+		t.Fatalf("expected one channel, got %#v", out)
+	}
+
+	requireBulkString(t, arr.Elements[0], "news.tech")
+
+	out = resp.ToRESP2(pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "NUMSUB", "sports", "missing")))
+	arr, ok = out.(*resp.Array)
+	if !ok || len(arr.Elements) != 4 {
+		t.Fatalf("expected two channel/count pairs, got %#v", out)
+	}
+
+	requireBulkString(t, arr.Elements[0], "sports")
+	requireInteger(t, arr.Elements[1], 1)
+	requireBulkString(t, arr.Elements[2], "missing")
+	requireInteger(t, arr.Elements[3], 0)
+
+	requireInteger(t, pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "NUMPAT")), 1)
+	requireError(t, pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "FOO")), "ERR unknown subcommand 'FOO'. Try PUBSUB HELP.")
+}
+
+func TestShardPublishAndIntrospection(t *testing.T) {
+	ps := pubsub.NewPubSub()
+	sub := &countingSubscriber{}
+	ps.Subscribe(sub, "orders")
+	ps.SSubscribe(sub, "orders")
+	ps.SSubscribe(&countingSubscriber{}, "orders")
+
+	requireInteger(t, pubsubDispatch(ps, newTestCommand(SPUBLISH_COMMAND, "orders", "new")), 2)
+
+	if sub.pushed != 1 {
+		t.Fatalf("expected SPUBLISH to skip regular subscribers, got %d pushes", sub.pushed)
+	}
+
+	out := pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "SHARDCHANNELS", "ord*"))
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 1 {
+		t.Fatalf("expected one shard channel, got %#v", out)
+	}
+
+	requireBulkString(t, arr.Elements[0], "orders")
+
+	out = resp.ToRESP2(pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "SHARDNUMSUB", "orders")))
+	arr, ok = out.(*resp.Array)
+	if !ok || len(arr.Elements) != 2 {
+		t.Fatalf("expected a channel/count pair, got %#v", out)
+	}
+
+	requireInteger(t, arr.Elements[1], 2)
+	requireError(t, pubsubDispatch(ps, newTestCommand(PUBSUB_COMMAND, "SHARDCHANNELS", "a", "b")), "ERR wrong number of arguments for 'pubsub|shardchannels' command")
+
+	if !IsWriteCommand(SPUBLISH_COMMAND) {
+		t.Fatal("expected SPUBLISH to be propagated to replicas")
+	}
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleScript serves SCRIPT LOAD script, SCRIPT EXISTS sha1 [sha1 ...],
+// SCRIPT FLUSH [ASYNC|SYNC] and SCRIPT KILL.
+func handleScript(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	subcommand, _ := handlerCtx.Cmd.ArgString(0)
+	subcommand = strings.ToUpper(subcommand)
+
+	arityError := &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for 'script|%s' command", strings.ToLower(subcommand))}
+
+	switch subcommand {
+	case "LOAD":
+		if argsLen != 2 {
+			return arityError
+		}
+
+		body, _ := handlerCtx.Cmd.ArgString(1)
+
+		sha, err := serverCtx.Scripts.Load(body)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return bulkString(sha)
+	case "EXISTS":
+		if argsLen < 2 {
+			return arityError
+		}
+
+		shas := make([]string, argsLen-1)
+		for i := range shas {
+			shas[i], _ = handlerCtx.Cmd.ArgString(i + 1)
+		}
+
+		exists := serverCtx.Scripts.Exists(shas)
+
+		arr := &resp.Array{Elements: make([]resp.Value, len(exists))}
+		for i, ok := range exists {
+			n := int64(0)
+			if ok {
+				n = 1
+			}
+
+			arr.Elements[i] = &resp.Integer{Number: n}
+		}
+
+		return arr
+	case "FLUSH":
+		if argsLen > 2 {
+			return arityError
+		}
+
+		if argsLen == 2 {
+			mode, _ := handlerCtx.Cmd.ArgString(1)
+			if mode = strings.ToUpper(mode); mode != "ASYNC" && mode != "SYNC" {
+				return &resp.Error{Msg: "ERR SCRIPT FLUSH only support SYNC|ASYNC option"}
+			}
+		}
+
+		serverCtx.Scripts.Flush()
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	case "KILL":
+		if argsLen != 1 {
+			return arityError
+		}
+
+		if err := serverCtx.Scripts.KillScript(); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	default:
+		return &resp.Error{Msg: fmt.Sprintf("ERR unknown subcommand '%s'. Try SCRIPT HELP.", subcommand)}
+	}
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"strings"
+
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
 	internalStore "github.com/codecrafters-io/redis-starter-go/internal/store"
 )
@@ -21,6 +23,13 @@ func handleSet(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value
 		return &resp.Error{Msg: "ERR invalid value for SET command"}
 	}
 
+	if argsLen == 3 {
+		if option, _ := handlerCtx.Cmd.ArgString(2); strings.EqualFold(option, "KEEPTTL") {
+			serverCtx.Store.SetKeepTTL(key, value)
+			return &resp.SimpleString{Bytes: []byte("OK")}
+		}
+	}
+
 	var expiryType internalStore.ExpiryType
 	var expTime int
 
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleSetbit serves SETBIT and GETBIT.
+func handleSetbit(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	expectedArgs := 3
+
+	if handlerCtx.Cmd.Name == GETBIT_COMMAND {
+		expectedArgs = 2
+	}
+
+	if handlerCtx.Cmd.ArgsLen() != expectedArgs {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	offset, ok := parseBitOffset(handlerCtx.Cmd, 1)
+	if !ok {
+		return &resp.Error{Msg: "ERR bit offset is not an integer or out of range"}
+	}
+
+	var bit byte
+	var err error
+
+	if handlerCtx.Cmd.Name == GETBIT_COMMAND {
+		bit, err = serverCtx.Store.GetBit(key, offset)
+	} else {
+		value, ok := handlerCtx.Cmd.ArgInt(2)
+		if !ok || (value != 0 && value != 1) {
+			return &resp.Error{Msg: "ERR bit is not an integer or out of range"}
+		}
+
+		bit, err = serverCtx.Store.SetBit(key, offset, byte(value))
+	}
+
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: int64(bit)}
+}
+
+func parseBitOffset(cmd *Command, idx int) (uint64, bool) {
+	s, ok := cmd.ArgString(idx)
+	if !ok {
+		return 0, false
+	}
+
+	offset, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return offset, true
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func TestHandleSetbitGetbit(t *testing.T) {
+	s := store.NewStore()
+
+	requireInteger(t, testDispatch(newTestCommand(SETBIT_COMMAND, "k", "7", "1"), s, false), 0)
+	requireInteger(t, testDispatch(newTestCommand(SETBIT_COMMAND, "k", "7", "0"), s, false), 1)
+	requireInteger(t, testDispatch(newTestCommand(SETBIT_COMMAND, "k", "7", "1"), s, false), 0)
+
+	requireInteger(t, testDispatch(newTestCommand(GETBIT_COMMAND, "k", "0"), s, false), 0)
+	requireInteger(t, testDispatch(newTestCommand(GETBIT_COMMAND, "k", "7"), s, false), 1)
+	requireInteger(t, testDispatch(newTestCommand(GETBIT_COMMAND, "k", "100"), s, false), 0)
+
+	if v, _ := s.Get("k"); string(v) != "\x01" {
+		t.Fatalf("unexpected value %q", v)
+	}
+
+	requireInteger(t, testDispatch(newTestCommand(SETBIT_COMMAND, "k", "23", "1"), s, false), 0)
+
+	if v, _ := s.Get("k"); string(v) != "\x01\x00\x01" {
+		t.Fatalf("expected string to grow with zero bytes, got %q", v)
+	}
+
+	out := testDispatch(newTestCommand(SETBIT_COMMAND, "k", "1", "2"), s, false)
+	if err, ok := out.(*resp.Error); !ok || err.Msg != "ERR bit is not an integer or out of range" {
+		t.Fatalf("expected bit error, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(SETBIT_COMMAND, "k", "4294967296", "1"), s, false)
+	if err, ok := out.(*resp.Error); !ok || err.Msg != "ERR bit offset is not an integer or out of range" {
+		t.Fatalf("expected bit offset error, got %#v", out)
+	}
+}
+
+func TestHandleBitcount(t *testing.T) {
+	s := store.NewStore()
+	createKeyWithValueForIndefiniteTime(t, s, "k", "foobar")
+
+	tests := []struct {
+		args []string
+		want int64
+	}{
+		{[]string{"k"}, 26},
+		{[]string{"k", "0", "0"}, 4},
+		{[]string{"k", "1", "1"}, 6},
+		{[]string{"k", "1", "1", "BYTE"}, 6},
+		{[]string{"k", "5", "30", "BIT"}, 17},
+		{[]string{"k", "-2", "-1"}, 7},
+		{[]string{"missing"}, 0},
+	}
+
+	for _, tt := range tests {
+		requireInteger(t, testDispatch(newTestCommand(BITCOUNT_COMMAND, tt.args...), s, false), tt.want)
+	}
+
+	out := testDispatch(newTestCommand(BITCOUNT_COMMAND, "k", "1"), s, false)
+	if _, ok := out.(*resp.Error); !ok {
+		t.Fatalf("expected syntax error, got %#v", out)
+	}
+}
+
+func TestHandleBitpos(t *testing.T) {
+	s := store.NewStore()
+	createKeyWithValueForIndefiniteTime(t, s, "a", "\xff\xf0\x00")
+	createKeyWithValueForIndefiniteTime(t, s, "b", "\x00\xff\xf0")
+	createKeyWithValueForIndefiniteTime(t, s, "c", "\x00\x00\x00")
+	createKeyWithValueForIndefiniteTime(t, s, "d", "\xff\xff\xff")
+
+	tests := []struct {
+		args []string
+		want int64
+	}{
+		{[]string{"a", "0"}, 12},
+		{[]string{"b", "1", "0"}, 8},
+		{[]string{"b", "1", "2"}, 16},
+		{[]string{"b", "1", "2", "-1", "BYTE"}, 16},
+		{[]string{"b", "1", "7", "15", "BIT"}, 8},
+		{[]string{"c", "1"}, -1},
+		{[]string{"d", "0"}, 24},
+		{[]string{"d", "0", "0", "-1"}, -1},
+		{[]string{"missing", "0"}, 0},
+		{[]string{"missing", "1"}, -1},
+	}
+
+	for _, tt := range tests {
+		requireInteger(t, testDispatch(newTestCommand(BITPOS_COMMAND, tt.args...), s, false), tt.want)
+	}
+}
+
+func TestHandleBitop(t *testing.T) {
+	s := store.NewStore()
+	createKeyWithValueForIndefiniteTime(t, s, "k1", "foobar")
+	createKeyWithValueForIndefiniteTime(t, s, "k2", "abcdef")
+
+	requireInteger(t, testDispatch(newTestCommand(BITOP_COMMAND, "AND", "dest", "k1", "k2"), s, false), 6)
+
+	if v, _ := s.Get("dest"); string(v) != "`bc`ab" {
+		t.Fatalf("unexpected AND result %q", v)
+	}
+
+	createKeyWithValueForIndefiniteTime(t, s, "short", "\x0f")
+	requireInteger(t, testDispatch(newTestCommand(BITOP_COMMAND, "OR", "dest", "short", "missing", "k2"), s, false), 6)
+
+	if v, _ := s.Get("dest"); string(v) != "obcdef" {
+		t.Fatalf("unexpected OR result %q", v)
+	}
+
+	requireInteger(t, testDispatch(newTestCommand(BITOP_COMMAND, "NOT", "dest", "short"), s, false), 1)
+
+	if v, _ := s.Get("dest"); string(v) != "\xf0" {
+		t.Fatalf("unexpected NOT result %q", v)
+	}
+
+	out := testDispatch(newTestCommand(BITOP_COMMAND, "NOT", "dest", "k1", "k2"), s, false)
+	if _, ok := out.(*resp.Error); !ok {
+		t.Fatalf("expected error for NOT with two keys, got %#v", out)
+	}
+
+	requireInteger(t, testDispatch(newTestCommand(BITOP_COMMAND, "XOR", "dest", "missing"), s, false), 0)
+
+	if _, ok := s.Get("dest"); ok {
+		t.Fatal("expected empty result to delete destination key")
+	}
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	internalStore "github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// handleSetex serves SETEX (seconds) and PSETEX (milliseconds).
+func handleSetex(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 3 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	expTime, ok := handlerCtx.Cmd.ArgInt(1)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	if expTime <= 0 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid expire time in '%s' command", strings.ToLower(string(handlerCtx.Cmd.Name)))}
+	}
+
+	value, ok := handlerCtx.Cmd.ArgBytes(2)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	expiryType := internalStore.EXPIRY_EX
+
+	if handlerCtx.Cmd.Name == PSETEX_COMMAND {
+		expiryType = internalStore.EXPIRY_PX
+	}
+
+	if ok := serverCtx.Store.Set(key, value, expiryType, expTime); !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR during executing store %s command", handlerCtx.Cmd.Name)}
+	}
+
+	return &resp.SimpleString{Bytes: []byte("OK")}
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleSetnx(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	value, ok := handlerCtx.Cmd.ArgBytes(1)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	if !serverCtx.Store.SetNX(key, value) {
+		return &resp.Integer{Number: 0}
+	}
+
+	return &resp.Integer{Number: 1}
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func handleSetrange(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 3 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	offset, ok := handlerCtx.Cmd.ArgInt(1)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	value, ok := handlerCtx.Cmd.ArgBytes(2)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	length, err := serverCtx.Store.Setrange(key, offset, value)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: length}
+}
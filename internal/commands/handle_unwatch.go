@@ -0,0 +1,18 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// handleUnwatch only runs for an UNWATCH queued in a transaction, where the
+// keys are already released by EXEC. Outside of MULTI the session releases
+// them itself.
+func handleUnwatch(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	if handlerCtx.Cmd.ArgsLen() != 0 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	return &resp.SimpleString{Bytes: []byte("OK")}
+}
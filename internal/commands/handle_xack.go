@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func handleXack(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 3 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	group, _ := handlerCtx.Cmd.ArgString(1)
+	ids := make([]store.StreamIdSpec, 0, argsLen-2)
+
+	for i := 2; i < argsLen; i++ {
+		literal, _ := handlerCtx.Cmd.ArgString(i)
+
+		id, ok := parseStrictStreamId(literal)
+		if !ok {
+			return errInvalidStreamId
+		}
+
+		ids = append(ids, id)
+	}
+
+	acked, err := serverCtx.Store.Xack(key, group, ids)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: acked}
+}
@@ -20,7 +20,32 @@ func handleXadd(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value
 		return &resp.Error{Msg: "ERR invalid key value for XADD command"}
 	}
 
-	sId, ok := handlerCtx.Cmd.ArgString(1)
+	opts := store.XaddOptions{}
+	idIdx := 1
+
+	for idIdx < argsLen {
+		option, _ := handlerCtx.Cmd.ArgString(idIdx)
+
+		if strings.EqualFold(option, "NOMKSTREAM") {
+			opts.NoMkStream = true
+			idIdx++
+			continue
+		}
+
+		spec, next, errValue := parseStreamTrimArgs(handlerCtx.Cmd, idIdx)
+		if errValue != nil {
+			return errValue
+		}
+
+		if next == idIdx {
+			break
+		}
+
+		opts.Trim = spec
+		idIdx = next
+	}
+
+	sId, ok := handlerCtx.Cmd.ArgString(idIdx)
 	if !ok {
 		return &resp.Error{Msg: "ERR invalid stream-id value for XADD command"}
 	}
@@ -38,21 +63,22 @@ func handleXadd(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value
 		}
 	}
 
-	restArgs := handlerCtx.Cmd.Args[2:]
+	fieldsIdx := idIdx + 1
+	restArgs := handlerCtx.Cmd.Args[min(fieldsIdx, argsLen):]
 
-	if len(restArgs)%2 != 0 {
+	if len(restArgs) == 0 || len(restArgs)%2 != 0 {
 		return &resp.Error{Msg: "ERR invalid number of arguments for XADD command"}
 	}
 
 	fields := [][]string{}
 
 	for i := 0; i < len(restArgs); i += 2 {
-		entryKey, okKey := handlerCtx.Cmd.ArgString(2 + i)
+		entryKey, okKey := handlerCtx.Cmd.ArgString(fieldsIdx + i)
 		if !okKey {
 			return &resp.Error{Msg: "ERR invalid key-pair key value for XADD command"}
 		}
 
-		entryValue, okValue := handlerCtx.Cmd.ArgString(2 + i + 1)
+		entryValue, okValue := handlerCtx.Cmd.ArgString(fieldsIdx + i + 1)
 		if !okValue {
 			return &resp.Error{Msg: "ERR invalid key-pair value for XADD command"}
 		}
@@ -60,12 +86,16 @@ func handleXadd(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value
 		fields = append(fields, []string{entryKey, entryValue})
 	}
 
-	id, err := serverCtx.Store.Xadd(key, streamId, fields)
+	id, added, err := serverCtx.Store.Xadd(key, streamId, fields, opts)
 
 	if err != nil {
 		return &resp.Error{Msg: err.Error()}
 	}
 
+	if !added {
+		return &resp.BulkString{Null: true}
+	}
+
 	return &resp.BulkString{Bytes: []byte(id)}
 }
 
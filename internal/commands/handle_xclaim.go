@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// handleXclaim serves
+// XCLAIM key group consumer min-idle-time id... [IDLE ms] [TIME unix-ms]
+// [RETRYCOUNT count] [FORCE] [JUSTID] [LASTID id]
+func handleXclaim(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 5 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	group, _ := handlerCtx.Cmd.ArgString(1)
+	consumer, _ := handlerCtx.Cmd.ArgString(2)
+
+	opts := store.XclaimOptions{}
+
+	minIdle, ok := parseNonNegativeInt64(handlerCtx.Cmd, 3)
+	if !ok {
+		return &resp.Error{Msg: "ERR Invalid min-idle-time argument for XCLAIM"}
+	}
+
+	opts.MinIdleMs = minIdle
+
+	idx := 4
+	ids := []store.StreamIdSpec{}
+
+	for ; idx < argsLen; idx++ {
+		literal, _ := handlerCtx.Cmd.ArgString(idx)
+
+		id, ok := parseStrictStreamId(literal)
+		if !ok {
+			break
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return errInvalidStreamId
+	}
+
+	for ; idx < argsLen; idx++ {
+		option, _ := handlerCtx.Cmd.ArgString(idx)
+
+		switch strings.ToUpper(option) {
+		case "FORCE":
+			opts.Force = true
+		case "JUSTID":
+			opts.JustId = true
+		case "IDLE", "TIME", "RETRYCOUNT":
+			value, ok := parseNonNegativeInt64(handlerCtx.Cmd, idx+1)
+			if !ok {
+				return &resp.Error{Msg: fmt.Sprintf("ERR Invalid %s option argument for XCLAIM", strings.ToUpper(option))}
+			}
+
+			switch strings.ToUpper(option) {
+			case "IDLE":
+				opts.DeliveryTime = time.Now().Add(-time.Duration(value) * time.Millisecond)
+			case "TIME":
+				opts.DeliveryTime = time.UnixMilli(value)
+			default:
+				opts.RetryCount = value
+				opts.HasRetryCount = true
+			}
+
+			idx++
+		case "LASTID":
+			literal, _ := handlerCtx.Cmd.ArgString(idx + 1)
+
+			id, ok := parseStrictStreamId(literal)
+			if !ok {
+				return errInvalidStreamId
+			}
+
+			opts.LastId = id
+			opts.HasLastId = true
+			idx++
+		default:
+			return &resp.Error{Msg: fmt.Sprintf("ERR Unrecognized XCLAIM option '%s'", option)}
+		}
+	}
+
+	claimed, err := serverCtx.Store.Xclaim(key, group, consumer, ids, opts)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return claimedEntriesToResp(claimed, opts.JustId)
+}
+
+// handleXautoclaim serves
+// XAUTOCLAIM key group consumer min-idle-time start [COUNT count] [JUSTID]
+func handleXautoclaim(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 5 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	group, _ := handlerCtx.Cmd.ArgString(1)
+	consumer, _ := handlerCtx.Cmd.ArgString(2)
+
+	opts := store.XclaimOptions{}
+
+	minIdle, ok := parseNonNegativeInt64(handlerCtx.Cmd, 3)
+	if !ok {
+		return &resp.Error{Msg: "ERR Invalid min-idle-time argument for XAUTOCLAIM"}
+	}
+
+	opts.MinIdleMs = minIdle
+
+	start, _ := handlerCtx.Cmd.ArgString(4)
+	count := 100
+
+	for idx := 5; idx < argsLen; idx++ {
+		option, _ := handlerCtx.Cmd.ArgString(idx)
+
+		switch strings.ToUpper(option) {
+		case "COUNT":
+			count, ok = handlerCtx.Cmd.ArgInt(idx + 1)
+			if !ok {
+				return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+			}
+
+			idx++
+		case "JUSTID":
+			opts.JustId = true
+		default:
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+	}
+
+	result, err := serverCtx.Store.Xautoclaim(key, group, consumer, start, count, opts)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	deleted := &resp.Array{Elements: make([]resp.Value, 0, len(result.Deleted))}
+	for _, id := range result.Deleted {
+		deleted.Elements = append(deleted.Elements, &resp.BulkString{Bytes: []byte(id)})
+	}
+
+	return &resp.Array{
+		Elements: []resp.Value{
+			&resp.BulkString{Bytes: []byte(result.Next)},
+			claimedEntriesToResp(result.Claimed, opts.JustId),
+			deleted,
+		},
+	}
+}
+
+func claimedEntriesToResp(claimed []store.StreamElement, justId bool) *resp.Array {
+	if !justId {
+		return populateRespArrayFromStream(claimed)
+	}
+
+	arr := &resp.Array{Elements: make([]resp.Value, 0, len(claimed))}
+	for _, el := range claimed {
+		arr.Elements = append(arr.Elements, &resp.BulkString{Bytes: []byte(el.Id.ToString())})
+	}
+
+	return arr
+}
+
+func parseNonNegativeInt64(cmd *Command, idx int) (int64, bool) {
+	literal, _ := cmd.ArgString(idx)
+
+	value, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+
+	return value, true
+}
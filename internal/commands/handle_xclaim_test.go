@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// newStoreWithPendingEntries returns a store with a stream "s" holding count
+// entries, all delivered to consumer alice of group g.
+func newStoreWithPendingEntries(t *testing.T, count int) *store.Store {
+	t.Helper()
+
+	s := store.NewStore()
+	addSequentialEntries(t, s, "s", count)
+	testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", ">"), s, false)
+
+	return s
+}
+
+func requirePendingEntry(t *testing.T, s *store.Store, id, consumer string, deliveries int64) {
+	t.Helper()
+
+	out := testDispatch(newTestCommand(XPENDING_COMMAND, "s", "g", id, id, "1"), s, false)
+	entries := out.(*resp.Array)
+	if len(entries.Elements) != 1 {
+		t.Fatalf("expected %s to be pending, got %#v", id, out)
+	}
+
+	entry := entries.Elements[0].(*resp.Array)
+	requireBulkString(t, entry.Elements[1], consumer)
+	requireInteger(t, entry.Elements[3], deliveries)
+}
+
+func TestHandleXclaim(t *testing.T) {
+	t.Run("claims idle entries", func(t *testing.T) {
+		s := newStoreWithPendingEntries(t, 2)
+
+		out := testDispatch(newTestCommand(XCLAIM_COMMAND, "s", "g", "bob", "60000", "1-0"), s, false)
+		requireStreamIds(t, out)
+
+		out = testDispatch(newTestCommand(XCLAIM_COMMAND, "s", "g", "bob", "0", "1-0", "2-0"), s, false)
+		requireStreamIds(t, out, "1-0", "2-0")
+		requirePendingEntry(t, s, "1-0", "bob", 2)
+	})
+
+	t.Run("JUSTID and RETRYCOUNT", func(t *testing.T) {
+		s := newStoreWithPendingEntries(t, 1)
+
+		out := testDispatch(newTestCommand(XCLAIM_COMMAND, "s", "g", "bob", "0", "1-0", "JUSTID"), s, false)
+		arr := out.(*resp.Array)
+		requireBulkString(t, arr.Elements[0], "1-0")
+		requirePendingEntry(t, s, "1-0", "bob", 1)
+
+		testDispatch(newTestCommand(XCLAIM_COMMAND, "s", "g", "alice", "0", "1-0", "RETRYCOUNT", "7", "IDLE", "5000"), s, false)
+		requirePendingEntry(t, s, "1-0", "alice", 7)
+	})
+
+	t.Run("FORCE adds entries missing from the pending list", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 1)
+		testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "$"), s, false)
+
+		out := testDispatch(newTestCommand(XCLAIM_COMMAND, "s", "g", "bob", "0", "1-0"), s, false)
+		requireStreamIds(t, out)
+
+		out = testDispatch(newTestCommand(XCLAIM_COMMAND, "s", "g", "bob", "0", "1-0", "FORCE"), s, false)
+		requireStreamIds(t, out, "1-0")
+	})
+
+	t.Run("deleted entries leave the pending list", func(t *testing.T) {
+		s := newStoreWithPendingEntries(t, 2)
+		testDispatch(newTestCommand(XDEL_COMMAND, "s", "1-0"), s, false)
+
+		out := testDispatch(newTestCommand(XCLAIM_COMMAND, "s", "g", "bob", "0", "1-0", "2-0"), s, false)
+		requireStreamIds(t, out, "2-0")
+
+		out = testDispatch(newTestCommand(XPENDING_COMMAND, "s", "g"), s, false)
+		requireInteger(t, out.(*resp.Array).Elements[0], 1)
+	})
+}
+
+func TestHandleXautoclaim(t *testing.T) {
+	s := newStoreWithPendingEntries(t, 5)
+	testDispatch(newTestCommand(XDEL_COMMAND, "s", "2-0"), s, false)
+
+	out := testDispatch(newTestCommand(XAUTOCLAIM_COMMAND, "s", "g", "bob", "0", "0-0", "COUNT", "2"), s, false)
+	reply := out.(*resp.Array)
+	requireBulkString(t, reply.Elements[0], "4-0")
+	requireStreamIds(t, reply.Elements[1], "1-0", "3-0")
+
+	deleted := reply.Elements[2].(*resp.Array)
+	if len(deleted.Elements) != 1 {
+		t.Fatalf("expected one deleted entry, got %#v", deleted)
+	}
+	requireBulkString(t, deleted.Elements[0], "2-0")
+
+	out = testDispatch(newTestCommand(XAUTOCLAIM_COMMAND, "s", "g", "bob", "0", "4-0", "JUSTID"), s, false)
+	reply = out.(*resp.Array)
+	requireBulkString(t, reply.Elements[0], "0-0")
+	requirePendingEntry(t, s, "4-0", "bob", 1)
+
+	out = testDispatch(newTestCommand(XAUTOCLAIM_COMMAND, "s", "g", "bob", "0", "0", "COUNT", "0"), s, false)
+	requireError(t, out, "ERR COUNT must be > 0")
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func handleXdel(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	ids := make([]store.StreamIdSpec, 0, argsLen-1)
+
+	for i := 1; i < argsLen; i++ {
+		literal, _ := handlerCtx.Cmd.ArgString(i)
+
+		id, ok := parseStrictStreamId(literal)
+		if !ok {
+			return &resp.Error{Msg: "ERR Invalid stream ID specified as stream command argument"}
+		}
+
+		ids = append(ids, id)
+	}
+
+	deleted, err := serverCtx.Store.Xdel(key, ids)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: deleted}
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func requireStreamIds(t *testing.T, out resp.Value, want ...string) {
+	t.Helper()
+
+	arr, ok := out.(*resp.Array)
+	if !ok {
+		t.Fatalf("expected Array, got %#v", out)
+	}
+
+	if len(arr.Elements) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(arr.Elements))
+	}
+
+	for i, id := range want {
+		entry := arr.Elements[i].(*resp.Array)
+		if got := string(entry.Elements[0].(*resp.BulkString).Bytes); got != id {
+			t.Fatalf("expected entry %d to be %s, got %s", i, id, got)
+		}
+	}
+}
+
+func TestHandleXdel(t *testing.T) {
+	s := store.NewStore()
+	addSequentialEntries(t, s, "s", 3)
+
+	out := testDispatch(newTestCommand(XDEL_COMMAND, "s", "3-0", "2-0", "9-0"), s, false)
+	requireInteger(t, out, 2)
+
+	out = testDispatch(newTestCommand(XLEN_COMMAND, "s"), s, false)
+	requireInteger(t, out, 1)
+
+	out = testDispatch(newTestCommand(XADD_COMMAND, "s", "3-0", "f", "v"), s, false)
+	if _, ok := out.(*resp.Error); !ok {
+		t.Fatalf("expected XADD at a deleted ID to fail, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(XDEL_COMMAND, "s", "bad"), s, false)
+	if _, ok := out.(*resp.Error); !ok {
+		t.Fatalf("expected error for invalid ID, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(XLEN_COMMAND, "missing"), s, false)
+	requireInteger(t, out, 0)
+}
+
+func TestHandleXrangeCountAndExclusive(t *testing.T) {
+	s := store.NewStore()
+	addSequentialEntries(t, s, "s", 5)
+
+	out := testDispatch(newTestCommand(XRANGE_COMMAND, "s", "-", "+", "COUNT", "2"), s, false)
+	requireStreamIds(t, out, "1-0", "2-0")
+
+	out = testDispatch(newTestCommand(XRANGE_COMMAND, "s", "(2-0", "(5-0"), s, false)
+	requireStreamIds(t, out, "3-0", "4-0")
+
+	out = testDispatch(newTestCommand(XREVRANGE_COMMAND, "s", "+", "-", "COUNT", "2"), s, false)
+	requireStreamIds(t, out, "5-0", "4-0")
+
+	out = testDispatch(newTestCommand(XREVRANGE_COMMAND, "s", "(4-0", "2"), s, false)
+	requireStreamIds(t, out, "3-0", "2-0")
+
+	out = testDispatch(newTestCommand(XRANGE_COMMAND, "s", "-", "+", "COUNT", "0"), s, false)
+	if arr, ok := out.(*resp.Array); !ok || !arr.Null {
+		t.Fatalf("expected null array for COUNT 0, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(XRANGE_COMMAND, "s", "(18446744073709551615-18446744073709551615", "+"), s, false)
+	if _, ok := out.(*resp.Error); !ok {
+		t.Fatalf("expected error for exclusive max ID, got %#v", out)
+	}
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+var errInvalidStreamId = &resp.Error{Msg: "ERR Invalid stream ID specified as stream command argument"}
+
+func handleXgroup(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	subcommand, _ := handlerCtx.Cmd.ArgString(0)
+	subcommand = strings.ToUpper(subcommand)
+
+	wantArgs := map[string]int{
+		"CREATE":         4,
+		"DESTROY":        3,
+		"SETID":          4,
+		"CREATECONSUMER": 4,
+		"DELCONSUMER":    4,
+	}
+
+	minArgs, known := wantArgs[subcommand]
+	if !known {
+		return &resp.Error{Msg: fmt.Sprintf("ERR unknown subcommand '%s'. Try XGROUP HELP.", subcommand)}
+	}
+
+	if argsLen < minArgs {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for 'xgroup|%s' command", strings.ToLower(subcommand))}
+	}
+
+	key, _ := handlerCtx.Cmd.ArgString(1)
+	group, _ := handlerCtx.Cmd.ArgString(2)
+
+	switch subcommand {
+	case "CREATE":
+		id, ok := parseGroupStreamId(handlerCtx.Cmd, 3)
+		if !ok {
+			return errInvalidStreamId
+		}
+
+		mkStream := false
+		entriesRead := int64(store.StreamInvalidEntriesRead)
+
+		for i := 4; i < argsLen; i++ {
+			option, _ := handlerCtx.Cmd.ArgString(i)
+
+			switch strings.ToUpper(option) {
+			case "MKSTREAM":
+				mkStream = true
+			case "ENTRIESREAD":
+				value, errValue := parseEntriesRead(handlerCtx.Cmd, i+1)
+				if errValue != nil {
+					return errValue
+				}
+
+				entriesRead = value
+				i++
+			default:
+				return &resp.Error{Msg: "ERR syntax error"}
+			}
+		}
+
+		if err := serverCtx.Store.XgroupCreate(key, group, id, mkStream, entriesRead); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	case "SETID":
+		id, ok := parseGroupStreamId(handlerCtx.Cmd, 3)
+		if !ok {
+			return errInvalidStreamId
+		}
+
+		entriesRead := int64(store.StreamInvalidEntriesRead)
+
+		if argsLen == 6 {
+			if option, _ := handlerCtx.Cmd.ArgString(4); !strings.EqualFold(option, "ENTRIESREAD") {
+				return &resp.Error{Msg: "ERR syntax error"}
+			}
+
+			value, errValue := parseEntriesRead(handlerCtx.Cmd, 5)
+			if errValue != nil {
+				return errValue
+			}
+
+			entriesRead = value
+		} else if argsLen != 4 {
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+
+		if err := serverCtx.Store.XgroupSetId(key, group, id, entriesRead); err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	}
+
+	if argsLen != minArgs {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for 'xgroup|%s' command", strings.ToLower(subcommand))}
+	}
+
+	switch subcommand {
+	case "DESTROY":
+		destroyed, err := serverCtx.Store.XgroupDestroy(key, group)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		if !destroyed {
+			return &resp.Integer{Number: 0}
+		}
+
+		return &resp.Integer{Number: 1}
+	case "CREATECONSUMER":
+		consumer, _ := handlerCtx.Cmd.ArgString(3)
+
+		created, err := serverCtx.Store.XgroupCreateConsumer(key, group, consumer)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		if !created {
+			return &resp.Integer{Number: 0}
+		}
+
+		return &resp.Integer{Number: 1}
+	default:
+		consumer, _ := handlerCtx.Cmd.ArgString(3)
+
+		pending, err := serverCtx.Store.XgroupDelConsumer(key, group, consumer)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return &resp.Integer{Number: pending}
+	}
+}
+
+// parseGroupStreamId parses the ID argument of XGROUP CREATE and SETID
+// where "$" stands for the last entry of the stream.
+func parseGroupStreamId(cmd *Command, idx int) (store.StreamIdSpec, bool) {
+	literal, _ := cmd.ArgString(idx)
+
+	if literal == "$" {
+		return store.StreamIdSpec{IsMax: true}, true
+	}
+
+	return parseStrictStreamId(literal)
+}
+
+func parseEntriesRead(cmd *Command, idx int) (int64, *resp.Error) {
+	literal, _ := cmd.ArgString(idx)
+
+	value, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil {
+		return 0, &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	if value < 0 && value != store.StreamInvalidEntriesRead {
+		return 0, &resp.Error{Msg: "ERR value for ENTRIESREAD must be positive or -1"}
+	}
+
+	return value, nil
+}
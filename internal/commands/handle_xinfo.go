@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// xinfoFullDefaultCount is the number of entries XINFO STREAM FULL returns
+// when COUNT is not given.
+const xinfoFullDefaultCount = 10
+
+// handleXinfo serves XINFO STREAM key [FULL [COUNT count]], XINFO GROUPS key
+// and XINFO CONSUMERS key group.
+func handleXinfo(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	subcommand, _ := handlerCtx.Cmd.ArgString(0)
+	subcommand = strings.ToUpper(subcommand)
+	key, _ := handlerCtx.Cmd.ArgString(1)
+
+	switch subcommand {
+	case "STREAM":
+		return handleXinfoStream(serverCtx, handlerCtx, key)
+	case "GROUPS":
+		if argsLen != 2 {
+			return &resp.Error{Msg: "ERR wrong number of arguments for 'xinfo|groups' command"}
+		}
+
+		groups, err := serverCtx.Store.XinfoGroups(key)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		arr := &resp.Array{Elements: make([]resp.Value, 0, len(groups))}
+		for _, g := range groups {
+			arr.Elements = append(arr.Elements, resp.NewMap(
+				bulkString("name"), bulkString(g.Name),
+				bulkString("consumers"), &resp.Integer{Number: g.ConsumersCount},
+				bulkString("pending"), &resp.Integer{Number: g.PendingCount},
+				bulkString("last-delivered-id"), bulkString(g.LastDeliveredId),
+				bulkString("entries-read"), optionalInteger(g.EntriesRead, g.HasEntriesRead),
+				bulkString("lag"), optionalInteger(g.Lag, g.HasLag),
+			))
+		}
+
+		return arr
+	case "CONSUMERS":
+		if argsLen != 3 {
+			return &resp.Error{Msg: "ERR wrong number of arguments for 'xinfo|consumers' command"}
+		}
+
+		group, _ := handlerCtx.Cmd.ArgString(2)
+
+		consumers, err := serverCtx.Store.XinfoConsumers(key, group)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		arr := &resp.Array{Elements: make([]resp.Value, 0, len(consumers))}
+		for _, c := range consumers {
+			arr.Elements = append(arr.Elements, resp.NewMap(
+				bulkString("name"), bulkString(c.Name),
+				bulkString("pending"), &resp.Integer{Number: c.PendingCount},
+				bulkString("idle"), &resp.Integer{Number: c.IdleMs},
+				bulkString("inactive"), &resp.Integer{Number: c.InactiveMs},
+			))
+		}
+
+		return arr
+	default:
+		return &resp.Error{Msg: fmt.Sprintf("ERR unknown subcommand '%s'. Try XINFO HELP.", subcommand)}
+	}
+}
+
+func handleXinfoStream(serverCtx *ServerContext, handlerCtx *HandlerContext, key string) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+	full := false
+	count := xinfoFullDefaultCount
+
+	if argsLen > 2 {
+		option, _ := handlerCtx.Cmd.ArgString(2)
+		if !strings.EqualFold(option, "FULL") {
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+
+		full = true
+
+		if argsLen == 5 {
+			option, _ = handlerCtx.Cmd.ArgString(3)
+			if !strings.EqualFold(option, "COUNT") {
+				return &resp.Error{Msg: "ERR syntax error"}
+			}
+
+			var ok bool
+			count, ok = handlerCtx.Cmd.ArgInt(4)
+			if !ok {
+				return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+			}
+
+			count = max(count, 0)
+		} else if argsLen != 3 {
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+	}
+
+	info, err := serverCtx.Store.XinfoStream(key, full, count)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	reply := []resp.Value{
+		bulkString("length"), &resp.Integer{Number: info.Length},
+		bulkString("radix-tree-keys"), &resp.Integer{Number: info.RadixTreeKeys},
+		bulkString("radix-tree-nodes"), &resp.Integer{Number: info.RadixTreeNodes},
+		bulkString("last-generated-id"), bulkString(info.LastGeneratedId),
+		bulkString("max-deleted-entry-id"), bulkString(info.MaxDeletedEntryId),
+		bulkString("entries-added"), &resp.Integer{Number: info.EntriesAdded},
+		bulkString("recorded-first-entry-id"), bulkString(info.RecordedFirstEntryId),
+	}
+
+	if !full {
+		reply = append(reply,
+			bulkString("groups"), &resp.Integer{Number: info.Groups},
+			bulkString("first-entry"), optionalStreamEntry(info.FirstEntry),
+			bulkString("last-entry"), optionalStreamEntry(info.LastEntry),
+		)
+
+		return resp.NewMap(reply...)
+	}
+
+	groups := &resp.Array{Elements: make([]resp.Value, 0, len(info.GroupDetails))}
+
+	for _, g := range info.GroupDetails {
+		pending := &resp.Array{Elements: make([]resp.Value, 0, len(g.Pending))}
+		for _, p := range g.Pending {
+			pending.Elements = append(pending.Elements, &resp.Array{
+				Elements: []resp.Value{
+					bulkString(p.Id),
+					bulkString(p.Consumer),
+					&resp.Integer{Number: p.DeliveryTime.UnixMilli()},
+					&resp.Integer{Number: p.DeliveryCount},
+				},
+			})
+		}
+
+		consumers := &resp.Array{Elements: make([]resp.Value, 0, len(g.Consumers))}
+		for _, c := range g.Consumers {
+			consumerPending := &resp.Array{Elements: make([]resp.Value, 0, len(c.Pending))}
+			for _, p := range c.Pending {
+				consumerPending.Elements = append(consumerPending.Elements, &resp.Array{
+					Elements: []resp.Value{
+						bulkString(p.Id),
+						&resp.Integer{Number: p.DeliveryTime.UnixMilli()},
+						&resp.Integer{Number: p.DeliveryCount},
+					},
+				})
+			}
+
+			activeTime := int64(-1)
+			if !c.ActiveTime.IsZero() {
+				activeTime = c.ActiveTime.UnixMilli()
+			}
+
+			consumers.Elements = append(consumers.Elements, resp.NewMap(
+				bulkString("name"), bulkString(c.Name),
+				bulkString("seen-time"), &resp.Integer{Number: c.SeenTime.UnixMilli()},
+				bulkString("active-time"), &resp.Integer{Number: activeTime},
+				bulkString("pel-count"), &resp.Integer{Number: c.PendingCount},
+				bulkString("pending"), consumerPending,
+			))
+		}
+
+		groups.Elements = append(groups.Elements, resp.NewMap(
+			bulkString("name"), bulkString(g.Name),
+			bulkString("last-delivered-id"), bulkString(g.LastDeliveredId),
+			bulkString("entries-read"), optionalInteger(g.EntriesRead, g.HasEntriesRead),
+			bulkString("lag"), optionalInteger(g.Lag, g.HasLag),
+			bulkString("pel-count"), &resp.Integer{Number: g.PendingCount},
+			bulkString("pending"), pending,
+			bulkString("consumers"), consumers,
+		))
+	}
+
+	reply = append(reply,
+		bulkString("entries"), populateRespArrayFromStream(info.Entries),
+		bulkString("groups"), groups,
+	)
+
+	return resp.NewMap(reply...)
+}
+
+func bulkString(s string) *resp.BulkString {
+	return &resp.BulkString{Bytes: []byte(s)}
+}
+
+func optionalInteger(n int64, ok bool) resp.Value {
+	if !ok {
+		return &resp.BulkString{Null: true}
+	}
+
+	return &resp.Integer{Number: n}
+}
+
+func optionalStreamEntry(el *store.StreamElement) resp.Value {
+	if el == nil {
+		return &resp.BulkString{Null: true}
+	}
+
+	return populateRespArrayFromStream([]store.StreamElement{*el}).Elements[0]
+}
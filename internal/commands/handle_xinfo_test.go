@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// infoField returns the value following name in an XINFO reply, flattened
+// the way it is sent to RESP2 clients.
+func infoField(t *testing.T, out resp.Value, name string) resp.Value {
+	t.Helper()
+
+	arr, ok := resp.ToRESP2(out).(*resp.Array)
+	if !ok {
+		t.Fatalf("expected Array, got %#v", out)
+	}
+
+	for i := 0; i+1 < len(arr.Elements); i += 2 {
+		if bs, ok := arr.Elements[i].(*resp.BulkString); ok && string(bs.Bytes) == name {
+			return arr.Elements[i+1]
+		}
+	}
+
+	t.Fatalf("field %s not found in %#v", name, out)
+	return nil
+}
+
+func TestHandleXinfoStream(t *testing.T) {
+	s := store.NewStore()
+
+	out := testDispatch(newTestCommand(XINFO_COMMAND, "STREAM", "s"), s, false)
+	requireError(t, out, "ERR no such key")
+
+	addSequentialEntries(t, s, "s", 4)
+	testDispatch(newTestCommand(XDEL_COMMAND, "s", "3-0"), s, false)
+	testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+
+	out = testDispatch(newTestCommand(XINFO_COMMAND, "STREAM", "s"), s, false)
+	requireInteger(t, infoField(t, out, "length"), 3)
+	requireInteger(t, infoField(t, out, "entries-added"), 4)
+	requireInteger(t, infoField(t, out, "groups"), 1)
+	requireInteger(t, infoField(t, out, "radix-tree-keys"), 1)
+	requireBulkString(t, infoField(t, out, "last-generated-id"), "4-0")
+	requireBulkString(t, infoField(t, out, "max-deleted-entry-id"), "3-0")
+	requireBulkString(t, infoField(t, out, "recorded-first-entry-id"), "1-0")
+	requireStreamIds(t, &resp.Array{Elements: []resp.Value{infoField(t, out, "last-entry")}}, "4-0")
+
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "COUNT", "1", "STREAMS", "s", ">"), s, false)
+
+	out = testDispatch(newTestCommand(XINFO_COMMAND, "STREAM", "s", "FULL", "COUNT", "2"), s, false)
+	requireStreamIds(t, infoField(t, out, "entries"), "1-0", "2-0")
+
+	groups := infoField(t, out, "groups").(*resp.Array)
+	group := groups.Elements[0]
+	requireInteger(t, infoField(t, group, "pel-count"), 1)
+
+	consumers := infoField(t, group, "consumers").(*resp.Array)
+	requireBulkString(t, infoField(t, consumers.Elements[0], "name"), "alice")
+}
+
+func TestHandleXinfoGroupsLag(t *testing.T) {
+	s := store.NewStore()
+	addSequentialEntries(t, s, "s", 5)
+	testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+
+	lagOf := func() resp.Value {
+		out := testDispatch(newTestCommand(XINFO_COMMAND, "GROUPS", "s"), s, false)
+		return infoField(t, out.(*resp.Array).Elements[0], "lag")
+	}
+
+	requireInteger(t, lagOf(), 5)
+
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "COUNT", "2", "STREAMS", "s", ">"), s, false)
+	requireInteger(t, lagOf(), 3)
+
+	// a deletion ahead of the group makes the lag unknown
+	testDispatch(newTestCommand(XDEL_COMMAND, "s", "4-0"), s, false)
+	if bs, ok := lagOf().(*resp.BulkString); !ok || !bs.Null {
+		t.Fatalf("expected null lag, got %#v", lagOf())
+	}
+
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", ">"), s, false)
+	requireInteger(t, lagOf(), 0)
+
+	out := testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g2", "0", "ENTRIESREAD", "-2"), s, false)
+	requireError(t, out, "ERR value for ENTRIESREAD must be positive or -1")
+}
+
+func TestHandleXinfoConsumers(t *testing.T) {
+	s := store.NewStore()
+	addSequentialEntries(t, s, "s", 2)
+	testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+	testDispatch(newTestCommand(XGROUP_COMMAND, "CREATECONSUMER", "s", "g", "idle"), s, false)
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", ">"), s, false)
+
+	out := testDispatch(newTestCommand(XINFO_COMMAND, "CONSUMERS", "s", "g"), s, false)
+	consumers := out.(*resp.Array)
+	if len(consumers.Elements) != 2 {
+		t.Fatalf("expected 2 consumers, got %d", len(consumers.Elements))
+	}
+
+	requireBulkString(t, infoField(t, consumers.Elements[0], "name"), "alice")
+	requireInteger(t, infoField(t, consumers.Elements[0], "pending"), 2)
+	requireInteger(t, infoField(t, consumers.Elements[1], "inactive"), -1)
+
+	out = testDispatch(newTestCommand(XINFO_COMMAND, "CONSUMERS", "s", "missing"), s, false)
+	requireError(t, out, "NOGROUP No such consumer group 'missing' for key name 's'")
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// handleXpending serves XPENDING key group [[IDLE min-idle-time] start end count [consumer]]
+func handleXpending(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 2 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	group, _ := handlerCtx.Cmd.ArgString(1)
+
+	if argsLen == 2 {
+		summary, err := serverCtx.Store.XpendingSummary(key, group)
+		if err != nil {
+			return &resp.Error{Msg: err.Error()}
+		}
+
+		return pendingSummaryToResp(summary)
+	}
+
+	r := store.XpendingRange{}
+	idx := 2
+
+	if option, _ := handlerCtx.Cmd.ArgString(idx); strings.EqualFold(option, "IDLE") {
+		minIdle, ok := handlerCtx.Cmd.ArgInt(idx + 1)
+		if !ok {
+			return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+		}
+
+		r.MinIdleMs = int64(minIdle)
+		idx += 2
+	}
+
+	if argsLen-idx != 3 && argsLen-idx != 4 {
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	r.Start, _ = handlerCtx.Cmd.ArgString(idx)
+	r.End, _ = handlerCtx.Cmd.ArgString(idx + 1)
+
+	r.Count, ok = handlerCtx.Cmd.ArgInt(idx + 2)
+	if !ok {
+		return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+	}
+
+	if argsLen-idx == 4 {
+		r.Consumer, _ = handlerCtx.Cmd.ArgString(idx + 3)
+	}
+
+	if r.Count <= 0 {
+		return &resp.Array{}
+	}
+
+	entries, err := serverCtx.Store.XpendingRange(key, group, r)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	arr := &resp.Array{Elements: make([]resp.Value, 0, len(entries))}
+
+	for _, entry := range entries {
+		arr.Elements = append(arr.Elements, &resp.Array{
+			Elements: []resp.Value{
+				&resp.BulkString{Bytes: []byte(entry.Id)},
+				&resp.BulkString{Bytes: []byte(entry.Consumer)},
+				&resp.Integer{Number: entry.IdleMs},
+				&resp.Integer{Number: entry.DeliveryCount},
+			},
+		})
+	}
+
+	return arr
+}
+
+func pendingSummaryToResp(summary store.PendingSummary) resp.Value {
+	if summary.Count == 0 {
+		return &resp.Array{
+			Elements: []resp.Value{
+				&resp.Integer{Number: 0},
+				&resp.BulkString{Null: true},
+				&resp.BulkString{Null: true},
+				&resp.Array{Null: true},
+			},
+		}
+	}
+
+	consumers := &resp.Array{}
+
+	for _, c := range summary.Consumers {
+		consumers.Elements = append(consumers.Elements, &resp.Array{
+			Elements: []resp.Value{
+				&resp.BulkString{Bytes: []byte(c.Name)},
+				&resp.BulkString{Bytes: []byte(strconv.FormatInt(c.Count, 10))},
+			},
+		})
+	}
+
+	return &resp.Array{
+		Elements: []resp.Value{
+			&resp.Integer{Number: summary.Count},
+			&resp.BulkString{Bytes: []byte(summary.Min)},
+			&resp.BulkString{Bytes: []byte(summary.Max)},
+			consumers,
+		},
+	}
+}
@@ -1,41 +1,73 @@
 package commands
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
 )
 
+// handleXrange serves XRANGE and XREVRANGE. XREVRANGE takes the end bound
+// before the start bound.
 func handleXrange(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
 	argsLen := handlerCtx.Cmd.ArgsLen()
 
-	if argsLen < 3 {
-		return &resp.Error{Msg: "ERR invalid number of arguments for XRANGE command"}
+	if argsLen != 3 && argsLen != 5 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid number of arguments for %s command", handlerCtx.Cmd.Name)}
 	}
 
 	key, ok := handlerCtx.Cmd.ArgString(0)
 	if !ok {
-		return &resp.Error{Msg: "ERR invalid key value for XRANGE command"}
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
 	}
 
 	start, ok := handlerCtx.Cmd.ArgString(1)
 	if !ok {
-		return &resp.Error{Msg: "ERR invalid start value for XRANGE command"}
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid start value for %s command", handlerCtx.Cmd.Name)}
 	}
 
 	end, ok := handlerCtx.Cmd.ArgString(2)
 	if !ok {
-		return &resp.Error{Msg: "ERR invalid end value for XRANGE command"}
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid end value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	count := 0
+
+	if argsLen == 5 {
+		keyword, _ := handlerCtx.Cmd.ArgString(3)
+		if !strings.EqualFold(keyword, "COUNT") {
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+
+		count, ok = handlerCtx.Cmd.ArgInt(4)
+		if !ok {
+			return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+		}
+
+		if count <= 0 {
+			return &resp.Array{Null: true}
+		}
+	}
+
+	var elements []store.StreamElement
+	var err error
+
+	if handlerCtx.Cmd.Name == XREVRANGE_COMMAND {
+		elements, err = serverCtx.Store.Xrevrange(key, start, end, count)
+	} else {
+		elements, err = serverCtx.Store.Xrange(key, start, end, count)
 	}
 
-	stream, err := serverCtx.Store.Xrange(key, start, end)
 	if err != nil {
 		return &resp.Error{Msg: err.Error()}
 	}
 
-	if len(stream.Elements) == 0 {
+	if len(elements) == 0 {
 		return &resp.Array{}
 	}
 
-	arr := populateRespArrayFromStream(stream)
+	arr := populateRespArrayFromStream(elements)
 
 	return arr
 }
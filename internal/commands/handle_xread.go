@@ -6,66 +6,72 @@ import (
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
 )
 
+// handleXread serves XREAD [COUNT count] [BLOCK ms] STREAMS key... id...
 func handleXread(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
 	argsLen := handlerCtx.Cmd.ArgsLen()
 
-	if argsLen < 1 {
+	if argsLen < 3 {
 		return &resp.Error{Msg: "ERR invalid number of arguments for XREAD command"}
 	}
 
-	cmdIdentifier, ok := handlerCtx.Cmd.ArgString(0)
-	if !ok {
-		return &resp.Error{Msg: "ERR invalid identifier for XREAD command"}
-	}
-
-	isBlocking := strings.EqualFold(cmdIdentifier, "block")
-
-	if isBlocking && argsLen < 5 {
-		return &resp.Error{Msg: "ERR invalid number of arguments for XREAD BLOCK command"}
-	} else if !isBlocking && argsLen < 3 {
-		return &resp.Error{Msg: "ERR invalid number of arguments for XREAD STREAMS command"}
-	}
-
+	isBlocking := false
 	blockingTimeoutMs := 0
+	count := 0
+	streamsIdx := -1
 
-	if isBlocking {
-		blockingTimeoutMs, ok = handlerCtx.Cmd.ArgInt(1)
-
+	for i := 0; i < argsLen && streamsIdx < 0; i++ {
+		option, ok := handlerCtx.Cmd.ArgString(i)
 		if !ok {
-			return &resp.Error{Msg: "ERR invalid BLOCK timeout value for XREAD command"}
+			return &resp.Error{Msg: "ERR invalid identifier for XREAD command"}
 		}
 
-		streamsKeyword, ok := handlerCtx.Cmd.ArgString(2)
-		if !ok || !strings.EqualFold(streamsKeyword, "streams") {
+		switch strings.ToUpper(option) {
+		case "COUNT":
+			count, ok = handlerCtx.Cmd.ArgInt(i + 1)
+			if !ok {
+				return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+			}
+
+			count = max(count, 0)
+			i++
+		case "BLOCK":
+			blockingTimeoutMs, ok = handlerCtx.Cmd.ArgInt(i + 1)
+			if !ok {
+				return &resp.Error{Msg: "ERR invalid BLOCK timeout value for XREAD command"}
+			}
+
+			if blockingTimeoutMs < 0 {
+				return &resp.Error{Msg: "ERR timeout is negative"}
+			}
+
+			isBlocking = true
+			i++
+		case "STREAMS":
+			streamsIdx = i + 1
+		default:
 			return &resp.Error{Msg: "ERR invalid STREAMS identifier for XREAD command"}
 		}
 	}
 
-	if (argsLen-1)%2 != 0 {
-		return &resp.Error{Msg: "ERR invalid number of arguments for XREAD command"}
+	if streamsIdx < 0 {
+		return &resp.Error{Msg: "ERR invalid STREAMS identifier for XREAD command"}
 	}
 
-	pairsCount := (argsLen) / 2
-
-	if isBlocking {
-		pairsCount = pairsCount - 1
+	remaining := argsLen - streamsIdx
+	if remaining == 0 || remaining%2 != 0 {
+		return &resp.Error{Msg: "ERR invalid number of arguments for XREAD command"}
 	}
 
+	pairsCount := remaining / 2
 	streamKeyIdPairs := [][]string{}
 
-	streamsKeyIdsOffset := 1
-
-	if isBlocking {
-		streamsKeyIdsOffset = 3
-	}
-
 	for i := range pairsCount {
-		storeKey, ok := handlerCtx.Cmd.ArgString(i + streamsKeyIdsOffset)
+		storeKey, ok := handlerCtx.Cmd.ArgString(streamsIdx + i)
 		if !ok {
 			return &resp.Error{Msg: "ERR invalid stream name value for XREAD command"}
 		}
 
-		streamId, ok := handlerCtx.Cmd.ArgString(i + pairsCount + streamsKeyIdsOffset)
+		streamId, ok := handlerCtx.Cmd.ArgString(streamsIdx + pairsCount + i)
 		if !ok {
 			return &resp.Error{Msg: "ERR invalid stream id value for XREAD command"}
 		}
@@ -73,7 +79,11 @@ func handleXread(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Valu
 		streamKeyIdPairs = append(streamKeyIdPairs, []string{storeKey, streamId})
 	}
 
-	streams, err := serverCtx.Store.Xread(streamKeyIdPairs, blockingTimeoutMs, isBlocking)
+	if handlerCtx.InTransaction {
+		isBlocking = false
+	}
+
+	streams, err := serverCtx.Store.Xread(streamKeyIdPairs, count, blockingTimeoutMs, isBlocking)
 	if err != nil {
 		return &resp.Error{Msg: err.Error()}
 	}
@@ -82,7 +92,7 @@ func handleXread(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Valu
 	hasEntries := false
 
 	for i, stream := range streams {
-		if len(stream.Elements) == 0 {
+		if len(stream) == 0 {
 			continue
 		}
 
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"testing"
+	"time"
 
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
 	"github.com/codecrafters-io/redis-starter-go/internal/store"
@@ -371,3 +372,59 @@ func TestDispatchXreadCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestHandleXreadCountAndLastEntry(t *testing.T) {
+	s := store.NewStore()
+	addSequentialEntries(t, s, "a", 5)
+	addSequentialEntries(t, s, "b", 2)
+
+	streamEntries := func(t *testing.T, out resp.Value, idx int) resp.Value {
+		t.Helper()
+
+		arr, ok := out.(*resp.Array)
+		if !ok || len(arr.Elements) <= idx {
+			t.Fatalf("expected at least %d streams, got %#v", idx+1, out)
+		}
+
+		return arr.Elements[idx].(*resp.Array).Elements[1]
+	}
+
+	out := testDispatch(newTestCommand(XREAD_COMMAND, "COUNT", "2", "STREAMS", "a", "b", "0", "1-0"), s, false)
+	requireStreamIds(t, streamEntries(t, out, 0), "1-0", "2-0")
+	requireStreamIds(t, streamEntries(t, out, 1), "2-0")
+
+	out = testDispatch(newTestCommand(XREAD_COMMAND, "STREAMS", "a", "b", "+", "+"), s, false)
+	requireStreamIds(t, streamEntries(t, out, 0), "5-0")
+	requireStreamIds(t, streamEntries(t, out, 1), "2-0")
+
+	out = testDispatch(newTestCommand(XREAD_COMMAND, "STREAMS", "a", "$"), s, false)
+	if arr, ok := out.(*resp.Array); !ok || !arr.Null {
+		t.Fatalf("expected null array for $, got %#v", out)
+	}
+}
+
+func TestHandleXreadBlockingMultipleStreams(t *testing.T) {
+	s := store.NewStore()
+	addSequentialEntries(t, s, "a", 1)
+
+	result := make(chan resp.Value, 1)
+	go func() {
+		result <- testDispatch(newTestCommand(XREAD_COMMAND, "BLOCK", "0", "COUNT", "1", "STREAMS", "a", "b", "$", "+"), s, false)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	addSequentialEntries(t, s, "b", 2)
+
+	select {
+	case out := <-result:
+		arr := out.(*resp.Array)
+		if len(arr.Elements) != 1 {
+			t.Fatalf("expected only stream b, got %#v", out)
+		}
+
+		requireBulkString(t, arr.Elements[0].(*resp.Array).Elements[0], "b")
+		requireStreamIds(t, arr.Elements[0].(*resp.Array).Elements[1], "1-0")
+	case <-time.After(time.Second):
+		t.Fatal("XREAD did not unblock")
+	}
+}
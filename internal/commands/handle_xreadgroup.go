@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// handleXreadgroup serves
+// XREADGROUP GROUP group consumer [COUNT count] [BLOCK ms] [NOACK] STREAMS key... id...
+func handleXreadgroup(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 6 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	if keyword, _ := handlerCtx.Cmd.ArgString(0); !strings.EqualFold(keyword, "GROUP") {
+		return &resp.Error{Msg: "ERR Missing GROUP option for XREADGROUP"}
+	}
+
+	opts := store.XreadgroupOptions{}
+	opts.Group, _ = handlerCtx.Cmd.ArgString(1)
+	opts.Consumer, _ = handlerCtx.Cmd.ArgString(2)
+
+	isBlocking := false
+	blockingTimeoutMs := 0
+	streamsIdx := -1
+
+	for i := 3; i < argsLen && streamsIdx < 0; i++ {
+		option, _ := handlerCtx.Cmd.ArgString(i)
+
+		switch strings.ToUpper(option) {
+		case "COUNT":
+			count, ok := handlerCtx.Cmd.ArgInt(i + 1)
+			if !ok {
+				return &resp.Error{Msg: "ERR value is not an integer or out of range"}
+			}
+
+			opts.Count = max(count, 0)
+			i++
+		case "BLOCK":
+			timeout, ok := handlerCtx.Cmd.ArgInt(i + 1)
+			if !ok {
+				return &resp.Error{Msg: "ERR timeout is not an integer or out of range"}
+			}
+
+			if timeout < 0 {
+				return &resp.Error{Msg: "ERR timeout is negative"}
+			}
+
+			isBlocking = true
+			blockingTimeoutMs = timeout
+			i++
+		case "NOACK":
+			opts.NoAck = true
+		case "STREAMS":
+			streamsIdx = i + 1
+		default:
+			return &resp.Error{Msg: "ERR syntax error"}
+		}
+	}
+
+	if streamsIdx < 0 {
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	remaining := argsLen - streamsIdx
+	if remaining == 0 || remaining%2 != 0 {
+		return &resp.Error{Msg: "ERR Unbalanced 'xreadgroup' list of streams: for each stream key an ID or '>' must be specified."}
+	}
+
+	pairsCount := remaining / 2
+	streamKeyIdPairs := make([][]string, 0, pairsCount)
+
+	for i := range pairsCount {
+		storeKey, _ := handlerCtx.Cmd.ArgString(streamsIdx + i)
+		streamId, _ := handlerCtx.Cmd.ArgString(streamsIdx + pairsCount + i)
+
+		streamKeyIdPairs = append(streamKeyIdPairs, []string{storeKey, streamId})
+	}
+
+	if handlerCtx.InTransaction {
+		isBlocking = false
+	}
+
+	result, err := serverCtx.Store.Xreadgroup(streamKeyIdPairs, opts, blockingTimeoutMs, isBlocking)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	handlerCtx.Effects = groupDeliveryEffects(opts, result.Deliveries)
+
+	if !result.HasEntries {
+		return &resp.Array{Null: true}
+	}
+
+	arr := &resp.Array{}
+
+	for i, stream := range result.Streams {
+		if stream == nil {
+			continue
+		}
+
+		arr.Elements = append(arr.Elements, resp.Value(&resp.Array{
+			Elements: []resp.Value{
+				&resp.BulkString{Bytes: []byte(streamKeyIdPairs[i][0])},
+				populateRespArrayFromStream(stream),
+			},
+		}))
+	}
+
+	return arr
+}
+
+// groupDeliveryEffects replicates what a read changed in the consumer
+// groups, since reading again on a replica may deliver other entries: the
+// consumer creation, an XCLAIM per pending entry delivered and the new
+// position of the group.
+func groupDeliveryEffects(opts store.XreadgroupOptions, deliveries []store.GroupDelivery) []resp.Value {
+	effects := []resp.Value{}
+
+	for _, d := range deliveries {
+		if d.ConsumerCreated {
+			effects = append(effects, effectCommand(XGROUP_COMMAND, "CREATECONSUMER", d.Key, opts.Group, opts.Consumer))
+		}
+
+		for _, p := range d.Pending {
+			effects = append(effects, effectCommand(XCLAIM_COMMAND, d.Key, opts.Group, opts.Consumer, "0", p.Id,
+				"TIME", strconv.FormatInt(p.DeliveryTime.UnixMilli(), 10),
+				"RETRYCOUNT", strconv.FormatInt(p.DeliveryCount, 10),
+				"FORCE", "JUSTID", "LASTID", d.LastDeliveredId))
+		}
+
+		if d.Advanced {
+			effects = append(effects, effectCommand(XGROUP_COMMAND, "SETID", d.Key, opts.Group, d.LastDeliveredId,
+				"ENTRIESREAD", strconv.FormatInt(d.EntriesRead, 10)))
+		}
+	}
+
+	return effects
+}
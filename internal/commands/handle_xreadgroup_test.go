@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+// groupReadEntries returns the entries of the single stream in an
+// XREADGROUP reply.
+func groupReadEntries(t *testing.T, out resp.Value) resp.Value {
+	t.Helper()
+
+	arr, ok := out.(*resp.Array)
+	if !ok || len(arr.Elements) != 1 {
+		t.Fatalf("expected a single stream in XREADGROUP reply, got %#v", out)
+	}
+
+	return arr.Elements[0].(*resp.Array).Elements[1]
+}
+
+func TestHandleXgroup(t *testing.T) {
+	s := store.NewStore()
+
+	out := testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "$"), s, false)
+	if _, ok := out.(*resp.Error); !ok {
+		t.Fatalf("expected error when the key is missing, got %#v", out)
+	}
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "$", "MKSTREAM"), s, false)
+	requireSimpleString(t, out, "OK")
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+	requireError(t, out, "BUSYGROUP Consumer Group name already exists")
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "SETID", "s", "missing", "0"), s, false)
+	requireError(t, out, "NOGROUP No such consumer group 'missing' for key name 's'")
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "CREATECONSUMER", "s", "g", "alice"), s, false)
+	requireInteger(t, out, 1)
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "CREATECONSUMER", "s", "g", "alice"), s, false)
+	requireInteger(t, out, 0)
+
+	addSequentialEntries(t, s, "s", 2)
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", ">"), s, false)
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "DELCONSUMER", "s", "g", "alice"), s, false)
+	requireInteger(t, out, 2)
+
+	out = testDispatch(newTestCommand(XPENDING_COMMAND, "s", "g"), s, false)
+	requireInteger(t, out.(*resp.Array).Elements[0], 0)
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "DESTROY", "s", "g"), s, false)
+	requireInteger(t, out, 1)
+
+	out = testDispatch(newTestCommand(XGROUP_COMMAND, "DESTROY", "s", "g"), s, false)
+	requireInteger(t, out, 0)
+}
+
+func TestHandleXreadgroup(t *testing.T) {
+	t.Run("delivers new entries once per group", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 3)
+		testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+
+		out := testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "COUNT", "2", "STREAMS", "s", ">"), s, false)
+		requireStreamIds(t, groupReadEntries(t, out), "1-0", "2-0")
+
+		out = testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "bob", "STREAMS", "s", ">"), s, false)
+		requireStreamIds(t, groupReadEntries(t, out), "3-0")
+
+		out = testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "bob", "STREAMS", "s", ">"), s, false)
+		if arr, ok := out.(*resp.Array); !ok || !arr.Null {
+			t.Fatalf("expected null array when nothing is new, got %#v", out)
+		}
+	})
+
+	t.Run("history reads return pending entries", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 3)
+		testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+		testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", ">"), s, false)
+		testDispatch(newTestCommand(XDEL_COMMAND, "s", "2-0"), s, false)
+
+		out := testDispatch(newTestCommand(XACK_COMMAND, "s", "g", "1-0", "9-0"), s, false)
+		requireInteger(t, out, 1)
+
+		out = testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", "0"), s, false)
+		entries := groupReadEntries(t, out)
+		requireStreamIds(t, entries, "2-0", "3-0")
+
+		deleted := entries.(*resp.Array).Elements[0].(*resp.Array).Elements[1]
+		if arr, ok := deleted.(*resp.Array); !ok || !arr.Null {
+			t.Fatalf("expected null fields for deleted entry, got %#v", deleted)
+		}
+
+		out = testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", "3-0"), s, false)
+		requireStreamIds(t, groupReadEntries(t, out))
+	})
+
+	t.Run("NOACK skips the pending list", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 2)
+		testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+		testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "NOACK", "STREAMS", "s", ">"), s, false)
+
+		out := testDispatch(newTestCommand(XPENDING_COMMAND, "s", "g"), s, false)
+		requireInteger(t, out.(*resp.Array).Elements[0], 0)
+	})
+
+	t.Run("missing group", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 1)
+
+		out := testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "STREAMS", "s", ">"), s, false)
+		requireError(t, out, "NOGROUP No such key 's' or consumer group 'g' in XREADGROUP with GROUP option")
+	})
+
+	t.Run("BLOCK wakes up on XADD", func(t *testing.T) {
+		s := store.NewStore()
+		testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "$", "MKSTREAM"), s, false)
+
+		result := make(chan resp.Value, 1)
+		go func() {
+			result <- testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "BLOCK", "0", "STREAMS", "s", ">"), s, false)
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		addSequentialEntries(t, s, "s", 1)
+
+		select {
+		case out := <-result:
+			requireStreamIds(t, groupReadEntries(t, out), "1-0")
+		case <-time.After(time.Second):
+			t.Fatal("XREADGROUP did not unblock")
+		}
+	})
+
+	t.Run("BLOCK times out", func(t *testing.T) {
+		s := store.NewStore()
+		testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "$", "MKSTREAM"), s, false)
+
+		out := testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "BLOCK", "20", "STREAMS", "s", ">"), s, false)
+		if arr, ok := out.(*resp.Array); !ok || !arr.Null {
+			t.Fatalf("expected null array after timeout, got %#v", out)
+		}
+	})
+}
+
+func TestHandleXpending(t *testing.T) {
+	s := store.NewStore()
+	addSequentialEntries(t, s, "s", 3)
+	testDispatch(newTestCommand(XGROUP_COMMAND, "CREATE", "s", "g", "0"), s, false)
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "alice", "COUNT", "2", "STREAMS", "s", ">"), s, false)
+	testDispatch(newTestCommand(XREADGROUP_COMMAND, "GROUP", "g", "bob", "STREAMS", "s", ">"), s, false)
+
+	out := testDispatch(newTestCommand(XPENDING_COMMAND, "s", "g"), s, false)
+	summary := out.(*resp.Array)
+	requireInteger(t, summary.Elements[0], 3)
+	requireBulkString(t, summary.Elements[1], "1-0")
+	requireBulkString(t, summary.Elements[2], "3-0")
+
+	consumers := summary.Elements[3].(*resp.Array)
+	if len(consumers.Elements) != 2 {
+		t.Fatalf("expected 2 consumers, got %d", len(consumers.Elements))
+	}
+	requireBulkString(t, consumers.Elements[0].(*resp.Array).Elements[1], "2")
+
+	out = testDispatch(newTestCommand(XPENDING_COMMAND, "s", "g", "-", "+", "10", "bob"), s, false)
+	entries := out.(*resp.Array)
+	if len(entries.Elements) != 1 {
+		t.Fatalf("expected 1 entry pending for bob, got %d", len(entries.Elements))
+	}
+
+	entry := entries.Elements[0].(*resp.Array)
+	requireBulkString(t, entry.Elements[0], "3-0")
+	requireBulkString(t, entry.Elements[1], "bob")
+	requireInteger(t, entry.Elements[3], 1)
+
+	out = testDispatch(newTestCommand(XPENDING_COMMAND, "s", "g", "IDLE", "60000", "-", "+", "10"), s, false)
+	if arr := out.(*resp.Array); len(arr.Elements) != 0 {
+		t.Fatalf("expected no entries idle for a minute, got %d", len(arr.Elements))
+	}
+
+	out = testDispatch(newTestCommand(XPENDING_COMMAND, "s", "missing"), s, false)
+	requireError(t, out, "NOGROUP No such key 's' or consumer group 'missing'")
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func handleXtrim(serverCtx *ServerContext, handlerCtx *HandlerContext) resp.Value {
+	argsLen := handlerCtx.Cmd.ArgsLen()
+
+	if argsLen < 3 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	key, ok := handlerCtx.Cmd.ArgString(0)
+	if !ok {
+		return &resp.Error{Msg: fmt.Sprintf("ERR invalid key value for %s command", handlerCtx.Cmd.Name)}
+	}
+
+	spec, next, errValue := parseStreamTrimArgs(handlerCtx.Cmd, 1)
+	if errValue != nil {
+		return errValue
+	}
+
+	if spec.Strategy == store.STREAM_TRIM_NONE || next != argsLen {
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	removed, err := serverCtx.Store.Xtrim(key, spec)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return &resp.Integer{Number: removed}
+}
+
+// parseStreamTrimArgs parses "MAXLEN|MINID [=|~] threshold [LIMIT count]"
+// starting at idx. It returns an empty spec and idx unchanged when the
+// argument at idx is not a trimming strategy.
+func parseStreamTrimArgs(cmd *Command, idx int) (store.StreamTrimSpec, int, *resp.Error) {
+	spec := store.StreamTrimSpec{}
+	argsLen := cmd.ArgsLen()
+
+	strategy, _ := cmd.ArgString(idx)
+	spec.Strategy = store.StreamTrimStrategy(strings.ToUpper(strategy))
+
+	if spec.Strategy != store.STREAM_TRIM_MAXLEN && spec.Strategy != store.STREAM_TRIM_MINID {
+		return store.StreamTrimSpec{}, idx, nil
+	}
+
+	idx++
+
+	if operator, _ := cmd.ArgString(idx); operator == "=" || operator == "~" {
+		spec.Approx = operator == "~"
+		idx++
+	}
+
+	threshold, ok := cmd.ArgString(idx)
+	if !ok {
+		return spec, idx, &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	if spec.Strategy == store.STREAM_TRIM_MAXLEN {
+		maxLen, err := strconv.ParseInt(threshold, 10, 64)
+		if err != nil {
+			return spec, idx, &resp.Error{Msg: "ERR value is not an integer or out of range"}
+		}
+
+		if maxLen < 0 {
+			return spec, idx, &resp.Error{Msg: "ERR The MAXLEN argument must be >= 0."}
+		}
+
+		spec.MaxLen = maxLen
+	} else {
+		minId, ok := parseStrictStreamId(threshold)
+		if !ok {
+			return spec, idx, &resp.Error{Msg: "ERR Invalid stream ID specified as stream command argument"}
+		}
+
+		spec.MinId = minId
+	}
+
+	idx++
+
+	if spec.Approx {
+		spec.Limit = store.DefaultStreamTrimLimit
+	}
+
+	if keyword, _ := cmd.ArgString(idx); idx < argsLen && strings.EqualFold(keyword, "LIMIT") {
+		if !spec.Approx {
+			return spec, idx, &resp.Error{Msg: "ERR syntax error, LIMIT cannot be used without the special ~ option"}
+		}
+
+		limit, ok := cmd.ArgInt(idx + 1)
+		if !ok {
+			return spec, idx, &resp.Error{Msg: "ERR value is not an integer or out of range"}
+		}
+
+		if limit < 0 {
+			return spec, idx, &resp.Error{Msg: "ERR The LIMIT argument must be >= 0."}
+		}
+
+		spec.Limit = int64(limit)
+		idx += 2
+	}
+
+	return spec, idx, nil
+}
+
+// parseStrictStreamId parses an explicit "ms" or "ms-seq" stream ID. A
+// missing sequence part defaults to 0.
+func parseStrictStreamId(id string) (store.StreamIdSpec, bool) {
+	before, after, found := strings.Cut(id, "-")
+
+	msTime, err := strconv.ParseUint(before, 10, 64)
+	if err != nil {
+		return store.StreamIdSpec{}, false
+	}
+
+	if !found {
+		return store.StreamIdSpec{MsTime: msTime}, true
+	}
+
+	seq, err := strconv.ParseUint(after, 10, 64)
+	if err != nil {
+		return store.StreamIdSpec{}, false
+	}
+
+	return store.StreamIdSpec{MsTime: msTime, Seq: seq}, true
+}
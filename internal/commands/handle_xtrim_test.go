@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
+)
+
+func requireStreamLen(t *testing.T, s *store.Store, key string, want int) {
+	t.Helper()
+
+	entries, err := s.Xrange(key, "-", "+", 0)
+	if err != nil {
+		t.Fatalf("unexpected XRANGE error: %v", err)
+	}
+
+	if len(entries) != want {
+		t.Fatalf("expected stream %s to have %d entries, got %d", key, want, len(entries))
+	}
+}
+
+func addSequentialEntries(t *testing.T, s *store.Store, key string, count int) {
+	t.Helper()
+
+	for i := 1; i <= count; i++ {
+		out := testDispatch(newTestCommand(XADD_COMMAND, key, fmt.Sprintf("%d-0", i), "f", "v"), s, false)
+		if _, ok := out.(*resp.BulkString); !ok {
+			t.Fatalf("expected BulkString from XADD, got %#v", out)
+		}
+	}
+}
+
+func TestHandleXaddTrimming(t *testing.T) {
+	t.Run("exact MAXLEN keeps the newest entries", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 5)
+
+		out := testDispatch(newTestCommand(XADD_COMMAND, "s", "MAXLEN", "=", "2", "6-0", "f", "v"), s, false)
+		if bs, ok := out.(*resp.BulkString); !ok || string(bs.Bytes) != "6-0" {
+			t.Fatalf("expected 6-0 from XADD, got %#v", out)
+		}
+
+		entries, _ := s.Xrange("s", "-", "+", 0)
+		if len(entries) != 2 || entries[0].Id.ToString() != "5-0" {
+			t.Fatalf("expected entries 5-0 and 6-0, got %+v", entries)
+		}
+	})
+
+	t.Run("MINID removes older entries", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 5)
+
+		testDispatch(newTestCommand(XADD_COMMAND, "s", "MINID", "4", "6-0", "f", "v"), s, false)
+		requireStreamLen(t, s, "s", 3)
+	})
+
+	t.Run("NOMKSTREAM does not create the stream", func(t *testing.T) {
+		s := store.NewStore()
+
+		out := testDispatch(newTestCommand(XADD_COMMAND, "s", "NOMKSTREAM", "*", "f", "v"), s, false)
+		if bs, ok := out.(*resp.BulkString); !ok || !bs.Null {
+			t.Fatalf("expected null from XADD NOMKSTREAM, got %#v", out)
+		}
+
+		if _, ok := s.GetStoreRawValue("s"); ok {
+			t.Fatal("expected stream to not be created")
+		}
+
+		addSequentialEntries(t, s, "s", 1)
+
+		out = testDispatch(newTestCommand(XADD_COMMAND, "s", "NOMKSTREAM", "MAXLEN", "1", "2-0", "f", "v"), s, false)
+		if bs, ok := out.(*resp.BulkString); !ok || string(bs.Bytes) != "2-0" {
+			t.Fatalf("expected 2-0 from XADD NOMKSTREAM, got %#v", out)
+		}
+
+		requireStreamLen(t, s, "s", 1)
+	})
+
+	t.Run("LIMIT requires approximate trimming", func(t *testing.T) {
+		out := testDispatch(newTestCommand(XADD_COMMAND, "s", "MAXLEN", "1", "LIMIT", "10", "*", "f", "v"), store.NewStore(), false)
+		if err, ok := out.(*resp.Error); !ok || err.Msg != "ERR syntax error, LIMIT cannot be used without the special ~ option" {
+			t.Fatalf("expected LIMIT error, got %#v", out)
+		}
+	})
+}
+
+func TestHandleXtrim(t *testing.T) {
+	t.Run("exact trimming", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 10)
+
+		requireInteger(t, testDispatch(newTestCommand(XTRIM_COMMAND, "s", "MAXLEN", "3"), s, false), 7)
+		requireStreamLen(t, s, "s", 3)
+
+		requireInteger(t, testDispatch(newTestCommand(XTRIM_COMMAND, "s", "MINID", "10-0"), s, false), 2)
+		requireStreamLen(t, s, "s", 1)
+	})
+
+	t.Run("approximate trimming removes whole nodes only", func(t *testing.T) {
+		s := store.NewStore()
+		addSequentialEntries(t, s, "s", 250)
+
+		requireInteger(t, testDispatch(newTestCommand(XTRIM_COMMAND, "s", "MAXLEN", "~", "100", "LIMIT", "50"), s, false), 0)
+		requireInteger(t, testDispatch(newTestCommand(XTRIM_COMMAND, "s", "MAXLEN", "~", "100"), s, false), 100)
+		requireStreamLen(t, s, "s", 150)
+
+		// the last node is only partially filled but still goes as a whole
+		requireInteger(t, testDispatch(newTestCommand(XTRIM_COMMAND, "s", "MAXLEN", "~", "0", "LIMIT", "0"), s, false), 150)
+		requireStreamLen(t, s, "s", 0)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		requireInteger(t, testDispatch(newTestCommand(XTRIM_COMMAND, "s", "MAXLEN", "0"), store.NewStore(), false), 0)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		s := store.NewStore()
+
+		for _, args := range [][]string{
+			{"s", "MAXLEN", "-1"},
+			{"s", "MINID", "abc"},
+			{"s", "SIZE", "1"},
+			{"s", "MAXLEN", "1", "extra"},
+		} {
+			if _, ok := testDispatch(newTestCommand(XTRIM_COMMAND, args...), s, false).(*resp.Error); !ok {
+				t.Fatalf("expected error for XTRIM %v", args)
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// Effects returns what replicates the command of handlerCtx once it ran and
+// replied with reply: the effects recorded by its handler when its outcome
+// depends on when it ran, or when it is a script which may fail after some
+// writes, otherwise nothing when it failed or does not write and the
+// command itself when it does.
+func Effects(handlerCtx *HandlerContext, reply resp.Value) []resp.Value {
+	if handlerCtx.Effects != nil {
+		return handlerCtx.Effects
+	}
+
+	if _, isErr := reply.(*resp.Error); isErr || !IsWriteCommand(handlerCtx.Cmd.Name) {
+		return nil
+	}
+
+	return []resp.Value{handlerCtx.Cmd.Array()}
+}
+
+// effectCommand builds a command replicated as an effect.
+func effectCommand(name Name, args ...string) *resp.Array {
+	arr := &resp.Array{Elements: make([]resp.Value, 0, len(args)+1)}
+	arr.Elements = append(arr.Elements, bulkString(string(name)))
+
+	for _, arg := range args {
+		arr.Elements = append(arr.Elements, bulkString(arg))
+	}
+
+	return arr
+}
+
+// PropagateTransaction replicates effects, the writes of a transaction or
+// of a script, wrapped in a MULTI/EXEC block so that replicas apply them
+// atomically. Nothing is sent when there are none.
+func PropagateTransaction(serverCtx *ServerContext, effects []resp.Value) {
+	if len(effects) == 0 {
+		return
+	}
+
+	multi := &resp.Array{Elements: []resp.Value{bulkString(string(MULTI_COMMAND))}}
+	exec := &resp.Array{Elements: []resp.Value{bulkString(string(EXEC_COMMAND))}}
+
+	propagate(serverCtx, append(append([]resp.Value{multi}, effects...), exec)...)
+}
+
+// propagate sends values to the replicas, when the server is a master.
+func propagate(serverCtx *ServerContext, values ...resp.Value) {
+	if serverCtx.IsReplica || serverCtx.ReplicasRegistry == nil {
+		return
+	}
+
+	for _, v := range values {
+		serverCtx.ReplicasRegistry.BroadcastRespValue(v)
+		serverCtx.MasterOffset += resp.Size(v)
+	}
+}
@@ -8,6 +8,56 @@ import (
 	"github.com/codecrafters-io/redis-starter-go/internal/store"
 )
 
+func newTestCommand(name Name, args ...string) *Command {
+	cmd := &Command{Name: name}
+
+	for _, arg := range args {
+		cmd.Args = append(cmd.Args, &resp.BulkString{Bytes: []byte(arg)})
+	}
+
+	return cmd
+}
+
+func requireInteger(t *testing.T, out resp.Value, want int64) {
+	t.Helper()
+
+	i, ok := out.(*resp.Integer)
+	if !ok {
+		t.Fatalf("expected resp.Integer %d, got %#v", want, out)
+	}
+
+	if i.Number != want {
+		t.Fatalf("expected %d, got %d", want, i.Number)
+	}
+}
+
+func requireBulkString(t *testing.T, out resp.Value, want string) {
+	t.Helper()
+
+	bs, ok := out.(*resp.BulkString)
+	if !ok || bs.Null || string(bs.Bytes) != want {
+		t.Fatalf("expected bulk string %q, got %#v", want, out)
+	}
+}
+
+func requireSimpleString(t *testing.T, out resp.Value, want string) {
+	t.Helper()
+
+	ss, ok := out.(*resp.SimpleString)
+	if !ok || string(ss.Bytes) != want {
+		t.Fatalf("expected simple string %q, got %#v", want, out)
+	}
+}
+
+func requireError(t *testing.T, out resp.Value, want string) {
+	t.Helper()
+
+	e, ok := out.(*resp.Error)
+	if !ok || e.Msg != want {
+		t.Fatalf("expected error %q, got %#v", want, out)
+	}
+}
+
 func createListWithValues(t *testing.T, s *store.Store, key string, values []string) {
 	t.Helper()
 
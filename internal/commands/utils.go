@@ -92,11 +92,11 @@ func valueAsFloat(v resp.Value) (value float64, ok bool) {
 	}
 }
 
-func populateRespArrayFromStream(stream store.Stream) *resp.Array {
+func populateRespArrayFromStream(elements []store.StreamElement) *resp.Array {
 	arr := &resp.Array{}
 
-	for _, el := range stream.Elements {
-		fields := &resp.Array{}
+	for _, el := range elements {
+		fields := &resp.Array{Null: el.Deleted}
 
 		for _, field := range el.Fields {
 			for i := range 2 {
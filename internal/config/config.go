@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/glob"
+)
+
+// Param is a runtime configuration parameter. Set validates and applies a
+// new value, Get returns the current one formatted for CONFIG GET.
+type Param struct {
+	Get func() string
+	Set func(value string) error
+}
+
+// Config is the registry of the parameters exposed through CONFIG GET and
+// CONFIG SET. The components owning a setting register it on startup.
+type Config struct {
+	params map[string]Param
+	sync.RWMutex
+}
+
+func NewConfig() *Config {
+	return &Config{
+		params: make(map[string]Param),
+	}
+}
+
+// Register adds the parameter name, names are case insensitive.
+func (c *Config) Register(name string, p Param) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.params[strings.ToLower(name)] = p
+}
+
+// Get returns name/value pairs for every parameter matching the glob-style
+// pattern, sorted by name.
+func (c *Config) Get(pattern string) [][2]string {
+	c.RLock()
+	defer c.RUnlock()
+
+	pattern = strings.ToLower(pattern)
+	pairs := [][2]string{}
+
+	for name, p := range c.params {
+		if glob.Match(pattern, name) {
+			pairs = append(pairs, [2]string{name, p.Get()})
+		}
+	}
+
+	slices.SortFunc(pairs, func(a, b [2]string) int {
+		return strings.Compare(a[0], b[0])
+	})
+
+	return pairs
+}
+
+// Set applies value to the parameter name. The error messages are the ones
+// CONFIG SET replies with.
+func (c *Config) Set(name, value string) error {
+	c.RLock()
+	p, ok := c.params[strings.ToLower(name)]
+	c.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("ERR Unknown option or number of arguments for CONFIG SET - '%s'", name)
+	}
+
+	if err := p.Set(value); err != nil {
+		return fmt.Errorf("ERR CONFIG SET failed (possibly related to argument '%s') - %s", name, err.Error())
+	}
+
+	return nil
+}
+
+// ParseMemory parses a memory value the way Redis does: a number of bytes
+// optionally followed by a unit, k, m and g being powers of 1000 and kb, mb
+// and gb powers of 1024.
+func ParseMemory(value string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"kb", 1 << 10},
+		{"mb", 1 << 20},
+		{"gb", 1 << 30},
+		{"k", 1000},
+		{"m", 1000 * 1000},
+		{"g", 1000 * 1000 * 1000},
+		{"b", 1},
+	}
+
+	number, multiplier := strings.ToLower(value), int64(1)
+
+	for _, unit := range units {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, multiplier = strings.TrimSuffix(number, unit.suffix), unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("argument must be a memory value")
+	}
+
+	return n * multiplier, nil
+}
@@ -0,0 +1,122 @@
+package glob
+
+// Match reports whether s matches the glob-style pattern the same way Redis
+// matches channel patterns and CONFIG GET parameters. A star matches any
+// sequence of characters, a question mark any single character, brackets
+// one of the listed characters with support for ranges like [a-z] and
+// negation like [^abc], and a backslash escapes the following character.
+func Match(pattern, s string) bool {
+	return match(pattern, s, 0)
+}
+
+// maxMatchNesting limits the recursion on patterns with many stars, Redis
+// gives up on such patterns too.
+const maxMatchNesting = 1000
+
+func match(pattern, s string, nesting int) bool {
+	if nesting > maxMatchNesting {
+		return false
+	}
+
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for i := 0; i <= len(s); i++ {
+				if match(pattern[1:], s[i:], nesting+1) {
+					return true
+				}
+			}
+
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+
+			s = s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+
+			var ok bool
+			pattern, ok = matchClass(pattern[1:], s[0])
+			if !ok {
+				return false
+			}
+
+			s = s[1:]
+			// matchClass leaves pattern on the closing bracket
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+
+			s = s[1:]
+		}
+
+		pattern = pattern[1:]
+	}
+
+	return len(s) == 0
+}
+
+// matchClass matches c against the character class starting right after
+// the opening bracket. It returns the pattern positioned on the closing
+// bracket, or on its last character when the class is not terminated.
+func matchClass(pattern string, c byte) (string, bool) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+
+	for {
+		if len(pattern) == 0 {
+			// an unterminated class, Redis treats its end as the closing
+			// bracket
+			return "]", matched != negate
+		}
+
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			pattern = pattern[1:]
+			if pattern[0] == c {
+				matched = true
+			}
+		case pattern[0] == ']':
+			return pattern, matched != negate
+		case len(pattern) >= 3 && pattern[1] == '-':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+
+			pattern = pattern[2:]
+			if c >= start && c <= end {
+				matched = true
+			}
+		default:
+			if pattern[0] == c {
+				matched = true
+			}
+		}
+
+		pattern = pattern[1:]
+	}
+}
@@ -0,0 +1,34 @@
+package hyperloglog
+
+// Dense registers are 6 bits wide and packed starting from the least
+// significant bit of each byte, exactly as Redis lays them out.
+
+func denseGet(data []byte, idx int) uint8 {
+	byteIdx := idx * registerBits / 8
+	fb := uint(idx*registerBits) & 7
+	fb8 := 8 - fb
+
+	b0 := uint(data[byteIdx])
+	b1 := uint(0)
+
+	if byteIdx+1 < len(data) {
+		b1 = uint(data[byteIdx+1])
+	}
+
+	return uint8(((b0 >> fb) | (b1 << fb8)) & registerMax)
+}
+
+func denseSet(data []byte, idx int, value uint8) {
+	byteIdx := idx * registerBits / 8
+	fb := uint(idx*registerBits) & 7
+	fb8 := 8 - fb
+	v := uint(value)
+
+	data[byteIdx] &^= byte(registerMax << fb)
+	data[byteIdx] |= byte(v << fb)
+
+	if byteIdx+1 < len(data) {
+		data[byteIdx+1] &^= byte(registerMax >> fb8)
+		data[byteIdx+1] |= byte(v >> fb8)
+	}
+}
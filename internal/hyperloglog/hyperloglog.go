@@ -0,0 +1,281 @@
+// Package hyperloglog implements the HyperLogLog representation used by
+// Redis. Values are stored as plain strings starting with the "HYLL" header,
+// so they can be moved around with GET/SET and exchanged with Redis itself.
+package hyperloglog
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+const (
+	p             = 14
+	Registers     = 1 << p
+	pMask         = Registers - 1
+	q             = 64 - p
+	registerBits  = 6
+	registerMax   = 1<<registerBits - 1
+	headerSize    = 16
+	denseDataSize = (Registers*registerBits + 7) / 8
+	denseSize     = headerSize + denseDataSize
+	alphaInf      = 0.721347520444481703680
+
+	encodingDense  = 0
+	encodingSparse = 1
+
+	// SparseMaxBytes mirrors the default hll-sparse-max-bytes setting. Sparse
+	// values growing past it are promoted to the dense encoding.
+	SparseMaxBytes = 3000
+
+	sparseValMaxValue = 32
+	sparseValMaxLen   = 4
+	sparseZeroMaxLen  = 64
+	sparseXZeroMaxLen = 16384
+)
+
+var magic = []byte("HYLL")
+
+var (
+	ErrInvalid   = errors.New("WRONGTYPE Key is not a valid HyperLogLog string value.")
+	ErrCorrupted = errors.New("INVALIDOBJ Corrupted HLL object detected")
+)
+
+// New returns an empty HyperLogLog using the sparse encoding. Its cached
+// cardinality is a valid zero.
+func New() []byte {
+	hll := FromRegisters(make([]uint8, Registers), false)
+	clear(hll[8:16])
+
+	return hll
+}
+
+// Validate checks that b looks like a HyperLogLog header of either encoding.
+func Validate(b []byte) error {
+	if len(b) < headerSize || string(b[:4]) != string(magic) {
+		return ErrInvalid
+	}
+
+	switch b[4] {
+	case encodingDense:
+		if len(b) != denseSize {
+			return ErrInvalid
+		}
+	case encodingSparse:
+	default:
+		return ErrInvalid
+	}
+
+	return nil
+}
+
+// IsDense reports whether a valid HyperLogLog uses the dense encoding.
+func IsDense(b []byte) bool {
+	return b[4] == encodingDense
+}
+
+// Add hashes every element into hll. It returns the (possibly reallocated)
+// value and whether any register changed. hll must have passed Validate.
+func Add(hll []byte, elements [][]byte) ([]byte, bool, error) {
+	if IsDense(hll) {
+		changed := false
+
+		for _, el := range elements {
+			idx, count := patLen(el)
+
+			if denseGet(hll[headerSize:], idx) < count {
+				denseSet(hll[headerSize:], idx, count)
+				changed = true
+			}
+		}
+
+		if changed {
+			invalidateCache(hll)
+		}
+
+		return hll, changed, nil
+	}
+
+	regs, err := sparseRegisters(hll[headerSize:])
+	if err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+
+	for _, el := range elements {
+		idx, count := patLen(el)
+
+		if regs[idx] < count {
+			regs[idx] = count
+			changed = true
+		}
+	}
+
+	if !changed {
+		return hll, false, nil
+	}
+
+	return FromRegisters(regs, false), true, nil
+}
+
+// Count returns the estimated cardinality of hll. The cached cardinality in
+// the header is used when valid; otherwise it is recomputed and written back,
+// in which case updated is true.
+func Count(hll []byte) (card uint64, updated bool, err error) {
+	if cacheValid(hll) {
+		return binary.LittleEndian.Uint64(hll[8:16]), false, nil
+	}
+
+	regs, err := RegistersOf(hll)
+	if err != nil {
+		return 0, false, err
+	}
+
+	card = CountRegisters(regs)
+	binary.LittleEndian.PutUint64(hll[8:16], card)
+
+	return card, true, nil
+}
+
+// RegistersOf decodes hll into one byte per register.
+func RegistersOf(hll []byte) ([]uint8, error) {
+	if IsDense(hll) {
+		regs := make([]uint8, Registers)
+
+		for i := range Registers {
+			regs[i] = denseGet(hll[headerSize:], i)
+		}
+
+		return regs, nil
+	}
+
+	return sparseRegisters(hll[headerSize:])
+}
+
+// Merge stores in dst the maximum of every register of dst and hll.
+func Merge(dst []uint8, hll []byte) error {
+	regs, err := RegistersOf(hll)
+	if err != nil {
+		return err
+	}
+
+	for i, r := range regs {
+		dst[i] = max(dst[i], r)
+	}
+
+	return nil
+}
+
+// FromRegisters encodes regs as a HyperLogLog with an invalidated cache. The
+// sparse encoding is used unless dense is set or the registers cannot be
+// represented within SparseMaxBytes.
+func FromRegisters(regs []uint8, dense bool) []byte {
+	if !dense {
+		if sparse, ok := encodeSparse(regs); ok {
+			return sparse
+		}
+	}
+
+	out := make([]byte, denseSize)
+	writeHeader(out, encodingDense)
+
+	for i, r := range regs {
+		denseSet(out[headerSize:], i, r)
+	}
+
+	return out
+}
+
+// CountRegisters estimates the cardinality using the improved estimator by
+// Otmar Ertl, the same one used by Redis.
+func CountRegisters(regs []uint8) uint64 {
+	m := float64(Registers)
+	histogram := make([]int, 64)
+
+	for _, r := range regs {
+		histogram[r]++
+	}
+
+	z := m * tau((m-float64(histogram[q+1]))/m)
+
+	for j := q; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+
+	z += m * sigma(float64(histogram[0])/m)
+
+	return uint64(math.Round(alphaInf * m * m / z))
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+
+	y := 1.0
+	z := 1 - x
+
+	for {
+		x = math.Sqrt(x)
+		zPrime := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+
+		if zPrime == z {
+			break
+		}
+	}
+
+	return z / 3
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+
+	y := 1.0
+	z := x
+
+	for {
+		x *= x
+		zPrime := z
+		z += x * y
+		y += y
+
+		if zPrime == z {
+			break
+		}
+	}
+
+	return z
+}
+
+// patLen returns the register index for element and the length of the
+// 000..1 pattern that follows it.
+func patLen(element []byte) (int, uint8) {
+	hash := murmurHash64A(element, 0xadc83b19)
+	idx := int(hash & pMask)
+
+	hash >>= p
+	hash |= 1 << q
+
+	return idx, uint8(bits.TrailingZeros64(hash) + 1)
+}
+
+func writeHeader(b []byte, encoding byte) {
+	copy(b, magic)
+	b[4] = encoding
+	invalidateCache(b)
+}
+
+func cacheValid(hll []byte) bool {
+	return hll[15]&(1<<7) == 0
+}
+
+func invalidateCache(hll []byte) {
+	hll[15] |= 1 << 7
+}
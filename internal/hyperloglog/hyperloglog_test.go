@@ -0,0 +1,120 @@
+package hyperloglog
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestNewIsEmptySparse(t *testing.T) {
+	hll := New()
+
+	want := append([]byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"), 0x7f, 0xff)
+	if !bytes.Equal(hll, want) {
+		t.Fatalf("unexpected empty HLL encoding %q", hll)
+	}
+
+	card, updated, err := Count(hll)
+	if err != nil || card != 0 || updated {
+		t.Fatalf("expected cached zero cardinality, got %d %v %v", card, updated, err)
+	}
+}
+
+func TestAddAndCountWithinStandardError(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		hll := New()
+		elements := make([][]byte, 0, n)
+
+		for i := range n {
+			elements = append(elements, fmt.Appendf(nil, "element:%d", i))
+		}
+
+		hll, changed, err := Add(hll, elements)
+		if err != nil || !changed {
+			t.Fatalf("Add returned changed=%v err=%v", changed, err)
+		}
+
+		card, updated, err := Count(hll)
+		if err != nil || !updated {
+			t.Fatalf("Count returned updated=%v err=%v", updated, err)
+		}
+
+		// 0.81% standard error, allow for a few deviations
+		if diff := math.Abs(float64(card)-float64(n)) / float64(n); diff > 0.03 {
+			t.Fatalf("estimate %d for %d elements is off by %.2f%%", card, n, diff*100)
+		}
+
+		cached, updated, _ := Count(hll)
+		if updated || cached != card {
+			t.Fatalf("expected cached cardinality %d, got %d (updated=%v)", card, cached, updated)
+		}
+	}
+}
+
+func TestSparsePromotedToDense(t *testing.T) {
+	hll := New()
+
+	for i := range 5000 {
+		var err error
+		hll, _, err = Add(hll, [][]byte{fmt.Appendf(nil, "%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !IsDense(hll) || len(hll) != denseSize {
+		t.Fatalf("expected dense encoding of %d bytes, got encoding %d and %d bytes", denseSize, hll[4], len(hll))
+	}
+
+	if err := Validate(hll); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistersRoundTrip(t *testing.T) {
+	regs := make([]uint8, Registers)
+	regs[0] = 1
+	regs[100] = 32
+	regs[101] = 32
+	regs[16383] = 5
+
+	for _, dense := range []bool{false, true} {
+		hll := FromRegisters(regs, dense)
+
+		if err := Validate(hll); err != nil {
+			t.Fatal(err)
+		}
+
+		if IsDense(hll) != dense {
+			t.Fatalf("expected dense=%v", dense)
+		}
+
+		got, err := RegistersOf(hll)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, regs) {
+			t.Fatalf("registers did not round trip with dense=%v", dense)
+		}
+	}
+
+	regs[7] = 33
+	if hll := FromRegisters(regs, false); !IsDense(hll) {
+		t.Fatal("expected values above 32 to force the dense encoding")
+	}
+}
+
+func TestCorruptedSparse(t *testing.T) {
+	hll := New()
+	hll = hll[:len(hll)-1]
+
+	if _, err := RegistersOf(hll); err != ErrCorrupted {
+		t.Fatalf("expected ErrCorrupted, got %v", err)
+	}
+
+	if err := Validate([]byte("not an hll")); err != ErrInvalid {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package hyperloglog
+
+// The sparse encoding is a sequence of three opcodes:
+//
+//	ZERO  00xxxxxx           run of xxxxxx+1 empty registers (1-64)
+//	XZERO 01xxxxxx yyyyyyyy  run of xxxxxxyyyyyyyy+1 empty registers (1-16384)
+//	VAL   1vvvvvxx           run of xx+1 registers set to vvvvv+1 (1-32)
+
+func sparseRegisters(data []byte) ([]uint8, error) {
+	regs := make([]uint8, Registers)
+	idx := 0
+
+	for i := 0; i < len(data); {
+		op := data[i]
+		runLen := 0
+		value := uint8(0)
+
+		switch {
+		case op&0xc0 == 0x00:
+			runLen = int(op&0x3f) + 1
+			i++
+		case op&0xc0 == 0x40:
+			if i+1 >= len(data) {
+				return nil, ErrCorrupted
+			}
+
+			runLen = (int(op&0x3f)<<8 | int(data[i+1])) + 1
+			i += 2
+		default:
+			runLen = int(op&0x3) + 1
+			value = (op>>2)&0x1f + 1
+			i++
+		}
+
+		if idx+runLen > Registers {
+			return nil, ErrCorrupted
+		}
+
+		for j := range runLen {
+			regs[idx+j] = value
+		}
+
+		idx += runLen
+	}
+
+	if idx != Registers {
+		return nil, ErrCorrupted
+	}
+
+	return regs, nil
+}
+
+// encodeSparse encodes regs using the sparse representation. It fails when
+// a register does not fit a VAL opcode or the result exceeds SparseMaxBytes.
+func encodeSparse(regs []uint8) ([]byte, bool) {
+	out := make([]byte, headerSize, headerSize+8)
+	writeHeader(out, encodingSparse)
+
+	for i := 0; i < len(regs); {
+		value := regs[i]
+		runLen := 1
+
+		for i+runLen < len(regs) && regs[i+runLen] == value {
+			runLen++
+		}
+
+		i += runLen
+
+		if value > sparseValMaxValue {
+			return nil, false
+		}
+
+		for runLen > 0 {
+			switch {
+			case value != 0:
+				n := min(runLen, sparseValMaxLen)
+				out = append(out, 0x80|(value-1)<<2|byte(n-1))
+				runLen -= n
+			case runLen > sparseZeroMaxLen:
+				n := min(runLen, sparseXZeroMaxLen)
+				out = append(out, 0x40|byte((n-1)>>8), byte(n-1))
+				runLen -= n
+			default:
+				out = append(out, byte(runLen-1))
+				runLen = 0
+			}
+		}
+
+		if len(out)-headerSize > SparseMaxBytes {
+			return nil, false
+		}
+	}
+
+	return out, true
+}
@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/pubsub"
+)
+
+// Class is a set of keyspace event classes, as configured with the
+// notify-keyspace-events flags.
+type Class int
+
+const (
+	Keyspace Class = 1 << iota // K, __keyspace@<db>__ channels
+	Keyevent                   // E, __keyevent@<db>__ channels
+	Generic                    // g, type independent commands like DEL
+	String                     // $
+	List                       // l
+	Set                        // s
+	Hash                       // h
+	Zset                       // z
+	Expired                    // x, keys removed because they expired
+	Evicted                    // e, keys evicted for maxmemory
+	Stream                     // t
+	KeyMiss                    // m, lookups of missing keys
+	New                        // n, keys created
+)
+
+// All is the A flag, every class but KeyMiss and New.
+const All = Generic | String | List | Set | Hash | Zset | Expired | Evicted | Stream
+
+// db is the only database the server has.
+const db = "0"
+
+var errInvalidFlag = errors.New("Invalid event class character. Use 'Ag$lshzxeKEtmn'.")
+
+var flagClasses = []struct {
+	flag  byte
+	class Class
+}{
+	{'g', Generic},
+	{'$', String},
+	{'l', List},
+	{'s', Set},
+	{'h', Hash},
+	{'z', Zset},
+	{'x', Expired},
+	{'e', Evicted},
+	{'t', Stream},
+	{'K', Keyspace},
+	{'E', Keyevent},
+	{'m', KeyMiss},
+	{'n', New},
+}
+
+// ParseFlags converts a notify-keyspace-events string to classes.
+func ParseFlags(flags string) (Class, error) {
+	var classes Class
+
+outer:
+	for i := 0; i < len(flags); i++ {
+		if flags[i] == 'A' {
+			classes |= All
+			continue
+		}
+
+		for _, fc := range flagClasses {
+			if fc.flag == flags[i] {
+				classes |= fc.class
+				continue outer
+			}
+		}
+
+		return 0, errInvalidFlag
+	}
+
+	return classes, nil
+}
+
+// String formats classes the way CONFIG GET notify-keyspace-events reports
+// them, with A standing for all the type classes.
+func (c Class) String() string {
+	var sb strings.Builder
+
+	all := c&All == All
+	if all {
+		sb.WriteByte('A')
+	}
+
+	for _, fc := range flagClasses {
+		if all && fc.class&All != 0 {
+			continue
+		}
+
+		if c&fc.class != 0 {
+			sb.WriteByte(fc.flag)
+		}
+	}
+
+	return sb.String()
+}
+
+// Notifier publishes keyspace events raised by the store on the
+// __keyspace@0__:<key> and __keyevent@0__:<event> channels, filtered by the
+// configured classes. No event is published until classes are set.
+type Notifier struct {
+	pubSub  *pubsub.PubSub
+	classes atomic.Int64
+}
+
+func NewNotifier(pubSub *pubsub.PubSub) *Notifier {
+	return &Notifier{pubSub: pubSub}
+}
+
+func (n *Notifier) Classes() Class {
+	return Class(n.classes.Load())
+}
+
+func (n *Notifier) SetClasses(classes Class) {
+	n.classes.Store(int64(classes))
+}
+
+// Notify publishes event for key when class is enabled.
+func (n *Notifier) Notify(class Class, event, key string) {
+	classes := n.Classes()
+
+	if classes&class == 0 {
+		return
+	}
+
+	if classes&Keyspace != 0 {
+		n.pubSub.Publish("__keyspace@"+db+"__:"+key, []byte(event))
+	}
+
+	if classes&Keyevent != 0 {
+		n.pubSub.Publish("__keyevent@"+db+"__:"+event, []byte(key))
+	}
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+type recordingSubscriber struct {
+	messages []*resp.Push
+}
+
+func (r *recordingSubscriber) Push(v resp.Value) {
+	r.messages = append(r.messages, v.(*resp.Push))
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		flags string
+		want  Class
+		str   string
+	}{
+		{"", 0, ""},
+		{"KEA", Keyspace | Keyevent | All, "AKE"},
+		{"Ex", Keyevent | Expired, "xE"},
+		{"K$lg", Keyspace | String | List | Generic, "g$lK"},
+		{"AKEmn", All | Keyspace | Keyevent | KeyMiss | New, "AKEmn"},
+		{"g$lshzxetKE", All | Keyspace | Keyevent, "AKE"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFlags(tt.flags)
+		if err != nil {
+			t.Fatalf("ParseFlags(%q): %v", tt.flags, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseFlags(%q) = %b, want %b", tt.flags, got, tt.want)
+		}
+
+		if got.String() != tt.str {
+			t.Errorf("ParseFlags(%q).String() = %q, want %q", tt.flags, got.String(), tt.str)
+		}
+	}
+
+	if _, err := ParseFlags("KEQ"); err == nil {
+		t.Fatal("expected an error for an unknown flag")
+	}
+}
+
+func TestNotifierPublishesEnabledClasses(t *testing.T) {
+	ps := pubsub.NewPubSub()
+	sub := &recordingSubscriber{}
+	ps.PSubscribe(sub, "__key*__:*")
+
+	n := NewNotifier(ps)
+	n.Notify(String, "set", "foo")
+
+	if len(sub.messages) != 0 {
+		t.Fatalf("expected no events before classes are set, got %d", len(sub.messages))
+	}
+
+	n.SetClasses(Keyspace | Keyevent | String)
+	n.Notify(String, "set", "foo")
+	n.Notify(List, "lpush", "bar")
+
+	if len(sub.messages) != 2 {
+		t.Fatalf("expected a keyspace and a keyevent message, got %d", len(sub.messages))
+	}
+
+	requireMessage(t, sub.messages[0], "__keyspace@0__:foo", "set")
+	requireMessage(t, sub.messages[1], "__keyevent@0__:set", "foo")
+}
+
+func requireMessage(t *testing.T, msg *resp.Push, channel, payload string) {
+	t.Helper()
+
+	// pmessage, pattern, channel, payload
+	if got := msg.Elements[2].String(); got != channel {
+		t.Fatalf("expected channel %q, got %q", channel, got)
+	}
+
+	if got := msg.Elements[3].String(); got != payload {
+		t.Fatalf("expected payload %q, got %q", payload, got)
+	}
+}
@@ -0,0 +1,470 @@
+package pubsub
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/cluster"
+	"github.com/codecrafters-io/redis-starter-go/internal/glob"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// Subscriber receives the messages published to its channels and patterns.
+// Push is called with the PubSub lock held, so it must not block and must
+// not call back into PubSub.
+type Subscriber interface {
+	Push(v resp.Value)
+}
+
+type subscriberSet map[Subscriber]struct{}
+
+type subscriptions struct {
+	channels      map[string]struct{}
+	patterns      map[string]struct{}
+	shardChannels map[string]struct{}
+}
+
+// count returns the number of channels and patterns, shard channels are
+// counted separately like Redis does in the subscription replies.
+func (s *subscriptions) count() int {
+	return len(s.channels) + len(s.patterns)
+}
+
+func (s *subscriptions) shardCount() int {
+	return len(s.shardChannels)
+}
+
+// PubSub keeps track of channel, pattern and shard channel subscriptions and
+// delivers published messages to the subscribers. Shard channels are
+// indexed by their hash slot, so the channels of a slot can be found without
+// scanning the others.
+type PubSub struct {
+	channels      map[string]subscriberSet
+	patterns      map[string]subscriberSet
+	shardChannels map[int]map[string]subscriberSet
+	subscriptions map[Subscriber]*subscriptions
+	sync.RWMutex
+}
+
+func NewPubSub() *PubSub {
+	return &PubSub{
+		channels:      make(map[string]subscriberSet),
+		patterns:      make(map[string]subscriberSet),
+		shardChannels: make(map[int]map[string]subscriberSet),
+		subscriptions: make(map[Subscriber]*subscriptions),
+	}
+}
+
+func (p *PubSub) subscriptionsOf(sub Subscriber) *subscriptions {
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		subs = &subscriptions{
+			channels:      make(map[string]struct{}),
+			patterns:      make(map[string]struct{}),
+			shardChannels: make(map[string]struct{}),
+		}
+		p.subscriptions[sub] = subs
+	}
+
+	return subs
+}
+
+func (p *PubSub) forget(sub Subscriber, subs *subscriptions) {
+	if subs.count() == 0 && subs.shardCount() == 0 {
+		delete(p.subscriptions, sub)
+	}
+}
+
+func add(index map[string]subscriberSet, name string, sub Subscriber) {
+	set, ok := index[name]
+	if !ok {
+		set = make(subscriberSet)
+		index[name] = set
+	}
+
+	set[sub] = struct{}{}
+}
+
+func remove(index map[string]subscriberSet, name string, sub Subscriber) {
+	set := index[name]
+	delete(set, sub)
+
+	if len(set) == 0 {
+		delete(index, name)
+	}
+}
+
+// Subscribe subscribes sub to channel and returns the number of channels and
+// patterns sub is subscribed to afterwards.
+func (p *PubSub) Subscribe(sub Subscriber, channel string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	subs := p.subscriptionsOf(sub)
+	subs.channels[channel] = struct{}{}
+	add(p.channels, channel, sub)
+
+	return subs.count()
+}
+
+// Unsubscribe removes the subscription of sub to channel, if any, and
+// returns the number of subscriptions left.
+func (p *PubSub) Unsubscribe(sub Subscriber, channel string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return 0
+	}
+
+	if _, ok := subs.channels[channel]; ok {
+		delete(subs.channels, channel)
+		remove(p.channels, channel, sub)
+	}
+
+	p.forget(sub, subs)
+
+	return subs.count()
+}
+
+// PSubscribe subscribes sub to every channel matching pattern and returns
+// the number of subscriptions of sub afterwards.
+func (p *PubSub) PSubscribe(sub Subscriber, pattern string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	subs := p.subscriptionsOf(sub)
+	subs.patterns[pattern] = struct{}{}
+	add(p.patterns, pattern, sub)
+
+	return subs.count()
+}
+
+// PUnsubscribe removes the subscription of sub to pattern, if any, and
+// returns the number of subscriptions left.
+func (p *PubSub) PUnsubscribe(sub Subscriber, pattern string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return 0
+	}
+
+	if _, ok := subs.patterns[pattern]; ok {
+		delete(subs.patterns, pattern)
+		remove(p.patterns, pattern, sub)
+	}
+
+	p.forget(sub, subs)
+
+	return subs.count()
+}
+
+// SSubscribe subscribes sub to the shard channel and returns the number of
+// shard channels sub is subscribed to afterwards.
+func (p *PubSub) SSubscribe(sub Subscriber, channel string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	subs := p.subscriptionsOf(sub)
+	subs.shardChannels[channel] = struct{}{}
+
+	slot := cluster.KeySlot(channel)
+	index, ok := p.shardChannels[slot]
+	if !ok {
+		index = make(map[string]subscriberSet)
+		p.shardChannels[slot] = index
+	}
+
+	add(index, channel, sub)
+
+	return subs.shardCount()
+}
+
+// SUnsubscribe removes the subscription of sub to the shard channel, if
+// any, and returns the number of shard channels left.
+func (p *PubSub) SUnsubscribe(sub Subscriber, channel string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return 0
+	}
+
+	if _, ok := subs.shardChannels[channel]; ok {
+		delete(subs.shardChannels, channel)
+		p.removeShard(channel, sub)
+	}
+
+	p.forget(sub, subs)
+
+	return subs.shardCount()
+}
+
+func (p *PubSub) removeShard(channel string, sub Subscriber) {
+	slot := cluster.KeySlot(channel)
+	index := p.shardChannels[slot]
+	remove(index, channel, sub)
+
+	if len(index) == 0 {
+		delete(p.shardChannels, slot)
+	}
+}
+
+// Channels returns the channels sub is subscribed to, sorted.
+func (p *PubSub) Channels(sub Subscriber) []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return nil
+	}
+
+	return sortedKeys(subs.channels)
+}
+
+// Patterns returns the patterns sub is subscribed to, sorted.
+func (p *PubSub) Patterns(sub Subscriber) []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return nil
+	}
+
+	return sortedKeys(subs.patterns)
+}
+
+// ShardChannels returns the shard channels sub is subscribed to, sorted.
+func (p *PubSub) ShardChannels(sub Subscriber) []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return nil
+	}
+
+	return sortedKeys(subs.shardChannels)
+}
+
+// Count returns the number of channels and patterns sub is subscribed to.
+func (p *PubSub) Count(sub Subscriber) int {
+	p.RLock()
+	defer p.RUnlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return 0
+	}
+
+	return subs.count()
+}
+
+// ShardCount returns the number of shard channels sub is subscribed to.
+func (p *PubSub) ShardCount(sub Subscriber) int {
+	p.RLock()
+	defer p.RUnlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return 0
+	}
+
+	return subs.shardCount()
+}
+
+// IsSubscribed reports whether sub has any subscription left, of any kind.
+func (p *PubSub) IsSubscribed(sub Subscriber) bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	_, ok := p.subscriptions[sub]
+
+	return ok
+}
+
+// UnsubscribeAll drops every subscription of sub, it is used when a client
+// disconnects.
+func (p *PubSub) UnsubscribeAll(sub Subscriber) {
+	p.Lock()
+	defer p.Unlock()
+
+	subs, ok := p.subscriptions[sub]
+	if !ok {
+		return
+	}
+
+	for channel := range subs.channels {
+		remove(p.channels, channel, sub)
+	}
+
+	for pattern := range subs.patterns {
+		remove(p.patterns, pattern, sub)
+	}
+
+	for channel := range subs.shardChannels {
+		p.removeShard(channel, sub)
+	}
+
+	delete(p.subscriptions, sub)
+}
+
+// Publish delivers message to the subscribers of channel and to the
+// subscribers of every pattern matching it. It returns the number of
+// deliveries, a client subscribed through several patterns is counted once
+// per pattern.
+func (p *PubSub) Publish(channel string, message []byte) int {
+	p.RLock()
+	defer p.RUnlock()
+
+	receivers := 0
+
+	for sub := range p.channels[channel] {
+		sub.Push(newMessage(channel, message))
+		receivers++
+	}
+
+	for pattern, set := range p.patterns {
+		if !glob.Match(pattern, channel) {
+			continue
+		}
+
+		for sub := range set {
+			sub.Push(newPatternMessage(pattern, channel, message))
+			receivers++
+		}
+	}
+
+	return receivers
+}
+
+// SPublish delivers message to the subscribers of the shard channel and
+// returns their number. Patterns never match shard channels.
+func (p *PubSub) SPublish(channel string, message []byte) int {
+	p.RLock()
+	defer p.RUnlock()
+
+	receivers := 0
+
+	for sub := range p.shardChannels[cluster.KeySlot(channel)][channel] {
+		sub.Push(newShardMessage(channel, message))
+		receivers++
+	}
+
+	return receivers
+}
+
+// ActiveChannels returns the channels with at least one subscriber, sorted.
+// When pattern is not empty only the channels matching it are returned.
+// Pattern subscriptions are not taken into account.
+func (p *PubSub) ActiveChannels(pattern string) []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	channels := []string{}
+
+	for channel := range p.channels {
+		if pattern == "" || glob.Match(pattern, channel) {
+			channels = append(channels, channel)
+		}
+	}
+
+	slices.Sort(channels)
+
+	return channels
+}
+
+// NumSub returns the number of subscribers of channel, pattern
+// subscriptions are not counted.
+func (p *PubSub) NumSub(channel string) int {
+	p.RLock()
+	defer p.RUnlock()
+
+	return len(p.channels[channel])
+}
+
+// ActiveShardChannels returns the shard channels with at least one
+// subscriber, sorted. When pattern is not empty only the channels matching
+// it are returned.
+func (p *PubSub) ActiveShardChannels(pattern string) []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	channels := []string{}
+
+	for _, index := range p.shardChannels {
+		for channel := range index {
+			if pattern == "" || glob.Match(pattern, channel) {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	slices.Sort(channels)
+
+	return channels
+}
+
+// ShardNumSub returns the number of subscribers of the shard channel.
+func (p *PubSub) ShardNumSub(channel string) int {
+	p.RLock()
+	defer p.RUnlock()
+
+	return len(p.shardChannels[cluster.KeySlot(channel)][channel])
+}
+
+// NumPat returns the number of unique patterns subscribed to by all clients.
+func (p *PubSub) NumPat() int {
+	p.RLock()
+	defer p.RUnlock()
+
+	return len(p.patterns)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+func newMessage(channel string, message []byte) *resp.Push {
+	return &resp.Push{
+		Elements: []resp.Value{
+			&resp.BulkString{Bytes: []byte("message")},
+			&resp.BulkString{Bytes: []byte(channel)},
+			&resp.BulkString{Bytes: message},
+		},
+	}
+}
+
+func newPatternMessage(pattern, channel string, message []byte) *resp.Push {
+	return &resp.Push{
+		Elements: []resp.Value{
+			&resp.BulkString{Bytes: []byte("pmessage")},
+			&resp.BulkString{Bytes: []byte(pattern)},
+			&resp.BulkString{Bytes: []byte(channel)},
+			&resp.BulkString{Bytes: message},
+		},
+	}
+}
+
+func newShardMessage(channel string, message []byte) *resp.Push {
+	return &resp.Push{
+		Elements: []resp.Value{
+			&resp.BulkString{Bytes: []byte("smessage")},
+			&resp.BulkString{Bytes: []byte(channel)},
+			&resp.BulkString{Bytes: message},
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package pubsub
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+type recordingSubscriber struct {
+	messages []*resp.Push
+}
+
+func (r *recordingSubscriber) Push(v resp.Value) {
+	r.messages = append(r.messages, v.(*resp.Push))
+}
+
+func TestPublishDeliversToChannelAndPatternSubscribers(t *testing.T) {
+	ps := NewPubSub()
+	channelSub := &recordingSubscriber{}
+	patternSub := &recordingSubscriber{}
+
+	if got := ps.Subscribe(channelSub, "news.tech"); got != 1 {
+		t.Fatalf("expected 1 subscription, got %d", got)
+	}
+
+	ps.PSubscribe(patternSub, "news.*")
+	if got := ps.PSubscribe(patternSub, "*.tech"); got != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", got)
+	}
+
+	if got := ps.Publish("news.tech", []byte("hello")); got != 3 {
+		t.Fatalf("expected 3 receivers, got %d", got)
+	}
+
+	if len(channelSub.messages) != 1 {
+		t.Fatalf("expected one message, got %v", channelSub.messages)
+	}
+
+	if got := channelSub.messages[0].Elements[0].String(); got != "message" {
+		t.Fatalf("expected a message push, got %q", got)
+	}
+
+	if len(patternSub.messages) != 2 {
+		t.Fatalf("expected a message per matching pattern, got %d", len(patternSub.messages))
+	}
+
+	if got := patternSub.messages[0].Elements[0].String(); got != "pmessage" {
+		t.Fatalf("expected a pmessage push, got %q", got)
+	}
+
+	if got := ps.Publish("sports", []byte("goal")); got != 0 {
+		t.Fatalf("expected no receivers, got %d", got)
+	}
+}
+
+func TestUnsubscribeAndIntrospection(t *testing.T) {
+	ps := NewPubSub()
+	a := &recordingSubscriber{}
+	b := &recordingSubscriber{}
+
+	ps.Subscribe(a, "foo")
+	ps.Subscribe(a, "bar")
+	ps.Subscribe(b, "foo")
+	ps.PSubscribe(a, "f*")
+	ps.PSubscribe(b, "f*")
+
+	if got := ps.ActiveChannels(""); !slices.Equal(got, []string{"bar", "foo"}) {
+		t.Fatalf("expected [bar foo], got %v", got)
+	}
+
+	if got := ps.ActiveChannels("f*"); !slices.Equal(got, []string{"foo"}) {
+		t.Fatalf("expected [foo], got %v", got)
+	}
+
+	if got := ps.NumSub("foo"); got != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", got)
+	}
+
+	if got := ps.NumPat(); got != 1 {
+		t.Fatalf("expected 1 pattern, got %d", got)
+	}
+
+	if got := ps.Unsubscribe(a, "foo"); got != 2 {
+		t.Fatalf("expected 2 subscriptions left, got %d", got)
+	}
+
+	if got := ps.Unsubscribe(a, "missing"); got != 2 {
+		t.Fatalf("expected 2 subscriptions left, got %d", got)
+	}
+
+	if got := ps.Channels(a); !slices.Equal(got, []string{"bar"}) {
+		t.Fatalf("expected [bar], got %v", got)
+	}
+
+	ps.UnsubscribeAll(b)
+
+	if got := ps.NumSub("foo"); got != 0 {
+		t.Fatalf("expected no subscribers left, got %d", got)
+	}
+
+	if got := ps.NumPat(); got != 1 {
+		t.Fatalf("expected the pattern of a to remain, got %d", got)
+	}
+
+	if got := ps.Count(b); got != 0 {
+		t.Fatalf("expected no subscriptions for b, got %d", got)
+	}
+}
+
+func TestShardChannels(t *testing.T) {
+	ps := NewPubSub()
+	a := &recordingSubscriber{}
+	b := &recordingSubscriber{}
+
+	ps.Subscribe(a, "{user1}.events")
+	ps.PSubscribe(a, "*")
+
+	if got := ps.SSubscribe(a, "{user1}.events"); got != 1 {
+		t.Fatalf("expected 1 shard subscription, got %d", got)
+	}
+
+	if got := ps.SSubscribe(a, "{user1}.alerts"); got != 2 {
+		t.Fatalf("expected 2 shard subscriptions, got %d", got)
+	}
+
+	ps.SSubscribe(b, "orders")
+
+	if got := ps.Count(a); got != 2 {
+		t.Fatalf("expected shard channels not to be counted with channels, got %d", got)
+	}
+
+	if got := ps.SPublish("{user1}.events", []byte("hi")); got != 1 {
+		t.Fatalf("expected 1 shard receiver, got %d", got)
+	}
+
+	if len(a.messages) != 1 || a.messages[0].Elements[0].String() != "smessage" {
+		t.Fatalf("expected a single smessage push, got %v", a.messages)
+	}
+
+	if got := ps.ActiveShardChannels(""); !slices.Equal(got, []string{"orders", "{user1}.alerts", "{user1}.events"}) {
+		t.Fatalf("unexpected shard channels %v", got)
+	}
+
+	if got := ps.ActiveShardChannels("{user1}*"); !slices.Equal(got, []string{"{user1}.alerts", "{user1}.events"}) {
+		t.Fatalf("unexpected shard channels %v", got)
+	}
+
+	if got := ps.ShardNumSub("orders"); got != 1 {
+		t.Fatalf("expected 1 shard subscriber, got %d", got)
+	}
+
+	if got := ps.SUnsubscribe(b, "orders"); got != 0 {
+		t.Fatalf("expected no shard subscriptions left, got %d", got)
+	}
+
+	if ps.IsSubscribed(b) {
+		t.Fatal("expected b to leave subscriber mode")
+	}
+
+	ps.UnsubscribeAll(a)
+
+	if got := ps.ActiveShardChannels(""); len(got) != 0 {
+		t.Fatalf("expected no shard channels left, got %v", got)
+	}
+
+	if len(ps.shardChannels) != 0 {
+		t.Fatalf("expected empty slots to be dropped, got %d", len(ps.shardChannels))
+	}
+}
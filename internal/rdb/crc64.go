@@ -0,0 +1,37 @@
+package rdb
+
+// jonesPoly is the reflected form of the Jones polynomial Redis uses for the
+// RDB and DUMP checksums.
+const jonesPoly = 0x95ac9329ac4bc9b5
+
+var crcTable = makeCrcTable()
+
+func makeCrcTable() *[256]uint64 {
+	t := new([256]uint64)
+
+	for i := range t {
+		crc := uint64(i)
+		for range 8 {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ jonesPoly
+			} else {
+				crc >>= 1
+			}
+		}
+
+		t[i] = crc
+	}
+
+	return t
+}
+
+// Checksum returns the CRC64 of b as Redis computes it, without the initial
+// and final inversion of hash/crc64.
+func Checksum(b []byte) uint64 {
+	crc := uint64(0)
+	for _, c := range b {
+		crc = crcTable[byte(crc)^c] ^ crc>>8
+	}
+
+	return crc
+}
@@ -0,0 +1,401 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Version is the RDB format version written by the server.
+const Version = 11
+
+const (
+	opFunction2 = 0xF5
+	opAux       = 0xFA
+	opSelectDB  = 0xFE
+	opEOF       = 0xFF
+)
+
+const (
+	// special encodings of the strings, found after a length byte with the
+	// 0b11 prefix: integers and LZF compressed strings
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+var ErrBadPayload = errors.New("ERR payload version or checksum are wrong")
+
+// Snapshot is the content of an RDB file. The keyspace itself is not part of
+// it yet, a snapshot holds the auxiliary fields and the function libraries.
+type Snapshot struct {
+	// Aux holds the auxiliary fields as name/value pairs, in file order
+	Aux [][2]string
+	// Functions holds the code of every function library
+	Functions []string
+}
+
+// Encode returns s as an RDB file.
+func (s *Snapshot) Encode() []byte {
+	b := fmt.Appendf(nil, "REDIS%04d", Version)
+
+	for _, field := range s.Aux {
+		b = append(b, opAux)
+		b = appendString(b, field[0])
+		b = appendString(b, field[1])
+	}
+
+	for _, code := range s.Functions {
+		b = append(b, opFunction2)
+		b = appendString(b, code)
+	}
+
+	b = append(b, opEOF)
+
+	return binary.LittleEndian.AppendUint64(b, Checksum(b))
+}
+
+// Decode parses an RDB file produced by Encode or by Redis. The databases
+// come after the auxiliary fields and the functions, they are skipped
+// without being read since the keyspace is not loaded, the checksum being
+// left unchecked then. Versions newer than Version are accepted, the
+// records they add before the databases are reported as unsupported.
+func Decode(data []byte) (*Snapshot, error) {
+	if len(data) < 9 || string(data[:5]) != "REDIS" {
+		return nil, errors.New("wrong signature trying to load DB")
+	}
+
+	version, err := strconv.Atoi(string(data[5:9]))
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("can't handle RDB format version %s", data[5:9])
+	}
+
+	s := &Snapshot{}
+	r := &reader{data: data, pos: 9}
+
+	for {
+		op, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case opAux:
+			name, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+
+			s.Aux = append(s.Aux, [2]string{name, value})
+		case opFunction2:
+			code, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+
+			s.Functions = append(s.Functions, code)
+		case opSelectDB:
+			return s, nil
+		case opEOF:
+			// a zero checksum means checksums were disabled when the file
+			// was written
+			end := r.pos
+			if len(data) >= end+8 {
+				if sum := binary.LittleEndian.Uint64(data[end:]); sum != 0 && sum != Checksum(data[:end]) {
+					return nil, errors.New("wrong RDB checksum")
+				}
+			}
+
+			return s, nil
+		default:
+			return nil, fmt.Errorf("unsupported RDB opcode 0x%02x", op)
+		}
+	}
+}
+
+// EncodeFunctions returns the libraries in codes in the format of the
+// FUNCTION DUMP payload: the function records followed by the RDB version
+// and a checksum.
+func EncodeFunctions(codes []string) []byte {
+	var b []byte
+
+	for _, code := range codes {
+		b = append(b, opFunction2)
+		b = appendString(b, code)
+	}
+
+	b = binary.LittleEndian.AppendUint16(b, Version)
+
+	return binary.LittleEndian.AppendUint64(b, Checksum(b))
+}
+
+// DecodeFunctions parses a FUNCTION DUMP payload and returns the code of the
+// libraries it holds.
+func DecodeFunctions(payload []byte) ([]string, error) {
+	if len(payload) < 10 {
+		return nil, ErrBadPayload
+	}
+
+	footer := len(payload) - 10
+	version := binary.LittleEndian.Uint16(payload[footer:])
+	sum := binary.LittleEndian.Uint64(payload[footer+2:])
+
+	if version > Version || sum != Checksum(payload[:footer+2]) {
+		return nil, ErrBadPayload
+	}
+
+	codes := []string{}
+	r := &reader{data: payload[:footer]}
+
+	for r.pos < len(r.data) {
+		op, _ := r.readByte()
+		if op != opFunction2 {
+			return nil, errors.New("ERR given type is not a function")
+		}
+
+		code, err := r.readString()
+		if err != nil {
+			return nil, ErrBadPayload
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+func appendLength(b []byte, n uint64) []byte {
+	switch {
+	case n < 1<<6:
+		return append(b, byte(n))
+	case n < 1<<14:
+		return append(b, 0x40|byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0x80), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0x81), n)
+	}
+}
+
+// appendString writes s with the string encoding, strings holding a small
+// integer are stored as the integer like Redis does.
+func appendString(b []byte, s string) []byte {
+	if v, err := strconv.ParseInt(s, 10, 32); err == nil && strconv.FormatInt(v, 10) == s {
+		switch {
+		case v >= math.MinInt8 && v <= math.MaxInt8:
+			return append(b, 0xC0|encInt8, byte(v))
+		case v >= math.MinInt16 && v <= math.MaxInt16:
+			return binary.LittleEndian.AppendUint16(append(b, 0xC0|encInt16), uint16(v))
+		default:
+			return binary.LittleEndian.AppendUint32(append(b, 0xC0|encInt32), uint32(v))
+		}
+	}
+
+	b = appendLength(b, uint64(len(s)))
+
+	return append(b, s...)
+}
+
+type reader struct {
+	data []byte
+	pos  int
+}
+
+var errTruncated = errors.New("unexpected end of RDB data")
+
+func (r *reader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errTruncated
+	}
+
+	c := r.data[r.pos]
+	r.pos++
+
+	return c, nil
+}
+
+func (r *reader) read(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errTruncated
+	}
+
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+
+	return b, nil
+}
+
+// readLength reads a length. When the 0b11 prefix is found the remaining
+// bits are returned as the special encoding of a string instead.
+func (r *reader) readLength() (n uint64, encoded bool, err error) {
+	c, err := r.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch c >> 6 {
+	case 0:
+		return uint64(c & 0x3F), false, nil
+	case 1:
+		next, err := r.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+
+		return uint64(c&0x3F)<<8 | uint64(next), false, nil
+	case 2:
+		switch c {
+		case 0x80:
+			b, err := r.read(4)
+			if err != nil {
+				return 0, false, err
+			}
+
+			return uint64(binary.BigEndian.Uint32(b)), false, nil
+		case 0x81:
+			b, err := r.read(8)
+			if err != nil {
+				return 0, false, err
+			}
+
+			return binary.BigEndian.Uint64(b), false, nil
+		default:
+			return 0, false, fmt.Errorf("unknown length encoding 0x%02x", c)
+		}
+	default:
+		return uint64(c & 0x3F), true, nil
+	}
+}
+
+func (r *reader) readString() (string, error) {
+	n, encoded, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+
+	if !encoded {
+		b, err := r.read(n)
+		return string(b), err
+	}
+
+	switch n {
+	case encInt8:
+		b, err := r.read(1)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(int(int8(b[0]))), nil
+	case encInt16:
+		b, err := r.read(2)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(b)))), nil
+	case encInt32:
+		b, err := r.read(4)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(b)))), nil
+	case encLZF:
+		return r.readLZF()
+	default:
+		return "", fmt.Errorf("unsupported string encoding %d", n)
+	}
+}
+
+// readLZF reads a compressed string: its compressed and uncompressed
+// lengths followed by the LZF data.
+func (r *reader) readLZF() (string, error) {
+	clen, _, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+
+	n, _, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+
+	compressed, err := r.read(clen)
+	if err != nil {
+		return "", err
+	}
+
+	return lzfDecompress(compressed, n)
+}
+
+var errBadLZF = errors.New("invalid LZF compressed string")
+
+// lzfDecompress expands data into a string of n bytes. A control byte below
+// 32 is followed by that many literal bytes minus one, any other one is a
+// back reference: its top 3 bits are the length minus 2, 7 meaning that a
+// byte holding the rest follows, and its low 5 bits with the next byte the
+// offset minus one.
+func lzfDecompress(data []byte, n uint64) (string, error) {
+	if n > uint64(len(data))*256 {
+		return "", errBadLZF
+	}
+
+	out := make([]byte, 0, n)
+
+	for i := 0; i < len(data); {
+		ctrl := int(data[i])
+		i++
+
+		if ctrl < 32 {
+			end := i + ctrl + 1
+			if end > len(data) {
+				return "", errBadLZF
+			}
+
+			out = append(out, data[i:end]...)
+			i = end
+
+			continue
+		}
+
+		length := ctrl >> 5
+		if length == 7 {
+			if i >= len(data) {
+				return "", errBadLZF
+			}
+
+			length += int(data[i])
+			i++
+		}
+
+		if i >= len(data) {
+			return "", errBadLZF
+		}
+
+		ref := len(out) - (ctrl&0x1F)<<8 - int(data[i]) - 1
+		i++
+
+		if ref < 0 {
+			return "", errBadLZF
+		}
+
+		// the reference may overlap what it produces, copy byte by byte
+		for j := range length + 2 {
+			out = append(out, out[ref+j])
+		}
+	}
+
+	if uint64(len(out)) != n {
+		return "", errBadLZF
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,120 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// redisEmptyRDB is the RDB file written by Redis 7.2 for an empty keyspace.
+const redisEmptyRDB = "524544495330303131fa0972656469732d76657205372e322e30fa0a72656469732d62697473c040fa056374696d65c26d08bc65fa08757365642d6d656dc2b0c41000fa08616f662d62617365c000fff06e3bfec0ff5aa2"
+
+func TestChecksum(t *testing.T) {
+	if got := Checksum([]byte("123456789")); got != 0xe9c6d914c4b8d9ca {
+		t.Fatalf("expected the CRC64 Jones check value, got %x", got)
+	}
+}
+
+func TestDecodeRedisEmptyRDB(t *testing.T) {
+	data, _ := hex.DecodeString(redisEmptyRDB)
+
+	s, err := Decode(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	want := [][2]string{{"redis-ver", "7.2.0"}, {"redis-bits", "64"}}
+	if !slices.Equal(s.Aux[:2], want) {
+		t.Fatalf("expected aux fields starting with %v, got %v", want, s.Aux)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	long := string(make([]byte, 20000))
+
+	s := &Snapshot{
+		Aux:       [][2]string{{"redis-ver", "7.2.0"}, {"redis-bits", "64"}, {"ctime", "1700000000"}, {"neg", "-300"}},
+		Functions: []string{"#!lua name=lib\nreturn 1", long},
+	}
+
+	decoded, err := Decode(s.Encode())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if !slices.Equal(decoded.Aux, s.Aux) || !slices.Equal(decoded.Functions, s.Functions) {
+		t.Fatalf("expected %v, got %v", s, decoded)
+	}
+
+	corrupted := s.Encode()
+	corrupted[12] ^= 0xFF
+
+	if _, err := Decode(corrupted); err == nil {
+		t.Fatal("expected a corrupted snapshot to fail the checksum")
+	}
+}
+
+func TestFunctionsPayload(t *testing.T) {
+	codes := []string{"#!lua name=a\n", "#!lua name=b\n"}
+
+	decoded, err := DecodeFunctions(EncodeFunctions(codes))
+	if err != nil || !slices.Equal(decoded, codes) {
+		t.Fatalf("expected %v, got %v (%v)", codes, decoded, err)
+	}
+
+	payload := EncodeFunctions(codes)
+	payload[len(payload)-1] ^= 0xFF
+
+	if _, err := DecodeFunctions(payload); !errors.Is(err, ErrBadPayload) {
+		t.Fatalf("expected a bad payload error, got %v", err)
+	}
+}
+
+func TestDecodeSkipsTheDatabases(t *testing.T) {
+	data, _ := hex.DecodeString(redisEmptyRDB)
+
+	// Redis 7.4 writes version 12, the keys follow the functions
+	data = append([]byte("REDIS0012"), data[9:len(data)-9]...)
+	data = append(data, opFunction2)
+	data = appendString(data, "#!lua name=lib\nreturn 1")
+	data = append(data, opSelectDB, 0, 0xFB, 1, 0, 0, 1, 'k', 1, 'v', opEOF)
+	data = binary.LittleEndian.AppendUint64(data, 0)
+
+	s, err := Decode(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if !slices.Equal(s.Functions, []string{"#!lua name=lib\nreturn 1"}) {
+		t.Fatalf("unexpected functions %q", s.Functions)
+	}
+}
+
+func TestDecodeLZFStrings(t *testing.T) {
+	// "abc" as literals, then 6 bytes copied from 3 bytes back
+	compressed := []byte{0x02, 'a', 'b', 'c', 0x80, 0x02}
+
+	data := []byte("REDIS0011")
+	data = append(data, opAux)
+	data = appendString(data, "name")
+	data = append(data, 0xC0|encLZF, byte(len(compressed)), 9)
+	data = append(data, compressed...)
+	data = append(data, opEOF)
+	data = binary.LittleEndian.AppendUint64(data, Checksum(data))
+
+	s, err := Decode(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if want := [][2]string{{"name", "abcabcabc"}}; !slices.Equal(s.Aux, want) {
+		t.Fatalf("expected %v, got %v", want, s.Aux)
+	}
+
+	// a reference before the start is refused
+	if _, err := lzfDecompress([]byte{0x80, 0x02}, 6); err == nil {
+		t.Fatal("expected an invalid reference to be refused")
+	}
+}
@@ -3,20 +3,182 @@ package resp
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
+// maxSizeDigits is the length of the longest size accepted in a header.
+const maxSizeDigits = 20
+
 type Decoder struct {
-	r ByteReadReader
+	r      ByteReadReader
+	limits *Limits
+	// pending is the size of the bulk strings of the request being read,
+	// checked against the query buffer limit
+	pending int64
 }
 
 func NewDecoder(reader ByteReadReader) *Decoder {
 	return &Decoder{
-		r: reader,
+		r:      reader,
+		limits: NewLimits(),
+	}
+}
+
+// SetLimits makes the decoder enforce limits instead of the defaults.
+func (d *Decoder) SetLimits(limits *Limits) {
+	d.limits = limits
+}
+
+// ReadRequest returns the next request of a client, an array of bulk
+// strings. Like Redis, any other request is read as an inline one and the
+// elements of an array must be bulk strings, so that a client cannot make
+// the server read unbounded lines or deeply nested aggregates. Empty
+// requests are skipped.
+func (d *Decoder) ReadRequest() (*Array, error) {
+	for {
+		d.pending = 0
+
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		var request *Array
+		if b == '*' {
+			request, err = d.processMultibulk()
+		} else {
+			request, err = d.processInline(b)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(request.Elements) > 0 {
+			return request, nil
+		}
 	}
 }
 
+// processMultibulk reads the size and the bulk strings of a request.
+func (d *Decoder) processMultibulk() (*Array, error) {
+	size, err := d.getSizeOfTheData()
+	if err != nil {
+		return nil, err
+	}
+
+	if size > MaxMultibulkLen {
+		return nil, &ProtocolError{Msg: "invalid multibulk length"}
+	}
+
+	if err := d.readClrf(); err != nil {
+		return nil, err
+	}
+
+	arr := &Array{}
+
+	for size > len(arr.Elements) {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b != '$' {
+			return nil, &ProtocolError{Msg: fmt.Sprintf("expected '$', got '%c'", b)}
+		}
+
+		str, err := d.processBulkString()
+		if err != nil {
+			return nil, err
+		}
+
+		if str.Null {
+			return nil, &ProtocolError{Msg: "invalid bulk length"}
+		}
+
+		arr.Elements = append(arr.Elements, str)
+	}
+
+	return arr, nil
+}
+
+// Read returns the next value of any type, such as a reply read by a
+// client. A value that does not start with a type byte is read as an inline
+// request, empty ones being skipped. The requests of the clients of the
+// server are read with ReadRequest.
 func (d *Decoder) Read() (Value, error) {
+	for {
+		d.pending = 0
+
+		p, err := d.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if isTypeByte(p[0]) {
+			return d.readValue()
+		}
+
+		b, _ := d.r.ReadByte()
+
+		value, err := d.processInline(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(value.Elements) > 0 {
+			return value, nil
+		}
+	}
+}
+
+// ServerError is an error reply read by ReadReply, telling it apart from
+// the I/O and protocol errors.
+type ServerError struct {
+	Msg string
+}
+
+func (e *ServerError) Error() string {
+	return e.Msg
+}
+
+// Code returns the first word of the error, such as ERR or NOAUTH.
+func (e *ServerError) Code() string {
+	code, _, _ := strings.Cut(e.Msg, " ")
+	return code
+}
+
+// ReadReply reads the reply of a server to a request, for the clients of
+// another server such as a replica. An error reply is returned as a
+// *ServerError rather than as a value.
+func (d *Decoder) ReadReply() (Value, error) {
+	d.pending = 0
+
+	v, err := d.readValue()
+	if err != nil {
+		return nil, err
+	}
+
+	if errReply, ok := v.(*Error); ok {
+		return nil, &ServerError{Msg: errReply.Msg}
+	}
+
+	return v, nil
+}
+
+func isTypeByte(b byte) bool {
+	switch b {
+	case '+', '-', '*', '$', ':', '_', '#', ',', '(', '=', '!', '%', '~', '>', '|':
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Decoder) readValue() (Value, error) {
 	b, err := d.r.ReadByte()
 
 	if err != nil {
@@ -56,6 +218,71 @@ func (d *Decoder) Read() (Value, error) {
 		}
 
 		return value, nil
+	case byte('-'):
+		str, err := d.processSimpleString()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Error{Msg: string(str.Bytes)}, nil
+	case byte('!'):
+		str, err := d.processBulkString()
+		if err != nil {
+			return nil, err
+		}
+
+		if str.Null {
+			return nil, fmt.Errorf("ERR invalid blob error")
+		}
+
+		return &Error{Msg: string(str.Bytes)}, nil
+	case byte('_'):
+		if err := d.readClrf(); err != nil {
+			return nil, err
+		}
+
+		return &Null{}, nil
+	case byte('#'):
+		return d.processBoolean()
+	case byte(','):
+		return d.processDouble()
+	case byte('('):
+		return d.processBigNumber()
+	case byte('='):
+		return d.processVerbatimString()
+	case byte('%'):
+		entries, err := d.processEntries()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Map{Entries: entries}, nil
+	case byte('~'):
+		elements, err := d.processElements()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Set{Elements: elements}, nil
+	case byte('>'):
+		elements, err := d.processElements()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Push{Elements: elements}, nil
+	case byte('|'):
+		entries, err := d.processEntries()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Attribute{Entries: entries, Value: value}, nil
 	default:
 		return nil, fmt.Errorf("unknown data type: %s", string(b))
 	}
@@ -82,6 +309,10 @@ func (d *Decoder) getSizeOfTheData() (int, error) {
 		}
 
 		sizeStr = append(sizeStr, rByte)
+
+		if len(sizeStr) > maxSizeDigits {
+			return 0, fmt.Errorf("ERR invalid size")
+		}
 	}
 
 	num, err := strconv.Atoi(string(sizeStr))
@@ -95,7 +326,7 @@ func (d *Decoder) getSizeOfTheData() (int, error) {
 
 func (d *Decoder) readClrf() error {
 	b := make([]byte, 2)
-	n, err := d.r.Read(b)
+	n, err := io.ReadFull(d.r, b)
 
 	if err != nil {
 		return err
@@ -149,6 +380,10 @@ func (d *Decoder) processArray() (*Array, error) {
 		return nil, err
 	}
 
+	if arrSize > MaxMultibulkLen || arrSize < -1 {
+		return nil, &ProtocolError{Msg: "invalid multibulk length"}
+	}
+
 	if err := d.readClrf(); err != nil {
 		return nil, err
 	}
@@ -166,7 +401,7 @@ func (d *Decoder) processArray() (*Array, error) {
 	}
 
 	for arrSize > len(arr.Elements) {
-		el, err := d.Read()
+		el, err := d.readValue()
 		if err != nil {
 			return nil, err
 		}
@@ -184,6 +419,17 @@ func (d *Decoder) processBulkString() (*BulkString, error) {
 		return nil, err
 	}
 
+	if int64(strSize) > d.limits.MaxBulkLen() || strSize < -1 {
+		return nil, &ProtocolError{Msg: "invalid bulk length"}
+	}
+
+	if strSize > 0 {
+		d.pending += int64(strSize)
+		if d.pending > d.limits.QueryBufferLimit() {
+			return nil, ErrQueryBufferLimit
+		}
+	}
+
 	if err := d.readClrf(); err != nil {
 		return nil, err
 	}
@@ -274,3 +520,125 @@ func (d *Decoder) processInteger() (*Integer, error) {
 
 	return integer, nil
 }
+
+func (d *Decoder) processBoolean() (*Boolean, error) {
+	line, err := d.processSimpleString()
+	if err != nil {
+		return nil, err
+	}
+
+	switch string(line.Bytes) {
+	case "t":
+		return &Boolean{Value: true}, nil
+	case "f":
+		return &Boolean{Value: false}, nil
+	default:
+		return nil, fmt.Errorf("ERR invalid boolean value")
+	}
+}
+
+func (d *Decoder) processDouble() (*Double, error) {
+	line, err := d.processSimpleString()
+	if err != nil {
+		return nil, err
+	}
+
+	// ParseFloat also accepts inf, -inf and nan
+	f, err := strconv.ParseFloat(string(line.Bytes), 64)
+	if err != nil {
+		return nil, fmt.Errorf("ERR invalid double value")
+	}
+
+	return &Double{Number: f}, nil
+}
+
+func (d *Decoder) processBigNumber() (*BigNumber, error) {
+	line, err := d.processSimpleString()
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := new(big.Int).SetString(string(line.Bytes), 10)
+	if !ok {
+		return nil, fmt.Errorf("ERR invalid big number value")
+	}
+
+	return &BigNumber{Number: n}, nil
+}
+
+func (d *Decoder) processVerbatimString() (*VerbatimString, error) {
+	str, err := d.processBulkString()
+	if err != nil {
+		return nil, err
+	}
+
+	if str.Null || len(str.Bytes) < 4 || str.Bytes[3] != ':' {
+		return nil, fmt.Errorf("ERR invalid verbatim string")
+	}
+
+	return &VerbatimString{Format: string(str.Bytes[:3]), Bytes: str.Bytes[4:]}, nil
+}
+
+// processElements reads the size and the elements of a set or a push.
+func (d *Decoder) processElements() ([]Value, error) {
+	size, err := d.getSizeOfTheData()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.readClrf(); err != nil {
+		return nil, err
+	}
+
+	if size > MaxMultibulkLen || size < 0 {
+		return nil, &ProtocolError{Msg: "invalid multibulk length"}
+	}
+
+	elements := []Value{}
+
+	for size > len(elements) {
+		el, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, el)
+	}
+
+	return elements, nil
+}
+
+// processEntries reads the number of entries and the keys and values of a
+// map or of attributes.
+func (d *Decoder) processEntries() ([]MapEntry, error) {
+	size, err := d.getSizeOfTheData()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.readClrf(); err != nil {
+		return nil, err
+	}
+
+	if size > MaxMultibulkLen || size < 0 {
+		return nil, &ProtocolError{Msg: "invalid multibulk length"}
+	}
+
+	entries := []MapEntry{}
+
+	for size > len(entries) {
+		key, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, MapEntry{Key: key, Value: value})
+	}
+
+	return entries, nil
+}
@@ -3,8 +3,10 @@ package resp
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -324,3 +326,340 @@ func TestRESP_IntegerExtremesRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+// TestDecoder_Read_RESP3Types ensures that the RESP3 types are decoded and
+// written back identically by a RESP3 encoder.
+func TestDecoder_Read_RESP3Types(t *testing.T) {
+	cases := []string{
+		"%2\r\n+first\r\n:1\r\n$6\r\nsecond\r\n*1\r\n#f\r\n",
+		"~2\r\n$1\r\na\r\n$1\r\nb\r\n",
+		">3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n",
+		"_\r\n",
+		"#t\r\n",
+		",3.25\r\n",
+		",inf\r\n",
+		"(-3492890328409238509324850943850943825024385\r\n",
+		"=15\r\ntxt:Some string\r\n",
+		"|1\r\n+key-popularity\r\n%1\r\n$1\r\na\r\n,0.1923\r\n*1\r\n:2\r\n",
+	}
+
+	for _, input := range cases {
+		dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(input)))
+
+		v, err := dec.Read()
+		if err != nil {
+			t.Fatalf("decoder.Read() returned error for %q: %v", input, err)
+		}
+
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetProtocol(RESP3)
+
+		if err := enc.Write(v); err != nil {
+			t.Fatalf("encoder.Write() returned error for %q: %v", input, err)
+		}
+
+		if got := buf.String(); got != input {
+			t.Fatalf("unexpected round-trip output: got %q, want %q", got, input)
+		}
+	}
+}
+
+// TestDecoder_Read_RESP3Invalid ensures malformed RESP3 values are rejected.
+func TestDecoder_Read_RESP3Invalid(t *testing.T) {
+	cases := []string{
+		"#x\r\n",
+		",abc\r\n",
+		"(12a\r\n",
+		"=5\r\nhello\r\n",
+		"%-1\r\n",
+	}
+
+	for _, input := range cases {
+		dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(input)))
+
+		if _, err := dec.Read(); err == nil {
+			t.Fatalf("expected decoder.Read() to fail for %q", input)
+		}
+	}
+}
+
+// TestDecoder_Read_Inline verifies that requests not starting with a type
+// byte are split into arguments like redis-cli does, skipping empty lines.
+func TestDecoder_Read_Inline(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{"PING\r\n", []string{"PING"}},
+		{"PING\n", []string{"PING"}},
+		{"\r\n\nECHO  hey\r\n", []string{"ECHO", "hey"}},
+		{"SET k \"hello world\"\r\n", []string{"SET", "k", "hello world"}},
+		{"SET k \"a\\x41\\n\\\"\"\r\n", []string{"SET", "k", "aA\n\""}},
+		{"SET k 'it\\'s \\n'\r\n", []string{"SET", "k", "it's \\n"}},
+		{"SET k \"\"\r\n", []string{"SET", "k", ""}},
+	}
+
+	for _, tc := range cases {
+		dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(tc.input)))
+
+		v, err := dec.Read()
+		if err != nil {
+			t.Fatalf("decoder.Read() returned error for %q: %v", tc.input, err)
+		}
+
+		arr, ok := v.(*Array)
+		if !ok || len(arr.Elements) != len(tc.want) {
+			t.Fatalf("expected %d arguments for %q, got %#v", len(tc.want), tc.input, v)
+		}
+
+		for i, want := range tc.want {
+			if got := arr.Elements[i].(*BulkString).String(); got != want {
+				t.Fatalf("expected argument %d of %q to be %q, got %q", i, tc.input, want, got)
+			}
+		}
+	}
+}
+
+// TestDecoder_Read_InlineErrors ensures that unbalanced quotes and requests
+// longer than MaxInlineSize are protocol errors.
+func TestDecoder_Read_InlineErrors(t *testing.T) {
+	cases := []string{
+		"SET k \"unterminated\r\n",
+		"SET k 'unterminated\r\n",
+		"SET k \"quoted\"trailing\r\n",
+		strings.Repeat("a", MaxInlineSize+1) + "\r\n",
+	}
+
+	for _, input := range cases {
+		dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(input)))
+
+		var protoErr *ProtocolError
+		if _, err := dec.Read(); !errors.As(err, &protoErr) {
+			t.Fatalf("expected a protocol error for %.20q, got %v", input, err)
+		}
+	}
+}
+
+// TestDecoder_ReadReply verifies that replies are decoded, error replies
+// being returned as a *ServerError.
+func TestDecoder_ReadReply(t *testing.T) {
+	input := "-NOAUTH Authentication required.\r\n!21\r\nSYNTAX invalid syntax\r\n$-1\r\n*-1\r\n+OK\r\n"
+	dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(input)))
+
+	_, err := dec.ReadReply()
+
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.Code() != "NOAUTH" || serverErr.Msg != "NOAUTH Authentication required." {
+		t.Fatalf("expected a NOAUTH server error, got %v", err)
+	}
+
+	if _, err := dec.ReadReply(); !errors.As(err, &serverErr) || serverErr.Code() != "SYNTAX" {
+		t.Fatalf("expected a SYNTAX blob error, got %v", err)
+	}
+
+	if v, err := dec.ReadReply(); err != nil || !v.(*BulkString).Null {
+		t.Fatalf("expected a null bulk string, got %#v, %v", v, err)
+	}
+
+	if v, err := dec.ReadReply(); err != nil || !v.(*Array).Null {
+		t.Fatalf("expected a null array, got %#v, %v", v, err)
+	}
+
+	if v, err := dec.ReadReply(); err != nil || v.String() != "OK" {
+		t.Fatalf("expected OK, got %#v, %v", v, err)
+	}
+
+	// I/O errors are not server errors
+	if _, err := dec.ReadReply(); err == nil || errors.As(err, &serverErr) {
+		t.Fatalf("expected an I/O error, got %v", err)
+	}
+}
+
+// TestDecoder_Read_Limits ensures that lengths beyond the multibulk and bulk
+// limits are protocol errors, before anything is allocated for them.
+func TestDecoder_Read_Limits(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"*2147483647\r\n", "Protocol error: invalid multibulk length"},
+		{"*-5\r\n", "Protocol error: invalid multibulk length"},
+		{"~2000000\r\n", "Protocol error: invalid multibulk length"},
+		{"%2000000\r\n", "Protocol error: invalid multibulk length"},
+		{"*1\r\n$999999999\r\n", "Protocol error: invalid bulk length"},
+		{"*1\r\n$-5\r\n", "Protocol error: invalid bulk length"},
+		{"=999999999\r\n", "Protocol error: invalid bulk length"},
+	}
+
+	for _, tc := range cases {
+		dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(tc.input)))
+		limits := NewLimits()
+		limits.SetMaxBulkLen(1024 * 1024)
+		dec.SetLimits(limits)
+
+		var protoErr *ProtocolError
+		if _, err := dec.Read(); !errors.As(err, &protoErr) || err.Error() != tc.want {
+			t.Fatalf("expected %q for %q, got %v", tc.want, tc.input, err)
+		}
+	}
+
+	dec := NewDecoder(bufio.NewReader(bytes.NewBufferString("*1\r\n$" + strings.Repeat("9", 30) + "\r\n")))
+	if _, err := dec.Read(); err == nil {
+		t.Fatalf("expected decoder.Read() to fail for an oversized length")
+	}
+}
+
+// TestDecoder_Read_QueryBufferLimit ensures that a request whose bulk
+// strings add up to more than the query buffer limit is rejected, while the
+// limit applies to each request separately.
+func TestDecoder_Read_QueryBufferLimit(t *testing.T) {
+	input := "*2\r\n$4\r\nECHO\r\n$4\r\nabcd\r\n*2\r\n$4\r\nECHO\r\n$8\r\nabcdefgh\r\n"
+	dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(input)))
+	limits := NewLimits()
+	limits.SetQueryBufferLimit(10)
+	dec.SetLimits(limits)
+
+	if _, err := dec.Read(); err != nil {
+		t.Fatalf("expected the first request to fit, got %v", err)
+	}
+
+	if _, err := dec.Read(); !errors.Is(err, ErrQueryBufferLimit) {
+		t.Fatalf("expected ErrQueryBufferLimit, got %v", err)
+	}
+}
+
+// FuzzDecoder feeds arbitrary input to the decoder under small limits. It
+// must never panic nor allocate past the limits, and every value it accepts
+// must survive an encoding round trip.
+// TestDecoder_ReadRequest ensures that only arrays of bulk strings and
+// inline requests are read from clients, so that neither an endless line
+// nor deeply nested aggregates are accepted.
+func TestDecoder_ReadRequest(t *testing.T) {
+	dec := NewDecoder(bufio.NewReader(bytes.NewBufferString("*0\r\n*-1\r\n*2\r\n$4\r\nECHO\r\n$2\r\nhi\r\n+PING\r\n")))
+
+	for _, want := range [][]string{{"ECHO", "hi"}, {"+PING"}} {
+		request, err := dec.ReadRequest()
+		if err != nil {
+			t.Fatalf("expected %v, got %v", want, err)
+		}
+
+		if len(request.Elements) != len(want) {
+			t.Fatalf("expected %v, got %#v", want, request)
+		}
+
+		for i, w := range want {
+			if got := request.Elements[i].(*BulkString); string(got.Bytes) != w {
+				t.Fatalf("expected %v, got %q", want, got.Bytes)
+			}
+		}
+	}
+
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"+" + strings.Repeat("a", MaxInlineSize+1), "Protocol error: too big inline request"},
+		{":" + strings.Repeat("1", MaxInlineSize+1), "Protocol error: too big inline request"},
+		{strings.Repeat("*1\r\n", 100000), "Protocol error: expected '$', got '*'"},
+		{"*1\r\n%1\r\n", "Protocol error: expected '$', got '%'"},
+		{"*1\r\n+OK\r\n", "Protocol error: expected '$', got '+'"},
+		{"*1\r\n$-1\r\n", "Protocol error: invalid bulk length"},
+		{"*2147483647\r\n", "Protocol error: invalid multibulk length"},
+	}
+
+	for _, tc := range cases {
+		dec := NewDecoder(bufio.NewReader(bytes.NewBufferString(tc.input)))
+
+		var protoErr *ProtocolError
+		if _, err := dec.ReadRequest(); !errors.As(err, &protoErr) || err.Error() != tc.want {
+			t.Fatalf("expected %q for %.20q, got %v", tc.want, tc.input, err)
+		}
+	}
+}
+
+func FuzzDecoder(f *testing.F) {
+	seeds := []string{
+		"*1\r\n$4\r\nPING\r\n",
+		"*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n",
+		"$-1\r\n*-1\r\n",
+		":-42\r\n+OK\r\n",
+		"-ERR bad\r\n!3\r\nbad\r\n",
+		"%1\r\n+k\r\n:1\r\n~2\r\n#t\r\n,1.5\r\n",
+		">2\r\n+a\r\n(123\r\n=7\r\ntxt:abc\r\n_\r\n",
+		"|1\r\n+a\r\n+b\r\n+c\r\n",
+		"PING\r\n",
+		"SET k \"a\\x41\"\r\n",
+		"*2147483647\r\n",
+		"$999999999\r\n",
+		"*1\r\n$99999999999999999999999\r\n",
+	}
+
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		dec := NewDecoder(bufio.NewReader(bytes.NewReader(input)))
+		limits := NewLimits()
+		limits.SetMaxBulkLen(1024)
+		limits.SetQueryBufferLimit(4096)
+		dec.SetLimits(limits)
+
+		for {
+			v, err := dec.Read()
+			if err != nil {
+				return
+			}
+
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.SetProtocol(RESP3)
+
+			if err := enc.Write(v); err != nil {
+				t.Fatalf("encoder.Write(%#v) returned error: %v", v, err)
+			}
+
+			encoded := buf.String()
+			if _, err := NewDecoder(bufio.NewReader(&buf)).Read(); err != nil {
+				t.Fatalf("failed to decode %q, encoded from %#v: %v", encoded, v, err)
+			}
+		}
+	})
+}
+
+func FuzzDecoder_ReadRequest(f *testing.F) {
+	seeds := []string{
+		"*1\r\n$4\r\nPING\r\n",
+		"*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n",
+		"*-1\r\n*0\r\n",
+		"*1\r\n*1\r\n$1\r\na\r\n",
+		"+OK\r\n",
+		"SET k \"a\\x41\"\r\n",
+	}
+
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		dec := NewDecoder(bufio.NewReader(bytes.NewReader(input)))
+		limits := NewLimits()
+		limits.SetMaxBulkLen(1024)
+		limits.SetQueryBufferLimit(4096)
+		dec.SetLimits(limits)
+
+		for {
+			request, err := dec.ReadRequest()
+			if err != nil {
+				return
+			}
+
+			for _, e := range request.Elements {
+				if str, ok := e.(*BulkString); !ok || str.Null {
+					t.Fatalf("expected bulk strings, got %#v", request)
+				}
+			}
+		}
+	})
+}
@@ -3,19 +3,46 @@ package resp
 import (
 	"fmt"
 	"io"
+	"strings"
+)
+
+const (
+	// RESP2 is the protocol clients speak until they switch with HELLO
+	RESP2 = 2
+	RESP3 = 3
 )
 
 type Encoder struct {
-	w io.Writer
+	w        io.Writer
+	protocol int
 }
 
 func NewEncoder(writer io.Writer) *Encoder {
 	return &Encoder{
-		w: writer,
+		w:        writer,
+		protocol: RESP2,
 	}
 }
 
+// Protocol returns the protocol version the values are encoded with.
+func (e *Encoder) Protocol() int {
+	return e.protocol
+}
+
+// SetProtocol switches the encoder to RESP2 or RESP3. In RESP2 the RESP3
+// types are written as their RESP2 counterparts, see ToRESP2.
+func (e *Encoder) SetProtocol(protocol int) {
+	e.protocol = protocol
+}
+
 func (e *Encoder) Write(v Value) error {
+	if e.protocol < RESP3 {
+		switch v.(type) {
+		case *Null, *Boolean, *Double, *BigNumber, *VerbatimString:
+			v = ToRESP2(v)
+		}
+	}
+
 	switch v := v.(type) {
 	case *SimpleString:
 		if _, err := fmt.Fprintf(e.w, "+%s\r\n", v.Bytes); err != nil {
@@ -23,7 +50,7 @@ func (e *Encoder) Write(v Value) error {
 		}
 	case *BulkString:
 		if v.Null {
-			if _, err := fmt.Fprintf(e.w, "$-1\r\n"); err != nil {
+			if _, err := fmt.Fprint(e.w, e.null("$-1\r\n")); err != nil {
 				return err
 			}
 
@@ -37,35 +64,69 @@ func (e *Encoder) Write(v Value) error {
 			return err
 		}
 	case *Error:
-		if _, err := fmt.Fprintf(e.w, "-%s\r\n", v.Msg); err != nil {
+		// like Redis, newlines would end the error early, they become spaces
+		msg := strings.NewReplacer("\r", " ", "\n", " ").Replace(v.Msg)
+		if _, err := fmt.Fprintf(e.w, "-%s\r\n", msg); err != nil {
 			return err
 		}
 	case *Array:
 		if v.Null {
-			if _, err := fmt.Fprintf(e.w, "*-1\r\n"); err != nil {
+			if _, err := fmt.Fprint(e.w, e.null("*-1\r\n")); err != nil {
 				return err
 			}
 
 			break
 		}
 
-		if len(v.Elements) == 0 {
-			if _, err := fmt.Fprintf(e.w, "*0\r\n"); err != nil {
+		if err := e.writeAggregate('*', v.Elements); err != nil {
+			return err
+		}
+	case *Map:
+		if err := e.writeMap(e.prefix('%'), v.Entries); err != nil {
+			return err
+		}
+	case *Set:
+		if err := e.writeAggregate(e.prefix('~'), v.Elements); err != nil {
+			return err
+		}
+	case *Push:
+		if err := e.writeAggregate(e.prefix('>'), v.Elements); err != nil {
+			return err
+		}
+	case *Attribute:
+		if e.protocol >= RESP3 {
+			if err := e.writeMap('|', v.Entries); err != nil {
 				return err
 			}
-
-			break
 		}
 
-		if _, err := fmt.Fprintf(e.w, "*%d\r\n", len(v.Elements)); err != nil {
+		if err := e.Write(v.Value); err != nil {
+			return err
+		}
+	case *Null:
+		if _, err := fmt.Fprint(e.w, "_\r\n"); err != nil {
 			return err
 		}
+	case *Boolean:
+		b := 'f'
+		if v.Value {
+			b = 't'
+		}
 
-		for _, v := range v.Elements {
-			err := e.Write(v)
-			if err != nil {
-				return err
-			}
+		if _, err := fmt.Fprintf(e.w, "#%c\r\n", b); err != nil {
+			return err
+		}
+	case *Double:
+		if _, err := fmt.Fprintf(e.w, ",%s\r\n", FormatDouble(v.Number)); err != nil {
+			return err
+		}
+	case *BigNumber:
+		if _, err := fmt.Fprintf(e.w, "(%s\r\n", v.Number); err != nil {
+			return err
+		}
+	case *VerbatimString:
+		if _, err := fmt.Fprintf(e.w, "=%d\r\n%s:%s\r\n", len(v.Format)+1+len(v.Bytes), v.Format, v.Bytes); err != nil {
+			return err
 		}
 	default:
 		return fmt.Errorf("unknown value type %T", v)
@@ -73,3 +134,61 @@ func (e *Encoder) Write(v Value) error {
 
 	return nil
 }
+
+// null returns the RESP3 null in place of resp2, the RESP2 null bulk
+// string or array.
+func (e *Encoder) null(resp2 string) string {
+	if e.protocol >= RESP3 {
+		return "_\r\n"
+	}
+
+	return resp2
+}
+
+// prefix returns the prefix of an aggregate type, RESP2 only has arrays.
+func (e *Encoder) prefix(resp3 byte) byte {
+	if e.protocol >= RESP3 {
+		return resp3
+	}
+
+	return '*'
+}
+
+func (e *Encoder) writeAggregate(prefix byte, elements []Value) error {
+	if _, err := fmt.Fprintf(e.w, "%c%d\r\n", prefix, len(elements)); err != nil {
+		return err
+	}
+
+	for _, v := range elements {
+		if err := e.Write(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeMap writes entries as a map, or as a flat array of keys and values
+// when prefix is the array one.
+func (e *Encoder) writeMap(prefix byte, entries []MapEntry) error {
+	n := len(entries)
+	if prefix == '*' {
+		n *= 2
+	}
+
+	if _, err := fmt.Fprintf(e.w, "%c%d\r\n", prefix, n); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := e.Write(entry.Key); err != nil {
+			return err
+		}
+
+		if err := e.Write(entry.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package resp
 
 import (
 	"bytes"
+	"math"
+	"math/big"
 	"testing"
 )
 
@@ -175,3 +177,85 @@ func TestEncoder_Write_Inner_Arrays(t *testing.T) {
 		t.Fatalf("unexpected output: got %q, want %q", got, want)
 	}
 }
+
+// TestEncoder_Write_RESP3Types ensures that the RESP3 types are written with
+// their own prefixes once the encoder is switched to RESP3, and as their
+// RESP2 counterparts before.
+func TestEncoder_Write_RESP3Types(t *testing.T) {
+	cases := []struct {
+		value Value
+		resp2 string
+		resp3 string
+	}{
+		{
+			value: NewMap(&BulkString{Bytes: []byte("a")}, &Integer{Number: 1}),
+			resp2: "*2\r\n$1\r\na\r\n:1\r\n",
+			resp3: "%1\r\n$1\r\na\r\n:1\r\n",
+		},
+		{
+			value: &Set{Elements: []Value{&BulkString{Bytes: []byte("x")}}},
+			resp2: "*1\r\n$1\r\nx\r\n",
+			resp3: "~1\r\n$1\r\nx\r\n",
+		},
+		{
+			value: &Push{Elements: []Value{&BulkString{Bytes: []byte("x")}}},
+			resp2: "*1\r\n$1\r\nx\r\n",
+			resp3: ">1\r\n$1\r\nx\r\n",
+		},
+		{value: &Null{}, resp2: "$-1\r\n", resp3: "_\r\n"},
+		{value: &BulkString{Null: true}, resp2: "$-1\r\n", resp3: "_\r\n"},
+		{value: &Array{Null: true}, resp2: "*-1\r\n", resp3: "_\r\n"},
+		{value: &Boolean{Value: true}, resp2: ":1\r\n", resp3: "#t\r\n"},
+		{value: &Double{Number: 1.5}, resp2: "$3\r\n1.5\r\n", resp3: ",1.5\r\n"},
+		{value: &Double{Number: math.Inf(-1)}, resp2: "$4\r\n-inf\r\n", resp3: ",-inf\r\n"},
+		{
+			value: &BigNumber{Number: new(big.Int).Lsh(big.NewInt(1), 70)},
+			resp2: "$22\r\n1180591620717411303424\r\n",
+			resp3: "(1180591620717411303424\r\n",
+		},
+		{
+			value: &VerbatimString{Format: "txt", Bytes: []byte("hi")},
+			resp2: "$2\r\nhi\r\n",
+			resp3: "=6\r\ntxt:hi\r\n",
+		},
+		{
+			value: &Attribute{
+				Entries: []MapEntry{{Key: &SimpleString{Bytes: []byte("ttl")}, Value: &Integer{Number: 3}}},
+				Value:   &Integer{Number: 7},
+			},
+			resp2: ":7\r\n",
+			resp3: "|1\r\n+ttl\r\n:3\r\n:7\r\n",
+		},
+	}
+
+	for _, tc := range cases {
+		for _, protocol := range []int{RESP2, RESP3} {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.SetProtocol(protocol)
+
+			if err := enc.Write(tc.value); err != nil {
+				t.Fatalf("encoder.Write() returned error: %v", err)
+			}
+
+			want := tc.resp2
+			if protocol == RESP3 {
+				want = tc.resp3
+			}
+
+			if got := buf.String(); got != want {
+				t.Fatalf("unexpected RESP%d output for %#v: got %q, want %q", protocol, tc.value, got, want)
+			}
+
+			// Size counts the RESP2 types in their RESP2 form
+			switch tc.value.(type) {
+			case *BulkString, *Array:
+				continue
+			}
+
+			if protocol == RESP3 && Size(tc.value) != len(want) {
+				t.Fatalf("expected Size of %#v to be %d, got %d", tc.value, len(want), Size(tc.value))
+			}
+		}
+	}
+}
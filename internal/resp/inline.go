@@ -0,0 +1,183 @@
+package resp
+
+// MaxInlineSize is the maximum length of an inline request, like the Redis
+// PROTO_INLINE_MAX_SIZE.
+const MaxInlineSize = 64 * 1024
+
+// ProtocolError is a malformed request. The connection cannot be trusted to
+// be in sync anymore, it has to be closed after the error is replied.
+type ProtocolError struct {
+	Msg string
+}
+
+func (e *ProtocolError) Error() string {
+	return "Protocol error: " + e.Msg
+}
+
+// processInline reads an inline request, a line of space separated
+// arguments as sent by telnet users, first being its first byte. The
+// arguments are returned as an array of bulk strings like a regular
+// request. An empty line gives an empty array.
+func (d *Decoder) processInline(first byte) (*Array, error) {
+	line := []byte{first}
+
+	for first != '\n' {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b == '\n' {
+			break
+		}
+
+		line = append(line, b)
+
+		if len(line) > MaxInlineSize {
+			return nil, &ProtocolError{Msg: "too big inline request"}
+		}
+	}
+
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+
+	args, ok := splitArgs(line)
+	if !ok {
+		return nil, &ProtocolError{Msg: "unbalanced quotes in request"}
+	}
+
+	arr := &Array{Elements: make([]Value, len(args))}
+	for i, arg := range args {
+		arr.Elements[i] = &BulkString{Bytes: arg}
+	}
+
+	return arr, nil
+}
+
+// splitArgs splits line the way redis-cli and the inline protocol do, like
+// sdssplitargs. Arguments are separated by spaces and may be quoted:
+// double quoted arguments support the \n, \r, \t, \b, \a and \xHH escapes,
+// single quoted ones only \'. A closing quote must be followed by a space
+// or the end of the line, otherwise ok is false.
+func splitArgs(line []byte) (args [][]byte, ok bool) {
+	args = [][]byte{}
+	p := 0
+
+	for {
+		for p < len(line) && isSpace(line[p]) {
+			p++
+		}
+
+		if p == len(line) {
+			return args, true
+		}
+
+		arg := []byte{}
+		inDoubleQuotes, inSingleQuotes := false, false
+
+		for done := false; !done; p++ {
+			if p == len(line) {
+				if inDoubleQuotes || inSingleQuotes {
+					return nil, false
+				}
+
+				break
+			}
+
+			c := line[p]
+
+			switch {
+			case inDoubleQuotes:
+				switch {
+				case c == '\\' && p+3 < len(line) && line[p+1] == 'x' && isHex(line[p+2]) && isHex(line[p+3]):
+					arg = append(arg, hexValue(line[p+2])<<4|hexValue(line[p+3]))
+					p += 3
+				case c == '\\' && p+1 < len(line):
+					p++
+					arg = append(arg, unescape(line[p]))
+				case c == '"':
+					if p+1 < len(line) && !isSpace(line[p+1]) {
+						return nil, false
+					}
+
+					done = true
+				default:
+					arg = append(arg, c)
+				}
+			case inSingleQuotes:
+				switch {
+				case c == '\\' && p+1 < len(line) && line[p+1] == '\'':
+					p++
+					arg = append(arg, '\'')
+				case c == '\'':
+					if p+1 < len(line) && !isSpace(line[p+1]) {
+						return nil, false
+					}
+
+					done = true
+				default:
+					arg = append(arg, c)
+				}
+			default:
+				switch {
+				case isSpace(c) || c == 0:
+					done = true
+				case c == '"':
+					inDoubleQuotes = true
+				case c == '\'':
+					inSingleQuotes = true
+				default:
+					arg = append(arg, c)
+				}
+			}
+		}
+
+		args = append(args, arg)
+	}
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	default:
+		return false
+	}
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return c
+	}
+}
@@ -0,0 +1,58 @@
+package resp
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+const (
+	// DefaultMaxBulkLen is the default proto-max-bulk-len, 512mb like Redis
+	DefaultMaxBulkLen = 512 * 1024 * 1024
+	// DefaultQueryBufferLimit is the default client-query-buffer-limit, 1gb
+	// like Redis
+	DefaultQueryBufferLimit = 1024 * 1024 * 1024
+	// MaxMultibulkLen is the maximum number of elements of an aggregate
+	MaxMultibulkLen = 1024 * 1024
+)
+
+// ErrQueryBufferLimit is returned when a request is larger than the
+// client-query-buffer-limit. Redis closes such clients without replying.
+var ErrQueryBufferLimit = errors.New("query buffer limit reached")
+
+// Limits bounds the requests read by a Decoder so that the lengths declared
+// by a client cannot make the server allocate more than configured. It is
+// shared by the decoders of every client, and by the store which bounds the
+// strings it builds with the same proto-max-bulk-len, and may be changed
+// while they run.
+type Limits struct {
+	maxBulkLen       atomic.Int64
+	queryBufferLimit atomic.Int64
+}
+
+func NewLimits() *Limits {
+	l := &Limits{}
+	l.maxBulkLen.Store(DefaultMaxBulkLen)
+	l.queryBufferLimit.Store(DefaultQueryBufferLimit)
+
+	return l
+}
+
+// MaxBulkLen returns the proto-max-bulk-len, the maximum length of a bulk
+// string.
+func (l *Limits) MaxBulkLen() int64 {
+	return l.maxBulkLen.Load()
+}
+
+func (l *Limits) SetMaxBulkLen(n int64) {
+	l.maxBulkLen.Store(n)
+}
+
+// QueryBufferLimit returns the client-query-buffer-limit, the maximum size
+// of the bulk strings of a single request.
+func (l *Limits) QueryBufferLimit() int64 {
+	return l.queryBufferLimit.Load()
+}
+
+func (l *Limits) SetQueryBufferLimit(n int64) {
+	l.queryBufferLimit.Store(n)
+}
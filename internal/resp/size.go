@@ -4,7 +4,8 @@ import (
 	"fmt"
 )
 
-// Size returns the number of bytes the value will occupy when encoded
+// Size returns the number of bytes the value will occupy when encoded, the
+// RESP3 types being counted in their RESP3 form
 func Size(v Value) int {
 	switch v := v.(type) {
 	case *SimpleString:
@@ -41,7 +42,53 @@ func Size(v Value) int {
 			size += Size(elem)
 		}
 		return size
+	case *Map:
+		// %<count>\r\n + keys and values
+		return entriesSize(v.Entries)
+	case *Set:
+		// ~<count>\r\n + elements
+		return elementsSize(v.Elements)
+	case *Push:
+		// ><count>\r\n + elements
+		return elementsSize(v.Elements)
+	case *Attribute:
+		// |<count>\r\n + keys and values + value
+		return entriesSize(v.Entries) + Size(v.Value)
+	case *Null:
+		// _\r\n
+		return 3
+	case *Boolean:
+		// #t\r\n
+		return 4
+	case *Double:
+		// ,<double>\r\n
+		return 1 + len(FormatDouble(v.Number)) + 2
+	case *BigNumber:
+		// (<number>\r\n
+		return 1 + len(v.Number.String()) + 2
+	case *VerbatimString:
+		// =<len>\r\n<format>:<data>\r\n
+		n := len(v.Format) + 1 + len(v.Bytes)
+		return 1 + len(fmt.Sprintf("%d", n)) + 2 + n + 2
 	default:
 		return 0
 	}
 }
+
+func elementsSize(elements []Value) int {
+	size := 1 + len(fmt.Sprintf("%d", len(elements))) + 2
+	for _, elem := range elements {
+		size += Size(elem)
+	}
+
+	return size
+}
+
+func entriesSize(entries []MapEntry) int {
+	size := 1 + len(fmt.Sprintf("%d", len(entries))) + 2
+	for _, entry := range entries {
+		size += Size(entry.Key) + Size(entry.Value)
+	}
+
+	return size
+}
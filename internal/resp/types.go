@@ -2,6 +2,9 @@ package resp
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -71,3 +74,194 @@ func (s *Array) String() string {
 
 	return "[" + strings.Join(result, ",") + "]"
 }
+
+// MapEntry is a key and its value in a Map or in attributes.
+type MapEntry struct {
+	Key   Value
+	Value Value
+}
+
+// Map is the RESP3 map type, it is sent as a flat array of keys and values
+// to RESP2 clients.
+type Map struct {
+	Entries []MapEntry
+}
+
+func (s *Map) isValue() {}
+
+func (s *Map) String() string {
+	result := make([]string, 0, len(s.Entries))
+
+	for _, e := range s.Entries {
+		result = append(result, e.Key.String()+":"+e.Value.String())
+	}
+
+	return "{" + strings.Join(result, ",") + "}"
+}
+
+// Set is the RESP3 set type, an array to RESP2 clients.
+type Set struct {
+	Elements []Value
+}
+
+func (s *Set) isValue() {}
+
+func (s *Set) String() string {
+	return (&Array{Elements: s.Elements}).String()
+}
+
+// Null is the RESP3 null, a null bulk string to RESP2 clients.
+type Null struct{}
+
+func (s *Null) isValue() {}
+
+func (s *Null) String() string {
+	return ""
+}
+
+// Boolean is the RESP3 boolean, the integer 1 or 0 to RESP2 clients.
+type Boolean struct {
+	Value bool
+}
+
+func (s *Boolean) isValue() {}
+
+func (s *Boolean) String() string {
+	return strconv.FormatBool(s.Value)
+}
+
+// Double is the RESP3 double, a bulk string to RESP2 clients.
+type Double struct {
+	Number float64
+}
+
+func (s *Double) isValue() {}
+
+func (s *Double) String() string {
+	return FormatDouble(s.Number)
+}
+
+// FormatDouble formats f the way doubles are sent, with the shortest
+// representation and inf, -inf or nan for the special values.
+func FormatDouble(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+// BigNumber is the RESP3 big number, a bulk string to RESP2 clients.
+type BigNumber struct {
+	Number *big.Int
+}
+
+func (s *BigNumber) isValue() {}
+
+func (s *BigNumber) String() string {
+	return s.Number.String()
+}
+
+// VerbatimString is the RESP3 verbatim string, Format being its three
+// letters type such as txt or mkd. It is a bulk string to RESP2 clients.
+type VerbatimString struct {
+	Format string
+	Bytes  []byte
+}
+
+func (s *VerbatimString) isValue() {}
+
+func (s *VerbatimString) String() string {
+	return string(s.Bytes)
+}
+
+// Push is the RESP3 out of band data such as published messages, an array
+// to RESP2 clients.
+type Push struct {
+	Elements []Value
+}
+
+func (s *Push) isValue() {}
+
+func (s *Push) String() string {
+	return (&Array{Elements: s.Elements}).String()
+}
+
+// Attribute is a RESP3 reply with attributes, auxiliary data sent before
+// the reply itself. RESP2 clients only get the reply.
+type Attribute struct {
+	Entries []MapEntry
+	Value   Value
+}
+
+func (s *Attribute) isValue() {}
+
+func (s *Attribute) String() string {
+	return s.Value.String()
+}
+
+// ToRESP2 returns v with the RESP3 types replaced by their RESP2
+// counterparts, the way they are sent to RESP2 clients.
+func ToRESP2(v Value) Value {
+	switch v := v.(type) {
+	case *Array:
+		if v.Null {
+			return v
+		}
+
+		return &Array{Elements: toRESP2Elements(v.Elements)}
+	case *Map:
+		arr := &Array{Elements: make([]Value, 0, 2*len(v.Entries))}
+		for _, e := range v.Entries {
+			arr.Elements = append(arr.Elements, ToRESP2(e.Key), ToRESP2(e.Value))
+		}
+
+		return arr
+	case *Set:
+		return &Array{Elements: toRESP2Elements(v.Elements)}
+	case *Push:
+		return &Array{Elements: toRESP2Elements(v.Elements)}
+	case *Null:
+		return &BulkString{Null: true}
+	case *Boolean:
+		if v.Value {
+			return &Integer{Number: 1}
+		}
+
+		return &Integer{Number: 0}
+	case *Double:
+		return &BulkString{Bytes: []byte(FormatDouble(v.Number))}
+	case *BigNumber:
+		return &BulkString{Bytes: []byte(v.Number.String())}
+	case *VerbatimString:
+		return &BulkString{Bytes: v.Bytes}
+	case *Attribute:
+		return ToRESP2(v.Value)
+	default:
+		return v
+	}
+}
+
+func toRESP2Elements(elements []Value) []Value {
+	converted := make([]Value, len(elements))
+	for i, el := range elements {
+		converted[i] = ToRESP2(el)
+	}
+
+	return converted
+}
+
+// NewMap returns the map of the alternating keys and values in pairs.
+func NewMap(pairs ...Value) *Map {
+	m := &Map{Entries: make([]MapEntry, 0, len(pairs)/2)}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m.Entries = append(m.Entries, MapEntry{Key: pairs[i], Value: pairs[i+1]})
+	}
+
+	return m
+}
@@ -0,0 +1,533 @@
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/glob"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// FunctionEngine is the engine function libraries are written for, named in
+// the first line of their code.
+const FunctionEngine = "LUA"
+
+// functionLoadTimeout bounds the time the code of a library may run while
+// it registers its functions.
+const functionLoadTimeout = 500 * time.Millisecond
+
+var (
+	ErrLibraryNotFound  = errors.New("ERR Library not found")
+	ErrFunctionNotFound = errors.New("ERR Function not found")
+	errFunctionKilled   = errors.New("ERR Script killed by user with FUNCTION KILL...")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// functionFlags lists the flags accepted by redis.register_function. Only
+// no-writes changes how a function runs, the others are accepted for
+// compatibility.
+var functionFlags = map[string]bool{
+	"no-writes":             true,
+	"allow-oom":             true,
+	"allow-stale":           true,
+	"no-cluster":            true,
+	"allow-cross-slot-keys": true,
+}
+
+// library is a function library loaded with FUNCTION LOAD. Its code runs
+// once in a Lua state of its own, where it registers its functions. The
+// state is kept to call them.
+type library struct {
+	name      string
+	code      string
+	state     *lua.LState
+	functions map[string]*function
+}
+
+type function struct {
+	name        string
+	description string
+	flags       []string
+	noWrites    bool
+	callback    *lua.LFunction
+	library     *library
+}
+
+// LibraryInfo describes a function library for FUNCTION LIST.
+type LibraryInfo struct {
+	Name      string
+	Engine    string
+	Code      string
+	Functions []FunctionInfo
+}
+
+type FunctionInfo struct {
+	Name        string
+	Description string
+	Flags       []string
+}
+
+// FunctionStats is the reply of FUNCTION STATS. Running is nil when no
+// function is being executed.
+type FunctionStats struct {
+	Running        *RunningFunction
+	LibrariesCount int
+	FunctionsCount int
+}
+
+type RunningFunction struct {
+	Name     string
+	Command  []string
+	Duration time.Duration
+}
+
+// parseMetadata splits the code of a library into its name and the Lua code
+// following the "#!lua name=<library>" first line.
+func parseMetadata(code string) (name string, body string, err error) {
+	if !strings.HasPrefix(code, "#!") {
+		return "", "", errors.New("ERR Missing library metadata")
+	}
+
+	line, body, _ := strings.Cut(code, "\n")
+
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return "", "", errors.New("ERR Missing library metadata")
+	}
+
+	if !strings.EqualFold(fields[0], FunctionEngine) {
+		return "", "", fmt.Errorf("ERR Engine '%s' not found", fields[0])
+	}
+
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key != "name" {
+			return "", "", fmt.Errorf("ERR Invalid metadata value given: %s", field)
+		}
+
+		name = value
+	}
+
+	if name == "" {
+		return "", "", errors.New("ERR Library name was not given")
+	}
+
+	if !validName.MatchString(name) {
+		return "", "", errors.New("ERR Library names can only contain letters, numbers, or underscores(_) and must be at least one character long")
+	}
+
+	return name, body, nil
+}
+
+// newLibrary compiles code and runs it to collect the functions it
+// registers. The library is not added to the registry.
+func newLibrary(code string) (*library, error) {
+	name, body, err := parseMetadata(code)
+	if err != nil {
+		return nil, err
+	}
+
+	proto, err := compile(body, "user_function")
+	if err != nil {
+		return nil, fmt.Errorf("ERR Error compiling function: %s", err)
+	}
+
+	lib := &library{
+		name:      name,
+		code:      code,
+		state:     newState(),
+		functions: make(map[string]*function),
+	}
+
+	L := lib.state
+
+	ctx, cancel := context.WithTimeout(context.Background(), functionLoadTimeout)
+	defer cancel()
+
+	// only register_function is available while loading, the dataset
+	// cannot be accessed
+	loader := L.NewTable()
+	L.SetFuncs(loader, map[string]lua.LGFunction{
+		"register_function": lib.registerFunction,
+	})
+
+	L.SetContext(ctx)
+	L.SetGlobal("redis", loader)
+	protectGlobals(L)
+
+	L.Push(L.NewFunctionFromProto(proto))
+
+	if err := L.PCall(0, 0, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.New("ERR FUNCTION LOAD timeout")
+		}
+
+		if msg, ok := errorTableMessage(err); ok {
+			return nil, errors.New(msg)
+		}
+
+		return nil, fmt.Errorf("ERR Error registering functions: %s", err)
+	}
+
+	L.RemoveContext()
+
+	if len(lib.functions) == 0 {
+		return nil, errors.New("ERR No functions registered")
+	}
+
+	return lib, nil
+}
+
+// registerFunction implements redis.register_function, called either with
+// the name and the callback or with a table holding function_name,
+// callback, and optionally flags and description.
+func (lib *library) registerFunction(L *lua.LState) int {
+	fn := &function{library: lib}
+
+	switch L.GetTop() {
+	case 1:
+		args, ok := L.Get(1).(*lua.LTable)
+		if !ok {
+			raiseError(L, "ERR calling redis.register_function with a single argument is only applicable to Lua table (representing named arguments).")
+		}
+
+		args.ForEach(func(key, value lua.LValue) {
+			switch key.String() {
+			case "function_name":
+				name, ok := value.(lua.LString)
+				if !ok {
+					raiseError(L, "ERR function_name argument given to redis.register_function must be a string")
+				}
+
+				fn.name = string(name)
+			case "callback":
+				callback, ok := value.(*lua.LFunction)
+				if !ok {
+					raiseError(L, "ERR callback argument given to redis.register_function must be a function")
+				}
+
+				fn.callback = callback
+			case "description":
+				description, ok := value.(lua.LString)
+				if !ok {
+					raiseError(L, "ERR description argument given to redis.register_function must be a string")
+				}
+
+				fn.description = string(description)
+			case "flags":
+				flags, ok := value.(*lua.LTable)
+				if !ok {
+					raiseError(L, "ERR flags argument to redis.register_function must be a table representing function flags")
+				}
+
+				for i := 1; i <= flags.Len(); i++ {
+					flag := flags.RawGetInt(i).String()
+					if !functionFlags[flag] {
+						raiseError(L, "ERR unknown flag given")
+					}
+
+					fn.flags = append(fn.flags, flag)
+					fn.noWrites = fn.noWrites || flag == "no-writes"
+				}
+			default:
+				raiseError(L, "ERR unknown argument given to redis.register_function")
+			}
+		})
+	case 2:
+		name, ok := L.Get(1).(lua.LString)
+		if !ok {
+			raiseError(L, "ERR first argument to redis.register_function must be a string")
+		}
+
+		callback, ok := L.Get(2).(*lua.LFunction)
+		if !ok {
+			raiseError(L, "ERR second argument to redis.register_function must be a function")
+		}
+
+		fn.name, fn.callback = string(name), callback
+	default:
+		raiseError(L, "ERR wrong number of arguments to redis.register_function")
+	}
+
+	if fn.name == "" || fn.callback == nil {
+		raiseError(L, "ERR redis.register_function must get a function name and a callback argument")
+	}
+
+	if !validName.MatchString(fn.name) {
+		raiseError(L, "ERR Function names can only contain letters, numbers, or underscores(_) and must be at least one character long")
+	}
+
+	if _, ok := lib.functions[fn.name]; ok {
+		raiseError(L, "ERR Function already exists in the library")
+	}
+
+	lib.functions[fn.name] = fn
+
+	return 0
+}
+
+// raiseError raises msg as an error table, it is replied as is.
+func raiseError(L *lua.LState, msg string) {
+	L.Error(replyTable(L, "err", msg), 1)
+}
+
+func errorTableMessage(err error) (string, bool) {
+	apiErr, ok := err.(*lua.ApiError)
+	if !ok {
+		return "", false
+	}
+
+	tb, ok := apiErr.Object.(*lua.LTable)
+	if !ok {
+		return "", false
+	}
+
+	msg, ok := tb.RawGetString("err").(lua.LString)
+
+	return string(msg), ok
+}
+
+// LoadLibrary loads the library in code and returns its name. An existing
+// library with the same name is only replaced when replace is set.
+func (s *Scripts) LoadLibrary(code string, replace bool) (string, error) {
+	lib, err := newLibrary(code)
+	if err != nil {
+		return "", err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	return lib.name, s.addLibraries([]*library{lib}, replace)
+}
+
+// addLibraries registers libs, none of them when any conflicts with the
+// loaded libraries.
+func (s *Scripts) addLibraries(libs []*library, replace bool) error {
+	for _, lib := range libs {
+		if _, ok := s.libraries[lib.name]; ok && !replace {
+			return fmt.Errorf("ERR Library '%s' already exists", lib.name)
+		}
+
+		for name := range lib.functions {
+			if existing, ok := s.functions[name]; ok && existing.library.name != lib.name {
+				return fmt.Errorf("ERR Function %s already exists", name)
+			}
+		}
+	}
+
+	for _, lib := range libs {
+		s.removeLibrary(lib.name)
+
+		s.libraries[lib.name] = lib
+		for name, fn := range lib.functions {
+			s.functions[name] = fn
+		}
+	}
+
+	return nil
+}
+
+func (s *Scripts) removeLibrary(name string) {
+	lib, ok := s.libraries[name]
+	if !ok {
+		return
+	}
+
+	for fn := range lib.functions {
+		delete(s.functions, fn)
+	}
+
+	delete(s.libraries, name)
+}
+
+// DeleteLibrary removes the library name and its functions.
+func (s *Scripts) DeleteLibrary(name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.libraries[name]; !ok {
+		return ErrLibraryNotFound
+	}
+
+	s.removeLibrary(name)
+
+	return nil
+}
+
+// FlushLibraries removes every library.
+func (s *Scripts) FlushLibraries() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.libraries = make(map[string]*library)
+	s.functions = make(map[string]*function)
+}
+
+// RestoreLibraries loads the libraries in codes, as found in a FUNCTION
+// DUMP payload or a snapshot. policy is FLUSH to drop the loaded libraries
+// first, APPEND to fail on a library that already exists or REPLACE to
+// replace it.
+func (s *Scripts) RestoreLibraries(codes []string, policy string) error {
+	libs := make([]*library, 0, len(codes))
+
+	for _, code := range codes {
+		lib, err := newLibrary(code)
+		if err != nil {
+			return err
+		}
+
+		libs = append(libs, lib)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	switch policy {
+	case "FLUSH":
+		libraries, functions := s.libraries, s.functions
+		s.libraries = make(map[string]*library)
+		s.functions = make(map[string]*function)
+
+		if err := s.addLibraries(libs, false); err != nil {
+			s.libraries, s.functions = libraries, functions
+			return err
+		}
+
+		return nil
+	case "REPLACE":
+		return s.addLibraries(libs, true)
+	default:
+		return s.addLibraries(libs, false)
+	}
+}
+
+// Libraries describes the libraries whose name matches the glob-style
+// pattern, every library when pattern is empty, sorted by name.
+func (s *Scripts) Libraries(pattern string) []LibraryInfo {
+	s.Lock()
+	defer s.Unlock()
+
+	infos := []LibraryInfo{}
+
+	for name, lib := range s.libraries {
+		if pattern != "" && !glob.Match(pattern, name) {
+			continue
+		}
+
+		info := LibraryInfo{Name: name, Engine: FunctionEngine, Code: lib.code}
+		for _, fn := range lib.functions {
+			info.Functions = append(info.Functions, FunctionInfo{
+				Name:        fn.name,
+				Description: fn.description,
+				Flags:       fn.flags,
+			})
+		}
+
+		slices.SortFunc(info.Functions, func(a, b FunctionInfo) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		infos = append(infos, info)
+	}
+
+	slices.SortFunc(infos, func(a, b LibraryInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return infos
+}
+
+// LibraryCodes returns the code of every library sorted by library name, to
+// dump or snapshot them.
+func (s *Scripts) LibraryCodes() []string {
+	libs := s.Libraries("")
+
+	codes := make([]string, len(libs))
+	for i, lib := range libs {
+		codes[i] = lib.Code
+	}
+
+	return codes
+}
+
+// FunctionNoWrites reports whether the function name has the no-writes
+// flag, it may then be called with FCALL_RO.
+func (s *Scripts) FunctionNoWrites(name string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	fn, ok := s.functions[name]
+	if !ok {
+		return false, ErrFunctionNotFound
+	}
+
+	return fn.noWrites, nil
+}
+
+// Stats returns the reply of FUNCTION STATS.
+func (s *Scripts) Stats() FunctionStats {
+	s.Lock()
+	defer s.Unlock()
+
+	stats := FunctionStats{
+		LibrariesCount: len(s.libraries),
+		FunctionsCount: len(s.functions),
+	}
+
+	if s.running != nil && s.running.function != "" {
+		stats.Running = &RunningFunction{
+			Name:     s.running.function,
+			Command:  s.running.command,
+			Duration: time.Since(s.running.start),
+		}
+	}
+
+	return stats
+}
+
+// FCall calls the function name with the keys and argv tables, command being
+// the command calling it. Like Eval, it does not take care of atomicity.
+func (s *Scripts) FCall(name string, command, keys, argv []string, call Caller) resp.Value {
+	s.Lock()
+	fn, ok := s.functions[name]
+	s.Unlock()
+
+	if !ok {
+		return &resp.Error{Msg: ErrFunctionNotFound.Error()}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := &execution{scripts: s, run: s.begin(cancel, name, command), call: call}
+	defer s.end()
+
+	L := fn.library.state
+
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+
+	L.G.Global.RawSetString("redis", e.redisLib(L))
+
+	err := L.CallByParam(lua.P{Fn: fn.callback, NRet: 1, Protect: true}, stringsTable(L, keys), stringsTable(L, argv))
+	if err != nil {
+		if s.wasKilled(e.run) {
+			return &resp.Error{Msg: errFunctionKilled.Error()}
+		}
+
+		return scriptError(err, name)
+	}
+
+	ret := L.Get(-1)
+	L.Pop(1)
+
+	return toResp(ret)
+}
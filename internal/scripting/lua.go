@@ -0,0 +1,307 @@
+package scripting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Caller runs a command on behalf of a script, args holding the command
+// name followed by its arguments. It returns the reply of the command and
+// whether the command wrote to the dataset.
+type Caller func(args []string) (reply resp.Value, wrote bool)
+
+// execution is a script being run with the caller executing its commands.
+type execution struct {
+	scripts *Scripts
+	run     *run
+	call    Caller
+}
+
+// Eval adds body to the cache and runs it with the KEYS and ARGV globals set
+// to keys and argv. The script must be run atomically, Eval does not take
+// care of it.
+func (s *Scripts) Eval(body string, keys, argv []string, call Caller) resp.Value {
+	sha, proto, err := s.load(body)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
+
+	return s.execute(sha, proto, keys, argv, call)
+}
+
+// EvalSha runs the cached script sha like Eval does.
+func (s *Scripts) EvalSha(sha string, keys, argv []string, call Caller) resp.Value {
+	proto, ok := s.lookup(sha)
+	if !ok {
+		return &resp.Error{Msg: "NOSCRIPT No matching script. Please use EVAL."}
+	}
+
+	return s.execute(sha, proto, keys, argv, call)
+}
+
+func (s *Scripts) execute(sha string, proto *lua.FunctionProto, keys, argv []string, call Caller) resp.Value {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := &execution{scripts: s, run: s.begin(cancel, "", nil), call: call}
+	defer s.end()
+
+	L := newState()
+	defer L.Close()
+
+	L.SetContext(ctx)
+	L.SetGlobal("KEYS", stringsTable(L, keys))
+	L.SetGlobal("ARGV", stringsTable(L, argv))
+	L.SetGlobal("redis", e.redisLib(L))
+	protectGlobals(L)
+
+	L.Push(L.NewFunctionFromProto(proto))
+
+	if err := L.PCall(0, 1, nil); err != nil {
+		if s.wasKilled(e.run) {
+			return &resp.Error{Msg: errScriptKilled.Error()}
+		}
+
+		return scriptError(err, sha)
+	}
+
+	return toResp(L.Get(-1))
+}
+
+// newState returns a Lua state with only the libraries a script may use,
+// scripts have no access to the file system.
+func newState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	L.SetGlobal("dofile", lua.LNil)
+	L.SetGlobal("loadfile", lua.LNil)
+
+	return L
+}
+
+// protectGlobals makes reading an undefined global or defining a new one an
+// error, scripts have to use local variables.
+func protectGlobals(L *lua.LState) {
+	mt := L.NewTable()
+
+	L.SetField(mt, "__newindex", L.NewFunction(func(L *lua.LState) int {
+		L.RaiseError("Script attempted to create global variable '%s'", L.Get(2).String())
+		return 0
+	}))
+	L.SetField(mt, "__index", L.NewFunction(func(L *lua.LState) int {
+		L.RaiseError("Script attempted to access nonexistent global variable '%s'", L.Get(2).String())
+		return 0
+	}))
+
+	L.SetMetatable(L.G.Global, mt)
+}
+
+func (e *execution) redisLib(L *lua.LState) *lua.LTable {
+	lib := L.NewTable()
+
+	L.SetFuncs(lib, map[string]lua.LGFunction{
+		"call": func(L *lua.LState) int {
+			return e.callCommand(L, true)
+		},
+		"pcall": func(L *lua.LState) int {
+			return e.callCommand(L, false)
+		},
+		"error_reply": func(L *lua.LState) int {
+			L.Push(replyTable(L, "err", L.CheckString(1)))
+			return 1
+		},
+		"status_reply": func(L *lua.LState) int {
+			L.Push(replyTable(L, "ok", L.CheckString(1)))
+			return 1
+		},
+		"sha1hex": func(L *lua.LState) int {
+			L.Push(lua.LString(Sha1Hex(L.CheckString(1))))
+			return 1
+		},
+	})
+
+	return lib
+}
+
+// callCommand implements redis.call and redis.pcall. An error reply is
+// raised as a Lua error by redis.call and returned as an error table by
+// redis.pcall.
+func (e *execution) callCommand(L *lua.LState, raise bool) int {
+	var reply resp.Value
+
+	args, err := commandArgs(L)
+	if err != nil {
+		reply = &resp.Error{Msg: err.Error()}
+	} else {
+		var wrote bool
+
+		reply, wrote = e.call(args)
+		if wrote {
+			e.scripts.markWrite(e.run)
+		}
+	}
+
+	if errReply, ok := reply.(*resp.Error); ok && raise {
+		L.Error(replyTable(L, "err", errReply.Msg), 1)
+		return 0
+	}
+
+	L.Push(toLua(L, reply))
+
+	return 1
+}
+
+func commandArgs(L *lua.LState) ([]string, error) {
+	argc := L.GetTop()
+	if argc == 0 {
+		return nil, fmt.Errorf("ERR Please specify at least one argument for this redis lib call")
+	}
+
+	args := make([]string, argc)
+
+	for i := range args {
+		switch v := L.Get(i + 1).(type) {
+		case lua.LString:
+			args[i] = string(v)
+		case lua.LNumber:
+			args[i] = v.String()
+		default:
+			return nil, fmt.Errorf("ERR Lua redis lib command arguments must be strings or integers")
+		}
+	}
+
+	return args, nil
+}
+
+// scriptError turns an error raised by a script into an error reply. Error
+// tables, as raised by redis.call or returned by redis.error_reply, are
+// replied as is.
+func scriptError(err error, sha string) *resp.Error {
+	msg := err.Error()
+
+	if apiErr, ok := err.(*lua.ApiError); ok {
+		if tb, ok := apiErr.Object.(*lua.LTable); ok {
+			if errMsg, ok := tb.RawGetString("err").(lua.LString); ok {
+				return &resp.Error{Msg: string(errMsg)}
+			}
+		}
+
+		msg = apiErr.Object.String()
+	}
+
+	return &resp.Error{Msg: fmt.Sprintf("ERR %s script: %s", msg, sha)}
+}
+
+func replyTable(L *lua.LState, field, msg string) *lua.LTable {
+	tb := L.NewTable()
+	tb.RawSetString(field, lua.LString(msg))
+
+	return tb
+}
+
+func stringsTable(L *lua.LState, values []string) *lua.LTable {
+	tb := L.CreateTable(len(values), 0)
+	for _, v := range values {
+		tb.Append(lua.LString(v))
+	}
+
+	return tb
+}
+
+// toLua converts a command reply to a Lua value following the Redis rules:
+// integers become numbers, bulk strings become strings, arrays become
+// tables, null replies become false, and status and error replies become
+// tables with a single ok or err field.
+func toLua(L *lua.LState, v resp.Value) lua.LValue {
+	switch v := v.(type) {
+	case *resp.Integer:
+		return lua.LNumber(v.Number)
+	case *resp.BulkString:
+		if v.Null {
+			return lua.LFalse
+		}
+
+		return lua.LString(v.Bytes)
+	case *resp.SimpleString:
+		return replyTable(L, "ok", string(v.Bytes))
+	case *resp.Error:
+		return replyTable(L, "err", v.Msg)
+	case *resp.Array:
+		if v.Null {
+			return lua.LFalse
+		}
+
+		tb := L.CreateTable(len(v.Elements), 0)
+		for _, el := range v.Elements {
+			tb.Append(toLua(L, el))
+		}
+
+		return tb
+	default:
+		// scripts see the RESP2 form of the RESP3 types
+		if converted := resp.ToRESP2(v); converted != v {
+			return toLua(L, converted)
+		}
+
+		return lua.LFalse
+	}
+}
+
+// toResp converts the value returned by a script to a reply, the reverse of
+// toLua: numbers are truncated to integers, true becomes 1, false and nil
+// become a null bulk string and tables are read as arrays up to their first
+// nil, unless they have an err or ok field.
+func toResp(v lua.LValue) resp.Value {
+	switch v := v.(type) {
+	case lua.LNumber:
+		return &resp.Integer{Number: int64(v)}
+	case lua.LString:
+		return &resp.BulkString{Bytes: []byte(v)}
+	case lua.LBool:
+		if v {
+			return &resp.Integer{Number: 1}
+		}
+
+		return &resp.BulkString{Null: true}
+	case *lua.LTable:
+		if msg, ok := v.RawGetString("err").(lua.LString); ok {
+			return &resp.Error{Msg: string(msg)}
+		}
+
+		if msg, ok := v.RawGetString("ok").(lua.LString); ok {
+			return &resp.SimpleString{Bytes: []byte(msg)}
+		}
+
+		arr := &resp.Array{Elements: []resp.Value{}}
+
+		for i := 1; ; i++ {
+			el := v.RawGetInt(i)
+			if el == lua.LNil {
+				break
+			}
+
+			arr.Elements = append(arr.Elements, toResp(el))
+		}
+
+		return arr
+	default:
+		return &resp.BulkString{Null: true}
+	}
+}
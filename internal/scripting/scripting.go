@@ -0,0 +1,235 @@
+package scripting
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// DefaultBusyTime is the time after which a running script makes the server
+// reply BUSY to other clients, like the Redis default of busy-reply-threshold.
+const DefaultBusyTime = 5 * time.Second
+
+var (
+	ErrNotBusy      = errors.New("NOTBUSY No scripts in execution right now.")
+	ErrUnkillable   = errors.New("UNKILLABLE Sorry the script already executed write commands against the dataset. You can either wait the script termination or kill the server in a hard way using the SHUTDOWN NOSAVE command.")
+	errScriptKilled = errors.New("ERR Script killed by user with SCRIPT KILL...")
+)
+
+// run is the script or function being executed.
+type run struct {
+	start  time.Time
+	cancel context.CancelFunc
+	wrote  bool
+	killed bool
+	// function is the name of the running function, empty for a script
+	// run by EVAL
+	function string
+	// command is the command running the function, for FUNCTION STATS
+	command []string
+}
+
+// Scripts is the script cache shared by every client, keyed by the SHA1 of
+// the script bodies, along with the function libraries. It keeps track of
+// the script or function being executed so that other clients can be told
+// the server is busy and kill it.
+type Scripts struct {
+	protos map[string]*lua.FunctionProto
+	// libraries and functions index the loaded function libraries, see
+	// functions.go
+	libraries map[string]*library
+	functions map[string]*function
+	// busyTime is the busy-script-time in milliseconds
+	busyTime atomic.Int64
+	running  *run
+	sync.Mutex
+}
+
+func NewScripts() *Scripts {
+	s := &Scripts{
+		protos:    make(map[string]*lua.FunctionProto),
+		libraries: make(map[string]*library),
+		functions: make(map[string]*function),
+	}
+	s.busyTime.Store(DefaultBusyTime.Milliseconds())
+
+	return s
+}
+
+// Sha1Hex returns the SHA1 digest of body as lowercase hex, the name of a
+// script in the cache.
+func Sha1Hex(body string) string {
+	sum := sha1.Sum([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load compiles body and adds it to the cache. It returns the SHA1 of the
+// script, the script is not compiled again when it is already cached.
+func (s *Scripts) Load(body string) (string, error) {
+	sha, _, err := s.load(body)
+	return sha, err
+}
+
+func (s *Scripts) load(body string) (string, *lua.FunctionProto, error) {
+	sha := Sha1Hex(body)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if proto, ok := s.protos[sha]; ok {
+		return sha, proto, nil
+	}
+
+	proto, err := compile(body, "user_script")
+	if err != nil {
+		return "", nil, errors.New("ERR Error compiling script (new function): " + err.Error())
+	}
+
+	s.protos[sha] = proto
+
+	return sha, proto, nil
+}
+
+func compile(body, name string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(body), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return lua.Compile(chunk, name)
+}
+
+// Exists reports for every SHA1 in shas whether the script is cached.
+func (s *Scripts) Exists(shas []string) []bool {
+	s.Lock()
+	defer s.Unlock()
+
+	exists := make([]bool, len(shas))
+	for i, sha := range shas {
+		_, exists[i] = s.protos[strings.ToLower(sha)]
+	}
+
+	return exists
+}
+
+// Flush empties the cache.
+func (s *Scripts) Flush() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.protos = make(map[string]*lua.FunctionProto)
+}
+
+func (s *Scripts) lookup(sha string) (*lua.FunctionProto, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	proto, ok := s.protos[strings.ToLower(sha)]
+
+	return proto, ok
+}
+
+// BusyTime returns the busy-script-time.
+func (s *Scripts) BusyTime() time.Duration {
+	return time.Duration(s.busyTime.Load()) * time.Millisecond
+}
+
+// SetBusyTime sets the busy-script-time, the time after which a running
+// script makes the server reply BUSY. 0 disables the BUSY replies.
+func (s *Scripts) SetBusyTime(d time.Duration) {
+	s.busyTime.Store(d.Milliseconds())
+}
+
+// Busy reports whether a script has been running for longer than the
+// busy-script-time, in which case clients are only allowed to kill it.
+func (s *Scripts) Busy() bool {
+	busyTime := s.BusyTime()
+
+	s.Lock()
+	defer s.Unlock()
+
+	return s.running != nil && busyTime > 0 && time.Since(s.running.start) >= busyTime
+}
+
+// BusyError returns the error replied to the clients while the server is
+// busy, it tells how to stop the running script or function.
+func (s *Scripts) BusyError() error {
+	s.Lock()
+	defer s.Unlock()
+
+	kill := "SCRIPT KILL"
+	if s.running != nil && s.running.function != "" {
+		kill = "FUNCTION KILL"
+	}
+
+	return fmt.Errorf("BUSY Redis is busy running a script. You can only call %s or SHUTDOWN NOSAVE.", kill)
+}
+
+// KillScript stops the script run by EVAL. A script that already wrote to
+// the dataset cannot be killed since that would break atomicity.
+func (s *Scripts) KillScript() error {
+	return s.kill(false)
+}
+
+// KillFunction stops the running function, like KillScript does for
+// scripts.
+func (s *Scripts) KillFunction() error {
+	return s.kill(true)
+}
+
+func (s *Scripts) kill(function bool) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.running == nil || (s.running.function != "") != function {
+		return ErrNotBusy
+	}
+
+	if s.running.wrote {
+		return ErrUnkillable
+	}
+
+	s.running.killed = true
+	s.running.cancel()
+
+	return nil
+}
+
+func (s *Scripts) begin(cancel context.CancelFunc, function string, command []string) *run {
+	s.Lock()
+	defer s.Unlock()
+
+	s.running = &run{start: time.Now(), cancel: cancel, function: function, command: command}
+
+	return s.running
+}
+
+func (s *Scripts) end() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.running = nil
+}
+
+func (s *Scripts) markWrite(r *run) {
+	s.Lock()
+	defer s.Unlock()
+
+	r.wrote = true
+}
+
+func (s *Scripts) wasKilled(r *run) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return r.killed
+}
@@ -0,0 +1,162 @@
+package scripting
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func TestKillAfterWriteIsRefused(t *testing.T) {
+	s := NewScripts()
+	calling := make(chan struct{})
+	resume := make(chan struct{})
+
+	call := func(args []string) (resp.Value, bool) {
+		close(calling)
+		<-resume
+
+		return &resp.SimpleString{Bytes: []byte("OK")}, true
+	}
+
+	done := make(chan resp.Value)
+	go func() {
+		done <- s.Eval("redis.call('SET', 'foo', 'bar'); return 1", nil, nil, call)
+	}()
+
+	<-calling
+
+	// the write is reported when the call returns
+	if err := s.KillScript(); err != nil {
+		t.Fatalf("expected the script to be killable before its first write, got %v", err)
+	}
+
+	close(resume)
+
+	if out, ok := (<-done).(*resp.Error); !ok || out.Msg != errScriptKilled.Error() {
+		t.Fatalf("expected the script to be killed, got %#v", out)
+	}
+
+	calling = make(chan struct{})
+	resume = make(chan struct{})
+
+	go func() {
+		done <- s.Eval("redis.call('SET', 'foo', 'bar'); redis.call('SET', 'foo', 'baz'); return 1", nil, nil, func(args []string) (resp.Value, bool) {
+			if args[2] == "baz" {
+				close(calling)
+				<-resume
+			}
+
+			return &resp.SimpleString{Bytes: []byte("OK")}, true
+		})
+	}()
+
+	<-calling
+
+	if err := s.KillScript(); !errors.Is(err, ErrUnkillable) {
+		t.Fatalf("expected a script that wrote to be unkillable, got %v", err)
+	}
+
+	close(resume)
+
+	if out, ok := (<-done).(*resp.Integer); !ok || out.Number != 1 {
+		t.Fatalf("expected the script to complete, got %#v", out)
+	}
+}
+
+func TestValueConversions(t *testing.T) {
+	s := NewScripts()
+
+	call := func(args []string) (resp.Value, bool) {
+		switch args[0] {
+		case "int":
+			return &resp.Integer{Number: 7}, false
+		case "null":
+			return &resp.BulkString{Null: true}, false
+		case "status":
+			return &resp.SimpleString{Bytes: []byte("PONG")}, false
+		default:
+			return &resp.Array{Elements: []resp.Value{&resp.BulkString{Bytes: []byte("a")}, &resp.Array{Null: true}}}, false
+		}
+	}
+
+	script := `
+		local arr = redis.call('arr')
+		return {
+			redis.call('int') + 1,
+			tostring(redis.call('null')),
+			redis.call('status')['ok'],
+			arr[1],
+			tostring(arr[2]),
+			true,
+		}`
+
+	out, ok := s.Eval(script, nil, nil, call).(*resp.Array)
+	if !ok || len(out.Elements) != 6 {
+		t.Fatalf("expected 6 elements, got %#v", out)
+	}
+
+	want := []string{":8", "$false", "$PONG", "$a", "$false", ":1"}
+
+	for i, el := range out.Elements {
+		var got string
+
+		switch v := el.(type) {
+		case *resp.Integer:
+			got = ":" + v.String()
+		case *resp.BulkString:
+			got = "$" + string(v.Bytes)
+		}
+
+		if got != want[i] {
+			t.Errorf("element %d: expected %s, got %s", i, want[i], got)
+		}
+	}
+}
+
+func TestKillFunction(t *testing.T) {
+	s := NewScripts()
+
+	if _, err := s.LoadLibrary("#!lua name=lib\nredis.register_function('f', function() redis.call('GET', 'foo'); return 1 end)", false); err != nil {
+		t.Fatal(err)
+	}
+
+	calling := make(chan struct{})
+	resume := make(chan struct{})
+
+	call := func(args []string) (resp.Value, bool) {
+		close(calling)
+		<-resume
+
+		return &resp.BulkString{Null: true}, false
+	}
+
+	done := make(chan resp.Value)
+	go func() {
+		done <- s.FCall("f", []string{"FCALL", "f", "0"}, nil, nil, call)
+	}()
+
+	<-calling
+
+	if stats := s.Stats(); stats.Running == nil || stats.Running.Name != "f" || stats.LibrariesCount != 1 || stats.FunctionsCount != 1 {
+		t.Fatalf("expected the running function in the stats, got %#v", stats)
+	}
+
+	if err := s.KillScript(); !errors.Is(err, ErrNotBusy) {
+		t.Fatalf("expected SCRIPT KILL not to kill a function, got %v", err)
+	}
+
+	if err := s.KillFunction(); err != nil {
+		t.Fatalf("expected the function to be killed, got %v", err)
+	}
+
+	close(resume)
+
+	if out, ok := (<-done).(*resp.Error); !ok || out.Msg != errFunctionKilled.Error() {
+		t.Fatalf("expected the function to be killed, got %#v", out)
+	}
+
+	if stats := s.Stats(); stats.Running != nil {
+		t.Fatalf("expected no running function, got %#v", stats.Running)
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAclRestrictsUser(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	admin := newInMemoryClient(t, srv)
+	t.Cleanup(admin.Close)
+
+	requireSimpleString(t, admin.do("ACL", "SETUSER", "alice", "on", ">secret", "~app:*", "+@read", "+set", "+multi", "+exec", "+acl|whoami"), "OK")
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("AUTH", "alice", "secret"), "OK")
+	requireBulkString(t, client.do("ACL", "WHOAMI"), "alice")
+	requireSimpleString(t, client.do("SET", "app:1", "v"), "OK")
+	requireBulkString(t, client.do("GET", "app:1"), "v")
+
+	if msg := client.doError("GET", "other"); msg != "NOPERM No permissions to access a key" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("INCR", "app:1"); msg != "NOPERM User alice has no permissions to run the 'incr' command" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("ACL", "LIST"); msg != "NOPERM User alice has no permissions to run the 'acl|list' command" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	// a denied command queued in a transaction aborts it
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "app:2", "v"), "QUEUED")
+	client.doError("SET", "other", "v")
+
+	if msg := client.doError("EXEC"); msg != "EXECABORT Transaction discarded because of previous errors." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	// the denials are logged, the most recent first, and the same denial
+	// is counted in a single entry
+	admin.do("HELLO", "3")
+
+	entries := requireArrayLen(t, admin.do("ACL", "LOG"), 3)
+	requireBulkString(t, mapField(t, entries.Elements[0], "object"), "other")
+	requireInteger(t, mapField(t, entries.Elements[0], "count"), 2)
+	requireBulkString(t, mapField(t, entries.Elements[0], "username"), "alice")
+	requireBulkString(t, mapField(t, entries.Elements[1], "reason"), "command")
+	requireBulkString(t, mapField(t, entries.Elements[1], "object"), "acl|list")
+
+	// the clients of a deleted user are disconnected
+	requireInteger(t, admin.do("ACL", "DELUSER", "alice"), 1)
+
+	client.send("PING")
+	if _, err := client.dec.Read(); err == nil {
+		t.Fatalf("expected the client to be disconnected")
+	}
+}
+
+func TestAclFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.acl")
+	if err := os.WriteFile(path, []byte("user alice on nopass ~* +get\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := NewRedisServer(0, false)
+	if err := srv.LoadACLFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireArrayLen(t, client.do("ACL", "USERS"), 2)
+	requireSimpleString(t, client.do("ACL", "SETUSER", "bob", "on", "nopass", "+ping"), "OK")
+	requireSimpleString(t, client.do("ACL", "SAVE"), "OK")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "user alice on nopass ~* resetchannels -@all +get\n" +
+		"user bob on nopass resetchannels -@all +ping\n" +
+		"user default on nopass ~* &* +@all\n"
+	if string(data) != want {
+		t.Fatalf("unexpected aclfile\n got: %s\nwant: %s", data, want)
+	}
+
+	// LOAD drops the users missing from the file
+	if err := os.WriteFile(path, []byte("user default on nopass ~* &* +@all\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	requireSimpleString(t, client.do("ACL", "LOAD"), "OK")
+	requireArrayLen(t, client.do("ACL", "USERS"), 1)
+
+	if msg := client.doError("CONFIG", "SET", "aclfile", "other.acl"); msg == "" {
+		t.Fatalf("expected aclfile to be immutable")
+	}
+}
+
+func TestDeniedWriteIsNotPropagated(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	admin := newInMemoryClient(t, srv)
+	t.Cleanup(admin.Close)
+
+	requireSimpleString(t, admin.do("ACL", "SETUSER", "alice", "on", ">secret", "~app:*", "+set"), "OK")
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("AUTH", "alice", "secret"), "OK")
+
+	if msg := client.doError("SET", "other", "v"); msg != "NOPERM No permissions to access a key" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	replica.expectNothing(admin)
+
+	requireSimpleString(t, client.do("SET", "app:1", "v"), "OK")
+	replica.expect("SET app:1 v")
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+	"github.com/codecrafters-io/redis-starter-go/internal/commands"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// masterAuth holds the credentials a replica authenticates to its master
+// with, set by masteruser and masterauth. No AUTH is sent without a
+// password.
+type masterAuth struct {
+	mu       sync.RWMutex
+	user     string
+	password string
+}
+
+func (m *masterAuth) get() (user, password string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.user, m.password
+}
+
+func (m *masterAuth) setUser(user string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.user = user
+}
+
+func (m *masterAuth) setPassword(password string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.password = password
+}
+
+// isAuthenticated reports whether the session may run commands, which it
+// may as long as the default user is on and needs no password.
+func (s *Session) isAuthenticated() bool {
+	if s.authenticated {
+		return true
+	}
+
+	defaultUser := s.serverCtx.ACL.DefaultUser()
+
+	return defaultUser.Enabled() && defaultUser.NoPass()
+}
+
+// authRequired reports whether the session has to authenticate before
+// running cmd. Only AUTH, HELLO and QUIT are allowed beforehand.
+func (s *Session) authRequired(cmd *commands.Command) bool {
+	if s.isAuthenticated() {
+		return false
+	}
+
+	switch cmd.Name {
+	case commands.AUTH_COMMAND, commands.HELLO_COMMAND, commands.QUIT_COMMAND:
+		return false
+	default:
+		return true
+	}
+}
+
+// authenticate switches the session to the user called username when
+// password is one of its passwords. A failure is logged with the command
+// that tried, AUTH or HELLO.
+func (s *Session) authenticate(cmd *commands.Command, username, password string) bool {
+	user, ok := s.serverCtx.ACL.Authenticate(username, password)
+	if !ok {
+		s.serverCtx.ACL.Log(acl.LogEntry{
+			Reason:     acl.AuthReason,
+			Context:    commands.TopLevelContext,
+			Object:     string(cmd.Name),
+			Username:   username,
+			ClientInfo: fmt.Sprintf("addr=%s user=%s", s.getRemoteAddr(), s.user.Name()),
+		})
+
+		return false
+	}
+
+	s.user = user
+	s.authenticated = true
+
+	return true
+}
+
+// handleAuth serves AUTH [username] password, authenticating the session
+// as username, the default user when omitted.
+func (s *Session) handleAuth(cmd *commands.Command) resp.Value {
+	argsLen := cmd.ArgsLen()
+	if argsLen == 0 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", cmd.Name)}
+	}
+
+	if argsLen > 2 {
+		return &resp.Error{Msg: "ERR syntax error"}
+	}
+
+	username, password := acl.DefaultUser, ""
+	if argsLen == 1 {
+		password, _ = cmd.ArgString(0)
+
+		if s.serverCtx.ACL.DefaultUser().NoPass() {
+			return &resp.Error{Msg: "ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?"}
+		}
+	} else {
+		username, _ = cmd.ArgString(0)
+		password, _ = cmd.ArgString(1)
+	}
+
+	if !s.authenticate(cmd, username, password) {
+		return &resp.Error{Msg: "WRONGPASS invalid username-password pair or user is disabled."}
+	}
+
+	return &resp.SimpleString{Bytes: []byte("OK")}
+}
+
+// handlerContext returns the context cmd is dispatched with. The commands
+// of the master are not checked against the ACL.
+func (s *Session) handlerContext(cmd *commands.Command) *commands.HandlerContext {
+	handlerCtx := &commands.HandlerContext{
+		Cmd:        cmd,
+		RemoteAddr: s.getRemoteAddr(),
+	}
+
+	if !s.isReplicationSession {
+		handlerCtx.User = s.user
+	}
+
+	return handlerCtx
+}
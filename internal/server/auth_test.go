@@ -0,0 +1,100 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestAuthWithoutRequirePass(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("PING"), "PONG")
+
+	if msg := client.doError("AUTH", "secret"); msg != "ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	// the default user has no password, any one is accepted
+	requireSimpleString(t, client.do("AUTH", "default", "anything"), "OK")
+
+	if msg := client.doError("AUTH", "alice", "anything"); msg != "WRONGPASS invalid username-password pair or user is disabled." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("AUTH", "a", "b", "c"); msg != "ERR syntax error" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+}
+
+func TestRequirePass(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	admin := newInMemoryClient(t, srv)
+	t.Cleanup(admin.Close)
+
+	// the clients connected without a password stay authenticated
+	requireSimpleString(t, admin.do("CONFIG", "SET", "requirepass", "secret"), "OK")
+	requireSimpleString(t, admin.do("SET", "k", "v"), "OK")
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	for _, args := range [][]string{{"GET", "k"}, {"PING"}, {"MULTI"}, {"CONFIG", "GET", "requirepass"}} {
+		if msg := client.doError(args...); msg != "NOAUTH Authentication required." {
+			t.Fatalf("expected %v to be refused, got %q", args, msg)
+		}
+	}
+
+	if msg := client.doError("HELLO", "3"); msg != "NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("AUTH", "wrong"); msg != "WRONGPASS invalid username-password pair or user is disabled." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("AUTH", "default", "wrong"); msg != "WRONGPASS invalid username-password pair or user is disabled." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	requireSimpleString(t, client.do("AUTH", "secret"), "OK")
+	requireBulkString(t, client.do("GET", "k"), "v")
+
+	// HELLO authenticates and switches the protocol at once
+	other := newInMemoryClient(t, srv)
+	t.Cleanup(other.Close)
+
+	if msg := other.doError("HELLO", "3", "AUTH", "default", "wrong"); msg != "WRONGPASS invalid username-password pair or user is disabled." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	requireInteger(t, mapField(t, other.do("HELLO", "3", "AUTH", "default", "secret"), "proto"), 3)
+	requireBulkString(t, other.do("GET", "k"), "v")
+
+	// QUIT needs no authentication
+	quitter := newInMemoryClient(t, srv)
+	t.Cleanup(quitter.Close)
+
+	requireSimpleString(t, quitter.do("QUIT"), "OK")
+}
+
+func TestUnauthenticatedWriteIsNotPropagated(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	admin := newInMemoryClient(t, srv)
+	t.Cleanup(admin.Close)
+
+	requireSimpleString(t, admin.do("CONFIG", "SET", "requirepass", "secret"), "OK")
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	if msg := client.doError("SET", "k", "pwned"); msg != "NOAUTH Authentication required." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	replica.expectNothing(admin)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/commands"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// redisVersion is the Redis version the server reports to clients and
+// replicas.
+const redisVersion = "7.2.0"
+
+// handleHello serves HELLO [protover [AUTH username password] [SETNAME
+// clientname]]. It switches the session to protover and replies with the
+// properties of the server and of the connection, in the new protocol. No
+// option is applied when one of them is invalid.
+func (s *Session) handleHello(cmd *commands.Command) resp.Value {
+	argsLen := cmd.ArgsLen()
+	protocol := s.encoder.Protocol()
+	name := s.name
+	authenticate := false
+	var username, password string
+
+	if argsLen > 0 {
+		protover, ok := cmd.ArgInt(0)
+		if !ok {
+			return &resp.Error{Msg: "ERR Protocol version is not an integer or out of range"}
+		}
+
+		if protover < resp.RESP2 || protover > resp.RESP3 {
+			return &resp.Error{Msg: "NOPROTO unsupported protocol version"}
+		}
+
+		protocol = protover
+	}
+
+	for i := 1; i < argsLen; i++ {
+		option, _ := cmd.ArgString(i)
+
+		switch strings.ToUpper(option) {
+		case "AUTH":
+			if i+2 >= argsLen {
+				return &resp.Error{Msg: fmt.Sprintf("ERR Syntax error in HELLO option '%s'", option)}
+			}
+
+			username, _ = cmd.ArgString(i + 1)
+			password, _ = cmd.ArgString(i + 2)
+			authenticate = true
+			i += 2
+		case "SETNAME":
+			if i+1 >= argsLen {
+				return &resp.Error{Msg: fmt.Sprintf("ERR Syntax error in HELLO option '%s'", option)}
+			}
+
+			name, _ = cmd.ArgString(i + 1)
+			i++
+
+			if !validClientName(name) {
+				return &resp.Error{Msg: "ERR Client names cannot contain spaces, newlines or special characters."}
+			}
+		default:
+			return &resp.Error{Msg: fmt.Sprintf("ERR Syntax error in HELLO option '%s'", option)}
+		}
+	}
+
+	if authenticate {
+		if !s.authenticate(cmd, username, password) {
+			return &resp.Error{Msg: "WRONGPASS invalid username-password pair or user is disabled."}
+		}
+	}
+
+	if !s.isAuthenticated() {
+		return &resp.Error{Msg: "NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time"}
+	}
+
+	s.name = name
+
+	s.writeMu.Lock()
+	s.encoder.SetProtocol(protocol)
+	s.writeMu.Unlock()
+
+	role := "master"
+	if s.serverCtx.IsReplica {
+		role = "replica"
+	}
+
+	return resp.NewMap(
+		&resp.BulkString{Bytes: []byte("server")}, &resp.BulkString{Bytes: []byte("redis")},
+		&resp.BulkString{Bytes: []byte("version")}, &resp.BulkString{Bytes: []byte(redisVersion)},
+		&resp.BulkString{Bytes: []byte("proto")}, &resp.Integer{Number: int64(protocol)},
+		&resp.BulkString{Bytes: []byte("id")}, &resp.Integer{Number: s.clientId},
+		&resp.BulkString{Bytes: []byte("mode")}, &resp.BulkString{Bytes: []byte("standalone")},
+		&resp.BulkString{Bytes: []byte("role")}, &resp.BulkString{Bytes: []byte(role)},
+		&resp.BulkString{Bytes: []byte("modules")}, &resp.Array{Elements: []resp.Value{}},
+	)
+}
+
+// validClientName reports whether name only holds printable characters
+// other than spaces, like Redis requires.
+func validClientName(name string) bool {
+	for _, c := range name {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
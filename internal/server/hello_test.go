@@ -0,0 +1,92 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// mapField returns the value of the field name in a RESP3 map.
+func mapField(t *testing.T, v resp.Value, name string) resp.Value {
+	t.Helper()
+
+	m, ok := v.(*resp.Map)
+	if !ok {
+		t.Fatalf("expected a map, got %#v", v)
+	}
+
+	for _, e := range m.Entries {
+		if bs, ok := e.Key.(*resp.BulkString); ok && string(bs.Bytes) == name {
+			return e.Value
+		}
+	}
+
+	t.Fatalf("field %s not found in %#v", name, v)
+	return nil
+}
+
+func TestHelloSwitchesProtocol(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	// RESP2 clients get the reply as a flat array
+	requireArrayLen(t, client.do("HELLO"), 14)
+
+	if msg := client.doError("HELLO", "4"); msg != "NOPROTO unsupported protocol version" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("HELLO", "3", "AUTH", "nobody", "secret"); msg != "WRONGPASS invalid username-password pair or user is disabled." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	// the failed HELLO did not switch the protocol
+	requireArrayLen(t, client.do("CONFIG", "GET", "busy-script-time"), 2)
+
+	out := client.do("HELLO", "3", "SETNAME", "myclient")
+	requireInteger(t, mapField(t, out, "proto"), 3)
+	requireBulkString(t, mapField(t, out, "server"), "redis")
+	requireBulkString(t, mapField(t, out, "role"), "master")
+
+	if _, ok := client.do("GET", "missing").(*resp.Null); !ok {
+		t.Fatal("expected a RESP3 null for a missing key")
+	}
+
+	mapField(t, client.do("CONFIG", "GET", "busy-script-time"), "busy-script-time")
+
+	// the reply to HELLO is sent in the new protocol
+	hello := requireArrayLen(t, client.do("HELLO", "2"), 14)
+	requireInteger(t, hello.Elements[5], 2)
+	requireArrayLen(t, client.do("CONFIG", "GET", "busy-script-time"), 2)
+}
+
+func TestResp3SubscriberGetsPushes(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	subscriber := newInMemoryClient(t, srv)
+	t.Cleanup(subscriber.Close)
+
+	publisher := newInMemoryClient(t, srv)
+	t.Cleanup(publisher.Close)
+
+	subscriber.do("HELLO", "3")
+
+	if push, ok := subscriber.do("SUBSCRIBE", "news").(*resp.Push); !ok || len(push.Elements) != 3 {
+		t.Fatalf("expected a subscribe push, got %#v", push)
+	}
+
+	// RESP3 subscribers are not restricted to the subscription commands
+	requireSimpleString(t, subscriber.do("SET", "foo", "bar"), "OK")
+
+	requireInteger(t, publisher.do("PUBLISH", "news", "hello"), 1)
+
+	push, ok := subscriber.read().(*resp.Push)
+	if !ok || len(push.Elements) != 3 {
+		t.Fatalf("expected a message push, got %#v", push)
+	}
+
+	requireBulkString(t, push.Elements[0], "message")
+	requireBulkString(t, push.Elements[2], "hello")
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"io"
+	"testing"
+)
+
+func TestInlineCommands(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	// inline requests are sent as is, the way telnet users type them
+	client.writer.WriteString("PING\r\n\r\nSET greeting \"hello world\"\r\nGET greeting\r\n")
+	client.writer.Flush()
+
+	requireSimpleString(t, client.read(), "PONG")
+	requireSimpleString(t, client.read(), "OK")
+	requireBulkString(t, client.read(), "hello world")
+
+	// a protocol error is replied before the connection is closed
+	client.writer.WriteString("SET greeting 'unbalanced\r\n")
+	client.writer.Flush()
+
+	if msg := client.readError(); msg != "ERR Protocol error: unbalanced quotes in request" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the connection to be closed, got %v", err)
+	}
+}
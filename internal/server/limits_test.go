@@ -0,0 +1,144 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func TestProtoLimits(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireArrayLen(t, client.do("CONFIG", "GET", "proto-max-bulk-len"), 2)
+
+	if msg := client.doError("CONFIG", "SET", "proto-max-bulk-len", "1k"); msg != "ERR CONFIG SET failed (possibly related to argument 'proto-max-bulk-len') - argument must be between 1048576 and 9223372036854775807 inclusive" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("CONFIG", "SET", "client-query-buffer-limit", "lots"); msg != "ERR CONFIG SET failed (possibly related to argument 'client-query-buffer-limit') - argument must be a memory value" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	requireSimpleString(t, client.do("CONFIG", "SET", "proto-max-bulk-len", "1mb"), "OK")
+	requireBulkString(t, requireArrayLen(t, client.do("CONFIG", "GET", "proto-max-bulk-len"), 2).Elements[1], "1048576")
+
+	// a bulk string longer than proto-max-bulk-len closes the connection
+	client.writer.WriteString("*2\r\n$4\r\nECHO\r\n$1048577\r\n")
+	client.writer.Flush()
+
+	if msg := client.readError(); msg != "ERR Protocol error: invalid bulk length" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the connection to be closed, got %v", err)
+	}
+
+	// so does a multibulk length beyond the maximum
+	client = newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	client.writer.WriteString("*2147483647\r\n")
+	client.writer.Flush()
+
+	if msg := client.readError(); msg != "ERR Protocol error: invalid multibulk length" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the connection to be closed, got %v", err)
+	}
+}
+
+func TestQueryBufferLimit(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("CONFIG", "SET", "client-query-buffer-limit", "1mb"), "OK")
+
+	// the client is closed without a reply once its request outgrows the
+	// limit, the rest of the request is never read
+	client.writer.WriteString("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1048576\r\n")
+	client.writer.Flush()
+
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the connection to be closed, got %v", err)
+	}
+}
+
+func TestOnlyBulkStringRequestsAreRead(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	// a simple string is read as an inline request, bounded in size
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	go func() {
+		client.writer.WriteString("+" + strings.Repeat("a", resp.MaxInlineSize+1))
+		client.writer.Flush()
+	}()
+
+	if msg := client.readError(); msg != "ERR Protocol error: too big inline request" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the connection to be closed, got %v", err)
+	}
+
+	// the elements of a request cannot be aggregates
+	client = newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	client.writer.WriteString("*1\r\n*1\r\n*1\r\n")
+	client.writer.Flush()
+
+	if msg := client.readError(); msg != "ERR Protocol error: expected '$', got '*'" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the connection to be closed, got %v", err)
+	}
+}
+
+func TestProtoMaxBulkLenBoundsBuiltStrings(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("CONFIG", "SET", "proto-max-bulk-len", "1mb"), "OK")
+
+	// the last byte and the last bit within the limit are addressable
+	requireInteger(t, client.do("SETRANGE", "s", "1048575", "x"), 1048576)
+	requireInteger(t, client.do("SETBIT", "b", "8388607", "1"), 0)
+
+	if msg := client.doError("SETRANGE", "s", "1048576", "x"); msg != "ERR string exceeds maximum allowed size (proto-max-bulk-len)" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	for _, args := range [][]string{
+		{"SETBIT", "b", "8388608", "1"},
+		{"GETBIT", "b", "8388608"},
+		{"BITFIELD", "b", "SET", "u8", "8388601", "1"},
+	} {
+		if msg := client.doError(args...); msg != "ERR bit offset is not an integer or out of range" {
+			t.Fatalf("%v: unexpected error %q", args, msg)
+		}
+	}
+
+	requireSimpleString(t, client.do("SET", "a", strings.Repeat("a", 600)), "OK")
+	requireSimpleString(t, client.do("SET", "c", strings.Repeat("c", 600)), "OK")
+
+	if msg := client.doError("LCS", "a", "c"); msg != "ERR Insufficient memory, transient memory for LCS exceeds proto-max-bulk-len" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+}
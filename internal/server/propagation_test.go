@@ -0,0 +1,423 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// testReplica is a replica registered with a server, it receives the
+// replication stream.
+type testReplica struct {
+	t        *testing.T
+	received chan string
+	// held stops the replica from reading past the next command until
+	// resume is called
+	held    atomic.Bool
+	resumed chan struct{}
+}
+
+func newTestReplica(t *testing.T, srv *RedisServer) *testReplica {
+	t.Helper()
+
+	replicaConn, masterConn := net.Pipe()
+	t.Cleanup(func() { replicaConn.Close() })
+
+	if err := srv.replicasRegistry.AddReplica(masterConn.RemoteAddr().String(), 6380); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.replicasRegistry.AddReplicaConnection(masterConn); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &testReplica{t: t, received: make(chan string, 64), resumed: make(chan struct{})}
+
+	go func() {
+		dec := resp.NewDecoder(bufio.NewReader(replicaConn))
+
+		for {
+			v, err := dec.Read()
+			if err != nil {
+				close(r.received)
+				return
+			}
+
+			args := []string{}
+			for _, e := range v.(*resp.Array).Elements {
+				args = append(args, e.String())
+			}
+
+			r.received <- strings.Join(args, " ")
+
+			if r.held.Load() {
+				<-r.resumed
+			}
+		}
+	}()
+
+	return r
+}
+
+// hold makes the replica stop reading once it received the next command,
+// the writes of the master then block until resume is called.
+func (r *testReplica) hold() {
+	r.held.Store(true)
+}
+
+func (r *testReplica) resume() {
+	r.held.Store(false)
+	close(r.resumed)
+}
+
+// expect checks the commands received next, in order.
+func (r *testReplica) expect(want ...string) {
+	r.t.Helper()
+
+	for _, w := range want {
+		select {
+		case got := <-r.received:
+			if got != w {
+				r.t.Fatalf("expected the replica to receive %q, got %q", w, got)
+			}
+		case <-time.After(time.Second):
+			r.t.Fatalf("expected the replica to receive %q, got nothing", w)
+		}
+	}
+}
+
+// expectNothing checks that nothing was propagated before the write client
+// runs now, which is received first otherwise.
+func (r *testReplica) expectNothing(client *testClient) {
+	r.t.Helper()
+
+	requireSimpleString(r.t, client.do("SET", "sentinel", "1"), "OK")
+	r.expect("SET sentinel 1")
+}
+
+func TestTransactionsArePropagatedOnExec(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	// nothing is propagated before EXEC
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "k1", "v"), "QUEUED")
+	requireSimpleString(t, client.do("INCR", "k1"), "QUEUED")
+	requireSimpleString(t, client.do("GET", "k1"), "QUEUED")
+
+	// the failed INCR is left out, the read too
+	requireArrayLen(t, client.do("EXEC"), 3)
+	replica.expect("MULTI", "SET k1 v", "EXEC")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "k2", "v"), "QUEUED")
+	requireSimpleString(t, client.do("DISCARD"), "OK")
+	replica.expectNothing(client)
+
+	// a transaction cancelled by a watched key runs nothing
+	other := newInMemoryClient(t, srv)
+	t.Cleanup(other.Close)
+
+	requireSimpleString(t, client.do("WATCH", "k3"), "OK")
+	requireSimpleString(t, other.do("SET", "k3", "v"), "OK")
+	replica.expect("SET k3 v")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "k3", "w"), "QUEUED")
+	requireNullArray(t, client.do("EXEC"))
+	replica.expectNothing(client)
+
+	// a transaction with no write sends no empty block
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("GET", "k3"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 1)
+	replica.expectNothing(client)
+}
+
+func TestScriptInTransactionIsPropagatedWithIt(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "k", "1"), "QUEUED")
+	requireSimpleString(t, client.do("EVAL", "return redis.call('set',KEYS[1],'2')", "1", "k"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 2)
+
+	// the writes of the script are part of the block of the transaction
+	replica.expect("MULTI", "SET k 1", "SET k 2", "EXEC")
+	replica.expectNothing(client)
+}
+
+func TestFunctionInTransactionIsPropagatedWithIt(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	code := "#!lua name=lib\nredis.register_function('f', function() return 1 end)"
+
+	requireBulkString(t, client.do("FUNCTION", "LOAD", code), "lib")
+	replica.expect("FUNCTION LOAD " + code)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "k", "1"), "QUEUED")
+	requireSimpleString(t, client.do("FUNCTION", "DELETE", "lib"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 2)
+
+	replica.expect("MULTI", "SET k 1", "FUNCTION DELETE lib", "EXEC")
+}
+
+func TestTransactionsAreReplicatedBeforeOtherCommandsRun(t *testing.T) {
+	for _, run := range []func(c *testClient){
+		func(c *testClient) {
+			c.do("MULTI")
+			c.do("SET", "k", "block")
+			c.send("EXEC")
+		},
+		func(c *testClient) {
+			c.send("EVAL", "redis.call('set',KEYS[1],'block')", "1", "k")
+		},
+	} {
+		srv := NewRedisServer(0, false)
+		replica := newTestReplica(t, srv)
+
+		client := newInMemoryClient(t, srv)
+		t.Cleanup(client.Close)
+
+		other := newInMemoryClient(t, srv)
+		t.Cleanup(other.Close)
+
+		// the block is being replicated until the replica resumes, a write
+		// running meanwhile would reach replicas before it
+		replica.hold()
+
+		run(client)
+		replica.expect("MULTI")
+
+		other.send("GET", "k")
+
+		replied := make(chan resp.Value)
+		go func() { replied <- other.read() }()
+
+		select {
+		case v := <-replied:
+			t.Fatalf("expected the block to be replicated before another command runs, got %s", describe(v))
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		replica.resume()
+		replica.expect("SET k block", "EXEC")
+
+		requireBulkString(t, <-replied, "block")
+		client.read()
+	}
+}
+
+func TestBlpopIsPropagatedAsLpop(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	reader := newInMemoryClient(t, srv)
+	t.Cleanup(reader.Close)
+
+	requireInteger(t, client.do("RPUSH", "l", "a"), 1)
+	requireArrayLen(t, reader.do("BLPOP", "l", "0"), 2)
+	replica.expect("RPUSH l a", "LPOP l")
+
+	// the element handed to a blocked client is popped after the push
+	reader.send("BLPOP", "l", "0")
+	time.Sleep(20 * time.Millisecond)
+
+	requireInteger(t, client.do("RPUSH", "l", "b", "c"), 2)
+	requireArrayLen(t, reader.read(), 2)
+	replica.expect("RPUSH l b c", "LPOP l")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("BLPOP", "l", "0"), "QUEUED")
+	requireSimpleString(t, client.do("BLPOP", "l", "0"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 2)
+	replica.expect("MULTI", "LPOP l", "EXEC")
+
+	// a timeout pops nothing
+	requireNullArray(t, client.do("BLPOP", "l", "0.01"))
+	replica.expectNothing(client)
+}
+
+func TestRelativeWritesArePropagatedAsAbsolute(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireBulkString(t, client.do("INCRBYFLOAT", "f", "10.5"), "10.5")
+	replica.expect("SET f 10.5 KEEPTTL")
+
+	requireSimpleString(t, client.do("SET", "k", "v"), "OK")
+	replica.expect("SET k v")
+
+	before := time.Now().Add(10 * time.Second).UnixMilli()
+	requireBulkString(t, client.do("GETEX", "k", "EX", "10"), "v")
+	after := time.Now().Add(10 * time.Second).UnixMilli()
+
+	got := <-replica.received
+	at, err := strconv.ParseInt(strings.TrimPrefix(got, "PEXPIREAT k "), 10, 64)
+	if err != nil || at < before || at > after {
+		t.Fatalf("expected the replica to receive PEXPIREAT k with the expiry, got %q", got)
+	}
+
+	requireBulkString(t, client.do("GETEX", "k", "PERSIST"), "v")
+	replica.expect("PERSIST k")
+
+	// a plain GETEX changes nothing
+	requireBulkString(t, client.do("GETEX", "k"), "v")
+	replica.expectNothing(client)
+}
+
+func TestFailedWritesAreNotPropagated(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("SET", "k", "v"), "OK")
+	replica.expect("SET k v")
+
+	if msg := client.doError("INCR", "k"); msg != "ERR value is not an integer or out of range" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	replica.expectNothing(client)
+}
+
+func TestAbortedTransactionIsNotPropagated(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "k", "v"), "QUEUED")
+
+	// the malformed write is rejected at queue time
+	if msg := client.doError("SET", "k"); msg != "ERR wrong number of arguments for SET command" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	if msg := client.doError("EXEC"); msg != "EXECABORT Transaction discarded because of previous errors." {
+		t.Fatalf("unexpected error %q", msg)
+	}
+
+	replica.expectNothing(client)
+}
+
+// describe renders a reply as a string to compare replies.
+func describe(v resp.Value) string {
+	switch v := v.(type) {
+	case *resp.Array:
+		parts := make([]string, len(v.Elements))
+		for i, e := range v.Elements {
+			parts[i] = describe(e)
+		}
+
+		return "[" + strings.Join(parts, " ") + "]"
+	case *resp.Integer:
+		return strconv.FormatInt(v.Number, 10)
+	default:
+		return v.String()
+	}
+}
+
+func TestXreadgroupPropagatesItsEffects(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	replica := newTestReplica(t, srv)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	reader := newInMemoryClient(t, srv)
+	t.Cleanup(reader.Close)
+
+	requireSimpleString(t, client.do("XGROUP", "CREATE", "s", "g", "$", "MKSTREAM"), "OK")
+
+	// the read blocks until an entry is added, reading again on a replica
+	// would find nothing to deliver
+	reader.send("XREADGROUP", "GROUP", "g", "alice", "BLOCK", "0", "STREAMS", "s", ">")
+
+	for deadline := time.Now().Add(time.Second); ; {
+		if arr, ok := client.do("XINFO", "CONSUMERS", "s", "g").(*resp.Array); ok && len(arr.Elements) == 1 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the reader to block")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	requireBulkString(t, client.do("XADD", "s", "1-1", "f", "v"), "1-1")
+	requireArrayLen(t, reader.read(), 1)
+
+	// the pending entry is delivered a second time
+	requireArrayLen(t, reader.do("XREADGROUP", "GROUP", "g", "alice", "STREAMS", "s", "0"), 1)
+
+	// the effects applied on another server give the same group
+	mirrorSrv := NewRedisServer(0, false)
+	mirror := newInMemoryClient(t, mirrorSrv)
+	t.Cleanup(mirror.Close)
+
+	want := []string{
+		"XGROUP CREATE s g $ MKSTREAM",
+		"XADD s 1-1 f v",
+		"XGROUP CREATECONSUMER s g alice",
+		"XCLAIM s g alice 0 1-1 TIME",
+		"XGROUP SETID s g 1-1 ENTRIESREAD 1",
+		"XCLAIM s g alice 0 1-1 TIME",
+	}
+
+	for _, w := range want {
+		select {
+		case got := <-replica.received:
+			if !strings.HasPrefix(got, w) {
+				t.Fatalf("expected the replica to receive %q, got %q", w, got)
+			}
+
+			if v, isErr := mirror.do(strings.Fields(got)...).(*resp.Error); isErr {
+				t.Fatalf("applying %q: %s", got, v.Msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected the replica to receive %q, got nothing", w)
+		}
+	}
+
+	for _, args := range [][]string{{"XINFO", "GROUPS", "s"}, {"XPENDING", "s", "g"}} {
+		if got, want := describe(mirror.do(args...)), describe(client.do(args...)); got != want {
+			t.Fatalf("%v differs on the replica\n got: %s\nwant: %s", args, got, want)
+		}
+	}
+
+	// the delivery count, left out of the replies above with the idle time
+	for _, c := range []*testClient{client, mirror} {
+		entry := requireArrayLen(t, c.do("XPENDING", "s", "g", "-", "+", "10"), 1).Elements[0].(*resp.Array)
+		requireInteger(t, entry.Elements[3], 2)
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/commands"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func isSubscriptionCommand(name commands.Name) bool {
+	switch name {
+	case commands.SUBSCRIBE_COMMAND, commands.UNSUBSCRIBE_COMMAND,
+		commands.PSUBSCRIBE_COMMAND, commands.PUNSUBSCRIBE_COMMAND,
+		commands.SSUBSCRIBE_COMMAND, commands.SUNSUBSCRIBE_COMMAND:
+		return true
+	default:
+		return false
+	}
+}
+
+// isSubscriber reports whether the session is in subscriber mode, that is
+// subscribed to at least one channel, pattern or shard channel.
+func (s *Session) isSubscriber() bool {
+	return s.serverCtx.PubSub.IsSubscribed(s)
+}
+
+// executeSubscriberCommand runs cmd in subscriber mode, where only the
+// subscription commands, PING and QUIT are accepted.
+func (s *Session) executeSubscriberCommand(cmd *commands.Command) resp.Value {
+	if isSubscriptionCommand(cmd.Name) {
+		return s.handleSubscription(cmd)
+	}
+
+	if cmd.Name == commands.PING_COMMAND {
+		return s.handleSubscriberPing(cmd)
+	}
+
+	return &resp.Error{Msg: fmt.Sprintf(
+		"ERR Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT are allowed in this context",
+		strings.ToLower(string(cmd.Name)),
+	)}
+}
+
+// handleSubscriberPing answers PING in subscriber mode, where the reply is
+// an array holding "pong" and the optional message instead of +PONG.
+func (s *Session) handleSubscriberPing(cmd *commands.Command) resp.Value {
+	if cmd.ArgsLen() > 1 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", cmd.Name)}
+	}
+
+	message, _ := cmd.ArgBytes(0)
+
+	return &resp.Array{
+		Elements: []resp.Value{
+			&resp.BulkString{Bytes: []byte("pong")},
+			&resp.BulkString{Bytes: message},
+		},
+	}
+}
+
+// handleSubscription serves SUBSCRIBE, UNSUBSCRIBE, PSUBSCRIBE,
+// PUNSUBSCRIBE, SSUBSCRIBE and SUNSUBSCRIBE. Every channel or pattern gets
+// its own confirmation, so the replies are written here and nil is
+// returned.
+func (s *Session) handleSubscription(cmd *commands.Command) resp.Value {
+	ps := s.serverCtx.PubSub
+	argsLen := cmd.ArgsLen()
+
+	isSubscribe := cmd.Name == commands.SUBSCRIBE_COMMAND ||
+		cmd.Name == commands.PSUBSCRIBE_COMMAND ||
+		cmd.Name == commands.SSUBSCRIBE_COMMAND
+
+	if argsLen == 0 && isSubscribe {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", cmd.Name)}
+	}
+
+	names := make([]string, argsLen)
+	for i := range names {
+		names[i], _ = cmd.ArgString(i)
+	}
+
+	// hold the write lock so that no message for a new subscription is
+	// pushed before its confirmation
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	kind := strings.ToLower(string(cmd.Name))
+
+	switch cmd.Name {
+	case commands.SUBSCRIBE_COMMAND:
+		for _, channel := range names {
+			s.encoder.Write(subscriptionReply(kind, channel, ps.Subscribe(s, channel)))
+		}
+	case commands.PSUBSCRIBE_COMMAND:
+		for _, pattern := range names {
+			s.encoder.Write(subscriptionReply(kind, pattern, ps.PSubscribe(s, pattern)))
+		}
+	case commands.UNSUBSCRIBE_COMMAND:
+		if argsLen == 0 {
+			names = ps.Channels(s)
+		}
+
+		for _, channel := range names {
+			s.encoder.Write(subscriptionReply(kind, channel, ps.Unsubscribe(s, channel)))
+		}
+	case commands.PUNSUBSCRIBE_COMMAND:
+		if argsLen == 0 {
+			names = ps.Patterns(s)
+		}
+
+		for _, pattern := range names {
+			s.encoder.Write(subscriptionReply(kind, pattern, ps.PUnsubscribe(s, pattern)))
+		}
+	case commands.SSUBSCRIBE_COMMAND:
+		for _, channel := range names {
+			s.encoder.Write(subscriptionReply(kind, channel, ps.SSubscribe(s, channel)))
+		}
+	case commands.SUNSUBSCRIBE_COMMAND:
+		if argsLen == 0 {
+			names = ps.ShardChannels(s)
+		}
+
+		for _, channel := range names {
+			s.encoder.Write(subscriptionReply(kind, channel, ps.SUnsubscribe(s, channel)))
+		}
+	}
+
+	// unsubscribing from everything without any subscription still gets a
+	// confirmation, with a null channel
+	if len(names) == 0 {
+		count := ps.Count(s)
+		if cmd.Name == commands.SUNSUBSCRIBE_COMMAND {
+			count = ps.ShardCount(s)
+		}
+
+		s.encoder.Write(&resp.Push{
+			Elements: []resp.Value{
+				&resp.BulkString{Bytes: []byte(kind)},
+				&resp.BulkString{Null: true},
+				&resp.Integer{Number: int64(count)},
+			},
+		})
+	}
+
+	s.writer.Flush()
+
+	return nil
+}
+
+func subscriptionReply(kind, name string, count int) *resp.Push {
+	return &resp.Push{
+		Elements: []resp.Value{
+			&resp.BulkString{Bytes: []byte(kind)},
+			&resp.BulkString{Bytes: []byte(name)},
+			&resp.Integer{Number: int64(count)},
+		},
+	}
+}
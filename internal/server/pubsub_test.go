@@ -0,0 +1,200 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+// read returns the next value sent by the server, a reply or a push.
+func (c *testClient) read() resp.Value {
+	c.t.Helper()
+
+	v, err := c.dec.Read()
+	if err != nil {
+		c.t.Fatalf("read: %v", err)
+	}
+
+	return v
+}
+
+// doError sends a command expecting an error reply and returns its
+// message.
+func (c *testClient) doError(parts ...string) string {
+	c.t.Helper()
+
+	c.send(parts...)
+
+	return c.readError()
+}
+
+// readError reads an error reply and returns its message.
+func (c *testClient) readError() string {
+	c.t.Helper()
+
+	v := c.read()
+
+	errReply, ok := v.(*resp.Error)
+	if !ok {
+		c.t.Fatalf("expected an error reply, got %#v", v)
+	}
+
+	return errReply.Msg
+}
+
+func requirePush(t *testing.T, v resp.Value, expected ...any) {
+	t.Helper()
+
+	arr := requireArrayLen(t, v, len(expected))
+
+	for i, want := range expected {
+		switch want := want.(type) {
+		case string:
+			requireBulkString(t, arr.Elements[i], want)
+		case int:
+			n, ok := arr.Elements[i].(*resp.Integer)
+			if !ok || n.Number != int64(want) {
+				t.Fatalf("expected element %d to be %d, got %#v", i, want, arr.Elements[i])
+			}
+		case nil:
+			bs, ok := arr.Elements[i].(*resp.BulkString)
+			if !ok || !bs.Null {
+				t.Fatalf("expected element %d to be a null bulk string, got %#v", i, arr.Elements[i])
+			}
+		}
+	}
+}
+
+func TestSubscribeAndPublish(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	subscriber := newInMemoryClient(t, srv)
+	t.Cleanup(subscriber.Close)
+
+	publisher := newInMemoryClient(t, srv)
+	t.Cleanup(publisher.Close)
+
+	requirePush(t, subscriber.do("SUBSCRIBE", "news", "sports"), "subscribe", "news", 1)
+	requirePush(t, subscriber.read(), "subscribe", "sports", 2)
+	requirePush(t, subscriber.do("PSUBSCRIBE", "n*"), "psubscribe", "n*", 3)
+
+	requireInteger(t, publisher.do("PUBLISH", "news", "hello"), 2)
+	requirePush(t, subscriber.read(), "message", "news", "hello")
+	requirePush(t, subscriber.read(), "pmessage", "n*", "news", "hello")
+
+	requireInteger(t, publisher.do("PUBLISH", "weather", "sunny"), 0)
+
+	requirePush(t, subscriber.do("UNSUBSCRIBE"), "unsubscribe", "news", 2)
+	requirePush(t, subscriber.read(), "unsubscribe", "sports", 1)
+	requirePush(t, subscriber.do("PUNSUBSCRIBE", "n*"), "punsubscribe", "n*", 0)
+
+	// out of subscriber mode regular commands work again
+	requireSimpleString(t, subscriber.do("SET", "foo", "bar"), "OK")
+	requirePush(t, subscriber.do("UNSUBSCRIBE"), "unsubscribe", nil, 0)
+}
+
+func TestShardSubscribeAndPublish(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	subscriber := newInMemoryClient(t, srv)
+	t.Cleanup(subscriber.Close)
+
+	publisher := newInMemoryClient(t, srv)
+	t.Cleanup(publisher.Close)
+
+	requirePush(t, subscriber.do("SSUBSCRIBE", "{user1}.a", "{user1}.b"), "ssubscribe", "{user1}.a", 1)
+	requirePush(t, subscriber.read(), "ssubscribe", "{user1}.b", 2)
+
+	requireInteger(t, publisher.do("PUBLISH", "{user1}.a", "ignored"), 0)
+	requireInteger(t, publisher.do("SPUBLISH", "{user1}.a", "hello"), 1)
+	requirePush(t, subscriber.read(), "smessage", "{user1}.a", "hello")
+
+	if got := subscriber.doError("GET", "foo"); !strings.HasPrefix(got, "ERR Can't execute 'get'") {
+		t.Fatalf("expected subscriber mode error, got %q", got)
+	}
+
+	requirePush(t, subscriber.do("SUNSUBSCRIBE"), "sunsubscribe", "{user1}.a", 1)
+	requirePush(t, subscriber.read(), "sunsubscribe", "{user1}.b", 0)
+	requireSimpleString(t, subscriber.do("SET", "foo", "bar"), "OK")
+}
+
+func TestSubscriberModeRestrictsCommands(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requirePush(t, client.do("SUBSCRIBE", "news"), "subscribe", "news", 1)
+
+	if got := client.doError("GET", "foo"); got != "ERR Can't execute 'get': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT are allowed in this context" {
+		t.Fatalf("expected subscriber mode error, got %q", got)
+	}
+
+	requirePush(t, client.do("PING"), "pong", "")
+	requirePush(t, client.do("PING", "hi"), "pong", "hi")
+	requireSimpleString(t, client.do("QUIT"), "OK")
+
+	if _, err := client.dec.Read(); err == nil {
+		t.Fatal("expected the connection to be closed after QUIT")
+	}
+
+	publisher := newInMemoryClient(t, srv)
+	t.Cleanup(publisher.Close)
+
+	requireInteger(t, publisher.do("PUBLISH", "news", "hello"), 0)
+}
+
+func TestSubscribeNotAllowedInTransaction(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+
+	if got := client.doError("SUBSCRIBE", "news"); got != "ERR Command not allowed inside a transaction" {
+		t.Fatalf("expected transaction error, got %q", got)
+	}
+}
+
+func requireInteger(t *testing.T, v resp.Value, expected int64) {
+	t.Helper()
+
+	n, ok := v.(*resp.Integer)
+	if !ok || n.Number != expected {
+		t.Fatalf("expected Integer %d, got %#v", expected, v)
+	}
+}
+
+func TestKeyspaceNotifications(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	subscriber := newInMemoryClient(t, srv)
+	t.Cleanup(subscriber.Close)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requirePush(t, subscriber.do("PSUBSCRIBE", "__key*@0__:*"), "psubscribe", "__key*@0__:*", 1)
+
+	// nothing is published until the classes are configured
+	requireSimpleString(t, client.do("SET", "foo", "bar"), "OK")
+	requireSimpleString(t, client.do("CONFIG", "SET", "notify-keyspace-events", "KEA"), "OK")
+
+	reply := requireArrayLen(t, client.do("CONFIG", "GET", "notify-keyspace-events"), 2)
+	requireBulkString(t, reply.Elements[1], "AKE")
+
+	requireSimpleString(t, client.do("SET", "foo", "baz", "PX", "1"), "OK")
+	requirePush(t, subscriber.read(), "pmessage", "__key*@0__:*", "__keyspace@0__:foo", "set")
+	requirePush(t, subscriber.read(), "pmessage", "__key*@0__:*", "__keyevent@0__:set", "foo")
+	requirePush(t, subscriber.read(), "pmessage", "__key*@0__:*", "__keyspace@0__:foo", "expire")
+	requirePush(t, subscriber.read(), "pmessage", "__key*@0__:*", "__keyevent@0__:expire", "foo")
+
+	time.Sleep(5 * time.Millisecond)
+	srv.store.ActiveExpireCycle()
+
+	requirePush(t, subscriber.read(), "pmessage", "__key*@0__:*", "__keyspace@0__:foo", "expired")
+	requirePush(t, subscriber.read(), "pmessage", "__key*@0__:*", "__keyevent@0__:expired", "foo")
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func TestConnectToMasterReportsErrorReplies(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	// the master refuses the handshake
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		dec := resp.NewDecoder(bufio.NewReader(conn))
+		if _, err := dec.Read(); err != nil {
+			return
+		}
+
+		conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+
+	srv := NewRedisServer(0, true)
+	err = srv.ConnectToMaster(host+" "+port, 6380)
+
+	var serverErr *resp.ServerError
+	if !errors.As(err, &serverErr) || serverErr.Code() != "NOAUTH" {
+		t.Fatalf("expected the error reply of the master, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "PING") {
+		t.Fatalf("expected the error to name the failed step, got %v", err)
+	}
+}
+
+func TestConnectToMasterAuthenticates(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	requests := make(chan string, 4)
+
+	// the master refuses the PING until the replica authenticates, then
+	// stops the handshake at REPLCONF
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		dec := resp.NewDecoder(bufio.NewReader(conn))
+		replies := []string{
+			"-NOAUTH Authentication required.\r\n",
+			"+OK\r\n",
+			"-ERR stop here\r\n",
+		}
+
+		for _, reply := range replies {
+			v, err := dec.Read()
+			if err != nil {
+				return
+			}
+
+			args := []string{}
+			for _, e := range v.(*resp.Array).Elements {
+				args = append(args, e.String())
+			}
+			requests <- strings.Join(args, " ")
+
+			conn.Write([]byte(reply))
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+
+	srv := NewRedisServer(0, true)
+	if err := srv.SetConfig("masterauth", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = srv.ConnectToMaster(host+" "+port, 6380)
+	if err == nil || !strings.Contains(err.Error(), "REPLCONF listening-port") {
+		t.Fatalf("expected the handshake to get past AUTH, got %v", err)
+	}
+
+	for _, want := range []string{"PING", "AUTH secret", "REPLCONF listening-port 6380"} {
+		if got := <-requests; got != want {
+			t.Fatalf("expected the replica to send %q, got %q", want, got)
+		}
+	}
+}
+
+func TestConnectToMasterKeepsTheLinkOnUnreadableRDB(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	// the master sends a snapshot with a record the replica cannot read,
+	// then a write
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		t.Cleanup(func() { conn.Close() })
+
+		dec := resp.NewDecoder(bufio.NewReader(conn))
+		replies := []string{"+PONG\r\n", "+OK\r\n", "+OK\r\n", "+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb 0\r\n"}
+
+		for _, reply := range replies {
+			if _, err := dec.Read(); err != nil {
+				return
+			}
+
+			conn.Write([]byte(reply))
+		}
+
+		snapshot := "REDIS0012\xf7\x00\xff"
+		conn.Write([]byte("$" + strconv.Itoa(len(snapshot)) + "\r\n" + snapshot))
+		conn.Write([]byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n"))
+
+		// keep the link up until the test ends
+		dec.Read()
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+
+	srv := NewRedisServer(0, true)
+	go srv.ConnectToMaster(host+" "+port, 6380)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	for deadline := time.Now().Add(time.Second); ; {
+		if v, ok := client.do("GET", "k").(*resp.BulkString); ok && !v.Null {
+			requireBulkString(t, v, "v")
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the write of the master to be applied")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScriptKillWhenBusy(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	other := newInMemoryClient(t, srv)
+	t.Cleanup(other.Close)
+
+	requireSimpleString(t, client.do("CONFIG", "SET", "busy-script-time", "20"), "OK")
+
+	client.send("EVAL", "while true do end", "0")
+
+	// the script keeps running, other clients are told so once it exceeded
+	// busy-script-time
+	deadline := time.Now().Add(time.Second)
+	for !srv.scripts.Busy() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the script to make the server busy")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := other.doError("GET", "foo"); !strings.HasPrefix(got, "BUSY ") {
+		t.Fatalf("expected a BUSY error, got %q", got)
+	}
+
+	requireSimpleString(t, other.do("SCRIPT", "KILL"), "OK")
+
+	if got := client.readError(); got != "ERR Script killed by user with SCRIPT KILL..." {
+		t.Fatalf("expected the script to be killed, got %q", got)
+	}
+
+	requireInteger(t, client.do("EVAL", "return 1", "0"), 1)
+}
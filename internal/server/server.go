@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net"
 	"os"
 	"os/signal"
@@ -15,19 +17,36 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
+	"github.com/codecrafters-io/redis-starter-go/internal/commands"
+	"github.com/codecrafters-io/redis-starter-go/internal/config"
 	"github.com/codecrafters-io/redis-starter-go/internal/logger"
+	"github.com/codecrafters-io/redis-starter-go/internal/notify"
+	"github.com/codecrafters-io/redis-starter-go/internal/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/internal/rdb"
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
 	"github.com/codecrafters-io/redis-starter-go/internal/store"
 	"github.com/codecrafters-io/redis-starter-go/internal/transactions"
 )
 
 const shutdownTimeout = 5 * time.Second
 
+// activeExpireInterval is how often the store looks for expired keys that
+// are not accessed anymore, Redis runs its cycle 10 times per second too.
+const activeExpireInterval = 100 * time.Millisecond
+
 type RedisServer struct {
 	port             int
 	listener         net.Listener
 	store            *store.Store
 	transactions     *transactions.Transactions
+	pubSub           *pubsub.PubSub
+	config           *config.Config
+	scripts          *scripting.Scripts
+	limits           *resp.Limits
+	acl              *acl.ACL
+	masterAuth       masterAuth
 	wg               sync.WaitGroup // tracks active connections
 	isReplica        bool
 	replicasRegistry *ReplicasRegistry
@@ -41,112 +60,187 @@ func NewRedisServer(port int, isReplica bool) *RedisServer {
 		replicationId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
 	}
 
-	return &RedisServer{
+	s := store.NewStore()
+
+	r := &RedisServer{
 		port:             port,
-		store:            store.NewStore(),
-		transactions:     transactions.NewTransactions(),
+		store:            s,
+		transactions:     transactions.NewTransactions(s),
+		pubSub:           pubsub.NewPubSub(),
+		config:           config.NewConfig(),
+		scripts:          scripting.NewScripts(),
+		limits:           resp.NewLimits(),
+		acl:              acl.New(commands.IsCommand),
 		isReplica:        isReplica,
 		replicasRegistry: NewReplicasRegistry(),
 		replicationId:    replicationId,
 	}
-}
 
-func (r *RedisServer) ConnectToMaster(replicaOf string, replicaPort int) error {
-	parts := strings.Split(replicaOf, " ")
-	conn, err := net.Dial("tcp", net.JoinHostPort(parts[0], parts[1]))
+	r.store.SetLimits(r.limits)
 
-	if err != nil {
-		return errors.Join(errors.New("error while trying to connect to the master server"), err)
-	}
+	notifier := notify.NewNotifier(r.pubSub)
+	r.store.SetNotifier(notifier)
+	r.config.Register("notify-keyspace-events", config.Param{
+		Get: func() string { return notifier.Classes().String() },
+		Set: func(value string) error {
+			classes, err := notify.ParseFlags(value)
+			if err != nil {
+				return err
+			}
 
-	session := NewSession(conn, r.store, r.transactions, r.isReplica, r.replicasRegistry, r.replicationId, true)
+			notifier.SetClasses(classes)
+			return nil
+		},
+	})
+
+	r.config.Register("busy-script-time", config.Param{
+		Get: func() string { return strconv.FormatInt(r.scripts.BusyTime().Milliseconds(), 10) },
+		Set: func(value string) error {
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || ms < 0 {
+				return fmt.Errorf("argument must be a non-negative number of milliseconds")
+			}
 
-	pingMsg := &resp.Array{
-		Elements: []resp.Value{
-			&resp.BulkString{
-				Bytes: []byte("PING"),
-			},
+			r.scripts.SetBusyTime(time.Duration(ms) * time.Millisecond)
+			return nil
 		},
-	}
+	})
 
-	if err = session.encoder.Write(pingMsg); err != nil {
-		return errors.Join(fmt.Errorf("error sending PING request to master %s from replica", replicaOf), err)
-	}
+	r.config.Register("requirepass", config.Param{
+		Get: r.acl.RequirePass,
+		Set: func(value string) error {
+			r.acl.SetRequirePass(value)
+			return nil
+		},
+	})
 
-	session.writer.Flush()
+	r.config.Register("masteruser", config.Param{
+		Get: func() string {
+			user, _ := r.masterAuth.get()
+			return user
+		},
+		Set: func(value string) error {
+			r.masterAuth.setUser(value)
+			return nil
+		},
+	})
 
-	pingResponse, err := session.decoder.Read()
-	if err != nil {
-		return errors.Join(fmt.Errorf("error reading PING response from replica %s", replicaOf), err)
-	}
+	r.config.Register("masterauth", config.Param{
+		Get: func() string {
+			_, password := r.masterAuth.get()
+			return password
+		},
+		Set: func(value string) error {
+			r.masterAuth.setPassword(value)
+			return nil
+		},
+	})
 
-	if _, ok := pingResponse.(*resp.Error); ok {
-		return fmt.Errorf("invalid PING response from replica %s", replicaOf)
-	}
+	// the aclfile is given on the command line, see LoadACLFile
+	r.config.Register("aclfile", config.Param{
+		Get: r.acl.File,
+		Set: func(value string) error {
+			return fmt.Errorf("can't set immutable config")
+		},
+	})
+
+	r.config.Register("acl-log-max-len", config.Param{
+		Get: func() string { return strconv.Itoa(r.acl.LogMaxLen()) },
+		Set: func(value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("argument must be a non-negative number")
+			}
 
-	replConfMsg := &resp.Array{
-		Elements: []resp.Value{
-			&resp.BulkString{Bytes: []byte("REPLCONF")},
-			&resp.BulkString{Bytes: []byte("listening-port")},
-			&resp.BulkString{Bytes: fmt.Append(nil, replicaPort)},
+			r.acl.SetLogMaxLen(n)
+			return nil
 		},
-	}
+	})
 
-	if err = session.encoder.Write(replConfMsg); err != nil {
-		return errors.Join(fmt.Errorf("error sending REPLCONF listening-port request to master %s from replica", replicaOf), err)
-	}
+	registerMemoryParam(r.config, "proto-max-bulk-len", r.limits.MaxBulkLen, r.limits.SetMaxBulkLen)
+	registerMemoryParam(r.config, "client-query-buffer-limit", r.limits.QueryBufferLimit, r.limits.SetQueryBufferLimit)
 
-	session.writer.Flush()
+	return r
+}
 
-	replConfListeningPortResponse, err := session.decoder.Read()
-	if err != nil {
-		return errors.Join(fmt.Errorf("error reading REPLCONF listening-port response from replica %s", replicaOf), err)
-	}
+// minProtoLimit is the smallest value of the protocol limits, Redis does
+// not accept less than 1mb either.
+const minProtoLimit = 1024 * 1024
+
+func registerMemoryParam(c *config.Config, name string, get func() int64, set func(int64)) {
+	c.Register(name, config.Param{
+		Get: func() string { return strconv.FormatInt(get(), 10) },
+		Set: func(value string) error {
+			n, err := config.ParseMemory(value)
+			if err != nil {
+				return err
+			}
 
-	if _, ok := replConfListeningPortResponse.(*resp.Error); ok {
-		return fmt.Errorf("invalid REPLCONF listening-port response from replica %s", replicaOf)
-	}
+			if n < minProtoLimit {
+				return fmt.Errorf("argument must be between %d and %d inclusive", minProtoLimit, int64(math.MaxInt64))
+			}
 
-	replConfMsg = &resp.Array{
-		Elements: []resp.Value{
-			&resp.BulkString{Bytes: []byte("REPLCONF")},
-			&resp.BulkString{Bytes: []byte("capa")},
-			&resp.BulkString{Bytes: []byte("psync2")},
+			set(n)
+			return nil
 		},
-	}
+	})
+}
 
-	if err = session.encoder.Write(replConfMsg); err != nil {
-		return errors.Join(fmt.Errorf("error sending REPLCONF capa request to master %s from replica", replicaOf), err)
-	}
+// LoadACLFile makes path the aclfile and loads the users from it.
+func (r *RedisServer) LoadACLFile(path string) error {
+	r.acl.SetFile(path)
+	return r.acl.LoadFile()
+}
 
-	session.writer.Flush()
+// SetConfig sets a configuration parameter like CONFIG SET does, it is used
+// to apply the command line options.
+func (r *RedisServer) SetConfig(name, value string) error {
+	return r.config.Set(name, value)
+}
+
+func (r *RedisServer) ConnectToMaster(replicaOf string, replicaPort int) error {
+	parts := strings.Split(replicaOf, " ")
+	conn, err := net.Dial("tcp", net.JoinHostPort(parts[0], parts[1]))
 
-	replConfCapaResponse, err := session.decoder.Read()
 	if err != nil {
-		return errors.Join(fmt.Errorf("error reading REPLCONF capa response from replica %s", replicaOf), err)
+		return errors.Join(errors.New("error while trying to connect to the master server"), err)
 	}
 
-	if _, ok := replConfCapaResponse.(*resp.Error); ok {
-		return fmt.Errorf("invalid REPLCONF capa response from replica %s", replicaOf)
+	session := NewSession(conn, r.store, r.transactions, r.pubSub, r.config, r.scripts, r.limits, r.acl, r.isReplica, r.replicasRegistry, r.replicationId, true)
+
+	masterUser, masterPassword := r.masterAuth.get()
+
+	// like Redis, a master requiring a password may refuse the PING, the
+	// replica authenticates right after it
+	if _, err := masterRequest(session, "PING"); err != nil {
+		var serverErr *resp.ServerError
+		if masterPassword == "" || !errors.As(err, &serverErr) || serverErr.Code() != "NOAUTH" {
+			return errors.Join(fmt.Errorf("error sending PING to master %s from replica", replicaOf), err)
+		}
 	}
 
-	psyncInitialMsg := &resp.Array{
-		Elements: []resp.Value{
-			&resp.BulkString{Bytes: []byte("PSYNC")},
-			&resp.BulkString{Bytes: []byte("?")},
-			&resp.BulkString{Bytes: []byte("-1")},
-		},
+	if masterPassword != "" {
+		args := []string{"AUTH", masterPassword}
+		if masterUser != "" {
+			args = []string{"AUTH", masterUser, masterPassword}
+		}
+
+		if _, err := masterRequest(session, args...); err != nil {
+			return errors.Join(fmt.Errorf("error sending AUTH to master %s from replica", replicaOf), err)
+		}
 	}
 
-	if err = session.encoder.Write(psyncInitialMsg); err != nil {
-		return errors.Join(fmt.Errorf("error sending PSYNC to master %s from replica", replicaOf), err)
+	if _, err := masterRequest(session, "REPLCONF", "listening-port", strconv.Itoa(replicaPort)); err != nil {
+		return errors.Join(fmt.Errorf("error sending REPLCONF listening-port to master %s from replica", replicaOf), err)
 	}
 
-	session.writer.Flush()
+	if _, err := masterRequest(session, "REPLCONF", "capa", "psync2"); err != nil {
+		return errors.Join(fmt.Errorf("error sending REPLCONF capa to master %s from replica", replicaOf), err)
+	}
 
-	psyncResponse, err := session.decoder.Read()
+	psyncResponse, err := masterRequest(session, "PSYNC", "?", "-1")
 	if err != nil {
-		return errors.Join(fmt.Errorf("error reading PSYNC response from master %s", replicaOf), err)
+		return errors.Join(fmt.Errorf("error sending PSYNC to master %s from replica", replicaOf), err)
 	}
 
 	ss, ok := psyncResponse.(*resp.SimpleString)
@@ -175,10 +269,18 @@ func (r *RedisServer) ConnectToMaster(replicaOf string, replicaPort int) error {
 
 	rdbContent := make([]byte, rdbContentLen)
 
-	if _, err := session.countingReader.Read(rdbContent); err != nil {
+	if _, err := io.ReadFull(session.countingReader, rdbContent); err != nil {
 		return fmt.Errorf("ERR while reading RDB content")
 	}
 
+	// only the functions are loaded from the snapshot, the replication
+	// goes on without them when it cannot be read
+	if snapshot, err := rdb.Decode(rdbContent); err != nil {
+		logger.Warn("skipping the RDB content sent by the master", "error", err)
+	} else if err := r.scripts.RestoreLibraries(snapshot.Functions, "FLUSH"); err != nil {
+		logger.Warn("failed to load the functions of the RDB content sent by the master", "error", err)
+	}
+
 	session.countingReader.Count = 0
 
 	session.Run()
@@ -186,6 +288,26 @@ func (r *RedisServer) ConnectToMaster(replicaOf string, replicaPort int) error {
 	return nil
 }
 
+// masterRequest sends the command in args to the master during the
+// replication handshake and returns its reply. An error reply is returned
+// as a *resp.ServerError.
+func masterRequest(session *Session, args ...string) (resp.Value, error) {
+	arr := &resp.Array{Elements: make([]resp.Value, len(args))}
+	for i, arg := range args {
+		arr.Elements[i] = &resp.BulkString{Bytes: []byte(arg)}
+	}
+
+	if err := session.encoder.Write(arr); err != nil {
+		return nil, err
+	}
+
+	if err := session.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return session.decoder.ReadReply()
+}
+
 func (r *RedisServer) Listen() error {
 	if r.port == 0 {
 		return fmt.Errorf("port is not specified")
@@ -208,7 +330,11 @@ func (r *RedisServer) Listen() error {
 
 	go r.acceptConnections()
 
+	stopExpire := make(chan struct{})
+	go r.expireKeys(stopExpire)
+
 	<-sigChan
+	close(stopExpire)
 	logger.Info("Shutdown signal received, stopping server...")
 
 	// Stop accepting new connections
@@ -238,6 +364,23 @@ func (r *RedisServer) Listen() error {
 	return nil
 }
 
+// expireKeys runs the active expire cycle of the store until stop is
+// closed, so that keys nobody reads anymore are removed and reported as
+// expired.
+func (r *RedisServer) expireKeys(stop <-chan struct{}) {
+	ticker := time.NewTicker(activeExpireInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.store.ActiveExpireCycle()
+		case <-stop:
+			return
+		}
+	}
+}
+
 func (r *RedisServer) acceptConnections() {
 	for {
 		conn, err := r.listener.Accept()
@@ -259,6 +402,6 @@ func (r *RedisServer) handleConnection(conn net.Conn) {
 	r.wg.Add(1)
 	defer r.wg.Done()
 
-	session := NewSession(conn, r.store, r.transactions, r.isReplica, r.replicasRegistry, r.replicationId, false)
+	session := NewSession(conn, r.store, r.transactions, r.pubSub, r.config, r.scripts, r.limits, r.acl, r.isReplica, r.replicasRegistry, r.replicationId, false)
 	session.Run()
 }
@@ -2,28 +2,46 @@ package server
 
 import (
 	"bufio"
-	_ "embed"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/codecrafters-io/redis-starter-go/internal/acl"
 	"github.com/codecrafters-io/redis-starter-go/internal/commands"
+	"github.com/codecrafters-io/redis-starter-go/internal/config"
 	"github.com/codecrafters-io/redis-starter-go/internal/logger"
+	"github.com/codecrafters-io/redis-starter-go/internal/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/internal/rdb"
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/scripting"
 	"github.com/codecrafters-io/redis-starter-go/internal/store"
 	"github.com/codecrafters-io/redis-starter-go/internal/transactions"
 )
 
-//go:embed empty.rdb
-var emptyRDB []byte
+// pushQueueSize is the number of published messages that may wait for
+// delivery to a subscriber. A subscriber that falls further behind is
+// disconnected, like Redis does once the pubsub output buffer limit is hit.
+const pushQueueSize = 1024
 
 type Session struct {
-	conn                 net.Conn
-	transactions         *transactions.Transactions
-	id                   string
+	conn         net.Conn
+	transactions *transactions.Transactions
+	id           string
+	clientId     int64
+	// name is the client name given with HELLO SETNAME
+	name string
+	// user is the ACL user the client is authenticated as
+	user *acl.User
+	// authenticated is set once the client passed AUTH, or from the start
+	// when no password was required when it connected
+	authenticated        bool
 	decoder              *resp.Decoder
 	encoder              *resp.Encoder
 	writer               *bufio.Writer
@@ -31,12 +49,17 @@ type Session struct {
 	serverCtx            *commands.ServerContext
 	isReplicationSession bool
 	countingReader       *resp.CountingReader
+	// writeMu serializes replies and pushed messages on the connection
+	writeMu sync.Mutex
+	pushes  chan resp.Value
+	done    chan struct{}
 }
 
 var nextClientId int64
 
-func NewSession(conn net.Conn, store *store.Store, transactions *transactions.Transactions, isReplica bool, replicasRegistry *ReplicasRegistry, replicationId string, isReplicationSession bool) *Session {
-	id := fmt.Sprintf("%d-%s", atomic.AddInt64(&nextClientId, 1), conn.RemoteAddr().String())
+func NewSession(conn net.Conn, store *store.Store, transactions *transactions.Transactions, pubSub *pubsub.PubSub, config *config.Config, scripts *scripting.Scripts, limits *resp.Limits, users *acl.ACL, isReplica bool, replicasRegistry *ReplicasRegistry, replicationId string, isReplicationSession bool) *Session {
+	clientId := atomic.AddInt64(&nextClientId, 1)
+	id := fmt.Sprintf("%d-%s", clientId, conn.RemoteAddr().String())
 
 	reader := bufio.NewReader(conn)
 	writer := bufio.NewWriter(conn)
@@ -44,24 +67,35 @@ func NewSession(conn net.Conn, store *store.Store, transactions *transactions.Tr
 		R: reader,
 	}
 	decoder := resp.NewDecoder(cr)
+	decoder.SetLimits(limits)
 	encoder := resp.NewEncoder(writer)
 
 	return &Session{
 		conn:                 conn,
 		transactions:         transactions,
 		id:                   id,
+		clientId:             clientId,
 		decoder:              decoder,
 		encoder:              encoder,
 		writer:               writer,
 		reader:               reader,
 		isReplicationSession: isReplicationSession,
+		user:                 users.DefaultUser(),
+		// the master is trusted, the replica authenticated to it
+		authenticated: isReplicationSession || (users.DefaultUser().Enabled() && users.DefaultUser().NoPass()),
 		serverCtx: &commands.ServerContext{
 			IsReplica:        isReplica,
 			ReplicasRegistry: replicasRegistry,
 			Store:            store,
 			ReplicationId:    replicationId,
+			PubSub:           pubSub,
+			Config:           config,
+			Scripts:          scripts,
+			ACL:              users,
 		},
 		countingReader: cr,
+		pushes:         make(chan resp.Value, pushQueueSize),
+		done:           make(chan struct{}),
 	}
 }
 
@@ -83,22 +117,80 @@ func (s *Session) handleDecoderError(err error) (stop bool) {
 		return true
 	}
 
-	s.encoder.Write(&resp.Error{Msg: "ERR protocol error"})
-	s.writer.Flush()
+	if errors.Is(err, resp.ErrQueryBufferLimit) {
+		logger.Warn("closing client that reached max query buffer length", "id", s.id)
+		s.transactions.Discard(s.id)
+		return true
+	}
+
+	// the request could not be delimited, the connection is out of sync
+	var protoErr *resp.ProtocolError
+	if errors.As(err, &protoErr) {
+		s.writeError("ERR " + protoErr.Error())
+		s.transactions.Discard(s.id)
+		return true
+	}
+
+	s.writeError("ERR protocol error")
 
 	return false
 }
 
 func (s *Session) writeError(msg string) {
-	s.encoder.Write(&resp.Error{Msg: msg})
+	s.write(&resp.Error{Msg: msg})
+}
+
+// write encodes v and flushes it to the connection.
+func (s *Session) write(v resp.Value) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.encoder.Write(v); err != nil {
+		s.encoder.Write(&resp.Error{Msg: fmt.Sprintf("ERR encoder failed to write a response: %s", err.Error())})
+	}
+
+	s.writer.Flush()
+}
+
+func (s *Session) flush() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	s.writer.Flush()
 }
 
+// Push queues a published message for delivery, it implements
+// pubsub.Subscriber. A subscriber whose queue is full is disconnected.
+func (s *Session) Push(v resp.Value) {
+	select {
+	case s.pushes <- v:
+	default:
+		logger.Warn("closing subscriber that is not consuming its messages", "id", s.id)
+		s.conn.Close()
+	}
+}
+
+func (s *Session) deliverPushes() {
+	for {
+		select {
+		case v := <-s.pushes:
+			s.write(v)
+		case <-s.done:
+			return
+		}
+	}
+}
+
 func (s *Session) Run() {
 	logger.Debug("accepted new connection", "RemoteAddr", s.conn.RemoteAddr())
 
 	defer s.conn.Close()
-	defer s.writer.Flush()
+	defer s.flush()
+	defer close(s.done)
+	defer s.serverCtx.PubSub.UnsubscribeAll(s)
+	defer s.transactions.Discard(s.id)
+
+	go s.deliverPushes()
 
 	for {
 		offset := s.countingReader.Count
@@ -107,9 +199,9 @@ func (s *Session) Run() {
 			logger.Debug("read offset", "offset", offset)
 		}
 
-		value, derr := s.decoder.Read()
+		value, derr := s.decoder.ReadRequest()
 
-		logger.Debug("decoder.Read result", slog.Any("value", value), slog.Any("derr", derr))
+		logger.Debug("decoder.ReadRequest result", slog.Any("value", value), slog.Any("derr", derr))
 
 		if derr != nil {
 			if stop := s.handleDecoderError(derr); stop {
@@ -119,11 +211,18 @@ func (s *Session) Run() {
 			continue
 		}
 
+		// the clients of a deleted user are disconnected
+		if s.user.Deleted() {
+			break
+		}
+
 		cmd, perr := commands.Parse(value)
 
 		logger.Debug("commands.Parse result", slog.Any("cmd", cmd), slog.Any("perr", perr))
 
 		if perr != nil {
+			// an unknown command inside MULTI fails the whole transaction
+			s.transactions.Flag(s.id)
 			s.writeError(perr.Error())
 			continue
 		}
@@ -136,15 +235,9 @@ func (s *Session) Run() {
 
 		logger.Debug("executeCommands result", slog.Any("out", out))
 
-		if cmd.Name == commands.SET_COMMAND && !s.serverCtx.IsReplica {
-			logger.Debug("replicas broadcasting", slog.Any("value", value))
-			s.serverCtx.ReplicasRegistry.BroadcastRespValue(value)
-			s.serverCtx.MasterOffset += resp.Size(value)
-		}
-
 		// no-op case, continue
 		if out == nil {
-			s.writer.Flush()
+			s.flush()
 			continue
 		}
 
@@ -152,16 +245,56 @@ func (s *Session) Run() {
 			continue
 		}
 
-		err := s.encoder.Write(out)
-		if err != nil {
-			s.encoder.Write(&resp.Error{Msg: fmt.Sprintf("ERR encoder failed to write a response: %s", err.Error())})
-		}
+		s.write(out)
 
-		s.writer.Flush()
+		if cmd.Name == commands.QUIT_COMMAND {
+			break
+		}
 	}
 }
 
 func (s *Session) executeCommand(cmd *commands.Command) resp.Value {
+	if cmd.Name == commands.QUIT_COMMAND {
+		s.transactions.Discard(s.id)
+		return &resp.SimpleString{Bytes: []byte("OK")}
+	}
+
+	if s.authRequired(cmd) {
+		s.transactions.Flag(s.id)
+		return &resp.Error{Msg: "NOAUTH Authentication required."}
+	}
+
+	if cmd.Name == commands.AUTH_COMMAND {
+		return s.handleAuth(cmd)
+	}
+
+	// like AUTH, HELLO may switch to another user and is always allowed
+	if cmd.Name != commands.HELLO_COMMAND {
+		if errReply := commands.Authorize(s.serverCtx, s.handlerContext(cmd), commands.TopLevelContext); errReply != nil {
+			s.transactions.Flag(s.id)
+			return errReply
+		}
+	}
+
+	if s.serverCtx.Scripts.Busy() {
+		return s.executeBusyCommand(cmd)
+	}
+
+	// RESP3 clients may run any command while subscribed, the messages
+	// being told apart from the replies by their push type
+	if s.isSubscriber() && s.encoder.Protocol() == resp.RESP2 {
+		return s.executeSubscriberCommand(cmd)
+	}
+
+	if isSubscriptionCommand(cmd.Name) {
+		if s.transactions.IsActive(s.id) {
+			s.transactions.Flag(s.id)
+			return &resp.Error{Msg: "ERR Command not allowed inside a transaction"}
+		}
+
+		return s.handleSubscription(cmd)
+	}
+
 	if cmd.Name == commands.MULTI_COMMAND {
 		return s.handleMulti(cmd)
 	}
@@ -174,6 +307,18 @@ func (s *Session) executeCommand(cmd *commands.Command) resp.Value {
 		return s.handleDiscard(cmd)
 	}
 
+	if cmd.Name == commands.WATCH_COMMAND {
+		return s.handleWatch(cmd)
+	}
+
+	if cmd.Name == commands.UNWATCH_COMMAND && !s.transactions.IsActive(s.id) {
+		return s.handleUnwatch(cmd)
+	}
+
+	if cmd.Name == commands.HELLO_COMMAND {
+		return s.handleHello(cmd)
+	}
+
 	if cmd.Name == commands.PSYNC && !s.serverCtx.IsReplica {
 		return s.handlePsync(cmd)
 	}
@@ -186,10 +331,7 @@ func (s *Session) executeCommand(cmd *commands.Command) resp.Value {
 		return &resp.SimpleString{Bytes: []byte("QUEUED")}
 	}
 
-	handlerContext := &commands.HandlerContext{
-		Cmd:        cmd,
-		RemoteAddr: s.getRemoteAddr(),
-	}
+	handlerContext := s.handlerContext(cmd)
 
 	return commands.Dispatch(s.serverCtx, handlerContext)
 }
@@ -213,7 +355,9 @@ func (s *Session) handlePsync(_ *commands.Command) resp.Value {
 
 	s.writer.Flush()
 
-	if _, err := fmt.Fprintf(s.writer, "$%d\r\n%s", len(emptyRDB), emptyRDB); err != nil {
+	snapshot := s.snapshot().Encode()
+
+	if _, err := fmt.Fprintf(s.writer, "$%d\r\n%s", len(snapshot), snapshot); err != nil {
 		logger.Error("failed to send RDB to replica", "error", err)
 		s.conn.Close()
 		return nil
@@ -230,12 +374,23 @@ func (s *Session) handlePsync(_ *commands.Command) resp.Value {
 	return nil
 }
 
+// snapshot returns the RDB file sent to a replica on a full resync. There
+// is no persistence of the keyspace, only the function libraries are sent.
+func (s *Session) snapshot() *rdb.Snapshot {
+	return &rdb.Snapshot{
+		Aux: [][2]string{
+			{"redis-ver", redisVersion},
+			{"redis-bits", "64"},
+			{"ctime", strconv.FormatInt(time.Now().Unix(), 10)},
+			{"aof-base", "0"},
+		},
+		Functions: s.serverCtx.Scripts.LibraryCodes(),
+	}
+}
+
 func (s *Session) handleMulti(cmd *commands.Command) resp.Value {
 	if !s.transactions.IsActive(s.id) {
-		handlerContext := &commands.HandlerContext{
-			Cmd:        cmd,
-			RemoteAddr: s.getRemoteAddr(),
-		}
+		handlerContext := s.handlerContext(cmd)
 
 		out := commands.Dispatch(s.serverCtx, handlerContext)
 
@@ -256,13 +411,31 @@ func (s *Session) handleExec() resp.Value {
 		return &resp.Error{Msg: "ERR EXEC without MULTI"}
 	}
 
-	return s.transactions.ExecuteAndDiscard(s.id, func(c *commands.Command) resp.Value {
-		handlerContext := &commands.HandlerContext{
-			Cmd:        c,
-			RemoteAddr: s.getRemoteAddr(),
+	// the writes that ran are replicated as a MULTI/EXEC block, nothing is
+	// when the transaction was aborted
+	var effects []resp.Value
+
+	out := s.transactions.ExecuteAndDiscard(s.id, func(tx *store.Store, c *commands.Command) resp.Value {
+		serverCtx := *s.serverCtx
+		serverCtx.Store = tx
+
+		handlerContext := s.handlerContext(c)
+		handlerContext.InTransaction = true
+
+		// the permissions may have changed since the command was queued
+		if errReply := commands.Authorize(&serverCtx, handlerContext, commands.MultiContext); errReply != nil {
+			return errReply
 		}
-		return commands.Dispatch(s.serverCtx, handlerContext)
+
+		reply := commands.Dispatch(&serverCtx, handlerContext)
+		effects = append(effects, commands.Effects(handlerContext, reply)...)
+
+		return reply
+	}, func() {
+		commands.PropagateTransaction(s.serverCtx, effects)
 	})
+
+	return out
 }
 
 func (s *Session) handleDiscard(cmd *commands.Command) resp.Value {
@@ -272,10 +445,54 @@ func (s *Session) handleDiscard(cmd *commands.Command) resp.Value {
 
 	s.transactions.Discard(s.id)
 
-	handlerContext := &commands.HandlerContext{
-		Cmd:        cmd,
-		RemoteAddr: s.getRemoteAddr(),
-	}
+	handlerContext := s.handlerContext(cmd)
 
 	return commands.Dispatch(s.serverCtx, handlerContext)
 }
+
+func (s *Session) handleWatch(cmd *commands.Command) resp.Value {
+	if s.transactions.IsActive(s.id) {
+		return &resp.Error{Msg: "ERR WATCH inside MULTI is not allowed"}
+	}
+
+	if cmd.ArgsLen() == 0 {
+		return &resp.Error{Msg: fmt.Sprintf("ERR wrong number of arguments for %s command", cmd.Name)}
+	}
+
+	keys := make([]string, cmd.ArgsLen())
+	for i := range keys {
+		keys[i], _ = cmd.ArgString(i)
+	}
+
+	s.transactions.Watch(s.id, keys)
+
+	return &resp.SimpleString{Bytes: []byte("OK")}
+}
+
+func (s *Session) handleUnwatch(cmd *commands.Command) resp.Value {
+	handlerContext := s.handlerContext(cmd)
+
+	out := commands.Dispatch(s.serverCtx, handlerContext)
+
+	if _, isErr := out.(*resp.Error); !isErr {
+		s.transactions.Unwatch(s.id)
+	}
+
+	return out
+}
+
+// executeBusyCommand runs cmd while a script has been running for longer
+// than busy-script-time. Only SCRIPT KILL, FUNCTION KILL and FUNCTION STATS
+// are accepted then, they are dispatched right away since the store is
+// locked by the script.
+func (s *Session) executeBusyCommand(cmd *commands.Command) resp.Value {
+	if (cmd.Name == commands.SCRIPT_COMMAND || cmd.Name == commands.FUNCTION_COMMAND) && cmd.ArgsLen() == 1 {
+		subcommand, _ := cmd.ArgString(0)
+
+		if strings.EqualFold(subcommand, "KILL") || (cmd.Name == commands.FUNCTION_COMMAND && strings.EqualFold(subcommand, "STATS")) {
+			return commands.Dispatch(s.serverCtx, s.handlerContext(cmd))
+		}
+	}
+
+	return &resp.Error{Msg: s.serverCtx.Scripts.BusyError().Error()}
+}
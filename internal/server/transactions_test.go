@@ -68,10 +68,125 @@ func TestMultipleConnectionsTransactionFlow(t *testing.T) {
 	requireBulkString(t, verifier.do("GET", "user:2"), "bob")
 }
 
+func TestTransactionIsIsolatedFromOtherClients(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	writer := newInMemoryClient(t, srv)
+	t.Cleanup(writer.Close)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				writer.do("SET", "foo", "other")
+			}
+		}
+	}()
+
+	for range 50 {
+		requireSimpleString(t, client.do("MULTI"), "OK")
+		requireSimpleString(t, client.do("SET", "foo", "mine"), "QUEUED")
+		requireSimpleString(t, client.do("GET", "foo"), "QUEUED")
+
+		execReply := requireArrayLen(t, client.do("EXEC"), 2)
+		requireBulkString(t, execReply.Elements[1], "mine")
+	}
+
+	close(done)
+	<-stopped
+}
+
+func TestBlockingCommandsInTransactionDoNotBlock(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("BLPOP", "list", "0"), "QUEUED")
+	requireSimpleString(t, client.do("XREAD", "BLOCK", "0", "STREAMS", "stream", "$"), "QUEUED")
+	requireSimpleString(t, client.do("RPUSH", "list", "a"), "QUEUED")
+	requireSimpleString(t, client.do("BLPOP", "list", "0"), "QUEUED")
+
+	execReply := requireArrayLen(t, client.do("EXEC"), 4)
+
+	for _, i := range []int{0, 1} {
+		if arr, ok := execReply.Elements[i].(*resp.Array); !ok || !arr.Null {
+			t.Fatalf("expected reply %d to be a null array, got %#v", i, execReply.Elements[i])
+		}
+	}
+
+	popped := requireArrayLen(t, execReply.Elements[3], 2)
+	requireBulkString(t, popped.Elements[1], "a")
+}
+
+func TestQueueTimeErrorAbortsExec(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "foo", "bar"), "QUEUED")
+
+	if got := client.doError("GET"); got != "ERR wrong number of arguments for GET command" {
+		t.Fatalf("expected an arity error at queue time, got %q", got)
+	}
+
+	requireSimpleString(t, client.do("INCR", "counter"), "QUEUED")
+
+	if got := client.doError("EXEC"); got != "EXECABORT Transaction discarded because of previous errors." {
+		t.Fatalf("expected EXEC to abort, got %q", got)
+	}
+
+	// nothing from the aborted transaction was applied
+	if v, ok := client.do("GET", "foo").(*resp.BulkString); !ok || !v.Null {
+		t.Fatalf("expected foo to be missing, got %#v", v)
+	}
+
+	// the next transaction starts clean
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "foo", "bar"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 1)
+}
+
+func TestUnknownCommandAbortsExec(t *testing.T) {
+	srv := NewRedisServer(0, false)
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	client.doError("NOSUCHCOMMAND", "foo")
+
+	if got := client.doError("EXEC"); got != "EXECABORT Transaction discarded because of previous errors." {
+		t.Fatalf("expected EXEC to abort, got %q", got)
+	}
+
+	// so does an unknown subcommand
+	requireSimpleString(t, client.do("MULTI"), "OK")
+
+	if got := client.doError("CONFIG", "FOO"); got != "ERR unknown subcommand 'FOO'. Try CONFIG HELP." {
+		t.Fatalf("expected an unknown subcommand error at queue time, got %q", got)
+	}
+
+	if got := client.doError("EXEC"); got != "EXECABORT Transaction discarded because of previous errors." {
+		t.Fatalf("expected EXEC to abort, got %q", got)
+	}
+}
+
 type testClient struct {
 	t      *testing.T
 	conn   net.Conn
 	writer *bufio.Writer
+	reader *bufio.Reader
 	enc    *resp.Encoder
 	dec    *resp.Decoder
 }
@@ -83,13 +198,15 @@ func newInMemoryClient(t *testing.T, srv *RedisServer) *testClient {
 	go srv.handleConnection(serverConn)
 
 	writer := bufio.NewWriter(clientConn)
+	reader := bufio.NewReader(clientConn)
 
 	return &testClient{
 		t:      t,
 		conn:   clientConn,
 		writer: writer,
+		reader: reader,
 		enc:    resp.NewEncoder(writer),
-		dec:    resp.NewDecoder(bufio.NewReader(clientConn)),
+		dec:    resp.NewDecoder(reader),
 	}
 }
 
@@ -100,6 +217,19 @@ func (c *testClient) Close() {
 func (c *testClient) do(parts ...string) resp.Value {
 	c.t.Helper()
 
+	c.send(parts...)
+
+	reply, err := c.dec.Read()
+	if err != nil {
+		c.t.Fatalf("read %v: %v", parts, err)
+	}
+
+	return reply
+}
+
+func (c *testClient) send(parts ...string) {
+	c.t.Helper()
+
 	arr := &resp.Array{
 		Elements: make([]resp.Value, len(parts)),
 	}
@@ -115,13 +245,6 @@ func (c *testClient) do(parts ...string) resp.Value {
 	if err := c.writer.Flush(); err != nil {
 		c.t.Fatalf("flush %v: %v", parts, err)
 	}
-
-	reply, err := c.dec.Read()
-	if err != nil {
-		c.t.Fatalf("read %v: %v", parts, err)
-	}
-
-	return reply
 }
 
 func requireSimpleString(t *testing.T, v resp.Value, expected string) {
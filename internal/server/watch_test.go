@@ -0,0 +1,119 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+)
+
+func requireNullArray(t *testing.T, v resp.Value) {
+	t.Helper()
+
+	arr, ok := v.(*resp.Array)
+	if !ok || !arr.Null {
+		t.Fatalf("expected a null array, got %#v", v)
+	}
+}
+
+func TestWatchAbortsExecOnConcurrentWrite(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	other := newInMemoryClient(t, srv)
+	t.Cleanup(other.Close)
+
+	requireSimpleString(t, client.do("SET", "balance", "10"), "OK")
+	requireSimpleString(t, client.do("WATCH", "balance"), "OK")
+
+	requireSimpleString(t, other.do("SET", "balance", "20"), "OK")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "balance", "11"), "QUEUED")
+	requireNullArray(t, client.do("EXEC"))
+
+	requireBulkString(t, client.do("GET", "balance"), "20")
+
+	// EXEC released the watch, the next transaction goes through
+	requireSimpleString(t, other.do("SET", "balance", "30"), "OK")
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "balance", "31"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 1)
+
+	requireBulkString(t, client.do("GET", "balance"), "31")
+}
+
+func TestWatchSucceedsWithoutWrites(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	requireSimpleString(t, client.do("WATCH", "foo", "bar"), "OK")
+
+	// reads do not touch the watched keys
+	client.do("GET", "foo")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("SET", "foo", "1"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 1)
+}
+
+func TestWatchDetectsDeleteAndExpiry(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	other := newInMemoryClient(t, srv)
+	t.Cleanup(other.Close)
+
+	requireSimpleString(t, client.do("SET", "foo", "bar"), "OK")
+	requireSimpleString(t, client.do("WATCH", "foo"), "OK")
+	requireBulkString(t, other.do("GETDEL", "foo"), "bar")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireNullArray(t, client.do("EXEC"))
+
+	// nobody accesses the key while it expires
+	requireSimpleString(t, client.do("SET", "foo", "bar", "PX", "5"), "OK")
+	requireSimpleString(t, client.do("WATCH", "foo"), "OK")
+	time.Sleep(10 * time.Millisecond)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireNullArray(t, client.do("EXEC"))
+}
+
+func TestUnwatchAndDiscardReleaseKeys(t *testing.T) {
+	srv := NewRedisServer(0, false)
+
+	client := newInMemoryClient(t, srv)
+	t.Cleanup(client.Close)
+
+	other := newInMemoryClient(t, srv)
+	t.Cleanup(other.Close)
+
+	requireSimpleString(t, client.do("WATCH", "foo"), "OK")
+	requireSimpleString(t, client.do("UNWATCH"), "OK")
+	requireSimpleString(t, other.do("SET", "foo", "1"), "OK")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("GET", "foo"), "QUEUED")
+	requireArrayLen(t, client.do("EXEC"), 1)
+
+	requireSimpleString(t, client.do("WATCH", "foo"), "OK")
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireSimpleString(t, client.do("DISCARD"), "OK")
+	requireSimpleString(t, other.do("SET", "foo", "2"), "OK")
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+	requireArrayLen(t, client.do("EXEC"), 0)
+
+	requireSimpleString(t, client.do("MULTI"), "OK")
+
+	if got := client.doError("WATCH", "foo"); got != "ERR WATCH inside MULTI is not allowed" {
+		t.Fatalf("expected WATCH inside MULTI to fail, got %q", got)
+	}
+}
@@ -0,0 +1,34 @@
+package store
+
+import "sync"
+
+// locker is the lock guarding the store. It is a sync.RWMutex, except in the
+// view handed out by Atomically where the lock is already held.
+type locker interface {
+	sync.Locker
+	RLock()
+	RUnlock()
+}
+
+// heldLock is the locker of a view whose lock is held by Atomically.
+type heldLock struct{}
+
+func (heldLock) Lock()    {}
+func (heldLock) Unlock()  {}
+func (heldLock) RLock()   {}
+func (heldLock) RUnlock() {}
+
+// Atomically calls fn with a view of the store while holding its lock, so
+// the operations fn runs on the view are isolated from every other client.
+// The view shares the data of the store and is only valid during fn. A
+// blocking operation on the view would never be woken up, fn must use the
+// non-blocking forms.
+func (s *Store) Atomically(fn func(tx *Store)) {
+	s.Lock()
+	defer s.Unlock()
+
+	tx := *s
+	tx.locker = heldLock{}
+
+	fn(&tx)
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAtomicallyIsolatesOtherClients(t *testing.T) {
+	s := NewStore()
+	written := make(chan struct{})
+
+	s.Atomically(func(tx *Store) {
+		tx.Set("foo", []byte("1"), "", 0)
+
+		go func() {
+			s.Set("foo", []byte("2"), "", 0)
+			close(written)
+		}()
+
+		select {
+		case <-written:
+			t.Error("expected a concurrent write to wait for the atomic block")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		tx.Set("foo", []byte("3"), "", 0)
+	})
+
+	<-written
+
+	if v, _ := s.Get("foo"); string(v) != "2" {
+		t.Fatalf("expected the concurrent write to apply last, got %q", v)
+	}
+}
+
+func TestBlpopWithoutBlocking(t *testing.T) {
+	s := NewStore()
+
+	if _, ok, timeout := s.Blpop("list", 0, false, func() {}); ok || !timeout {
+		t.Fatalf("expected an empty list to time out right away, got ok=%v timeout=%v", ok, timeout)
+	}
+
+	s.Rpush("list", []string{"a"})
+
+	if el, ok, _ := s.Blpop("list", 0, false, func() {}); !ok || el != "a" {
+		t.Fatalf("expected to pop %q, got %q", "a", el)
+	}
+}
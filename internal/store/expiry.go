@@ -7,6 +7,9 @@ type ExpiryType string
 const (
 	EXPIRY_PX ExpiryType = "px"
 	EXPIRY_EX ExpiryType = "ex"
+	// absolute unix time variants
+	EXPIRY_PXAT ExpiryType = "pxat"
+	EXPIRY_EXAT ExpiryType = "exat"
 )
 
 func ProcessExpType(v string) (ExpiryType, bool) {
@@ -17,9 +20,10 @@ func ProcessExpType(v string) (ExpiryType, bool) {
 		return "", true
 	}
 
-	if vLower != string(EXPIRY_EX) && vLower != string(EXPIRY_PX) {
+	switch ExpiryType(vLower) {
+	case EXPIRY_EX, EXPIRY_PX, EXPIRY_EXAT, EXPIRY_PXAT:
+		return ExpiryType(vLower), true
+	default:
 		return "", false
 	}
-
-	return ExpiryType(vLower), true
 }
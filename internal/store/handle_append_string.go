@@ -0,0 +1,22 @@
+package store
+
+func (m innerMap) appendString(key string, value []byte) (int64, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		m[key] = newStoreValue(RawBytes{Bytes: value}, getPossibleEndTime())
+		return int64(len(value)), nil
+	}
+
+	current := v.value.(RawBytes).Bytes
+	newValue := make([]byte, 0, len(current)+len(value))
+	newValue = append(newValue, current...)
+	newValue = append(newValue, value...)
+
+	m[key] = newStoreValue(RawBytes{Bytes: newValue}, v.expiryTime)
+
+	return int64(len(newValue)), nil
+}
@@ -0,0 +1,227 @@
+package store
+
+import "math"
+
+type BitfieldOpKind string
+
+const (
+	BITFIELD_GET    BitfieldOpKind = "GET"
+	BITFIELD_SET    BitfieldOpKind = "SET"
+	BITFIELD_INCRBY BitfieldOpKind = "INCRBY"
+)
+
+type BitfieldOverflow string
+
+const (
+	BITFIELD_OVERFLOW_WRAP BitfieldOverflow = "WRAP"
+	BITFIELD_OVERFLOW_SAT  BitfieldOverflow = "SAT"
+	BITFIELD_OVERFLOW_FAIL BitfieldOverflow = "FAIL"
+)
+
+// BitfieldOp is a single GET, SET or INCRBY subcommand of BITFIELD.
+type BitfieldOp struct {
+	Kind     BitfieldOpKind
+	Signed   bool
+	Bits     uint
+	Offset   uint64
+	Value    int64
+	Overflow BitfieldOverflow
+}
+
+// BitfieldResult is the reply of a single BitfieldOp. Null is set when an
+// operation was skipped because of the FAIL overflow mode.
+type BitfieldResult struct {
+	Value int64
+	Null  bool
+}
+
+// hasBitfieldWrite reports whether ops modify the string, only GET does not.
+func hasBitfieldWrite(ops []BitfieldOp) bool {
+	for _, op := range ops {
+		if op.Kind != BITFIELD_GET {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m innerMap) bitfield(key string, ops []BitfieldOp, maxSize int64) ([]BitfieldResult, error) {
+	for _, op := range ops {
+		// written so that an offset close to the maximum does not wrap
+		if op.Offset > maxBitOffset(maxSize)-uint64(op.Bits)+1 {
+			return nil, errBitOffset
+		}
+	}
+
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	expiryTime := getPossibleEndTime()
+
+	if exists {
+		b = v.value.(RawBytes).Bytes
+		expiryTime = v.expiryTime
+	}
+
+	results := make([]BitfieldResult, 0, len(ops))
+	written := false
+
+	for _, op := range ops {
+		if op.Kind == BITFIELD_GET {
+			results = append(results, BitfieldResult{Value: readBitfield(b, op.Offset, op.Bits, op.Signed)})
+			continue
+		}
+
+		// copy the value before the first write so that a FAIL on every
+		// operation leaves the stored string untouched
+		if need := int((op.Offset + uint64(op.Bits) + 7) >> 3); !written || len(b) < need {
+			b = growBytes(b, need)
+			written = true
+		}
+
+		old := readBitfield(b, op.Offset, op.Bits, op.Signed)
+
+		var base, incr int64
+
+		if op.Kind == BITFIELD_SET {
+			base, incr = op.Value, 0
+		} else {
+			base, incr = old, op.Value
+		}
+
+		newValue, overflow := applyBitfieldOverflow(base, incr, op.Bits, op.Signed, op.Overflow)
+
+		if overflow && op.Overflow == BITFIELD_OVERFLOW_FAIL {
+			results = append(results, BitfieldResult{Null: true})
+			continue
+		}
+
+		writeBitfield(b, op.Offset, op.Bits, uint64(newValue))
+
+		if op.Kind == BITFIELD_SET {
+			results = append(results, BitfieldResult{Value: old})
+		} else {
+			results = append(results, BitfieldResult{Value: newValue})
+		}
+	}
+
+	if written {
+		m[key] = newStoreValue(RawBytes{Bytes: b}, expiryTime)
+	}
+
+	return results, nil
+}
+
+func readBitfield(b []byte, offset uint64, width uint, signed bool) int64 {
+	var value uint64
+
+	for i := range uint64(width) {
+		pos := offset + i
+		bit := uint64(0)
+
+		if pos>>3 < uint64(len(b)) {
+			bit = uint64(b[pos>>3]>>(7-pos&7)) & 1
+		}
+
+		value = value<<1 | bit
+	}
+
+	if signed && width < 64 && value&(1<<(width-1)) != 0 {
+		value |= math.MaxUint64 << width
+	}
+
+	return int64(value)
+}
+
+func writeBitfield(b []byte, offset uint64, width uint, value uint64) {
+	for i := range uint64(width) {
+		pos := offset + i
+		bit := byte(value>>(uint64(width)-1-i)) & 1
+		mask := byte(1) << (7 - pos&7)
+
+		if bit == 1 {
+			b[pos>>3] |= mask
+		} else {
+			b[pos>>3] &^= mask
+		}
+	}
+}
+
+// applyBitfieldOverflow computes value+incr for a field of the given width
+// and reports whether the result overflowed. The returned value is already
+// wrapped or saturated according to mode.
+func applyBitfieldOverflow(value, incr int64, width uint, signed bool, mode BitfieldOverflow) (int64, bool) {
+	if signed {
+		maxValue := int64(math.MaxInt64)
+
+		if width < 64 {
+			maxValue = int64(1)<<(width-1) - 1
+		}
+
+		minValue := -maxValue - 1
+		maxIncr := maxValue - value
+		minIncr := minValue - value
+
+		if value > maxValue || (width != 64 && incr > maxIncr) || (value >= 0 && incr > 0 && incr > maxIncr) {
+			if mode == BITFIELD_OVERFLOW_SAT {
+				return maxValue, true
+			}
+
+			return wrapSigned(value, incr, width), true
+		}
+
+		if value < minValue || (width != 64 && incr < minIncr) || (value < 0 && incr < 0 && incr < minIncr) {
+			if mode == BITFIELD_OVERFLOW_SAT {
+				return minValue, true
+			}
+
+			return wrapSigned(value, incr, width), true
+		}
+
+		return value + incr, false
+	}
+
+	maxValue := uint64(1)<<width - 1
+	uvalue := uint64(value)
+	maxIncr := int64(maxValue - uvalue)
+	minIncr := -int64(uvalue)
+
+	if uvalue > maxValue || (incr > 0 && incr > maxIncr) {
+		if mode == BITFIELD_OVERFLOW_SAT {
+			return int64(maxValue), true
+		}
+
+		return int64((uvalue + uint64(incr)) & maxValue), true
+	}
+
+	if incr < 0 && incr < minIncr {
+		if mode == BITFIELD_OVERFLOW_SAT {
+			return 0, true
+		}
+
+		return int64((uvalue + uint64(incr)) & maxValue), true
+	}
+
+	return int64(uvalue + uint64(incr)), false
+}
+
+func wrapSigned(value, incr int64, width uint) int64 {
+	c := uint64(value) + uint64(incr)
+
+	if width < 64 {
+		msb := uint64(1) << (width - 1)
+		mask := uint64(math.MaxUint64) << width
+
+		if c&msb != 0 {
+			c |= mask
+		} else {
+			c &^= mask
+		}
+	}
+
+	return int64(c)
+}
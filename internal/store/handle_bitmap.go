@@ -0,0 +1,233 @@
+package store
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// maxBitOffset is the largest addressable bit in a string of at most
+// maxSize bytes, the proto-max-bulk-len.
+func maxBitOffset(maxSize int64) uint64 {
+	return uint64(maxSize)*8 - 1
+}
+
+var errBitOffset = errors.New("ERR bit offset is not an integer or out of range")
+
+func (m innerMap) setbit(key string, offset uint64, bit byte, maxSize int64) (byte, error) {
+	if offset > maxBitOffset(maxSize) {
+		return 0, errBitOffset
+	}
+
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var current []byte
+	expiryTime := getPossibleEndTime()
+
+	if exists {
+		current = v.value.(RawBytes).Bytes
+		expiryTime = v.expiryTime
+	}
+
+	byteIdx := int(offset >> 3)
+	newValue := growBytes(current, byteIdx+1)
+
+	mask := byte(1) << (7 - offset&7)
+	old := byte(0)
+
+	if newValue[byteIdx]&mask != 0 {
+		old = 1
+	}
+
+	if bit == 1 {
+		newValue[byteIdx] |= mask
+	} else {
+		newValue[byteIdx] &^= mask
+	}
+
+	m[key] = newStoreValue(RawBytes{Bytes: newValue}, expiryTime)
+
+	return old, nil
+}
+
+func (m innerMap) getbit(key string, offset uint64, maxSize int64) (byte, error) {
+	if offset > maxBitOffset(maxSize) {
+		return 0, errBitOffset
+	}
+
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	b := v.value.(RawBytes).Bytes
+	byteIdx := offset >> 3
+
+	if byteIdx >= uint64(len(b)) {
+		return 0, nil
+	}
+
+	return (b[byteIdx] >> (7 - offset&7)) & 1, nil
+}
+
+// BitRange describes the optional [start end [BYTE|BIT]] range accepted by
+// BITCOUNT and BITPOS.
+type BitRange struct {
+	Start    int
+	End      int
+	HasStart bool
+	HasEnd   bool
+	IsBit    bool
+}
+
+// normalize resolves negative indexes against a string of strLen bytes. It
+// returns the inclusive range in units of the range mode and false if the
+// range is empty.
+func (r BitRange) normalize(strLen int) (start int, end int, ok bool) {
+	total := strLen
+
+	if r.IsBit {
+		total = strLen * 8
+	}
+
+	start, end = 0, total-1
+
+	if r.HasStart {
+		start = r.Start
+	}
+
+	if r.HasEnd {
+		end = r.End
+	}
+
+	if start < 0 && end < 0 && start > end {
+		return 0, 0, false
+	}
+
+	if start < 0 {
+		start = max(total+start, 0)
+	}
+
+	if end < 0 {
+		end = max(total+end, 0)
+	}
+
+	if end >= total {
+		end = total - 1
+	}
+
+	if total == 0 || start > end {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+func (m innerMap) bitcount(key string, r BitRange) (int64, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	b := v.value.(RawBytes).Bytes
+
+	start, end, ok := r.normalize(len(b))
+	if !ok {
+		return 0, nil
+	}
+
+	if !r.IsBit {
+		return int64(popcount(b[start : end+1])), nil
+	}
+
+	firstByte, lastByte := start>>3, end>>3
+	firstMask := byte(0xff) >> (start & 7)
+	lastMask := byte(0xff) << (7 - end&7)
+
+	if firstByte == lastByte {
+		return int64(bits.OnesCount8(b[firstByte] & firstMask & lastMask)), nil
+	}
+
+	count := bits.OnesCount8(b[firstByte]&firstMask) + bits.OnesCount8(b[lastByte]&lastMask)
+	count += popcount(b[firstByte+1 : lastByte])
+
+	return int64(count), nil
+}
+
+func (m innerMap) bitpos(key string, bit byte, r BitRange) (int64, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		if bit == 1 {
+			return -1, nil
+		}
+
+		return 0, nil
+	}
+
+	b := v.value.(RawBytes).Bytes
+
+	start, end, ok := r.normalize(len(b))
+	if !ok {
+		return -1, nil
+	}
+
+	firstBit, lastBit := start, end
+
+	if !r.IsBit {
+		firstBit, lastBit = start*8, end*8+7
+	}
+
+	for pos := firstBit; pos <= lastBit; {
+		// skip whole bytes that cannot contain the bit we are looking for
+		if pos&7 == 0 && pos+7 <= lastBit {
+			skip := byte(0x00)
+
+			if bit == 0 {
+				skip = 0xff
+			}
+
+			if b[pos>>3] == skip {
+				pos += 8
+				continue
+			}
+		}
+
+		if (b[pos>>3]>>(7-pos&7))&1 == bit {
+			return int64(pos), nil
+		}
+
+		pos++
+	}
+
+	// a string is considered to be padded with zeros on the right unless the
+	// caller restricted the range explicitly
+	if bit == 0 && !r.HasEnd {
+		return int64(lastBit + 1), nil
+	}
+
+	return -1, nil
+}
+
+func popcount(b []byte) int {
+	count := 0
+
+	for _, c := range b {
+		count += bits.OnesCount8(c)
+	}
+
+	return count
+}
+
+// growBytes returns a copy of b that is at least size bytes long.
+func growBytes(b []byte, size int) []byte {
+	out := make([]byte, max(len(b), size))
+	copy(out, b)
+
+	return out
+}
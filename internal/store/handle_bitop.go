@@ -0,0 +1,80 @@
+package store
+
+import "errors"
+
+type BitOp string
+
+const (
+	BITOP_AND BitOp = "AND"
+	BITOP_OR  BitOp = "OR"
+	BITOP_XOR BitOp = "XOR"
+	BITOP_NOT BitOp = "NOT"
+)
+
+func (m innerMap) bitop(op BitOp, dest string, keys []string) (int64, error) {
+	if op == BITOP_NOT && len(keys) != 1 {
+		return 0, errors.New("ERR BITOP NOT must be called with a single source key.")
+	}
+
+	sources := make([][]byte, 0, len(keys))
+	maxLen := 0
+
+	for _, key := range keys {
+		v, exists, err := m.getStringForWrite(key)
+		if err != nil {
+			return 0, err
+		}
+
+		var b []byte
+
+		if exists {
+			b = v.value.(RawBytes).Bytes
+		}
+
+		sources = append(sources, b)
+		maxLen = max(maxLen, len(b))
+	}
+
+	if maxLen == 0 {
+		delete(m, dest)
+		return 0, nil
+	}
+
+	result := make([]byte, maxLen)
+
+	for i := range maxLen {
+		out := byteAt(sources[0], i)
+
+		if op == BITOP_NOT {
+			result[i] = ^out
+			continue
+		}
+
+		for _, src := range sources[1:] {
+			c := byteAt(src, i)
+
+			switch op {
+			case BITOP_AND:
+				out &= c
+			case BITOP_OR:
+				out |= c
+			case BITOP_XOR:
+				out ^= c
+			}
+		}
+
+		result[i] = out
+	}
+
+	m[dest] = newStoreValue(RawBytes{Bytes: result}, getPossibleEndTime())
+
+	return int64(maxLen), nil
+}
+
+func byteAt(b []byte, i int) byte {
+	if i >= len(b) {
+		return 0
+	}
+
+	return b[i]
+}
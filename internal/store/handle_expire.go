@@ -0,0 +1,33 @@
+package store
+
+import "time"
+
+// pexpireat sets the expiry of key to t and reports whether key exists. A
+// time in the past deletes key.
+func (m innerMap) pexpireat(key string, t time.Time) bool {
+	v, ok := m[key]
+	if !ok || v.isExpired() {
+		return false
+	}
+
+	if !t.After(time.Now()) {
+		delete(m, key)
+		return true
+	}
+
+	m[key] = newStoreValue(v.value, t)
+
+	return true
+}
+
+// persist removes the expiry of key and reports whether it had one.
+func (m innerMap) persist(key string) bool {
+	v, ok := m[key]
+	if !ok || v.isExpired() || v.expiryTime == getPossibleEndTime() {
+		return false
+	}
+
+	m[key] = newStoreValue(v.value, getPossibleEndTime())
+
+	return true
+}
@@ -16,3 +16,24 @@ func (m innerMap) get(key string) (value []byte, ok bool, expired bool) {
 
 	return rb.Bytes, ok, false
 }
+
+// getStringForWrite looks up a string value for a mutating operation.
+// Expired keys are removed and reported as missing.
+func (m innerMap) getStringForWrite(key string) (value storeValue, exists bool, err error) {
+	v, ok := m[key]
+
+	if !ok {
+		return storeValue{}, false, nil
+	}
+
+	if v.isExpired() {
+		delete(m, key)
+		return storeValue{}, false, nil
+	}
+
+	if _, isRawBytes := v.value.(RawBytes); !isRawBytes {
+		return storeValue{}, false, errWrongType
+	}
+
+	return v, true, nil
+}
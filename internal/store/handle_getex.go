@@ -0,0 +1,64 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+func (m innerMap) getset(key string, value []byte) ([]byte, bool, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	m[key] = newStoreValue(RawBytes{Bytes: value}, getPossibleEndTime())
+
+	if !exists {
+		return nil, false, nil
+	}
+
+	return v.value.(RawBytes).Bytes, true, nil
+}
+
+func (m innerMap) getdel(key string) ([]byte, bool, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	delete(m, key)
+
+	return v.value.(RawBytes).Bytes, true, nil
+}
+
+// getex returns the value of key and sets its expiry, also returned.
+func (m innerMap) getex(key string, expType ExpiryType, expTime int, persist bool) ([]byte, time.Time, bool, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil || !exists {
+		return nil, time.Time{}, false, err
+	}
+
+	b := v.value.(RawBytes).Bytes
+	expiresAt := v.expiryTime
+
+	switch {
+	case persist:
+		expiresAt = getPossibleEndTime()
+		m[key] = newStoreValue(v.value, expiresAt)
+	case expType != "":
+		t, ok := getExpiryTime(expType, expTime)
+		if !ok {
+			return nil, time.Time{}, false, errors.New("ERR invalid expire time in 'getex' command")
+		}
+
+		expiresAt = t
+
+		if !t.After(time.Now()) {
+			delete(m, key)
+		} else {
+			m[key] = newStoreValue(v.value, t)
+		}
+	}
+
+	return append([]byte{}, b...), expiresAt, true, nil
+}
@@ -0,0 +1,50 @@
+package store
+
+func (m innerMap) getrange(key string, start, end int) ([]byte, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return []byte{}, nil
+	}
+
+	b := v.value.(RawBytes).Bytes
+	strLen := len(b)
+
+	if start < 0 && end < 0 && start > end {
+		return []byte{}, nil
+	}
+
+	if start < 0 {
+		start = max(strLen+start, 0)
+	}
+
+	if end < 0 {
+		end = max(strLen+end, 0)
+	}
+
+	if end >= strLen {
+		end = strLen - 1
+	}
+
+	if strLen == 0 || start > end {
+		return []byte{}, nil
+	}
+
+	return append([]byte{}, b[start:end+1]...), nil
+}
+
+func (m innerMap) strlen(key string) (int64, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		return 0, nil
+	}
+
+	return int64(len(v.value.(RawBytes).Bytes)), nil
+}
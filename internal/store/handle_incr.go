@@ -1,30 +1,62 @@
 package store
 
 import (
-	"fmt"
+	"errors"
+	"math"
 	"strconv"
 )
 
-func (m innerMap) incr(key string) (list int64, err error) {
-	valueRaw, ok := m[key]
-
-	if !ok {
-		m[key] = newStoreValue(RawBytes{Bytes: []byte("1")}, getPossibleEndTime())
-		return 1, nil
+func (m innerMap) incrBy(key string, delta int64) (int64, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return 0, err
 	}
 
-	value, ok := valueRaw.value.(RawBytes)
-	if !ok {
-		return 0, fmt.Errorf("WRONGTYPE Operation against a key holding the wrong kind of value")
+	if !exists {
+		m[key] = newStoreValue(RawBytes{Bytes: strconv.AppendInt(nil, delta, 10)}, getPossibleEndTime())
+		return delta, nil
 	}
 
-	valueInt, err := strconv.ParseInt(string(value.Bytes), 10, 64)
+	valueInt, err := strconv.ParseInt(string(v.value.(RawBytes).Bytes), 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("ERR value is not an integer or out of range")
+		return 0, errors.New("ERR value is not an integer or out of range")
+	}
+
+	if (delta > 0 && valueInt > math.MaxInt64-delta) || (delta < 0 && valueInt < math.MinInt64-delta) {
+		return 0, errors.New("ERR increment or decrement would overflow")
 	}
 
-	valueInt += 1
-	m[key] = newStoreValue(RawBytes{Bytes: fmt.Append(nil, valueInt)}, getPossibleEndTime())
+	valueInt += delta
+	m[key] = newStoreValue(RawBytes{Bytes: strconv.AppendInt(nil, valueInt, 10)}, v.expiryTime)
 
 	return valueInt, nil
 }
+
+func (m innerMap) incrByFloat(key string, delta float64) ([]byte, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return nil, err
+	}
+
+	current := float64(0)
+	expiryTime := getPossibleEndTime()
+
+	if exists {
+		current, err = strconv.ParseFloat(string(v.value.(RawBytes).Bytes), 64)
+		if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+			return nil, errors.New("ERR value is not a valid float")
+		}
+
+		expiryTime = v.expiryTime
+	}
+
+	result := current + delta
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return nil, errors.New("ERR increment would produce NaN or Infinity")
+	}
+
+	b := strconv.AppendFloat(nil, result, 'f', -1, 64)
+	m[key] = newStoreValue(RawBytes{Bytes: b}, expiryTime)
+
+	return append([]byte{}, b...), nil
+}
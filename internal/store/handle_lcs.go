@@ -0,0 +1,117 @@
+package store
+
+import "errors"
+
+// LcsMatch is a contiguous range shared by both strings of an LCS. Ranges
+// are inclusive byte offsets.
+type LcsMatch struct {
+	AStart, AEnd int
+	BStart, BEnd int
+}
+
+func (m LcsMatch) Len() int {
+	return m.AEnd - m.AStart + 1
+}
+
+type LcsResult struct {
+	Lcs     []byte
+	Matches []LcsMatch
+}
+
+func (m innerMap) lcsOperands(key1, key2 string) (a []byte, b []byte, err error) {
+	operands := make([][]byte, 0, 2)
+
+	for _, key := range []string{key1, key2} {
+		v, ok := m[key]
+
+		if !ok || v.isExpired() {
+			operands = append(operands, nil)
+			continue
+		}
+
+		rb, isRawBytes := v.value.(RawBytes)
+		if !isRawBytes {
+			return nil, nil, errors.New("ERR The specified keys must contain string values")
+		}
+
+		operands = append(operands, append([]byte{}, rb.Bytes...))
+	}
+
+	return operands[0], operands[1], nil
+}
+
+// lcs computes the longest common subsequence of a and b with the classic
+// dynamic programming table, then walks it backwards from the end of both
+// strings collecting the matched ranges, exactly like Redis does. The table
+// may not take more than maxSize bytes, the proto-max-bulk-len.
+func lcs(a, b []byte, maxSize int64) (LcsResult, error) {
+	alen, blen := len(a), len(b)
+	tableSize := uint64(alen+1) * uint64(blen+1)
+
+	if tableSize*4 > uint64(maxSize) {
+		return LcsResult{}, errors.New("ERR Insufficient memory, transient memory for LCS exceeds proto-max-bulk-len")
+	}
+
+	dp := make([]uint32, tableSize)
+	at := func(i, j int) uint32 {
+		return dp[j+i*(blen+1)]
+	}
+
+	for i := 1; i <= alen; i++ {
+		for j := 1; j <= blen; j++ {
+			switch {
+			case a[i-1] == b[j-1]:
+				dp[j+i*(blen+1)] = at(i-1, j-1) + 1
+			case at(i-1, j) > at(i, j-1):
+				dp[j+i*(blen+1)] = at(i-1, j)
+			default:
+				dp[j+i*(blen+1)] = at(i, j-1)
+			}
+		}
+	}
+
+	idx := int(at(alen, blen))
+	result := LcsResult{Lcs: make([]byte, idx)}
+
+	current := LcsMatch{AStart: -1}
+	i, j := alen, blen
+
+	for i > 0 && j > 0 {
+		emit := false
+
+		if a[i-1] == b[j-1] {
+			result.Lcs[idx-1] = a[i-1]
+
+			if current.AStart == -1 {
+				current = LcsMatch{AStart: i - 1, AEnd: i - 1, BStart: j - 1, BEnd: j - 1}
+			} else {
+				current.AStart--
+				current.BStart--
+			}
+
+			// the range cannot be extended past the first byte of a string
+			if current.AStart == 0 || current.BStart == 0 {
+				emit = true
+			}
+
+			idx--
+			i--
+			j--
+		} else {
+			if at(i-1, j) > at(i, j-1) {
+				i--
+			} else {
+				j--
+			}
+
+			emit = current.AStart != -1
+		}
+
+		if emit {
+			result.Matches = append(result.Matches, current)
+			current = LcsMatch{AStart: -1}
+		}
+	}
+
+	return result, nil
+}
@@ -30,7 +30,12 @@ func (m innerMap) lpop(key string, count int) (list List, ok bool) {
 	elems := l.Elements[:count]
 	newList := l.Elements[count:]
 
-	m[key] = newStoreValue(List{Elements: newList}, v.expiryTime)
+	// like Redis, a list left without elements is removed
+	if len(newList) == 0 {
+		delete(m, key)
+	} else {
+		m[key] = newStoreValue(List{Elements: newList}, v.expiryTime)
+	}
 
 	return List{Elements: elems}, true
 }
@@ -0,0 +1,25 @@
+package store
+
+func (m innerMap) mset(pairs [][]byte) {
+	for i := 0; i < len(pairs); i += 2 {
+		m[string(pairs[i])] = newStoreValue(RawBytes{Bytes: pairs[i+1]}, getPossibleEndTime())
+	}
+}
+
+func (m innerMap) msetnx(pairs [][]byte) bool {
+	for i := 0; i < len(pairs); i += 2 {
+		if m.exists(string(pairs[i])) {
+			return false
+		}
+	}
+
+	m.mset(pairs)
+
+	return true
+}
+
+func (m innerMap) exists(key string) bool {
+	v, ok := m[key]
+
+	return ok && !v.isExpired()
+}
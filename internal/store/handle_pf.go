@@ -0,0 +1,120 @@
+package store
+
+import "github.com/codecrafters-io/redis-starter-go/internal/hyperloglog"
+
+func (m innerMap) pfadd(key string, elements [][]byte) (bool, error) {
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return false, err
+	}
+
+	hll := hyperloglog.New()
+	expiryTime := getPossibleEndTime()
+
+	if exists {
+		hll = v.value.(RawBytes).Bytes
+		expiryTime = v.expiryTime
+
+		if err := hyperloglog.Validate(hll); err != nil {
+			return false, err
+		}
+	}
+
+	hll, changed, err := hyperloglog.Add(hll, elements)
+	if err != nil {
+		return false, err
+	}
+
+	if changed || !exists {
+		m[key] = newStoreValue(RawBytes{Bytes: hll}, expiryTime)
+	}
+
+	return changed || !exists, nil
+}
+
+func (m innerMap) pfcount(keys []string) (int64, error) {
+	if len(keys) == 1 {
+		v, exists, err := m.getStringForWrite(keys[0])
+		if err != nil || !exists {
+			return 0, err
+		}
+
+		hll := v.value.(RawBytes).Bytes
+
+		if err := hyperloglog.Validate(hll); err != nil {
+			return 0, err
+		}
+
+		// the cached cardinality is written back into the header in place
+		card, _, err := hyperloglog.Count(hll)
+
+		return int64(card), err
+	}
+
+	regs := make([]uint8, hyperloglog.Registers)
+
+	for _, key := range keys {
+		v, exists, err := m.getStringForWrite(key)
+		if err != nil {
+			return 0, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		hll := v.value.(RawBytes).Bytes
+
+		if err := hyperloglog.Validate(hll); err != nil {
+			return 0, err
+		}
+
+		if err := hyperloglog.Merge(regs, hll); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(hyperloglog.CountRegisters(regs)), nil
+}
+
+func (m innerMap) pfmerge(dest string, keys []string) error {
+	regs := make([]uint8, hyperloglog.Registers)
+	useDense := false
+	expiryTime := getPossibleEndTime()
+
+	destValue, destExists, err := m.getStringForWrite(dest)
+	if err != nil {
+		return err
+	}
+
+	if destExists {
+		expiryTime = destValue.expiryTime
+	}
+
+	for _, key := range append([]string{dest}, keys...) {
+		v, exists, err := m.getStringForWrite(key)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			continue
+		}
+
+		hll := v.value.(RawBytes).Bytes
+
+		if err := hyperloglog.Validate(hll); err != nil {
+			return err
+		}
+
+		useDense = useDense || hyperloglog.IsDense(hll)
+
+		if err := hyperloglog.Merge(regs, hll); err != nil {
+			return err
+		}
+	}
+
+	m[dest] = newStoreValue(RawBytes{Bytes: hyperloglog.FromRegisters(regs, useDense)}, expiryTime)
+
+	return nil
+}
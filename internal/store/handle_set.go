@@ -13,6 +13,26 @@ func (m innerMap) set(key string, value []byte, expType ExpiryType, expiryTime i
 	return true
 }
 
+// setKeepTTL sets key to value, keeping the expiry of key when it exists.
+func (m innerMap) setKeepTTL(key string, value []byte) {
+	expiryTime := getPossibleEndTime()
+
+	if v, ok := m[key]; ok && !v.isExpired() {
+		expiryTime = v.expiryTime
+	}
+
+	m[key] = newStoreValue(RawBytes{Bytes: value}, expiryTime)
+}
+
+func (m innerMap) setnx(key string, value []byte) bool {
+	if m.exists(key) {
+		return false
+	}
+
+	m[key] = newStoreValue(RawBytes{Bytes: value}, getPossibleEndTime())
+	return true
+}
+
 func getExpiryTime(expType ExpiryType, expTime int) (keyExpTime time.Time, ok bool) {
 	t := time.Now()
 
@@ -21,6 +41,10 @@ func getExpiryTime(expType ExpiryType, expTime int) (keyExpTime time.Time, ok bo
 		t = t.Add(time.Duration(expTime) * time.Second)
 	case EXPIRY_PX:
 		t = t.Add(time.Duration(expTime) * time.Millisecond)
+	case EXPIRY_EXAT:
+		t = time.Unix(int64(expTime), 0)
+	case EXPIRY_PXAT:
+		t = time.UnixMilli(int64(expTime))
 	case "":
 		t = getPossibleEndTime()
 	default:
@@ -0,0 +1,41 @@
+package store
+
+import "errors"
+
+// setrange fails when the string would grow past maxSize, the
+// proto-max-bulk-len.
+func (m innerMap) setrange(key string, offset int, value []byte, maxSize int64) (int64, error) {
+	if offset < 0 {
+		return 0, errors.New("ERR offset is out of range")
+	}
+
+	v, exists, err := m.getStringForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(value) > 0 && int64(offset)+int64(len(value)) > maxSize {
+		return 0, errors.New("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+	}
+
+	var current []byte
+	expiryTime := getPossibleEndTime()
+
+	if exists {
+		current = v.value.(RawBytes).Bytes
+		expiryTime = v.expiryTime
+	}
+
+	if len(value) == 0 {
+		return int64(len(current)), nil
+	}
+
+	newLen := max(len(current), offset+len(value))
+	newValue := make([]byte, newLen)
+	copy(newValue, current)
+	copy(newValue[offset:], value)
+
+	m[key] = newStoreValue(RawBytes{Bytes: newValue}, expiryTime)
+
+	return int64(newLen), nil
+}
@@ -0,0 +1,27 @@
+package store
+
+// xack removes the given IDs from the pending entries list of the group and
+// returns how many were pending.
+func (m innerMap) xack(key, group string, ids []StreamIdSpec) (int64, error) {
+	g, err := m.lookupGroup(key, group)
+	if err != nil || g == nil {
+		return 0, err
+	}
+
+	var acked int64
+
+	for _, spec := range ids {
+		id := storedStreamId{MsTime: spec.MsTime, Seq: spec.Seq}
+
+		entry, ok := g.Pending[id]
+		if !ok {
+			continue
+		}
+
+		delete(entry.consumer.Pending, id)
+		delete(g.Pending, id)
+		acked++
+	}
+
+	return acked, nil
+}
@@ -7,7 +7,7 @@ import (
 	"time"
 )
 
-func (m innerMap) xadd(key string, streamId StreamIdSpec, fields [][]string) (streamElement StreamElement, err error) {
+func (m innerMap) xadd(key string, streamId StreamIdSpec, fields [][]string, opts XaddOptions) (streamElement StreamElement, added bool, err error) {
 	sv, ok := m[key]
 
 	fields = cloneStreamFields(fields)
@@ -16,6 +16,10 @@ func (m innerMap) xadd(key string, streamId StreamIdSpec, fields [][]string) (st
 	msTime := streamId.MsTime
 
 	if !ok || sv.isExpired() {
+		if opts.NoMkStream {
+			return StreamElement{}, false, nil
+		}
+
 		msTime, seqNumber = getNewStreamId(streamId)
 
 		sEl := StreamElement{
@@ -23,21 +27,24 @@ func (m innerMap) xadd(key string, streamId StreamIdSpec, fields [][]string) (st
 			Fields: fields,
 		}
 
-		m[key] = newStoreValue(Stream{
-			Elements:           []StreamElement{sEl},
-			LtsInsertedIdParts: storedStreamId{msTime, seqNumber},
-		}, getPossibleEndTime())
+		stream := newStream()
+		stream.entries.append(sEl)
+		stream.LtsInsertedIdParts = sEl.Id
+		stream.EntriesAdded = 1
+		stream.trim(opts.Trim)
 
-		return sEl, nil
+		m[key] = newStoreValue(stream, getPossibleEndTime())
+
+		return sEl, true, nil
 	}
 
-	stream, okStream := sv.value.(Stream)
+	stream, okStream := sv.value.(*Stream)
 	if !okStream {
-		return StreamElement{}, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+		return StreamElement{}, false, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
 	}
 
 	if isValidStreamId := validateStreamIdParts(streamId, stream.LtsInsertedIdParts); !isValidStreamId {
-		return StreamElement{}, fmt.Errorf("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+		return StreamElement{}, false, fmt.Errorf("ERR The ID specified in XADD is equal or smaller than the target stream top item")
 	}
 
 	if streamId.AutoSeq {
@@ -47,7 +54,7 @@ func (m innerMap) xadd(key string, streamId StreamIdSpec, fields [][]string) (st
 			seqNumber = 0
 		} else {
 			if stream.LtsInsertedIdParts.Seq == math.MaxUint64 {
-				return StreamElement{}, fmt.Errorf("ERR sequence overflow for XADD command")
+				return StreamElement{}, false, fmt.Errorf("ERR sequence overflow for XADD command")
 			}
 
 			seqNumber = stream.LtsInsertedIdParts.Seq + 1
@@ -57,7 +64,7 @@ func (m innerMap) xadd(key string, streamId StreamIdSpec, fields [][]string) (st
 
 		if stream.LtsInsertedIdParts.MsTime == msTime {
 			if stream.LtsInsertedIdParts.Seq == math.MaxUint64 {
-				return StreamElement{}, fmt.Errorf("ERR sequence overflow for XADD command")
+				return StreamElement{}, false, fmt.Errorf("ERR sequence overflow for XADD command")
 			}
 
 			seqNumber = stream.LtsInsertedIdParts.Seq + 1
@@ -68,14 +75,12 @@ func (m innerMap) xadd(key string, streamId StreamIdSpec, fields [][]string) (st
 
 	sEl := StreamElement{Id: storedStreamId{msTime, seqNumber}, Fields: fields}
 
-	newElements := append(stream.Elements, sEl)
-
-	m[key] = newStoreValue(Stream{
-		Elements:           newElements,
-		LtsInsertedIdParts: storedStreamId{msTime, seqNumber},
-	}, sv.expiryTime)
+	stream.entries.append(sEl)
+	stream.LtsInsertedIdParts = sEl.Id
+	stream.EntriesAdded++
+	stream.trim(opts.Trim)
 
-	return sEl, nil
+	return sEl, true, nil
 }
 
 func cloneStreamFields(fields [][]string) [][]string {
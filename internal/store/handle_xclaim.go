@@ -0,0 +1,186 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+// XclaimOptions holds the options shared by XCLAIM and XAUTOCLAIM.
+type XclaimOptions struct {
+	MinIdleMs int64
+	// DeliveryTime is set on the claimed entries, zero means now. It is
+	// derived from the IDLE and TIME options.
+	DeliveryTime  time.Time
+	RetryCount    int64
+	HasRetryCount bool
+	Force         bool
+	JustId        bool
+	LastId        StreamIdSpec
+	HasLastId     bool
+}
+
+// XautoclaimResult is the reply of XAUTOCLAIM. Next is the cursor to pass
+// as start to continue the scan, "0-0" once the whole list was scanned.
+type XautoclaimResult struct {
+	Next    string
+	Claimed []StreamElement
+	Deleted []string
+}
+
+// xautoclaimAttemptsFactor bounds the number of pending entries scanned by
+// a single XAUTOCLAIM call to COUNT times this factor.
+const xautoclaimAttemptsFactor = 10
+
+// claim transfers entry to consumer.
+func (g *StreamGroup) claim(entry *streamPendingEntry, consumer *StreamConsumer, opts XclaimOptions, now time.Time) {
+	delete(entry.consumer.Pending, entry.id)
+
+	entry.consumer = consumer
+	consumer.Pending[entry.id] = entry
+	consumer.ActiveTime = now
+
+	entry.deliveryTime = now
+	if !opts.DeliveryTime.IsZero() && opts.DeliveryTime.Before(now) {
+		entry.deliveryTime = opts.DeliveryTime
+	}
+
+	if opts.HasRetryCount {
+		entry.deliveryCount = opts.RetryCount
+	} else if !opts.JustId {
+		entry.deliveryCount++
+	}
+}
+
+// unpend removes id from the pending entries list, used for entries that
+// were deleted from the stream.
+func (g *StreamGroup) unpend(entry *streamPendingEntry) {
+	delete(entry.consumer.Pending, entry.id)
+	delete(g.Pending, entry.id)
+}
+
+// xclaim transfers the given pending entries to consumer when they have been
+// idle for at least opts.MinIdleMs. Entries no longer in the stream are
+// dropped from the pending entries list and left out of the reply.
+func (m innerMap) xclaim(key, group, consumerName string, ids []StreamIdSpec, opts XclaimOptions) ([]StreamElement, error) {
+	stream, _, err := m.lookupStream(key)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := m.existingGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	consumer, _ := g.consumer(consumerName, now)
+
+	if opts.HasLastId {
+		lastId := storedStreamId{MsTime: opts.LastId.MsTime, Seq: opts.LastId.Seq}
+		if greater(lastId, g.LastDeliveredId) {
+			g.LastDeliveredId = lastId
+		}
+	}
+
+	claimed := []StreamElement{}
+
+	for _, spec := range ids {
+		id := storedStreamId{MsTime: spec.MsTime, Seq: spec.Seq}
+		el, exists := stream.entries.lookup(id)
+
+		entry, pending := g.Pending[id]
+		if !pending {
+			if !opts.Force || !exists {
+				continue
+			}
+
+			// FORCE creates the entry as if it was delivered to consumer
+			entry = &streamPendingEntry{id: id, consumer: consumer, deliveryTime: now}
+			g.Pending[id] = entry
+			consumer.Pending[id] = entry
+		}
+
+		if !exists {
+			g.unpend(entry)
+			continue
+		}
+
+		if opts.MinIdleMs > 0 && now.Sub(entry.deliveryTime).Milliseconds() < opts.MinIdleMs {
+			continue
+		}
+
+		g.claim(entry, consumer, opts, now)
+
+		if opts.JustId {
+			el = StreamElement{Id: id}
+		}
+
+		claimed = append(claimed, el)
+	}
+
+	return claimed, nil
+}
+
+func (m innerMap) xautoclaim(key, group, consumerName string, start string, count int, opts XclaimOptions) (XautoclaimResult, error) {
+	startId, _, err := resolveXrangeBounds(start, "+")
+	if err != nil {
+		return XautoclaimResult{}, err
+	}
+
+	if count <= 0 {
+		return XautoclaimResult{}, errors.New("ERR COUNT must be > 0")
+	}
+
+	stream, _, err := m.lookupStream(key)
+	if err != nil {
+		return XautoclaimResult{}, err
+	}
+
+	g, err := m.existingGroup(key, group)
+	if err != nil {
+		return XautoclaimResult{}, err
+	}
+
+	now := time.Now()
+	consumer, _ := g.consumer(consumerName, now)
+
+	result := XautoclaimResult{Next: "0-0", Claimed: []StreamElement{}, Deleted: []string{}}
+	attempts := count * xautoclaimAttemptsFactor
+
+	ids := sortedPendingIds(g.Pending)
+
+	for i, id := range ids {
+		if less(id, startId) {
+			continue
+		}
+
+		if attempts == 0 || len(result.Claimed) == count {
+			result.Next = ids[i].ToString()
+			break
+		}
+
+		attempts--
+		entry := g.Pending[id]
+
+		el, exists := stream.entries.lookup(id)
+		if !exists {
+			g.unpend(entry)
+			result.Deleted = append(result.Deleted, id.ToString())
+			continue
+		}
+
+		if opts.MinIdleMs > 0 && now.Sub(entry.deliveryTime).Milliseconds() < opts.MinIdleMs {
+			continue
+		}
+
+		g.claim(entry, consumer, opts, now)
+
+		if opts.JustId {
+			el = StreamElement{Id: id}
+		}
+
+		result.Claimed = append(result.Claimed, el)
+	}
+
+	return result, nil
+}
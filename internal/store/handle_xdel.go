@@ -0,0 +1,35 @@
+package store
+
+func (m innerMap) xlen(key string) (int64, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	return int64(stream.entries.len()), nil
+}
+
+func (m innerMap) xdel(key string, ids []StreamIdSpec) (int64, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	var deleted int64
+
+	for _, spec := range ids {
+		id := storedStreamId{MsTime: spec.MsTime, Seq: spec.Seq}
+
+		if !stream.entries.delete(id) {
+			continue
+		}
+
+		deleted++
+
+		if greater(id, stream.MaxDeletedEntryId) {
+			stream.MaxDeletedEntryId = id
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,271 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errXgroupNoKey = errors.New("ERR The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+
+// StreamInvalidEntriesRead marks a group whose entries-read counter is
+// unknown.
+const StreamInvalidEntriesRead = -1
+
+func noGroupError(key, group string) error {
+	return fmt.Errorf("NOGROUP No such consumer group '%s' for key name '%s'", group, key)
+}
+
+// lookupStream returns the stream stored at key. ok is false when the key
+// does not exist or is expired.
+func (m innerMap) lookupStream(key string) (stream *Stream, ok bool, err error) {
+	v, ok := m[key]
+
+	if !ok || v.isExpired() {
+		return nil, false, nil
+	}
+
+	stream, ok = v.value.(*Stream)
+	if !ok {
+		return nil, false, errWrongType
+	}
+
+	return stream, true, nil
+}
+
+// lookupGroup returns the named consumer group of the stream stored at key,
+// nil when either the key or the group does not exist.
+func (m innerMap) lookupGroup(key, group string) (*StreamGroup, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return stream.Groups[group], nil
+}
+
+// resolveGroupId turns the ID argument of XGROUP CREATE/SETID into a stream
+// ID, "$" standing for the last ID of the stream.
+func (s *Stream) resolveGroupId(id StreamIdSpec) storedStreamId {
+	if id.IsMax {
+		return s.LtsInsertedIdParts
+	}
+
+	return storedStreamId{MsTime: id.MsTime, Seq: id.Seq}
+}
+
+// hasTombstonesFrom reports whether entries with an ID >= id may have been
+// deleted, in which case counting entries by position is unreliable.
+func (s *Stream) hasTombstonesFrom(id storedStreamId) bool {
+	if s.entries.len() == 0 || s.MaxDeletedEntryId == (storedStreamId{}) {
+		return false
+	}
+
+	return !less(s.MaxDeletedEntryId, id)
+}
+
+// estimateEntriesRead returns the number of entries added to the stream up
+// to and including id, or StreamInvalidEntriesRead when deletions make it
+// impossible to tell.
+func (s *Stream) estimateEntriesRead(id storedStreamId) int64 {
+	if s.EntriesAdded == 0 {
+		return 0
+	}
+
+	length := int64(s.entries.len())
+
+	if length == 0 && !greater(id, s.LtsInsertedIdParts) {
+		return s.EntriesAdded
+	}
+
+	if id == s.LtsInsertedIdParts {
+		return s.EntriesAdded
+	}
+
+	if greater(id, s.LtsInsertedIdParts) {
+		return StreamInvalidEntriesRead
+	}
+
+	firstEntry, _ := s.entries.first()
+	first := firstEntry.Id
+
+	if s.MaxDeletedEntryId == (storedStreamId{}) || less(s.MaxDeletedEntryId, first) {
+		// nothing was deleted after the first entry, entries before it were
+		// trimmed away in order
+		if less(id, first) {
+			return s.EntriesAdded - length
+		}
+
+		if id == first {
+			return s.EntriesAdded - length + 1
+		}
+	}
+
+	return StreamInvalidEntriesRead
+}
+
+// lag returns the number of entries not yet delivered to the group. ok is
+// false when it cannot be computed.
+func (s *Stream) lag(g *StreamGroup) (lag int64, ok bool) {
+	if s.EntriesAdded == 0 {
+		return 0, true
+	}
+
+	if g.EntriesRead != StreamInvalidEntriesRead && !s.hasTombstonesFrom(g.LastDeliveredId) {
+		return s.EntriesAdded - g.EntriesRead, true
+	}
+
+	entriesRead := s.estimateEntriesRead(g.LastDeliveredId)
+	if entriesRead == StreamInvalidEntriesRead {
+		return 0, false
+	}
+
+	return s.EntriesAdded - entriesRead, true
+}
+
+// consumer returns the named consumer, creating it when missing. created
+// reports whether the consumer was created by this call.
+func (g *StreamGroup) consumer(name string, now time.Time) (c *StreamConsumer, created bool) {
+	if c, ok := g.Consumers[name]; ok {
+		c.SeenTime = now
+		return c, false
+	}
+
+	c = &StreamConsumer{
+		Name:     name,
+		SeenTime: now,
+		Pending:  make(map[storedStreamId]*streamPendingEntry),
+	}
+	g.Consumers[name] = c
+
+	return c, true
+}
+
+// xgroupCreate creates a consumer group. entriesRead is the ENTRIESREAD
+// argument, StreamInvalidEntriesRead when not given.
+func (m innerMap) xgroupCreate(key, group string, id StreamIdSpec, mkStream bool, entriesRead int64) error {
+	v, ok := m[key]
+
+	if !ok || v.isExpired() {
+		if !mkStream {
+			return errXgroupNoKey
+		}
+
+		v = newStoreValue(newStream(), getPossibleEndTime())
+	}
+
+	stream, ok := v.value.(*Stream)
+	if !ok {
+		return errWrongType
+	}
+
+	if _, exists := stream.Groups[group]; exists {
+		return errors.New("BUSYGROUP Consumer Group name already exists")
+	}
+
+	if stream.Groups == nil {
+		stream.Groups = make(map[string]*StreamGroup)
+	}
+
+	stream.Groups[group] = &StreamGroup{
+		LastDeliveredId: stream.resolveGroupId(id),
+		EntriesRead:     entriesRead,
+		Pending:         make(map[storedStreamId]*streamPendingEntry),
+		Consumers:       make(map[string]*StreamConsumer),
+	}
+
+	m[key] = newStoreValue(stream, v.expiryTime)
+
+	return nil
+}
+
+func (m innerMap) xgroupDestroy(key, group string) (bool, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil {
+		return false, err
+	}
+
+	if !ok {
+		return false, errXgroupNoKey
+	}
+
+	if _, exists := stream.Groups[group]; !exists {
+		return false, nil
+	}
+
+	delete(stream.Groups, group)
+
+	return true, nil
+}
+
+func (m innerMap) xgroupSetId(key, group string, id StreamIdSpec, entriesRead int64) error {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errXgroupNoKey
+	}
+
+	g, exists := stream.Groups[group]
+	if !exists {
+		return noGroupError(key, group)
+	}
+
+	g.LastDeliveredId = stream.resolveGroupId(id)
+	g.EntriesRead = entriesRead
+
+	return nil
+}
+
+func (m innerMap) xgroupCreateConsumer(key, group, consumer string) (bool, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil {
+		return false, err
+	}
+
+	if !ok {
+		return false, errXgroupNoKey
+	}
+
+	g, exists := stream.Groups[group]
+	if !exists {
+		return false, noGroupError(key, group)
+	}
+
+	_, created := g.consumer(consumer, time.Now())
+
+	return created, nil
+}
+
+// xgroupDelConsumer removes a consumer and its pending entries and returns
+// the number of entries it still had pending.
+func (m innerMap) xgroupDelConsumer(key, group, consumer string) (int64, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, errXgroupNoKey
+	}
+
+	g, exists := stream.Groups[group]
+	if !exists {
+		return 0, noGroupError(key, group)
+	}
+
+	c, exists := g.Consumers[consumer]
+	if !exists {
+		return 0, nil
+	}
+
+	for id := range c.Pending {
+		delete(g.Pending, id)
+	}
+
+	delete(g.Consumers, consumer)
+
+	return int64(len(c.Pending)), nil
+}
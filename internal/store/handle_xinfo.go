@@ -0,0 +1,228 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var errNoSuchKey = errors.New("ERR no such key")
+
+// StreamInfo is the reply of XINFO STREAM. Entries and GroupDetails are
+// only filled in for the FULL form, FirstEntry and LastEntry only for the
+// default one.
+type StreamInfo struct {
+	Length               int64
+	LastGeneratedId      string
+	MaxDeletedEntryId    string
+	EntriesAdded         int64
+	RecordedFirstEntryId string
+	Groups               int64
+	RadixTreeKeys        int64
+	RadixTreeNodes       int64
+	FirstEntry           *StreamElement
+	LastEntry            *StreamElement
+	Entries              []StreamElement
+	GroupDetails         []StreamGroupInfo
+}
+
+// StreamGroupInfo describes a consumer group. Pending and Consumers are
+// only filled in for XINFO STREAM FULL.
+type StreamGroupInfo struct {
+	Name            string
+	ConsumersCount  int64
+	PendingCount    int64
+	LastDeliveredId string
+	EntriesRead     int64
+	HasEntriesRead  bool
+	Lag             int64
+	HasLag          bool
+	Pending         []PendingEntryInfo
+	Consumers       []StreamConsumerInfo
+}
+
+// StreamConsumerInfo describes a consumer. ActiveTime is zero when the
+// consumer never read or claimed anything. Pending is only filled in for
+// XINFO STREAM FULL.
+type StreamConsumerInfo struct {
+	Name         string
+	PendingCount int64
+	SeenTime     time.Time
+	ActiveTime   time.Time
+	IdleMs       int64
+	InactiveMs   int64
+	Pending      []PendingEntryInfo
+}
+
+// xinfoStream describes the stream stored at key. For the FULL form count
+// limits the entries and pending entries returned, 0 means no limit.
+func (m innerMap) xinfoStream(key string, full bool, count int) (StreamInfo, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil {
+		return StreamInfo{}, err
+	}
+
+	if !ok {
+		return StreamInfo{}, errNoSuchKey
+	}
+
+	info := StreamInfo{
+		Length:               int64(stream.entries.len()),
+		LastGeneratedId:      stream.LtsInsertedIdParts.ToString(),
+		MaxDeletedEntryId:    stream.MaxDeletedEntryId.ToString(),
+		EntriesAdded:         stream.EntriesAdded,
+		RecordedFirstEntryId: storedStreamId{}.ToString(),
+		Groups:               int64(len(stream.Groups)),
+		RadixTreeKeys:        int64(stream.entries.blocks),
+		RadixTreeNodes:       int64(stream.entries.rax.nodes + 1),
+	}
+
+	first, hasEntries := stream.entries.first()
+	if hasEntries {
+		info.RecordedFirstEntryId = first.Id.ToString()
+	}
+
+	if !full {
+		if hasEntries {
+			last, _ := stream.entries.last()
+			info.FirstEntry = &first
+			info.LastEntry = &last
+		}
+
+		return info, nil
+	}
+
+	info.Entries = stream.entries.rangeEntries(storedStreamId{}, maxStreamId, count, false)
+
+	now := time.Now()
+
+	for _, name := range sortedGroupNames(stream.Groups) {
+		g := stream.Groups[name]
+		gi := stream.groupInfo(name, g)
+		gi.Pending = pendingInfos(g.Pending, count, now)
+
+		for _, c := range sortedConsumers(g.Consumers) {
+			ci := c.info(now)
+			ci.Pending = pendingInfos(c.Pending, count, now)
+			gi.Consumers = append(gi.Consumers, ci)
+		}
+
+		info.GroupDetails = append(info.GroupDetails, gi)
+	}
+
+	return info, nil
+}
+
+func (m innerMap) xinfoGroups(key string) ([]StreamGroupInfo, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errNoSuchKey
+	}
+
+	groups := []StreamGroupInfo{}
+
+	for _, name := range sortedGroupNames(stream.Groups) {
+		groups = append(groups, stream.groupInfo(name, stream.Groups[name]))
+	}
+
+	return groups, nil
+}
+
+func (m innerMap) xinfoConsumers(key, group string) ([]StreamConsumerInfo, error) {
+	stream, ok, err := m.lookupStream(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errNoSuchKey
+	}
+
+	g, ok := stream.Groups[group]
+	if !ok {
+		return nil, noGroupError(key, group)
+	}
+
+	now := time.Now()
+	consumers := []StreamConsumerInfo{}
+
+	for _, c := range sortedConsumers(g.Consumers) {
+		consumers = append(consumers, c.info(now))
+	}
+
+	return consumers, nil
+}
+
+func (s *Stream) groupInfo(name string, g *StreamGroup) StreamGroupInfo {
+	info := StreamGroupInfo{
+		Name:            name,
+		ConsumersCount:  int64(len(g.Consumers)),
+		PendingCount:    int64(len(g.Pending)),
+		LastDeliveredId: g.LastDeliveredId.ToString(),
+		EntriesRead:     g.EntriesRead,
+		HasEntriesRead:  g.EntriesRead != StreamInvalidEntriesRead,
+	}
+
+	info.Lag, info.HasLag = s.lag(g)
+
+	return info
+}
+
+func (c *StreamConsumer) info(now time.Time) StreamConsumerInfo {
+	info := StreamConsumerInfo{
+		Name:         c.Name,
+		PendingCount: int64(len(c.Pending)),
+		SeenTime:     c.SeenTime,
+		ActiveTime:   c.ActiveTime,
+		IdleMs:       now.Sub(c.SeenTime).Milliseconds(),
+		InactiveMs:   -1,
+	}
+
+	if !c.ActiveTime.IsZero() {
+		info.InactiveMs = now.Sub(c.ActiveTime).Milliseconds()
+	}
+
+	return info
+}
+
+func pendingInfos(pending map[storedStreamId]*streamPendingEntry, count int, now time.Time) []PendingEntryInfo {
+	ids := sortedPendingIds(pending)
+	if count > 0 && len(ids) > count {
+		ids = ids[:count]
+	}
+
+	infos := make([]PendingEntryInfo, 0, len(ids))
+	for _, id := range ids {
+		infos = append(infos, pending[id].info(now))
+	}
+
+	return infos
+}
+
+func sortedGroupNames(groups map[string]*StreamGroup) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func sortedConsumers(consumers map[string]*StreamConsumer) []*StreamConsumer {
+	sorted := make([]*StreamConsumer, 0, len(consumers))
+	for _, c := range consumers {
+		sorted = append(sorted, c)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
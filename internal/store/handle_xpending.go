@@ -0,0 +1,142 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PendingSummary is the reply of XPENDING without a range. Min and Max are
+// empty when nothing is pending.
+type PendingSummary struct {
+	Count     int64
+	Min       string
+	Max       string
+	Consumers []PendingConsumerCount
+}
+
+type PendingConsumerCount struct {
+	Name  string
+	Count int64
+}
+
+// PendingEntryInfo describes one entry of a pending entries list.
+type PendingEntryInfo struct {
+	Id            string
+	Consumer      string
+	IdleMs        int64
+	DeliveryTime  time.Time
+	DeliveryCount int64
+}
+
+func (e *streamPendingEntry) info(now time.Time) PendingEntryInfo {
+	return PendingEntryInfo{
+		Id:            e.id.ToString(),
+		Consumer:      e.consumer.Name,
+		IdleMs:        now.Sub(e.deliveryTime).Milliseconds(),
+		DeliveryTime:  e.deliveryTime,
+		DeliveryCount: e.deliveryCount,
+	}
+}
+
+// XpendingRange holds the [IDLE min-idle-time] start end count [consumer]
+// arguments of the extended XPENDING form.
+type XpendingRange struct {
+	Start     string
+	End       string
+	Count     int
+	Consumer  string
+	MinIdleMs int64
+}
+
+// existingGroup is like lookupGroup but reports a missing key or group as
+// an error.
+func (m innerMap) existingGroup(key, group string) (*StreamGroup, error) {
+	g, err := m.lookupGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	if g == nil {
+		return nil, fmt.Errorf("NOGROUP No such key '%s' or consumer group '%s'", key, group)
+	}
+
+	return g, nil
+}
+
+func (m innerMap) xpendingSummary(key, group string) (PendingSummary, error) {
+	g, err := m.existingGroup(key, group)
+	if err != nil {
+		return PendingSummary{}, err
+	}
+
+	summary := PendingSummary{Count: int64(len(g.Pending))}
+
+	if len(g.Pending) == 0 {
+		return summary, nil
+	}
+
+	ids := sortedPendingIds(g.Pending)
+	summary.Min = ids[0].ToString()
+	summary.Max = ids[len(ids)-1].ToString()
+
+	for name, c := range g.Consumers {
+		if len(c.Pending) == 0 {
+			continue
+		}
+
+		summary.Consumers = append(summary.Consumers, PendingConsumerCount{Name: name, Count: int64(len(c.Pending))})
+	}
+
+	sort.Slice(summary.Consumers, func(i, j int) bool {
+		return summary.Consumers[i].Name < summary.Consumers[j].Name
+	})
+
+	return summary, nil
+}
+
+func (m innerMap) xpendingRange(key, group string, r XpendingRange) ([]PendingEntryInfo, error) {
+	start, end, err := resolveXrangeBounds(r.Start, r.End)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := m.existingGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := g.Pending
+
+	if r.Consumer != "" {
+		c, ok := g.Consumers[r.Consumer]
+		if !ok {
+			return []PendingEntryInfo{}, nil
+		}
+
+		pending = c.Pending
+	}
+
+	now := time.Now()
+	entries := []PendingEntryInfo{}
+
+	for _, id := range sortedPendingIds(pending) {
+		if len(entries) >= r.Count {
+			break
+		}
+
+		if less(id, start) || greater(id, end) {
+			continue
+		}
+
+		info := pending[id].info(now)
+
+		if info.IdleMs < r.MinIdleMs {
+			continue
+		}
+
+		entries = append(entries, info)
+	}
+
+	return entries, nil
+}
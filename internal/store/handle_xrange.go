@@ -7,26 +7,38 @@ import (
 	"strings"
 )
 
-func (m innerMap) xrange(key, start, end string) (Stream, error) {
+// xrange returns the entries between start and end. When rev is set the
+// entries are returned from end to start. A count of 0 means no limit.
+func (m innerMap) xrange(key, start, end string, count int, rev bool) ([]StreamElement, error) {
+	startBound, endBound, err := resolveXrangeBounds(start, end)
+	if err != nil {
+		return nil, err
+	}
+
 	v, ok := m[key]
 
 	if !ok {
-		return Stream{}, nil
+		return []StreamElement{}, nil
 	}
 
 	if v.isExpired() {
-		return Stream{}, nil
+		return []StreamElement{}, nil
 	}
 
-	stream, ok := v.value.(Stream)
+	stream, ok := v.value.(*Stream)
 	if !ok {
-		return Stream{}, fmt.Errorf("MISSTYPE of the element in the underlying stream")
+		return nil, fmt.Errorf("MISSTYPE of the element in the underlying stream")
 	}
 
+	return stream.entries.rangeEntries(startBound, endBound, count, rev), nil
+}
+
+func resolveXrangeBounds(start, end string) (storedStreamId, storedStreamId, error) {
 	startId, ok := parseXrangeStreamId(start)
 	if !ok {
-		return Stream{}, fmt.Errorf("ERR invalid start value for XRANGE command")
+		return storedStreamId{}, storedStreamId{}, fmt.Errorf("ERR invalid start value for XRANGE command")
 	}
+
 	startBound := storedStreamId{MsTime: startId.MsTime, Seq: startId.Seq}
 	if startId.AutoSeq {
 		startBound.Seq = 0
@@ -40,10 +52,20 @@ func (m innerMap) xrange(key, start, end string) (Stream, error) {
 		startBound.Seq = math.MaxUint64
 	}
 
+	if startId.Exclusive {
+		next, ok := startBound.next()
+		if !ok {
+			return storedStreamId{}, storedStreamId{}, fmt.Errorf("ERR invalid start ID for the interval")
+		}
+
+		startBound = next
+	}
+
 	endId, ok := parseXrangeStreamId(end)
 	if !ok {
-		return Stream{}, fmt.Errorf("ERR invalid end value for XRANGE command")
+		return storedStreamId{}, storedStreamId{}, fmt.Errorf("ERR invalid end value for XRANGE command")
 	}
+
 	endBound := storedStreamId{MsTime: endId.MsTime, Seq: endId.Seq}
 	if endId.AutoSeq {
 		endBound.Seq = math.MaxUint64
@@ -52,23 +74,21 @@ func (m innerMap) xrange(key, start, end string) (Stream, error) {
 	if endId.IsMax {
 		endBound.MsTime = math.MaxUint64
 		endBound.Seq = math.MaxUint64
+	} else if endId.IsMin {
+		endBound.MsTime = 0
+		endBound.Seq = 0
 	}
 
-	result := Stream{}
-
-	for _, streamElement := range stream.Elements {
-		if less(streamElement.Id, startBound) {
-			continue
+	if endId.Exclusive {
+		prev, ok := endBound.prev()
+		if !ok {
+			return storedStreamId{}, storedStreamId{}, fmt.Errorf("ERR invalid end ID for the interval")
 		}
 
-		if greater(streamElement.Id, endBound) {
-			break
-		}
-
-		result.Elements = append(result.Elements, streamElement)
+		endBound = prev
 	}
 
-	return result, nil
+	return startBound, endBound, nil
 }
 
 func parseXrangeStreamId(id string) (streamId StreamIdSpec, ok bool) {
@@ -80,6 +100,9 @@ func parseXrangeStreamId(id string) (streamId StreamIdSpec, ok bool) {
 		return StreamIdSpec{IsMax: true}, true
 	}
 
+	exclusive := strings.HasPrefix(id, "(")
+	id = strings.TrimPrefix(id, "(")
+
 	before, after, found := strings.Cut(id, "-")
 
 	msTime, err := strconv.ParseUint(before, 10, 64)
@@ -95,14 +118,16 @@ func parseXrangeStreamId(id string) (streamId StreamIdSpec, ok bool) {
 		}
 
 		return StreamIdSpec{
-			MsTime: msTime,
-			Seq:    seq,
+			MsTime:    msTime,
+			Seq:       seq,
+			Exclusive: exclusive,
 		}, true
 	}
 
 	return StreamIdSpec{
-		MsTime:  msTime,
-		AutoSeq: true,
+		MsTime:    msTime,
+		AutoSeq:   true,
+		Exclusive: exclusive,
 	}, true
 }
 
@@ -6,61 +6,93 @@ import (
 	"strings"
 )
 
-func (m innerMap) xread(keys [][]string, returnOnFoundElements bool) ([]Stream, error) {
-	streams := []Stream{}
+// xreadId is a resolved XREAD ID. lastEntry is set for "+" on a stream that
+// has entries, only its last entry is read then.
+type xreadId struct {
+	id        storedStreamId
+	lastEntry bool
+}
 
-	for _, key := range keys {
-		id := key[1]
+var errXreadWrongType = fmt.Errorf("MISSTYPE of the element in the underlying stream")
 
-		streamIdSpec, ok := parseXreadStreamId(id)
+// resolveXreadIds turns the IDs of XREAD into concrete ones. "$" becomes the
+// last ID of the stream, so that a blocked reader only sees entries added
+// afterwards, and "+" stands for the last entry, or acts as "$" when the
+// stream is empty.
+func (m innerMap) resolveXreadIds(keys [][]string) ([]xreadId, error) {
+	ids := make([]xreadId, len(keys))
 
-		if !ok {
-			return streams, fmt.Errorf("ERR invalid stream id")
+	for i, pair := range keys {
+		if pair[1] != "$" && pair[1] != "+" {
+			spec, ok := parseXreadStreamId(pair[1])
+			if !ok {
+				return nil, fmt.Errorf("ERR invalid stream id %s", pair[1])
+			}
+
+			ids[i].id = storedStreamId{MsTime: spec.MsTime, Seq: spec.Seq}
+			continue
+		}
+
+		stream, ok, err := m.lookupStream(pair[0])
+		if err != nil {
+			return nil, errXreadWrongType
 		}
 
-		if streamIdSpec.IsMax && !returnOnFoundElements {
-			streams = append(streams, Stream{})
+		if !ok {
 			continue
 		}
 
-		keyValue := key[0]
-		storeStreamRawValue, ok := m[keyValue]
+		ids[i].id = stream.LtsInsertedIdParts
+		ids[i].lastEntry = pair[1] == "+" && stream.entries.len() > 0
+	}
+
+	return ids, nil
+}
+
+// xread reads the entries after ids[i] from the stream at keys[i][0]. Streams
+// without new entries are returned as nil.
+func (m innerMap) xread(keys [][]string, ids []xreadId, count int) (streams [][]StreamElement, hasEntries bool, err error) {
+	streams = make([][]StreamElement, len(keys))
+
+	for i, pair := range keys {
+		key := pair[0]
+		v, ok := m[key]
 
 		if !ok {
-			streams = append(streams, Stream{})
 			continue
 		}
 
-		if storeStreamRawValue.isExpired() {
-			m.delete(keyValue)
-			streams = append(streams, Stream{})
+		if v.isExpired() {
+			m.delete(key)
 			continue
 		}
 
-		storeStream, ok := storeStreamRawValue.value.(Stream)
+		stream, ok := v.value.(*Stream)
 		if !ok {
-			return streams, fmt.Errorf("MISSTYPE of the element in the underlying stream")
+			return nil, false, errXreadWrongType
 		}
 
-		elements := []StreamElement{}
-
-		for _, streamElement := range storeStream.Elements {
-			if streamElement.Id.MsTime < streamIdSpec.MsTime {
-				continue
+		if ids[i].lastEntry {
+			if last, ok := stream.entries.last(); ok {
+				streams[i] = []StreamElement{last}
+				hasEntries = true
 			}
 
-			if streamElement.Id.MsTime == streamIdSpec.MsTime {
-				if streamElement.Id.Seq <= streamIdSpec.Seq {
-					continue
-				}
-			}
-			elements = append(elements, streamElement)
+			continue
 		}
 
-		streams = append(streams, Stream{Elements: elements})
+		from, ok := ids[i].id.next()
+		if !ok {
+			continue
+		}
+
+		if elements := stream.entries.rangeEntries(from, maxStreamId, count, false); len(elements) > 0 {
+			streams[i] = elements
+			hasEntries = true
+		}
 	}
 
-	return streams, nil
+	return streams, hasEntries, nil
 }
 
 func parseXreadStreamId(id string) (spec StreamIdSpec, ok bool) {
@@ -70,15 +102,15 @@ func parseXreadStreamId(id string) (spec StreamIdSpec, ok bool) {
 
 	before, after, found := strings.Cut(id, "-")
 
-	if !found {
-		return StreamIdSpec{}, false
-	}
-
 	msTime, err := strconv.ParseUint(before, 10, 64)
 	if err != nil {
 		return StreamIdSpec{}, false
 	}
 
+	if !found {
+		return StreamIdSpec{MsTime: msTime}, true
+	}
+
 	seq, err := strconv.ParseUint(after, 10, 64)
 
 	if err != nil {
@@ -0,0 +1,235 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type XreadgroupOptions struct {
+	Group    string
+	Consumer string
+	// Count limits the entries returned per stream, 0 means no limit.
+	Count int
+	NoAck bool
+}
+
+// XreadgroupResult is the outcome of XREADGROUP. Streams read with ">"
+// that have no new entries are nil, history reads always give a non-nil
+// slice. HasEntries reports whether anything has to be replied to.
+type XreadgroupResult struct {
+	Streams    [][]StreamElement
+	HasEntries bool
+	// Deliveries holds what the read changed in the groups, even when it
+	// blocked and timed out after creating the consumer
+	Deliveries []GroupDelivery
+}
+
+// GroupDelivery is what XREADGROUP changed in the group of the stream at
+// Key. It is replicated instead of the read, which would deliver other
+// entries on a replica when it blocked or when the stream was trimmed.
+type GroupDelivery struct {
+	Key             string
+	ConsumerCreated bool
+	// Pending holds the delivered entries as they are in the pending
+	// entries list afterwards
+	Pending []PendingEntryInfo
+	// LastDeliveredId is the last ID delivered to the group. Advanced is
+	// set when the read moved it, EntriesRead being the position then
+	LastDeliveredId string
+	Advanced        bool
+	EntriesRead     int64
+}
+
+// groupReadId is the parsed ID argument of XREADGROUP. isNew is set for ">",
+// which reads entries never delivered to the group.
+type groupReadId struct {
+	id    storedStreamId
+	isNew bool
+}
+
+func parseGroupReadId(id string) (groupReadId, bool) {
+	if id == ">" {
+		return groupReadId{isNew: true}, true
+	}
+
+	before, after, found := strings.Cut(id, "-")
+
+	msTime, err := strconv.ParseUint(before, 10, 64)
+	if err != nil {
+		return groupReadId{}, false
+	}
+
+	var seq uint64
+
+	if found {
+		seq, err = strconv.ParseUint(after, 10, 64)
+		if err != nil {
+			return groupReadId{}, false
+		}
+	}
+
+	return groupReadId{id: storedStreamId{msTime, seq}}, true
+}
+
+// xreadgroup reads from the streams in keys on behalf of a consumer. Each
+// pair in keys holds the stream key and the ID to read from.
+func (m innerMap) xreadgroup(keys [][]string, opts XreadgroupOptions) (XreadgroupResult, error) {
+	groups := make([]*StreamGroup, len(keys))
+	ids := make([]groupReadId, len(keys))
+
+	for i, pair := range keys {
+		id, ok := parseGroupReadId(pair[1])
+		if !ok {
+			return XreadgroupResult{}, fmt.Errorf("ERR Invalid stream ID specified as stream command argument")
+		}
+
+		g, err := m.lookupGroup(pair[0], opts.Group)
+		if err != nil {
+			return XreadgroupResult{}, err
+		}
+
+		if g == nil {
+			return XreadgroupResult{}, fmt.Errorf("NOGROUP No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option", pair[0], opts.Group)
+		}
+
+		groups[i] = g
+		ids[i] = id
+	}
+
+	now := time.Now()
+	result := XreadgroupResult{Streams: make([][]StreamElement, len(keys))}
+
+	for i, pair := range keys {
+		stream, _, _ := m.lookupStream(pair[0])
+		g := groups[i]
+		consumer, created := g.consumer(opts.Consumer, now)
+		delivery := GroupDelivery{Key: pair[0], ConsumerCreated: created}
+
+		if ids[i].isNew {
+			elements := stream.readNew(g, consumer, opts, now)
+			if len(elements) > 0 {
+				result.Streams[i] = elements
+				result.HasEntries = true
+
+				delivery.Advanced = true
+				delivery.EntriesRead = g.EntriesRead
+			}
+		} else {
+			result.Streams[i] = stream.readHistory(consumer, ids[i].id, opts.Count, now)
+			result.HasEntries = true
+		}
+
+		for _, el := range result.Streams[i] {
+			if entry, ok := consumer.Pending[el.Id]; ok && !el.Deleted {
+				delivery.Pending = append(delivery.Pending, entry.info(now))
+			}
+		}
+
+		if delivery.ConsumerCreated || delivery.Advanced || len(delivery.Pending) > 0 {
+			delivery.LastDeliveredId = g.LastDeliveredId.ToString()
+			result.Deliveries = append(result.Deliveries, delivery)
+		}
+	}
+
+	return result, nil
+}
+
+// readNew delivers the entries after the group's last delivered ID to
+// consumer and adds them to the pending lists unless NOACK is given.
+func (s *Stream) readNew(g *StreamGroup, consumer *StreamConsumer, opts XreadgroupOptions, now time.Time) []StreamElement {
+	from, ok := g.LastDeliveredId.next()
+	if !ok {
+		return nil
+	}
+
+	elements := s.entries.rangeEntries(from, maxStreamId, opts.Count, false)
+	if len(elements) == 0 {
+		return nil
+	}
+
+	for _, el := range elements {
+		if g.EntriesRead != StreamInvalidEntriesRead && !s.hasTombstonesFrom(el.Id) {
+			g.EntriesRead++
+		} else if s.EntriesAdded > 0 {
+			g.EntriesRead = s.estimateEntriesRead(el.Id)
+		}
+
+		g.LastDeliveredId = el.Id
+
+		if opts.NoAck {
+			continue
+		}
+
+		g.addPending(el.Id, consumer, now)
+	}
+
+	consumer.ActiveTime = now
+
+	return elements
+}
+
+// addPending records id as delivered to consumer. An entry that was already
+// pending, e.g. after XGROUP SETID moved the group backwards, is handed over
+// to consumer with a fresh delivery count.
+func (g *StreamGroup) addPending(id storedStreamId, consumer *StreamConsumer, now time.Time) {
+	entry, ok := g.Pending[id]
+	if ok {
+		delete(entry.consumer.Pending, id)
+	} else {
+		entry = &streamPendingEntry{id: id}
+		g.Pending[id] = entry
+	}
+
+	entry.consumer = consumer
+	entry.deliveryTime = now
+	entry.deliveryCount = 1
+	consumer.Pending[id] = entry
+}
+
+// readHistory returns the entries pending for consumer with an ID greater
+// than start. Entries deleted from the stream are returned with Deleted set.
+func (s *Stream) readHistory(consumer *StreamConsumer, start storedStreamId, count int, now time.Time) []StreamElement {
+	ids := sortedPendingIds(consumer.Pending)
+	from := sort.Search(len(ids), func(i int) bool {
+		return greater(ids[i], start)
+	})
+	ids = ids[from:]
+
+	if count > 0 && len(ids) > count {
+		ids = ids[:count]
+	}
+
+	elements := make([]StreamElement, 0, len(ids))
+
+	for _, id := range ids {
+		el, ok := s.entries.lookup(id)
+		if !ok {
+			elements = append(elements, StreamElement{Id: id, Deleted: true})
+			continue
+		}
+
+		entry := consumer.Pending[id]
+		entry.deliveryTime = now
+		entry.deliveryCount++
+
+		elements = append(elements, el)
+	}
+
+	return elements
+}
+
+func sortedPendingIds(pending map[storedStreamId]*streamPendingEntry) []storedStreamId {
+	ids := make([]storedStreamId, 0, len(pending))
+	for id := range pending {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return less(ids[i], ids[j])
+	})
+
+	return ids
+}
@@ -0,0 +1,60 @@
+package store
+
+// streamNodeMaxEntries mirrors the default stream-node-max-entries setting,
+// the maximum number of entries of a stream block.
+const streamNodeMaxEntries = 100
+
+type StreamTrimStrategy string
+
+const (
+	STREAM_TRIM_NONE   StreamTrimStrategy = ""
+	STREAM_TRIM_MAXLEN StreamTrimStrategy = "MAXLEN"
+	STREAM_TRIM_MINID  StreamTrimStrategy = "MINID"
+)
+
+// StreamTrimSpec describes the MAXLEN|MINID [=|~] threshold [LIMIT count]
+// arguments of XADD and XTRIM.
+type StreamTrimSpec struct {
+	Strategy StreamTrimStrategy
+	MaxLen   int64
+	MinId    StreamIdSpec
+	Approx   bool
+	// Limit caps the number of entries removed by approximate trimming.
+	// Zero means no limit.
+	Limit int64
+}
+
+// DefaultStreamTrimLimit is used for approximate trimming when LIMIT is not
+// given.
+const DefaultStreamTrimLimit = 100 * streamNodeMaxEntries
+
+type XaddOptions struct {
+	NoMkStream bool
+	Trim       StreamTrimSpec
+}
+
+// trim removes entries from the head of the stream according to spec and
+// returns the number of removed entries. Approximate trimming only drops
+// whole blocks.
+func (s *Stream) trim(spec StreamTrimSpec) int64 {
+	if spec.Strategy == STREAM_TRIM_NONE {
+		return 0
+	}
+
+	return s.entries.trim(spec)
+}
+
+func (m innerMap) xtrim(key string, spec StreamTrimSpec) (int64, error) {
+	v, ok := m[key]
+
+	if !ok || v.isExpired() {
+		return 0, nil
+	}
+
+	stream, ok := v.value.(*Stream)
+	if !ok {
+		return 0, errWrongType
+	}
+
+	return stream.trim(spec), nil
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/notify"
+)
+
+const (
+	// activeExpireSampleSize is the number of keys looked at per round of
+	// the active expire cycle
+	activeExpireSampleSize = 20
+	// activeExpireStalePercent is the share of expired keys in a sample above
+	// which the cycle runs another round
+	activeExpireStalePercent = 10
+	// activeExpireBudget bounds the time a cycle may hold the store lock
+	activeExpireBudget = time.Millisecond
+)
+
+// Notifier receives the keyspace events raised by the store's write
+// operations. It is called with the store lock held, so it must not call
+// back into the store.
+type Notifier interface {
+	Notify(class notify.Class, event, key string)
+}
+
+func (s *Store) SetNotifier(n Notifier) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.notifier = n
+}
+
+// notify raises a keyspace event. Every event is a modification of key, so
+// it also invalidates the watchers of key.
+func (s *Store) notify(class notify.Class, event, key string) {
+	s.touchWatchedKey(key)
+
+	if s.notifier != nil {
+		s.notifier.Notify(class, event, key)
+	}
+}
+
+// expireIfNeeded removes key when it has expired and raises the expired
+// event. Write operations go through it before touching a key, so expiry is
+// reported even though the inner handlers drop expired keys silently.
+func (s *Store) expireIfNeeded(key string) bool {
+	v, ok := s.innerMap[key]
+	if !ok || !v.isExpired() {
+		return false
+	}
+
+	delete(s.innerMap, key)
+	s.notify(notify.Expired, "expired", key)
+
+	return true
+}
+
+// keyExists expires key if needed and reports whether it exists. Writes
+// call it first to know whether they create the key.
+func (s *Store) keyExists(key string) bool {
+	s.expireIfNeeded(key)
+
+	_, ok := s.innerMap[key]
+
+	return ok
+}
+
+// notifyWrite raises event for key after a successful write, preceded by
+// the new event when the write created key.
+func (s *Store) notifyWrite(class notify.Class, event, key string, existed bool) {
+	if _, ok := s.innerMap[key]; ok && !existed {
+		s.notify(notify.New, "new", key)
+	}
+
+	s.notify(class, event, key)
+}
+
+// notifyRemoved raises the del event when a write left key removed.
+func (s *Store) notifyRemoved(key string, existed bool) {
+	if _, ok := s.innerMap[key]; existed && !ok {
+		s.notify(notify.Generic, "del", key)
+	}
+}
+
+// ActiveExpireCycle removes expired keys that nobody accesses anymore. Like
+// Redis it samples a few keys at a time and keeps going while enough of the
+// sample was expired, within a small time budget. It returns the number of
+// removed keys.
+func (s *Store) ActiveExpireCycle() int {
+	s.Lock()
+	defer s.Unlock()
+
+	start := time.Now()
+	removed := 0
+
+	for {
+		sampled, expired := 0, 0
+
+		// map iteration starts at a random position, which makes the
+		// first keys a random sample
+		for key := range s.innerMap {
+			if s.expireIfNeeded(key) {
+				expired++
+			}
+
+			sampled++
+			if sampled == activeExpireSampleSize {
+				break
+			}
+		}
+
+		removed += expired
+
+		if expired*100 <= sampled*activeExpireStalePercent || time.Since(start) > activeExpireBudget {
+			return removed
+		}
+	}
+}
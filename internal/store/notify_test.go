@@ -0,0 +1,106 @@
+package store
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/notify"
+)
+
+type recordingNotifier struct {
+	events []string
+}
+
+func (r *recordingNotifier) Notify(class notify.Class, event, key string) {
+	r.events = append(r.events, event+":"+key)
+}
+
+func (r *recordingNotifier) requireEvents(t *testing.T, want ...string) {
+	t.Helper()
+
+	if !slices.Equal(r.events, want) {
+		t.Fatalf("expected events %v, got %v", want, r.events)
+	}
+
+	r.events = nil
+}
+
+func TestStoreRaisesKeyspaceEvents(t *testing.T) {
+	s := NewStore()
+	n := &recordingNotifier{}
+	s.SetNotifier(n)
+
+	s.Set("foo", []byte("1"), "", 0)
+	n.requireEvents(t, "new:foo", "set:foo")
+
+	s.IncrBy("foo", 2)
+	n.requireEvents(t, "incrby:foo")
+
+	s.Set("foo", []byte("1"), EXPIRY_EX, 10)
+	n.requireEvents(t, "set:foo", "expire:foo")
+
+	s.GetEx("foo", "", 0, true)
+	n.requireEvents(t, "persist:foo")
+
+	s.GetDel("foo")
+	n.requireEvents(t, "del:foo")
+
+	s.Rpush("list", []string{"a", "b"})
+	n.requireEvents(t, "new:list", "rpush:list")
+
+	s.Lpop("list", 1)
+	n.requireEvents(t, "lpop:list")
+
+	s.Lpop("list", 1)
+	n.requireEvents(t, "lpop:list", "del:list")
+
+	if _, ok := s.GetStoreRawValue("list"); ok {
+		t.Fatal("expected an emptied list to be removed")
+	}
+
+	s.Xadd("stream", StreamIdSpec{MsTime: 1, Seq: 1}, [][]string{{"f", "v"}}, XaddOptions{})
+	n.requireEvents(t, "new:stream", "xadd:stream")
+
+	s.Xadd("stream", StreamIdSpec{MsTime: 2, Seq: 1}, [][]string{{"f", "v"}}, XaddOptions{
+		Trim: StreamTrimSpec{Strategy: STREAM_TRIM_MAXLEN, MaxLen: 1},
+	})
+	n.requireEvents(t, "xadd:stream", "xtrim:stream")
+
+	// failed writes raise nothing
+	s.IncrBy("stream", 1)
+	s.Delete("missing")
+	n.requireEvents(t)
+}
+
+func TestStoreRaisesExpiredEvents(t *testing.T) {
+	s := NewStore()
+	n := &recordingNotifier{}
+
+	s.Set("lazy", []byte("v"), EXPIRY_PX, 1)
+	s.Set("active", []byte("v"), EXPIRY_PX, 1)
+	s.Set("kept", []byte("v"), "", 0)
+	s.SetNotifier(n)
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := s.Get("lazy"); ok {
+		t.Fatal("expected lazy to be expired")
+	}
+
+	n.requireEvents(t, "expired:lazy")
+
+	if removed := s.ActiveExpireCycle(); removed != 1 {
+		t.Fatalf("expected the active cycle to remove 1 key, got %d", removed)
+	}
+
+	n.requireEvents(t, "expired:active")
+
+	// a write to an expired key reports the expiry before the write
+	s.Set("again", []byte("v"), EXPIRY_PX, 1)
+	time.Sleep(5 * time.Millisecond)
+	n.events = nil
+
+	s.Set("again", []byte("v"), "", 0)
+	n.requireEvents(t, "expired:again", "new:again", "set:again")
+}
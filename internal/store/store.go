@@ -4,13 +4,21 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/internal/notify"
+	"github.com/codecrafters-io/redis-starter-go/internal/resp"
 )
 
 type Store struct {
-	sync.RWMutex
+	locker
 	innerMap
 	blpopQueue map[string][]blpopListener
 	xreadQueue map[string][]xreadListener
+	notifier   Notifier
+	// limits bounds the strings built by the store, see SetLimits
+	limits *resp.Limits
+	// keys watched by WATCH, see watch.go
+	watchedKeys map[string]map[*Watcher]struct{}
 }
 
 type blpopListener struct {
@@ -20,7 +28,6 @@ type blpopListener struct {
 
 type xreadEvent struct {
 	element StreamElement
-	isMax   bool
 	key     string
 }
 
@@ -31,12 +38,25 @@ type xreadListener struct {
 
 func NewStore() *Store {
 	return &Store{
-		innerMap:   make(innerMap),
-		blpopQueue: make(map[string][]blpopListener),
-		xreadQueue: make(map[string][]xreadListener),
+		locker:      &sync.RWMutex{},
+		innerMap:    make(innerMap),
+		blpopQueue:  make(map[string][]blpopListener),
+		xreadQueue:  make(map[string][]xreadListener),
+		watchedKeys: make(map[string]map[*Watcher]struct{}),
+		limits:      resp.NewLimits(),
 	}
 }
 
+// SetLimits makes the strings built by SETRANGE, SETBIT, BITFIELD and LCS
+// bounded by the proto-max-bulk-len of l, the limit of the bulk strings
+// read from the clients.
+func (s *Store) SetLimits(l *resp.Limits) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.limits = l
+}
+
 func (s *Store) Get(key string) (value []byte, ok bool) {
 	// lock read
 	s.RLock()
@@ -64,7 +84,7 @@ func (s *Store) Get(key string) (value []byte, ok bool) {
 
 	if !ok || expired {
 		if ok && expired {
-			s.delete(key)
+			s.expireIfNeeded(key)
 		}
 
 		return nil, false
@@ -77,42 +97,113 @@ func (s *Store) Get(key string) (value []byte, ok bool) {
 func (s *Store) Set(key string, value []byte, expType ExpiryType, expiryTime int) bool {
 	s.Lock()
 	defer s.Unlock()
+
+	existed := s.keyExists(key)
 	cpy := append([]byte{}, value...)
-	return s.set(key, cpy, expType, expiryTime)
+
+	if !s.set(key, cpy, expType, expiryTime) {
+		return false
+	}
+
+	s.notifyWrite(notify.String, "set", key, existed)
+	if expType != "" {
+		s.notify(notify.Generic, "expire", key)
+	}
+
+	return true
+}
+
+// SetKeepTTL sets key to value like Set, keeping the expiry of key.
+func (s *Store) SetKeepTTL(key string, value []byte) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+	s.setKeepTTL(key, append([]byte{}, value...))
+
+	s.notifyWrite(notify.String, "set", key, existed)
+}
+
+// Pexpireat sets the expiry of key to t and reports whether key exists. A
+// time in the past deletes key.
+func (s *Store) Pexpireat(key string, t time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	if !s.pexpireat(key, t) {
+		return false
+	}
+
+	if s.keyExists(key) {
+		s.notify(notify.Generic, "expire", key)
+	} else {
+		s.notify(notify.Generic, "del", key)
+	}
+
+	return true
 }
 
-func (s *Store) Rpush(key string, value []string) (int64, bool) {
+// Persist removes the expiry of key and reports whether it had one.
+func (s *Store) Persist(key string) bool {
 	s.Lock()
 	defer s.Unlock()
 
+	s.expireIfNeeded(key)
+
+	if !s.persist(key) {
+		return false
+	}
+
+	s.notify(notify.Generic, "persist", key)
+
+	return true
+}
+
+// Rpush appends value to the list at key and returns its length. served is
+// the number of elements then popped for the clients blocked in Blpop.
+func (s *Store) Rpush(key string, value []string) (length int64, served int, ok bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
 	cpy := append([]string{}, value...)
-	len, ok := s.append(key, cpy)
+	length, ok = s.append(key, cpy)
 
 	if ok {
-		s.produceElementToListeners(key)
+		s.notifyWrite(notify.List, "rpush", key, existed)
+		served = s.produceElementToListeners(key)
 	}
 
-	return len, ok
+	return length, served, ok
 }
 
-func (s *Store) Lpush(key string, value []string) (int64, bool) {
+// Lpush prepends value to the list at key, see Rpush.
+func (s *Store) Lpush(key string, value []string) (length int64, served int, ok bool) {
 	s.Lock()
 	defer s.Unlock()
 
+	existed := s.keyExists(key)
 	cpy := append([]string{}, value...)
-	len, ok := s.prepend(key, cpy)
+	length, ok = s.prepend(key, cpy)
 
 	if ok {
-		s.produceElementToListeners(key)
+		s.notifyWrite(notify.List, "lpush", key, existed)
+		served = s.produceElementToListeners(key)
 	}
 
-	return len, ok
+	return length, served, ok
 }
 
 func (s *Store) Delete(key string) {
 	s.Lock()
 	defer s.Unlock()
-	s.delete(key)
+
+	if s.keyExists(key) {
+		s.delete(key)
+		s.notify(notify.Generic, "del", key)
+	}
 }
 
 func (s *Store) Lrange(key string, start int, stop int) (list List, ok bool) {
@@ -136,7 +227,7 @@ func (s *Store) Lrange(key string, start int, stop int) (list List, ok bool) {
 		s.Lock()
 		defer s.Unlock()
 
-		s.delete(key)
+		s.expireIfNeeded(key)
 		return List{}, true
 	}
 
@@ -179,13 +270,33 @@ func (s *Store) Lrange(key string, start int, stop int) (list List, ok bool) {
 func (s *Store) Lpop(key string, count int) (list List, ok bool) {
 	s.Lock()
 	defer s.Unlock()
-	return s.lpop(key, count)
+	return s.popList(key, count)
+}
+
+// popList pops count elements from the head of the list at key and raises
+// the lpop event, and the del event when the list is left empty.
+func (s *Store) popList(key string, count int) (List, bool) {
+	existed := s.keyExists(key)
+	res, ok := s.lpop(key, count)
+
+	if ok && !res.IsEmpty() {
+		s.notify(notify.List, "lpop", key)
+		s.notifyRemoved(key, existed)
+	}
+
+	return res, ok
 }
 
-func (s *Store) Blpop(key string, timeoutInSeconds float64) (el string, ok bool, timeout bool) {
+// Blpop pops the first element of the list at key. When the list is empty
+// and isBlocking is set, it waits for an element until the timeout expires,
+// a timeout of 0 blocking forever. Without isBlocking an empty list is
+// reported as a timeout right away. popped is called with the lock held
+// when the element is popped from the list, not when it is handed by a
+// push.
+func (s *Store) Blpop(key string, timeoutInSeconds float64, isBlocking bool, popped func()) (el string, ok bool, timeout bool) {
 	s.Lock()
 
-	res, ok := s.lpop(key, 1)
+	res, ok := s.popList(key, 1)
 
 	if !ok {
 		s.Unlock()
@@ -193,10 +304,16 @@ func (s *Store) Blpop(key string, timeoutInSeconds float64) (el string, ok bool,
 	}
 
 	if ok && !res.IsEmpty() {
+		popped()
 		s.Unlock()
 		return res.Elements[0], true, false
 	}
 
+	if !isBlocking {
+		s.Unlock()
+		return "", false, true
+	}
+
 	listener := blpopListener{
 		id:      newId(),
 		valueCh: make(chan string, 1),
@@ -232,15 +349,17 @@ func (s *Store) Blpop(key string, timeoutInSeconds float64) (el string, ok bool,
 
 }
 
-func (s *Store) produceElementToListeners(key string) {
+// produceElementToListeners pops the elements of the list at key for the
+// clients blocked on it and returns how many were served.
+func (s *Store) produceElementToListeners(key string) (served int) {
 	queue, ok := s.blpopQueue[key]
 
 	if !ok || len(queue) == 0 {
-		return
+		return 0
 	}
 
 	for len(queue) > 0 {
-		res, ok := s.lpop(key, 1)
+		res, ok := s.popList(key, 1)
 
 		if !ok || res.IsEmpty() {
 			break
@@ -248,37 +367,72 @@ func (s *Store) produceElementToListeners(key string) {
 
 		queue[0].valueCh <- res.Elements[0]
 		queue = queue[1:]
+		served++
 	}
 
 	if len(queue) == 0 {
 		delete(s.blpopQueue, key)
-		return
+		return served
 	}
 
 	s.blpopQueue[key] = queue
+
+	return served
 }
 
 func (s *Store) GetStoreRawValue(key string) (StoreValueType, bool) {
 	s.Lock()
 	defer s.Unlock()
 
+	s.expireIfNeeded(key)
+
 	return s.getRawValue(key)
 }
 
-func (s *Store) Xadd(key string, streamId StreamIdSpec, fields [][]string) (newEntryId string, err error) {
+// Xadd appends a new entry to the stream stored at key. added is false when
+// the stream does not exist and opts.NoMkStream is set.
+func (s *Store) Xadd(key string, streamId StreamIdSpec, fields [][]string, opts XaddOptions) (newEntryId string, added bool, err error) {
 	s.Lock()
 
-	streamElement, err := s.xadd(key, streamId, fields)
+	existed := s.keyExists(key)
+	lengthBefore, _ := s.xlen(key)
+
+	streamElement, added, err := s.xadd(key, streamId, fields, opts)
+
+	if err == nil && added {
+		s.notifyWrite(notify.Stream, "xadd", key, existed)
+
+		// the new entry is missing from the length when XADD trimmed
+		if lengthAfter, _ := s.xlen(key); lengthAfter <= lengthBefore {
+			s.notify(notify.Stream, "xtrim", key)
+		}
+	}
 
 	s.Unlock()
 
-	if err != nil {
-		return "", err
+	if err != nil || !added {
+		return "", false, err
 	}
 
 	s.produceXaddEvents(key, streamElement)
 
-	return fmt.Sprintf("%d-%d", streamElement.Id.MsTime, streamElement.Id.Seq), nil
+	return fmt.Sprintf("%d-%d", streamElement.Id.MsTime, streamElement.Id.Seq), true, nil
+}
+
+// Xtrim trims the stream stored at key and returns the number of removed
+// entries.
+func (s *Store) Xtrim(key string, spec StreamTrimSpec) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	removed, err := s.xtrim(key, spec)
+	if err == nil && removed > 0 {
+		s.notify(notify.Stream, "xtrim", key)
+	}
+
+	return removed, err
 }
 
 func (s *Store) produceXaddEvents(key string, newElement StreamElement) {
@@ -293,7 +447,6 @@ func (s *Store) produceXaddEvents(key string, newElement StreamElement) {
 			event := xreadEvent{element: newElement, key: key}
 
 			if listener.id.IsMax {
-				event.isMax = true
 				listener.notify <- event
 				continue
 			}
@@ -319,123 +472,664 @@ func (s *Store) produceXaddEvents(key string, newElement StreamElement) {
 	}
 }
 
-func (s *Store) Xrange(key string, start string, end string) (Stream, error) {
+// Xrange returns up to count entries between start and end. A count of 0
+// means no limit.
+func (s *Store) Xrange(key string, start string, end string, count int) ([]StreamElement, error) {
 	s.Lock()
 	defer s.Unlock()
 
-	return s.xrange(key, start, end)
+	return s.xrange(key, start, end, count, false)
 }
 
-func (s *Store) Xread(keys [][]string, timeoutMs int, isBlocking bool) ([]Stream, error) {
+// Xrevrange is like Xrange but returns the entries in reverse order,
+// starting from end.
+func (s *Store) Xrevrange(key string, end string, start string, count int) ([]StreamElement, error) {
 	s.Lock()
+	defer s.Unlock()
 
-	streams, err := s.xread(keys, false)
+	return s.xrange(key, start, end, count, true)
+}
 
-	if err != nil {
-		s.Unlock()
-		return nil, err
+func (s *Store) Xlen(key string) (int64, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.xlen(key)
+}
+
+// Xdel removes the entries with the given IDs and returns how many existed.
+func (s *Store) Xdel(key string, ids []StreamIdSpec) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	deleted, err := s.xdel(key, ids)
+	if err == nil && deleted > 0 {
+		s.notify(notify.Stream, "xdel", key)
 	}
 
-	if !isBlocking {
-		s.Unlock()
-		return streams, nil
+	return deleted, err
+}
+
+func (s *Store) XgroupCreate(key, group string, id StreamIdSpec, mkStream bool, entriesRead int64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	err := s.xgroupCreate(key, group, id, mkStream, entriesRead)
+	if err == nil {
+		s.notifyWrite(notify.Stream, "xgroup-create", key, existed)
 	}
 
-	hasEntries := false
+	return err
+}
 
-	for _, stream := range streams {
-		if len(stream.Elements) > 0 {
-			hasEntries = true
-			break
-		}
+func (s *Store) XgroupDestroy(key, group string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	destroyed, err := s.xgroupDestroy(key, group)
+	if destroyed {
+		s.notify(notify.Stream, "xgroup-destroy", key)
 	}
 
-	if hasEntries {
+	return destroyed, err
+}
+
+func (s *Store) XgroupSetId(key, group string, id StreamIdSpec, entriesRead int64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	err := s.xgroupSetId(key, group, id, entriesRead)
+	if err == nil {
+		s.notify(notify.Stream, "xgroup-setid", key)
+	}
+
+	return err
+}
+
+func (s *Store) XgroupCreateConsumer(key, group, consumer string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	created, err := s.xgroupCreateConsumer(key, group, consumer)
+	if created {
+		s.notify(notify.Stream, "xgroup-createconsumer", key)
+	}
+
+	return created, err
+}
+
+func (s *Store) XgroupDelConsumer(key, group, consumer string) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	g, _ := s.lookupGroup(key, group)
+	existed := g != nil && g.Consumers[consumer] != nil
+
+	pending, err := s.xgroupDelConsumer(key, group, consumer)
+	if err == nil && existed {
+		s.notify(notify.Stream, "xgroup-delconsumer", key)
+	}
+
+	return pending, err
+}
+
+// Xreadgroup reads from the streams in keys on behalf of opts.Consumer. When
+// blocking and every stream is read with ">", it waits until new entries
+// are delivered to the group or the timeout expires. A timeout of 0 blocks
+// forever. The deliveries of every attempt are returned.
+func (s *Store) Xreadgroup(keys [][]string, opts XreadgroupOptions, timeoutMs int, isBlocking bool) (XreadgroupResult, error) {
+	s.Lock()
+
+	result, err := s.xreadgroup(keys, opts)
+	if err != nil || result.HasEntries || !isBlocking {
 		s.Unlock()
-		return streams, nil
+		return result, err
 	}
 
-	notifyCh := make(chan xreadEvent, len(keys))
+	deliveries := result.Deliveries
+
+	timeoutCh := (<-chan time.Time)(nil)
+	if timeoutMs > 0 {
+		timeoutCh = time.After(time.Duration(timeoutMs) * time.Millisecond)
+	}
 
-	cleanup := func() {
+	for {
+		// any new entry may be deliverable, another consumer can still take
+		// it first in which case we go back to waiting
+		notifyCh := make(chan xreadEvent, len(keys))
 		for _, pair := range keys {
-			queue := s.xreadQueue[pair[0]]
-			if len(queue) == 0 {
-				continue
-			}
+			s.xreadQueue[pair[0]] = append(s.xreadQueue[pair[0]], xreadListener{notify: notifyCh, id: StreamIdSpec{IsMax: true}})
+		}
 
-			filtered := queue[:0]
-			for _, listener := range queue {
-				if listener.notify != notifyCh {
-					filtered = append(filtered, listener)
-				}
-			}
+		s.Unlock()
+
+		select {
+		case <-notifyCh:
+			s.Lock()
+			s.removeXreadListeners(keys, notifyCh)
+
+			result, err = s.xreadgroup(keys, opts)
+			if err != nil || result.HasEntries {
+				s.Unlock()
 
-			if len(filtered) == 0 {
-				delete(s.xreadQueue, pair[0])
-			} else {
-				s.xreadQueue[pair[0]] = filtered
+				result.Deliveries = append(deliveries, result.Deliveries...)
+				return result, err
 			}
+		case <-timeoutCh:
+			s.Lock()
+			s.removeXreadListeners(keys, notifyCh)
+			s.Unlock()
+
+			return XreadgroupResult{Deliveries: deliveries}, nil
 		}
 	}
+}
 
+func (s *Store) removeXreadListeners(keys [][]string, notifyCh chan xreadEvent) {
 	for _, pair := range keys {
-		id, ok := parseXreadStreamId(pair[1])
-		if !ok {
-			cleanup()
-			s.Unlock()
-			return []Stream{}, fmt.Errorf("ERR invalid stream id %s", pair[1])
+		queue := s.xreadQueue[pair[0]]
+		if len(queue) == 0 {
+			continue
+		}
+
+		filtered := queue[:0]
+		for _, listener := range queue {
+			if listener.notify != notifyCh {
+				filtered = append(filtered, listener)
+			}
 		}
 
-		s.xreadQueue[pair[0]] = append(s.xreadQueue[pair[0]], xreadListener{notify: notifyCh, id: id})
+		if len(filtered) == 0 {
+			delete(s.xreadQueue, pair[0])
+		} else {
+			s.xreadQueue[pair[0]] = filtered
+		}
 	}
+}
 
-	s.Unlock()
+// Xack acknowledges the given IDs and returns how many were pending.
+func (s *Store) Xack(key, group string, ids []StreamIdSpec) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	acked, err := s.xack(key, group, ids)
+	if acked > 0 {
+		s.touchWatchedKey(key)
+	}
+
+	return acked, err
+}
+
+// Xclaim transfers ownership of pending entries to consumer.
+func (s *Store) Xclaim(key, group, consumer string, ids []StreamIdSpec, opts XclaimOptions) ([]StreamElement, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	claimed, err := s.xclaim(key, group, consumer, ids, opts)
+	if err == nil {
+		s.touchWatchedKey(key)
+	}
+
+	return claimed, err
+}
+
+// Xautoclaim scans the pending entries list from start and transfers up to
+// count idle entries to consumer.
+func (s *Store) Xautoclaim(key, group, consumer, start string, count int, opts XclaimOptions) (XautoclaimResult, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	result, err := s.xautoclaim(key, group, consumer, start, count, opts)
+	if err == nil {
+		s.touchWatchedKey(key)
+	}
+
+	return result, err
+}
+
+func (s *Store) XpendingSummary(key, group string) (PendingSummary, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.xpendingSummary(key, group)
+}
+
+func (s *Store) XpendingRange(key, group string, r XpendingRange) ([]PendingEntryInfo, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.xpendingRange(key, group, r)
+}
+
+// XinfoStream describes the stream stored at key. count limits the entries
+// of the FULL form, 0 meaning no limit.
+func (s *Store) XinfoStream(key string, full bool, count int) (StreamInfo, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.xinfoStream(key, full, count)
+}
+
+func (s *Store) XinfoGroups(key string) ([]StreamGroupInfo, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.xinfoGroups(key)
+}
+
+func (s *Store) XinfoConsumers(key, group string) ([]StreamConsumerInfo, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.xinfoConsumers(key, group)
+}
+
+// Xread returns the entries after the given IDs for every key/ID pair in
+// keys, up to count per stream with 0 meaning no limit. When blocking and no
+// stream has entries it waits until any of them receives one, then replies
+// with every stream that has data by then. A timeout of 0 blocks forever.
+func (s *Store) Xread(keys [][]string, count int, timeoutMs int, isBlocking bool) ([][]StreamElement, error) {
+	s.Lock()
+
+	ids, err := s.resolveXreadIds(keys)
+	if err != nil {
+		s.Unlock()
+		return nil, err
+	}
+
+	streams, hasEntries, err := s.xread(keys, ids, count)
+	if err != nil || hasEntries || !isBlocking {
+		s.Unlock()
+		return streams, err
+	}
 
 	timeoutCh := (<-chan time.Time)(nil)
 	if timeoutMs > 0 {
 		timeoutCh = time.After(time.Duration(float64(timeoutMs) * float64(time.Millisecond)))
 	}
 
-	select {
-	case event := <-notifyCh:
-		s.Lock()
+	for {
+		notifyCh := make(chan xreadEvent, len(keys))
 
-		if event.isMax {
-			cleanup()
-			s.Unlock()
-			streams := []Stream{}
-
-			for _, pair := range keys {
-				if pair[0] == event.key {
-					streams = append(streams, Stream{Elements: []StreamElement{event.element}})
-				} else {
-					streams = append(streams, Stream{})
-				}
+		for i, pair := range keys {
+			listenerId := StreamIdSpec{MsTime: ids[i].id.MsTime, Seq: ids[i].id.Seq}
+			s.xreadQueue[pair[0]] = append(s.xreadQueue[pair[0]], xreadListener{notify: notifyCh, id: listenerId})
+		}
+
+		s.Unlock()
+
+		select {
+		case <-notifyCh:
+			s.Lock()
+			s.removeXreadListeners(keys, notifyCh)
+
+			streams, hasEntries, err = s.xread(keys, ids, count)
+			if err != nil || hasEntries {
+				s.Unlock()
+				return streams, err
 			}
+		case <-timeoutCh:
+			// didn't receive any elements during timeout
+			s.Lock()
+			s.removeXreadListeners(keys, notifyCh)
+			s.Unlock()
 
-			return streams, nil
+			return [][]StreamElement{}, nil
 		}
+	}
+}
 
-		streams, err := s.xread(keys, true)
+func (s *Store) Incr(key string) (int64, error) {
+	return s.IncrBy(key, 1)
+}
 
-		cleanup()
-		s.Unlock()
+func (s *Store) IncrBy(key string, delta int64) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
 
-		return streams, err
-	case <-timeoutCh:
-		// didn't receive any elements during timeout
-		s.Lock()
-		cleanup()
-		s.Unlock()
+	existed := s.keyExists(key)
 
-		return []Stream{}, nil
+	value, err := s.incrBy(key, delta)
+	if err == nil {
+		s.notifyWrite(notify.String, "incrby", key, existed)
 	}
+
+	return value, err
 }
 
-func (s *Store) Incr(key string) (int64, error) {
+func (s *Store) IncrByFloat(key string, delta float64) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	value, err := s.incrByFloat(key, delta)
+	if err == nil {
+		s.notifyWrite(notify.String, "incrbyfloat", key, existed)
+	}
+
+	return value, err
+}
+
+// Mget returns the values of the given keys. Missing keys and keys holding
+// a non-string value are returned as nil.
+func (s *Store) Mget(keys []string) [][]byte {
+	s.RLock()
+	defer s.RUnlock()
+
+	values := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		value, ok, expired := s.get(key)
+
+		if ok && !expired {
+			values[i] = append([]byte{}, value...)
+		}
+	}
+
+	return values
+}
+
+// Mset sets all key-value pairs atomically. pairs holds keys at even
+// indexes and their values at odd indexes.
+func (s *Store) Mset(pairs [][]byte) {
 	s.Lock()
 	defer s.Unlock()
 
-	return s.incr(key)
+	existed := s.keysExist(pairs)
+	s.mset(cloneByteSlices(pairs))
+	s.notifyMset(pairs, existed)
+}
+
+// keysExist reports for every key of the key-value pairs whether it exists.
+func (s *Store) keysExist(pairs [][]byte) []bool {
+	existed := make([]bool, 0, len(pairs)/2)
+
+	for i := 0; i < len(pairs); i += 2 {
+		existed = append(existed, s.keyExists(string(pairs[i])))
+	}
+
+	return existed
+}
+
+func (s *Store) notifyMset(pairs [][]byte, existed []bool) {
+	for i := 0; i < len(pairs); i += 2 {
+		s.notifyWrite(notify.String, "set", string(pairs[i]), existed[i/2])
+	}
+}
+
+// Msetnx sets all key-value pairs only if none of the keys exist.
+func (s *Store) Msetnx(pairs [][]byte) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keysExist(pairs)
+
+	if !s.msetnx(cloneByteSlices(pairs)) {
+		return false
+	}
+
+	s.notifyMset(pairs, existed)
+
+	return true
+}
+
+func (s *Store) SetNX(key string, value []byte) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	if !s.setnx(key, append([]byte{}, value...)) {
+		return false
+	}
+
+	s.notifyWrite(notify.String, "set", key, false)
+
+	return true
+}
+
+func (s *Store) Append(key string, value []byte) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	length, err := s.appendString(key, append([]byte{}, value...))
+	if err == nil {
+		s.notifyWrite(notify.String, "append", key, existed)
+	}
+
+	return length, err
+}
+
+func (s *Store) Strlen(key string) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.strlen(key)
+}
+
+func (s *Store) Getrange(key string, start, end int) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.getrange(key, start, end)
+}
+
+func (s *Store) Setrange(key string, offset int, value []byte) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	length, err := s.setrange(key, offset, value, s.limits.MaxBulkLen())
+	if err == nil && len(value) > 0 {
+		s.notifyWrite(notify.String, "setrange", key, existed)
+	}
+
+	return length, err
+}
+
+func (s *Store) GetSet(key string, value []byte) ([]byte, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	old, ok, err := s.getset(key, append([]byte{}, value...))
+	if err == nil {
+		s.notifyWrite(notify.String, "set", key, existed)
+	}
+
+	return append([]byte{}, old...), ok, err
+}
+
+func (s *Store) GetDel(key string) ([]byte, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expireIfNeeded(key)
+
+	value, ok, err := s.getdel(key)
+	if ok {
+		s.notify(notify.Generic, "del", key)
+	}
+
+	return value, ok, err
+}
+
+// GetEx returns the value of key and optionally updates its expiry. When
+// persist is true any existing expiry is removed.
+// GetEx returns the value of key and sets its expiry, see the GETEX command.
+// expiresAt is the expiry of key once set.
+func (s *Store) GetEx(key string, expType ExpiryType, expTime int, persist bool) (value []byte, expiresAt time.Time, ok bool, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+	hadExpiry := existed && s.innerMap[key].expiryTime != getPossibleEndTime()
+
+	value, expiresAt, ok, err = s.getex(key, expType, expTime, persist)
+	if !ok {
+		return value, expiresAt, ok, err
+	}
+
+	switch {
+	case persist && hadExpiry:
+		s.notify(notify.Generic, "persist", key)
+	case expType != "":
+		if s.keyExists(key) {
+			s.notify(notify.Generic, "expire", key)
+		} else {
+			s.notify(notify.Generic, "del", key)
+		}
+	}
+
+	return value, expiresAt, ok, err
+}
+
+func (s *Store) SetBit(key string, offset uint64, bit byte) (byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	old, err := s.setbit(key, offset, bit, s.limits.MaxBulkLen())
+	if err == nil {
+		s.notifyWrite(notify.String, "setbit", key, existed)
+	}
+
+	return old, err
+}
+
+func (s *Store) GetBit(key string, offset uint64) (byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.getbit(key, offset, s.limits.MaxBulkLen())
+}
+
+func (s *Store) BitCount(key string, r BitRange) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.bitcount(key, r)
+}
+
+func (s *Store) BitPos(key string, bit byte, r BitRange) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.bitpos(key, bit, r)
+}
+
+// BitOp stores the result of op applied to the source keys in dest and
+// returns the length of the resulting string.
+func (s *Store) BitOp(op BitOp, dest string, keys []string) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, key := range keys {
+		s.expireIfNeeded(key)
+	}
+
+	existed := s.keyExists(dest)
+
+	length, err := s.bitop(op, dest, keys)
+	if err != nil {
+		return length, err
+	}
+
+	if length > 0 {
+		s.notifyWrite(notify.String, "set", dest, existed)
+	} else {
+		s.notifyRemoved(dest, existed)
+	}
+
+	return length, nil
+}
+
+func (s *Store) BitField(key string, ops []BitfieldOp) ([]BitfieldResult, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	results, err := s.bitfield(key, ops, s.limits.MaxBulkLen())
+	if err == nil && hasBitfieldWrite(ops) {
+		s.notifyWrite(notify.String, "setbit", key, existed)
+	}
+
+	return results, err
+}
+
+// Pfadd adds elements to the HyperLogLog stored at key and reports whether
+// the estimated cardinality may have changed.
+func (s *Store) Pfadd(key string, elements [][]byte) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	existed := s.keyExists(key)
+
+	updated, err := s.pfadd(key, cloneByteSlices(elements))
+	if updated {
+		s.notifyWrite(notify.String, "pfadd", key, existed)
+	}
+
+	return updated, err
+}
+
+// Pfcount returns the approximate cardinality of the union of the
+// HyperLogLogs stored at keys.
+func (s *Store) Pfcount(keys []string) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.pfcount(keys)
+}
+
+func (s *Store) Pfmerge(dest string, keys []string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, key := range keys {
+		s.expireIfNeeded(key)
+	}
+
+	existed := s.keyExists(dest)
+
+	err := s.pfmerge(dest, keys)
+	if err == nil {
+		s.notifyWrite(notify.String, "pfadd", dest, existed)
+	}
+
+	return err
+}
+
+// Lcs returns the longest common subsequence of the strings stored at key1
+// and key2. Missing keys are treated as empty strings.
+func (s *Store) Lcs(key1, key2 string) (LcsResult, error) {
+	s.RLock()
+	a, b, err := s.lcsOperands(key1, key2)
+	maxSize := s.limits.MaxBulkLen()
+	s.RUnlock()
+
+	if err != nil {
+		return LcsResult{}, err
+	}
+
+	return lcs(a, b, maxSize)
 }
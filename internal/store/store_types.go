@@ -1,6 +1,10 @@
 package store
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
 type StoreValueType interface {
 	GetType() string
@@ -27,14 +31,25 @@ func (l List) GetType() string {
 	return "list"
 }
 
+// Stream is the stream type stored in the keyspace. Its entries are kept in
+// a radix tree of blocks, see streamIndex, and handed out as copies.
 type Stream struct {
-	Elements           []StreamElement
+	entries            *streamIndex
 	LtsInsertedIdParts storedStreamId
+	// MaxDeletedEntryId is the greatest ID removed with XDEL. Deleted IDs
+	// stay reserved since LtsInsertedIdParts never moves backwards.
+	MaxDeletedEntryId storedStreamId
+	// EntriesAdded counts every entry ever added to the stream
+	EntriesAdded int64
+	// Groups holds the consumer groups of the stream by name.
+	Groups map[string]*StreamGroup
 }
 
 type StreamElement struct {
 	Id     storedStreamId
 	Fields [][]string
+	// Deleted is set for pending entries that no longer exist in the stream
+	Deleted bool
 }
 
 type storedStreamId struct {
@@ -46,6 +61,63 @@ func (s storedStreamId) ToString() string {
 	return fmt.Sprintf("%d-%d", s.MsTime, s.Seq)
 }
 
+// maxStreamId is the greatest possible stream ID.
+var maxStreamId = storedStreamId{math.MaxUint64, math.MaxUint64}
+
+// next returns the smallest ID greater than s.
+func (s storedStreamId) next() (storedStreamId, bool) {
+	if s.Seq < math.MaxUint64 {
+		return storedStreamId{s.MsTime, s.Seq + 1}, true
+	}
+
+	if s.MsTime < math.MaxUint64 {
+		return storedStreamId{s.MsTime + 1, 0}, true
+	}
+
+	return s, false
+}
+
+// prev returns the greatest ID smaller than s.
+func (s storedStreamId) prev() (storedStreamId, bool) {
+	if s.Seq > 0 {
+		return storedStreamId{s.MsTime, s.Seq - 1}, true
+	}
+
+	if s.MsTime > 0 {
+		return storedStreamId{s.MsTime - 1, math.MaxUint64}, true
+	}
+
+	return s, false
+}
+
+// StreamGroup is a consumer group. Pending is the group's pending entries
+// list, each entry is also referenced from the owning consumer.
+type StreamGroup struct {
+	LastDeliveredId storedStreamId
+	// EntriesRead is the logical position of LastDeliveredId, i.e. the
+	// number of entries added up to it. It is StreamInvalidEntriesRead when
+	// unknown, lag is then estimated from the stream.
+	EntriesRead int64
+	Pending     map[storedStreamId]*streamPendingEntry
+	Consumers   map[string]*StreamConsumer
+}
+
+type StreamConsumer struct {
+	Name string
+	// SeenTime is the last time the consumer attempted an interaction,
+	// ActiveTime the last time it actually read or claimed entries.
+	SeenTime   time.Time
+	ActiveTime time.Time
+	Pending    map[storedStreamId]*streamPendingEntry
+}
+
+type streamPendingEntry struct {
+	id            storedStreamId
+	consumer      *StreamConsumer
+	deliveryTime  time.Time
+	deliveryCount int64
+}
+
 type StreamIdSpec struct {
 	MsTime   uint64
 	Seq      uint64
@@ -53,8 +125,14 @@ type StreamIdSpec struct {
 	AutoFull bool
 	IsMax    bool
 	IsMin    bool
+	// Exclusive is set for "(id" range bounds
+	Exclusive bool
+}
+
+func newStream() *Stream {
+	return &Stream{entries: newStreamIndex()}
 }
 
-func (s Stream) GetType() string {
+func (s *Stream) GetType() string {
 	return "stream"
 }
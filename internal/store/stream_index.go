@@ -0,0 +1,477 @@
+package store
+
+import (
+	"encoding/binary"
+)
+
+// streamNodeMaxBytes mirrors the default stream-node-max-bytes setting, a
+// block is closed once its encoding grows past it.
+const streamNodeMaxBytes = 4096
+
+const (
+	// streamEntryDeleted marks an entry removed by XDEL or trimming, the
+	// bytes stay in the block until the whole block is dropped
+	streamEntryDeleted byte = 1 << iota
+	// streamEntrySameFields marks an entry with the same field names as
+	// the master entry of its block, only the values are encoded
+	streamEntrySameFields
+)
+
+// streamIndex stores the entries of a stream in blocks of up to
+// streamNodeMaxEntries entries. Blocks are indexed by their master ID in a
+// radix tree, which makes seeking to an ID logarithmic, and are linked in
+// ID order for iteration.
+type streamIndex struct {
+	rax    streamRax
+	head   *streamBlock
+	tail   *streamBlock
+	length int
+	blocks int
+}
+
+// streamBlock is a listpack-like block of entries. The first entry added to
+// a block is its master entry, its ID is the key of the block and its field
+// names are shared with every following entry that has the same fields.
+//
+// Each entry is encoded as
+//
+//	flags | uvarint body length | body
+//
+// where body holds the ms delta to the master ID, the sequence (a delta too
+// when the ms part equals the master's), and then either the values of the
+// master fields or the field count followed by name/value pairs. Strings
+// are encoded as uvarint length followed by the bytes.
+type streamBlock struct {
+	key    [16]byte
+	master storedStreamId
+	fields []string
+	data   []byte
+	count  int
+	live   int
+	lastId storedStreamId
+	prev   *streamBlock
+	next   *streamBlock
+}
+
+// blockEntry is a decoded entry header, fields are decoded on demand.
+type blockEntry struct {
+	off   int
+	flags byte
+	id    storedStreamId
+	body  []byte
+}
+
+func encodeStreamId(id storedStreamId) (key [16]byte) {
+	binary.BigEndian.PutUint64(key[:8], id.MsTime)
+	binary.BigEndian.PutUint64(key[8:], id.Seq)
+	return key
+}
+
+func newStreamIndex() *streamIndex {
+	return &streamIndex{}
+}
+
+func newStreamBlock(master StreamElement) *streamBlock {
+	b := &streamBlock{
+		key:    encodeStreamId(master.Id),
+		master: master.Id,
+		fields: make([]string, len(master.Fields)),
+	}
+
+	for i, pair := range master.Fields {
+		b.fields[i] = pair[0]
+	}
+
+	return b
+}
+
+func (b *streamBlock) sameFields(fields [][]string) bool {
+	if len(fields) != len(b.fields) {
+		return false
+	}
+
+	for i, pair := range fields {
+		if pair[0] != b.fields[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (b *streamBlock) append(el StreamElement) {
+	flags := byte(0)
+	body := make([]byte, 0, 32)
+
+	body = binary.AppendUvarint(body, el.Id.MsTime-b.master.MsTime)
+	if el.Id.MsTime == b.master.MsTime {
+		body = binary.AppendUvarint(body, el.Id.Seq-b.master.Seq)
+	} else {
+		body = binary.AppendUvarint(body, el.Id.Seq)
+	}
+
+	if b.sameFields(el.Fields) {
+		flags |= streamEntrySameFields
+
+		for _, pair := range el.Fields {
+			body = appendString(body, pair[1])
+		}
+	} else {
+		body = binary.AppendUvarint(body, uint64(len(el.Fields)))
+
+		for _, pair := range el.Fields {
+			body = appendString(body, pair[0])
+			body = appendString(body, pair[1])
+		}
+	}
+
+	b.data = append(b.data, flags)
+	b.data = binary.AppendUvarint(b.data, uint64(len(body)))
+	b.data = append(b.data, body...)
+
+	b.count++
+	b.live++
+	b.lastId = el.Id
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(buf []byte) (string, []byte) {
+	n, size := binary.Uvarint(buf)
+	buf = buf[size:]
+	return string(buf[:n]), buf[n:]
+}
+
+// entryAt decodes the header of the entry starting at off and returns the
+// offset of the next entry.
+func (b *streamBlock) entryAt(off int) (blockEntry, int) {
+	e := blockEntry{off: off, flags: b.data[off]}
+
+	bodyLen, size := binary.Uvarint(b.data[off+1:])
+	start := off + 1 + size
+	e.body = b.data[start : start+int(bodyLen)]
+
+	msDelta, n := binary.Uvarint(e.body)
+	seq, m := binary.Uvarint(e.body[n:])
+	e.body = e.body[n+m:]
+
+	e.id.MsTime = b.master.MsTime + msDelta
+	e.id.Seq = seq
+	if msDelta == 0 {
+		e.id.Seq += b.master.Seq
+	}
+
+	return e, start + int(bodyLen)
+}
+
+// element decodes the fields of e.
+func (b *streamBlock) element(e blockEntry) StreamElement {
+	body := e.body
+	var fields [][]string
+
+	if e.flags&streamEntrySameFields != 0 {
+		fields = make([][]string, len(b.fields))
+
+		for i, name := range b.fields {
+			var value string
+			value, body = readString(body)
+			fields[i] = []string{name, value}
+		}
+	} else {
+		n, size := binary.Uvarint(body)
+		body = body[size:]
+		fields = make([][]string, n)
+
+		for i := range fields {
+			var name, value string
+			name, body = readString(body)
+			value, body = readString(body)
+			fields[i] = []string{name, value}
+		}
+	}
+
+	return StreamElement{Id: e.id, Fields: fields}
+}
+
+// entries returns the headers of all entries of the block, deleted ones
+// included.
+func (b *streamBlock) entries() []blockEntry {
+	out := make([]blockEntry, 0, b.count)
+
+	for off := 0; off < len(b.data); {
+		var e blockEntry
+		e, off = b.entryAt(off)
+		out = append(out, e)
+	}
+
+	return out
+}
+
+func (b *streamBlock) markDeleted(e blockEntry) {
+	b.data[e.off] |= streamEntryDeleted
+	b.live--
+}
+
+func (idx *streamIndex) len() int {
+	return idx.length
+}
+
+// append adds el at the end of the stream, its ID must be greater than
+// every ID in the index.
+func (idx *streamIndex) append(el StreamElement) {
+	b := idx.tail
+
+	if b == nil || b.count >= streamNodeMaxEntries || len(b.data) >= streamNodeMaxBytes {
+		b = newStreamBlock(el)
+		idx.rax.insert(b.key[:], b)
+		idx.blocks++
+
+		b.prev = idx.tail
+		if idx.tail != nil {
+			idx.tail.next = b
+		} else {
+			idx.head = b
+		}
+
+		idx.tail = b
+	}
+
+	b.append(el)
+	idx.length++
+}
+
+func (idx *streamIndex) removeBlock(b *streamBlock) {
+	idx.rax.remove(b.key[:])
+	idx.blocks--
+	idx.length -= b.live
+
+	if b.prev != nil {
+		b.prev.next = b.next
+	} else {
+		idx.head = b.next
+	}
+
+	if b.next != nil {
+		b.next.prev = b.prev
+	} else {
+		idx.tail = b.prev
+	}
+
+	b.prev, b.next = nil, nil
+}
+
+// seekBlock returns the block that may hold id: the one with the greatest
+// master ID <= id, or the head block when id sorts before every block.
+func (idx *streamIndex) seekBlock(id storedStreamId) *streamBlock {
+	key := encodeStreamId(id)
+
+	if b := idx.rax.floor(key[:]); b != nil {
+		return b
+	}
+
+	return idx.head
+}
+
+// ascend calls fn for every live entry with an ID >= from, in ID order,
+// until fn returns false. Field decoding is left to fn.
+func (idx *streamIndex) ascend(from storedStreamId, fn func(b *streamBlock, e blockEntry) bool) {
+	for b := idx.seekBlock(from); b != nil; b = b.next {
+		if less(b.lastId, from) {
+			continue
+		}
+
+		for off := 0; off < len(b.data); {
+			var e blockEntry
+			e, off = b.entryAt(off)
+
+			if e.flags&streamEntryDeleted != 0 || less(e.id, from) {
+				continue
+			}
+
+			if !fn(b, e) {
+				return
+			}
+		}
+	}
+}
+
+// descend calls fn for every live entry with an ID <= from, in reverse ID
+// order, until fn returns false.
+func (idx *streamIndex) descend(from storedStreamId, fn func(b *streamBlock, e blockEntry) bool) {
+	key := encodeStreamId(from)
+
+	for b := idx.rax.floor(key[:]); b != nil; b = b.prev {
+		entries := b.entries()
+
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+
+			if e.flags&streamEntryDeleted != 0 || greater(e.id, from) {
+				continue
+			}
+
+			if !fn(b, e) {
+				return
+			}
+		}
+	}
+}
+
+// rangeEntries returns up to count live entries between start and end, both
+// inclusive. When rev is set the entries are returned from end to start. A
+// count of 0 means no limit.
+func (idx *streamIndex) rangeEntries(start, end storedStreamId, count int, rev bool) []StreamElement {
+	elements := []StreamElement{}
+
+	if greater(start, end) {
+		return elements
+	}
+
+	collect := func(b *streamBlock, e blockEntry) bool {
+		if (!rev && greater(e.id, end)) || (rev && less(e.id, start)) {
+			return false
+		}
+
+		elements = append(elements, b.element(e))
+
+		return count == 0 || len(elements) < count
+	}
+
+	if rev {
+		idx.descend(end, collect)
+	} else {
+		idx.ascend(start, collect)
+	}
+
+	return elements
+}
+
+// lookup returns the live entry with the given ID.
+func (idx *streamIndex) lookup(id storedStreamId) (el StreamElement, ok bool) {
+	idx.ascend(id, func(b *streamBlock, e blockEntry) bool {
+		if e.id == id {
+			el, ok = b.element(e), true
+		}
+
+		return false
+	})
+
+	return el, ok
+}
+
+// first returns the live entry with the smallest ID.
+func (idx *streamIndex) first() (el StreamElement, ok bool) {
+	idx.ascend(storedStreamId{}, func(b *streamBlock, e blockEntry) bool {
+		el, ok = b.element(e), true
+		return false
+	})
+
+	return el, ok
+}
+
+// last returns the live entry with the greatest ID.
+func (idx *streamIndex) last() (el StreamElement, ok bool) {
+	if idx.tail == nil {
+		return StreamElement{}, false
+	}
+
+	idx.descend(idx.tail.lastId, func(b *streamBlock, e blockEntry) bool {
+		el, ok = b.element(e), true
+		return false
+	})
+
+	return el, ok
+}
+
+// delete marks the entry with the given ID as deleted and drops its block
+// once no live entry is left in it.
+func (idx *streamIndex) delete(id storedStreamId) bool {
+	deleted := false
+
+	idx.ascend(id, func(b *streamBlock, e blockEntry) bool {
+		if e.id != id {
+			return false
+		}
+
+		b.markDeleted(e)
+		idx.length--
+		deleted = true
+
+		if b.live == 0 {
+			// removeBlock subtracts live entries only
+			idx.removeBlock(b)
+		}
+
+		return false
+	})
+
+	return deleted
+}
+
+// trim removes entries from the head of the stream, see Stream.trim.
+func (idx *streamIndex) trim(spec StreamTrimSpec) int64 {
+	var removed int64
+	minId := storedStreamId{MsTime: spec.MinId.MsTime, Seq: spec.MinId.Seq}
+
+	for b := idx.head; b != nil; b = idx.head {
+		if spec.Strategy == STREAM_TRIM_MAXLEN && int64(idx.length) <= spec.MaxLen {
+			break
+		}
+
+		if spec.Limit > 0 && removed+int64(b.live) > spec.Limit {
+			break
+		}
+
+		removeBlock := false
+		if spec.Strategy == STREAM_TRIM_MAXLEN {
+			removeBlock = int64(idx.length-b.live) >= spec.MaxLen
+		} else {
+			removeBlock = less(b.lastId, minId)
+		}
+
+		if removeBlock {
+			removed += int64(b.live)
+			idx.removeBlock(b)
+			continue
+		}
+
+		if spec.Approx {
+			break
+		}
+
+		// delete single entries from the block, it stays in place
+		for off := 0; off < len(b.data); {
+			var e blockEntry
+			e, off = b.entryAt(off)
+
+			if e.flags&streamEntryDeleted != 0 {
+				continue
+			}
+
+			if spec.Strategy == STREAM_TRIM_MAXLEN && int64(idx.length) <= spec.MaxLen {
+				break
+			}
+
+			if spec.Strategy == STREAM_TRIM_MINID && !less(e.id, minId) {
+				break
+			}
+
+			b.markDeleted(e)
+			idx.length--
+			removed++
+		}
+
+		// with MINID the live entries may all be gone while later deleted
+		// entries kept the block's last ID above the threshold
+		if b.live == 0 {
+			idx.removeBlock(b)
+		}
+
+		break
+	}
+
+	return removed
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func newTestEntry(ms, seq uint64) StreamElement {
+	return StreamElement{
+		Id:     storedStreamId{ms, seq},
+		Fields: [][]string{{"field", fmt.Sprintf("%d-%d", ms, seq)}},
+	}
+}
+
+func TestStreamRaxFloor(t *testing.T) {
+	r := streamRax{}
+	rng := rand.New(rand.NewSource(1))
+	keys := map[storedStreamId]*streamBlock{}
+
+	for range 2000 {
+		id := storedStreamId{rng.Uint64() % 5000, rng.Uint64() % 3}
+		b := &streamBlock{key: encodeStreamId(id), master: id}
+		keys[id] = b
+		r.insert(b.key[:], b)
+	}
+
+	// remove about half of the keys again
+	for id, b := range keys {
+		if rng.Intn(2) == 0 {
+			if !r.remove(b.key[:]) {
+				t.Fatalf("expected %s to be removed", id.ToString())
+			}
+
+			delete(keys, id)
+		}
+	}
+
+	sorted := make([]storedStreamId, 0, len(keys))
+	for id := range keys {
+		sorted = append(sorted, id)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+
+	for range 5000 {
+		probe := storedStreamId{rng.Uint64() % 5100, rng.Uint64() % 3}
+		key := encodeStreamId(probe)
+
+		i := sort.Search(len(sorted), func(i int) bool { return greater(sorted[i], probe) })
+
+		got := r.floor(key[:])
+		if i == 0 {
+			if got != nil {
+				t.Fatalf("expected no floor for %s, got %s", probe.ToString(), got.master.ToString())
+			}
+
+			continue
+		}
+
+		if got == nil || got.master != sorted[i-1] {
+			t.Fatalf("expected floor of %s to be %s, got %v", probe.ToString(), sorted[i-1].ToString(), got)
+		}
+	}
+}
+
+func TestStreamIndex(t *testing.T) {
+	idx := newStreamIndex()
+	model := []StreamElement{}
+
+	for ms := uint64(1); ms <= 1000; ms++ {
+		for seq := range uint64(3) {
+			el := newTestEntry(ms, seq)
+			if seq == 2 {
+				// a different field set is encoded without the master fields
+				el.Fields = [][]string{{"other", "x"}, {"field", "y"}}
+			}
+
+			idx.append(el)
+			model = append(model, el)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(2))
+	for range 500 {
+		i := rng.Intn(len(model))
+		if !idx.delete(model[i].Id) {
+			t.Fatalf("expected %s to be deleted", model[i].Id.ToString())
+		}
+
+		model = append(model[:i], model[i+1:]...)
+	}
+
+	if idx.len() != len(model) {
+		t.Fatalf("expected length %d, got %d", len(model), idx.len())
+	}
+
+	requireEntries := func(t *testing.T, got, want []StreamElement) {
+		t.Helper()
+
+		if len(got) != len(want) {
+			t.Fatalf("expected %d entries, got %d", len(want), len(got))
+		}
+
+		for i := range want {
+			if got[i].Id != want[i].Id || fmt.Sprint(got[i].Fields) != fmt.Sprint(want[i].Fields) {
+				t.Fatalf("entry %d: expected %+v, got %+v", i, want[i], got[i])
+			}
+		}
+	}
+
+	requireEntries(t, idx.rangeEntries(storedStreamId{}, maxStreamId, 0, false), model)
+
+	start, end := storedStreamId{200, 1}, storedStreamId{700, 0}
+	want := []StreamElement{}
+	for _, el := range model {
+		if !less(el.Id, start) && !greater(el.Id, end) {
+			want = append(want, el)
+		}
+	}
+
+	requireEntries(t, idx.rangeEntries(start, end, 0, false), want)
+	requireEntries(t, idx.rangeEntries(start, end, 10, false), want[:10])
+
+	reversed := make([]StreamElement, 0, 10)
+	for i := len(want) - 1; i >= len(want)-10; i-- {
+		reversed = append(reversed, want[i])
+	}
+	requireEntries(t, idx.rangeEntries(start, end, 10, true), reversed)
+
+	removed := idx.trim(StreamTrimSpec{Strategy: STREAM_TRIM_MAXLEN, MaxLen: 1000})
+	if removed != int64(len(model)-1000) || idx.len() != 1000 {
+		t.Fatalf("expected exact trim to keep 1000 entries, removed %d and kept %d", removed, idx.len())
+	}
+
+	requireEntries(t, idx.rangeEntries(storedStreamId{}, maxStreamId, 0, false), model[len(model)-1000:])
+}
+
+const benchmarkStreamLength = 10_000_000
+
+var (
+	benchmarkIndexOnce sync.Once
+	benchmarkIndex     *streamIndex
+)
+
+// loadBenchmarkIndex builds a 10M entry stream once for all benchmarks.
+func loadBenchmarkIndex(b *testing.B) *streamIndex {
+	b.Helper()
+
+	benchmarkIndexOnce.Do(func() {
+		benchmarkIndex = newStreamIndex()
+
+		for i := range uint64(benchmarkStreamLength) {
+			benchmarkIndex.append(StreamElement{
+				Id:     storedStreamId{i / 4, i % 4},
+				Fields: [][]string{{"sensor", "temp"}, {"value", "21.5"}},
+			})
+		}
+	})
+
+	return benchmarkIndex
+}
+
+func BenchmarkStreamIndexAppend(b *testing.B) {
+	idx := newStreamIndex()
+	fields := [][]string{{"sensor", "temp"}, {"value", "21.5"}}
+
+	for i := range uint64(b.N) {
+		idx.append(StreamElement{Id: storedStreamId{i, 0}, Fields: fields})
+	}
+}
+
+func BenchmarkStreamIndexSeek10M(b *testing.B) {
+	idx := loadBenchmarkIndex(b)
+	rng := rand.New(rand.NewSource(3))
+	b.ResetTimer()
+
+	for range b.N {
+		i := uint64(rng.Int63n(benchmarkStreamLength))
+		if _, ok := idx.lookup(storedStreamId{i / 4, i % 4}); !ok {
+			b.Fatal("entry not found")
+		}
+	}
+}
+
+func BenchmarkStreamIndexRange10M(b *testing.B) {
+	idx := loadBenchmarkIndex(b)
+	rng := rand.New(rand.NewSource(4))
+	b.ResetTimer()
+
+	for range b.N {
+		ms := uint64(rng.Int63n(benchmarkStreamLength / 4))
+		if got := idx.rangeEntries(storedStreamId{ms, 0}, maxStreamId, 10, false); len(got) == 0 {
+			b.Fatal("no entries in range")
+		}
+	}
+}
+
+func BenchmarkStreamIndexRevRange10M(b *testing.B) {
+	idx := loadBenchmarkIndex(b)
+	rng := rand.New(rand.NewSource(5))
+	b.ResetTimer()
+
+	for range b.N {
+		ms := uint64(rng.Int63n(benchmarkStreamLength / 4))
+		if got := idx.rangeEntries(storedStreamId{}, storedStreamId{ms, 3}, 10, true); len(got) == 0 {
+			b.Fatal("no entries in range")
+		}
+	}
+}
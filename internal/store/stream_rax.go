@@ -0,0 +1,173 @@
+package store
+
+import (
+	"bytes"
+	"sort"
+)
+
+// streamRax is a radix tree mapping the big-endian encoded master ID of a
+// stream block to the block. All keys have the same length, so values only
+// live at leaves and no key is a prefix of another one.
+type streamRax struct {
+	root raxNode
+	// nodes counts the nodes of the tree, not counting the root
+	nodes int
+}
+
+// raxNode is a node of the tree. prefix is the compressed edge leading to
+// the node, children are kept sorted by the first byte of their prefix.
+type raxNode struct {
+	prefix   []byte
+	children []*raxNode
+	block    *streamBlock
+}
+
+func commonPrefixLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+
+	return n
+}
+
+// childIndex returns the index of the child whose prefix starts with c, or
+// the index it has to be inserted at together with false.
+func (n *raxNode) childIndex(c byte) (int, bool) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= c
+	})
+
+	return i, i < len(n.children) && n.children[i].prefix[0] == c
+}
+
+func (r *streamRax) insert(key []byte, b *streamBlock) {
+	n := &r.root
+
+	for {
+		if len(key) == 0 {
+			n.block = b
+			return
+		}
+
+		i, found := n.childIndex(key[0])
+		if !found {
+			leaf := &raxNode{prefix: key, block: b}
+			n.children = append(n.children, nil)
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = leaf
+			r.nodes++
+			return
+		}
+
+		child := n.children[i]
+		common := commonPrefixLen(child.prefix, key)
+
+		if common == len(child.prefix) {
+			n = child
+			key = key[common:]
+			continue
+		}
+
+		// split the edge at the first differing byte
+		split := &raxNode{prefix: child.prefix[:common:common]}
+		child.prefix = child.prefix[common:]
+		leaf := &raxNode{prefix: key[common:], block: b}
+
+		if child.prefix[0] < leaf.prefix[0] {
+			split.children = []*raxNode{child, leaf}
+		} else {
+			split.children = []*raxNode{leaf, child}
+		}
+
+		n.children[i] = split
+		r.nodes += 2
+		return
+	}
+}
+
+func (r *streamRax) remove(key []byte) bool {
+	return r.removeFrom(&r.root, key)
+}
+
+func (r *streamRax) removeFrom(n *raxNode, key []byte) bool {
+	if len(key) == 0 {
+		if n.block == nil {
+			return false
+		}
+
+		n.block = nil
+		return true
+	}
+
+	i, found := n.childIndex(key[0])
+	if !found {
+		return false
+	}
+
+	child := n.children[i]
+	if !bytes.HasPrefix(key, child.prefix) || !r.removeFrom(child, key[len(child.prefix):]) {
+		return false
+	}
+
+	if child.block != nil {
+		return true
+	}
+
+	switch len(child.children) {
+	case 0:
+		n.children = append(n.children[:i], n.children[i+1:]...)
+		r.nodes--
+	case 1:
+		// merge the only grandchild into the child edge
+		grandchild := child.children[0]
+		grandchild.prefix = append(append([]byte{}, child.prefix...), grandchild.prefix...)
+		n.children[i] = grandchild
+		r.nodes--
+	}
+
+	return true
+}
+
+// floor returns the block with the greatest key <= key.
+func (r *streamRax) floor(key []byte) *streamBlock {
+	return r.root.floor(key)
+}
+
+func (n *raxNode) floor(key []byte) *streamBlock {
+	if len(key) == 0 {
+		return n.block
+	}
+
+	i, found := n.childIndex(key[0])
+
+	if found {
+		child := n.children[i]
+
+		switch bytes.Compare(child.prefix, key[:len(child.prefix)]) {
+		case 0:
+			if b := child.floor(key[len(child.prefix):]); b != nil {
+				return b
+			}
+		case -1:
+			return child.max()
+		}
+	}
+
+	// every child before i sorts entirely below key
+	if i > 0 {
+		return n.children[i-1].max()
+	}
+
+	return nil
+}
+
+func (n *raxNode) max() *streamBlock {
+	for len(n.children) > 0 {
+		n = n.children[len(n.children)-1]
+	}
+
+	return n.block
+}
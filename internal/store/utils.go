@@ -3,6 +3,7 @@ package store
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -17,3 +18,15 @@ func newId() string {
 func getPossibleEndTime() time.Time {
 	return time.Date(9999, 12, 31, 23, 59, 59, 999, time.UTC)
 }
+
+var errWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+func cloneByteSlices(pairs [][]byte) [][]byte {
+	out := make([][]byte, len(pairs))
+
+	for i, b := range pairs {
+		out[i] = append([]byte{}, b...)
+	}
+
+	return out
+}
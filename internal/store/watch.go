@@ -0,0 +1,91 @@
+package store
+
+// Watcher is the optimistic lock taken by WATCH. It becomes dirty as soon as
+// one of its keys is written, deleted or expires.
+type Watcher struct {
+	// keys maps every watched key to whether it held a live value when it
+	// got watched
+	keys  map[string]bool
+	dirty bool
+}
+
+func NewWatcher() *Watcher {
+	return &Watcher{keys: make(map[string]bool)}
+}
+
+// Watch adds keys to the keys watched by w.
+func (s *Store) Watch(w *Watcher, keys []string) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, key := range keys {
+		if _, ok := w.keys[key]; ok {
+			continue
+		}
+
+		v, ok := s.innerMap[key]
+		w.keys[key] = ok && !v.isExpired()
+
+		watchers, ok := s.watchedKeys[key]
+		if !ok {
+			watchers = make(map[*Watcher]struct{})
+			s.watchedKeys[key] = watchers
+		}
+
+		watchers[w] = struct{}{}
+	}
+}
+
+// Unwatch releases every key watched by w and resets it.
+func (s *Store) Unwatch(w *Watcher) {
+	s.Lock()
+	defer s.Unlock()
+
+	for key := range w.keys {
+		watchers := s.watchedKeys[key]
+		delete(watchers, w)
+
+		if len(watchers) == 0 {
+			delete(s.watchedKeys, key)
+		}
+	}
+
+	w.keys = make(map[string]bool)
+	w.dirty = false
+}
+
+// IsDirty reports whether a key watched by w was modified since it got
+// watched. A key that expired meanwhile counts as modified, whether it is
+// still waiting for an access or was already dropped by a read.
+func (s *Store) IsDirty(w *Watcher) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.isDirty(w)
+}
+
+func (s *Store) isDirty(w *Watcher) bool {
+	if w.dirty {
+		return true
+	}
+
+	for key, liveAtWatch := range w.keys {
+		if !liveAtWatch {
+			continue
+		}
+
+		if v, ok := s.innerMap[key]; !ok || v.isExpired() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// touchWatchedKey marks the watchers of key as dirty, every write to the
+// keyspace calls it.
+func (s *Store) touchWatchedKey(key string) {
+	for w := range s.watchedKeys[key] {
+		w.dirty = true
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatcherDirtyOnWrite(t *testing.T) {
+	s := NewStore()
+	w := NewWatcher()
+
+	s.Set("foo", []byte("1"), "", 0)
+	s.Watch(w, []string{"foo", "bar"})
+
+	s.Get("foo")
+	if s.IsDirty(w) {
+		t.Fatal("expected a read to leave the watcher clean")
+	}
+
+	s.Set("bar", []byte("1"), "", 0)
+	if !s.IsDirty(w) {
+		t.Fatal("expected creating a watched key to make the watcher dirty")
+	}
+
+	s.Unwatch(w)
+	s.Watch(w, []string{"foo"})
+
+	s.GetDel("foo")
+	if !s.IsDirty(w) {
+		t.Fatal("expected deleting a watched key to make the watcher dirty")
+	}
+}
+
+func TestWatcherDirtyOnExpiry(t *testing.T) {
+	s := NewStore()
+	w := NewWatcher()
+
+	s.Set("foo", []byte("1"), EXPIRY_PX, 5)
+	s.Watch(w, []string{"foo"})
+
+	time.Sleep(10 * time.Millisecond)
+
+	// the read drops the expired key without raising an event
+	s.Strlen("foo")
+	if !s.IsDirty(w) {
+		t.Fatal("expected an expired watched key to make the watcher dirty")
+	}
+
+	// a key already expired when watched is not a modification
+	s.Unwatch(w)
+	s.Set("foo", []byte("1"), EXPIRY_PX, 1)
+	time.Sleep(5 * time.Millisecond)
+	s.Watch(w, []string{"foo"})
+
+	s.Strlen("foo")
+	if s.IsDirty(w) {
+		t.Fatal("expected a key expired before WATCH to leave the watcher clean")
+	}
+}
@@ -6,10 +6,17 @@ import (
 
 	"github.com/codecrafters-io/redis-starter-go/internal/commands"
 	"github.com/codecrafters-io/redis-starter-go/internal/resp"
+	"github.com/codecrafters-io/redis-starter-go/internal/store"
 )
 
 type Transactions struct {
 	innerMap transactionsMap
+	store    *store.Store
+	// watchers holds the WATCH state of every session watching keys
+	watchers map[string]*store.Watcher
+	// flagged holds the sessions whose transaction got a command rejected
+	// at queue time, their EXEC fails with EXECABORT
+	flagged map[string]bool
 	sync.RWMutex
 }
 
@@ -17,11 +24,46 @@ type commandsList []*commands.Command
 
 type transactionsMap map[string]commandsList
 
-type Executor func(*commands.Command) resp.Value
+// Executor runs a queued command against tx, the view of the store the
+// transaction executes on.
+type Executor func(tx *store.Store, cmd *commands.Command) resp.Value
 
-func NewTransactions() *Transactions {
+func NewTransactions(s *store.Store) *Transactions {
 	return &Transactions{
 		innerMap: make(transactionsMap),
+		store:    s,
+		watchers: make(map[string]*store.Watcher),
+		flagged:  make(map[string]bool),
+	}
+}
+
+// Watch marks keys as watched by the session id, its next EXEC fails if any
+// of them is modified in the meantime.
+func (t *Transactions) Watch(id string, keys []string) {
+	t.Lock()
+	defer t.Unlock()
+
+	w, ok := t.watchers[id]
+	if !ok {
+		w = store.NewWatcher()
+		t.watchers[id] = w
+	}
+
+	t.store.Watch(w, keys)
+}
+
+// Unwatch forgets every key watched by the session id.
+func (t *Transactions) Unwatch(id string) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.unwatch(id)
+}
+
+func (t *Transactions) unwatch(id string) {
+	if w, ok := t.watchers[id]; ok {
+		t.store.Unwatch(w)
+		delete(t.watchers, id)
 	}
 }
 
@@ -38,8 +80,22 @@ func (t *Transactions) Begin(id string) {
 	t.Lock()
 	defer t.Unlock()
 	t.innerMap[id] = []*commands.Command{}
+	delete(t.flagged, id)
+}
+
+// Flag marks the transaction of the session id as failed because a command
+// was rejected before it could be queued.
+func (t *Transactions) Flag(id string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.innerMap[id]; ok {
+		t.flagged[id] = true
+	}
 }
 
+// Queue adds cmd to the transaction of the session id. A command failing
+// validation is not queued and flags the transaction.
 func (t *Transactions) Queue(id string, cmd *commands.Command) error {
 	t.Lock()
 	defer t.Unlock()
@@ -49,36 +105,94 @@ func (t *Transactions) Queue(id string, cmd *commands.Command) error {
 		return fmt.Errorf("ERR unknown session id")
 	}
 
+	if err := commands.Validate(cmd); err != nil {
+		t.flagged[id] = true
+		return err
+	}
+
 	list = append(list, cmd)
 	t.innerMap[id] = list
 
 	return nil
 }
 
-func (t *Transactions) ExecuteAndDiscard(id string, executor Executor) *resp.Array {
-	t.Lock()
-	defer t.Unlock()
-
+// ExecuteAndDiscard runs the commands queued by the session id and ends its
+// transaction. The commands run on a view of the store taken with
+// store.Atomically, so no other client observes or interleaves with a
+// partially applied transaction. When a command was rejected at queue time
+// nothing runs and EXECABORT is returned, when a watched key was modified
+// nothing runs and a null array is returned. The watched keys are released
+// either way. Only the state of the session is taken under the lock of t,
+// the commands run under the lock of the store alone. executed is called
+// once they all ran, before the store is unlocked, so that what they did is
+// replicated before the writes of other clients.
+func (t *Transactions) ExecuteAndDiscard(id string, executor Executor, executed func()) resp.Value {
 	arr := &resp.Array{}
 
-	list, ok := t.innerMap[id]
+	list, w, watching, err := t.take(id)
+	if err != nil {
+		return &resp.Error{Msg: err.Error()}
+	}
 
-	if !ok {
+	if list == nil {
 		return arr
 	}
 
-	for _, command := range list {
-		arr.Elements = append(arr.Elements, executor(command))
+	t.store.Atomically(func(tx *store.Store) {
+		if watching {
+			dirty := tx.IsDirty(w)
+			tx.Unwatch(w)
+
+			if dirty {
+				arr = &resp.Array{Null: true}
+				return
+			}
+		}
+
+		for _, command := range list {
+			arr.Elements = append(arr.Elements, executor(tx, command))
+		}
+
+		executed()
+	})
+
+	return arr
+}
+
+// take ends the transaction of the session id and returns its queued
+// commands and watcher, nil when there is no transaction. The watcher is
+// released when the transaction was flagged.
+func (t *Transactions) take(id string) (list commandsList, w *store.Watcher, watching bool, err error) {
+	t.Lock()
+	defer t.Unlock()
+
+	list, ok := t.innerMap[id]
+	if !ok {
+		return nil, nil, false, nil
 	}
 
 	delete(t.innerMap, id)
 
-	return arr
+	if t.flagged[id] {
+		delete(t.flagged, id)
+		t.unwatch(id)
+
+		return nil, nil, false, fmt.Errorf("EXECABORT Transaction discarded because of previous errors.")
+	}
+
+	w, watching = t.watchers[id]
+	delete(t.watchers, id)
+
+	return list, w, watching, nil
 }
 
+// Discard ends the transaction of the session id, if any, and releases its
+// watched keys.
 func (t *Transactions) Discard(id string) {
 	t.Lock()
 	defer t.Unlock()
 
 	delete(t.innerMap, id)
+	delete(t.flagged, id)
+	t.unwatch(id)
 }